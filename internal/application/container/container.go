@@ -8,6 +8,10 @@ import (
 	"github.com/jonesrussell/godo/internal/infrastructure/storage"
 )
 
+// ProfileName selects a configuration profile; empty uses the one set in the
+// config file
+type ProfileName string
+
 // Container holds all application dependencies
 type Container struct {
 	App    core.Application
@@ -17,7 +21,7 @@ type Container struct {
 
 // New creates a new container instance
 func New() (*Container, error) {
-	app, cleanup, err := InitializeApp()
+	app, cleanup, err := InitializeApp("")
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize application: %w", err)
 	}
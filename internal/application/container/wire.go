@@ -52,6 +52,14 @@ var (
 		ProvideNoteService,
 	)
 
+	// ProfileSet provides the graph of the active profile and builds the
+	// graph of another profile when the user switches to it
+	ProfileSet = wire.NewSet(
+		ProvideActiveProfile,
+		ProvideProfileLoader,
+		wire.FieldsOf(new(*core.Profile), "Store"),
+	)
+
 	// UISet provides user interface components
 	UISet = wire.NewSet(
 		ProvideFyneApp,
@@ -64,8 +72,7 @@ var (
 	CoreSet = wire.NewSet(
 		ConfigSet,
 		LoggingSet,
-		StorageSet,
-		ServiceSet,
+		ProfileSet,
 	)
 
 	// AppSet provides the main application
@@ -75,8 +82,9 @@ var (
 	)
 )
 
-// InitializeApp initializes the application with all dependencies
-func InitializeApp() (core.Application, func(), error) {
+// InitializeApp initializes the application with all dependencies, using
+// the named profile or, when it is empty, the one chosen in the config file
+func InitializeApp(profile ProfileName) (core.Application, func(), error) {
 	wire.Build(
 		CoreSet, // Configuration and core services
 		UISet,   // User interface
@@ -85,8 +93,24 @@ func InitializeApp() (core.Application, func(), error) {
 	return nil, nil, nil
 }
 
+// InitializeProfile builds the storage, repository and service graph of one
+// profile. The cleanup closes its storage.
+func InitializeProfile(cfg *config.Config, log logger.Logger) (*core.Profile, func(), error) {
+	wire.Build(
+		StorageSet,
+		ServiceSet,
+		wire.Struct(new(core.Profile), "Config", "Store", "NoteService"),
+	)
+	return nil, nil, nil
+}
+
 // Configuration provider - uses actual config system
-func ProvideConfig() (*config.Config, error) {
+func ProvideConfig(profile ProfileName) (*config.Config, error) {
+	return loadConfig(string(profile))
+}
+
+// loadConfig reads config.yaml over the defaults with the named profile applied
+func loadConfig(profile string) (*config.Config, error) {
 	// Start with default config
 	cfg := config.NewDefaultConfig()
 
@@ -104,22 +128,56 @@ func ProvideConfig() (*config.Config, error) {
 	// Also try current directory as fallback
 	v.AddConfigPath(".")
 
-	if err := v.ReadInConfig(); err == nil {
+	readErr := v.ReadInConfig()
+
+	// An unknown profile is an error even without a config file
+	name, err := config.ApplyProfile(v, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if readErr == nil {
 		// Config file found, unmarshal into our config
 		if configErr := v.Unmarshal(cfg); configErr != nil {
 			// If unmarshaling fails, keep defaults
 			fmt.Printf("Failed to parse config file, using defaults: %v\n", configErr)
 		} else {
-			fmt.Printf("Config file loaded: %s\n", v.ConfigFileUsed())
+			fmt.Printf("Config file loaded: %s (profile %s)\n", v.ConfigFileUsed(), name)
 		}
 	} else {
 		// No config file found, using defaults
-		fmt.Printf("No config file found, using defaults: %v\n", err)
+		fmt.Printf("No config file found, using defaults: %v\n", readErr)
 	}
+	cfg.Profile = name
 
 	return cfg, nil
 }
 
+// Active profile provider
+func ProvideActiveProfile(cfg *config.Config, log logger.Logger) (*core.Profile, func(), error) {
+	profile, cleanup, err := InitializeProfile(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile.WithCleanup(cleanup)
+	return profile, profile.Close, nil
+}
+
+// Profile loader provider, used to switch profiles at runtime
+func ProvideProfileLoader(log logger.Logger) core.ProfileLoader {
+	return func(name string) (*core.Profile, error) {
+		cfg, err := loadConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		profile, cleanup, err := InitializeProfile(cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		return profile.WithCleanup(cleanup), nil
+	}
+}
+
 // Logger provider
 func ProvideLogger(cfg *config.Config) (logger.Logger, func(), error) {
 	if cfg == nil {
@@ -163,16 +221,58 @@ func ProvideUnifiedStorage(log logger.Logger, cfg *config.Config) (domainstorage
 	storageConfig := &domainstorage.StorageConfig{
 		Type: domainstorage.StorageType(cfg.Storage.Type),
 		SQLite: domainstorage.SQLiteConfig{
-			FilePath: cfg.Storage.SQLite.FilePath,
+			FilePath:      cfg.Storage.SQLite.FilePath,
+			JournalMode:   cfg.Storage.SQLite.JournalMode,
+			BusyTimeoutMs: cfg.Storage.SQLite.BusyTimeoutMs,
+			Synchronous:   cfg.Storage.SQLite.Synchronous,
+			ForeignKeys:   &cfg.Storage.SQLite.ForeignKeys,
+			CacheSize:     cfg.Storage.SQLite.CacheSize,
+			MaxOpenConns:  cfg.Storage.SQLite.MaxOpenConns,
+			MaxIdleConns:  cfg.Storage.SQLite.MaxIdleConns,
+			Encryption: domainstorage.EncryptionConfig{
+				Enabled:       cfg.Storage.SQLite.Encryption.Enabled,
+				KeyFile:       cfg.Storage.SQLite.Encryption.KeyFile,
+				PassphraseEnv: cfg.Storage.SQLite.Encryption.PassphraseEnv,
+			},
+			Backup: domainstorage.BackupConfig{
+				Dir:       cfg.Storage.Backup.Dir,
+				KeepCount: cfg.Storage.Backup.KeepCount,
+				KeepDays:  cfg.Storage.Backup.KeepDays,
+			},
+			Attachments: domainstorage.AttachmentConfig{
+				Dir:       cfg.Storage.Attachments.Dir,
+				MaxSizeMB: cfg.Storage.Attachments.MaxSizeMB,
+			},
 		},
 		API: domainstorage.APIConfig{
 			BaseURL:    cfg.Storage.API.BaseURL,
 			Timeout:    cfg.Storage.API.Timeout,
 			RetryCount: cfg.Storage.API.RetryCount,
 			RetryDelay: cfg.Storage.API.RetryDelay,
+			OutboxPath: cfg.Storage.API.OutboxPath,
 			TLSInsecureSkipVerify: cfg.Storage.API.TLSInsecureSkipVerify ||
 				cfg.Storage.API.InsecureSkipVerify,
 		},
+		Memory: domainstorage.MemoryConfig{
+			SnapshotPath: cfg.Storage.Memory.SnapshotPath,
+			SaveOnClose:  cfg.Storage.Memory.SaveOnClose,
+		},
+		Markdown: domainstorage.MarkdownConfig{
+			Dir: cfg.Storage.Markdown.Dir,
+		},
+		Sync: domainstorage.SyncConfig{
+			Enabled:         cfg.Storage.Sync.Enabled,
+			IntervalSeconds: cfg.Storage.Sync.IntervalSeconds,
+			ConflictPolicy:  cfg.Storage.Sync.ConflictPolicy,
+		},
+		Events: domainstorage.EventsConfig{
+			BufferSize:  cfg.Storage.Events.BufferSize,
+			HistorySize: cfg.Storage.Events.HistorySize,
+		},
+		Middleware: make([]domainstorage.MiddlewareConfig, 0, len(cfg.Storage.Middleware)),
+	}
+	for _, m := range cfg.Storage.Middleware {
+		storageConfig.Middleware = append(storageConfig.Middleware, domainstorage.MiddlewareConfig(m))
 	}
 
 	// If storage config is not set, fall back to database config for backward compatibility
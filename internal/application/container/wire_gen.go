@@ -30,9 +30,10 @@ import (
 
 // Injectors from wire.go:
 
-// InitializeApp initializes the application with all dependencies
-func InitializeApp() (core.Application, func(), error) {
-	config, err := ProvideConfig()
+// InitializeApp initializes the application with all dependencies, using
+// the named profile or, when it is empty, the one chosen in the config file
+func InitializeApp(profile ProfileName) (core.Application, func(), error) {
+	config, err := ProvideConfig(profile)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -41,22 +42,41 @@ func InitializeApp() (core.Application, func(), error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	unifiedNoteStorage, cleanup2, err := ProvideUnifiedStorage(logger, config)
+	coreProfile, cleanup2, err := ProvideActiveProfile(config, logger)
 	if err != nil {
 		cleanup()
 		return nil, nil, err
 	}
-	noteRepository := ProvideNoteRepositoryFromUnified(unifiedNoteStorage)
-	noteService := ProvideNoteService(noteRepository, logger)
-	noteStoreAdapter := ProvideNoteStoreAdapter(unifiedNoteStorage)
-	window := ProvideMainWindow(app, noteStoreAdapter, logger, config)
-	coreApp := core.New(app, config, logger, noteService, window, noteStoreAdapter)
+	noteStore := coreProfile.Store
+	window := ProvideMainWindow(app, noteStore, logger, config)
+	profileLoader := ProvideProfileLoader(logger)
+	coreApp := core.New(app, logger, window, coreProfile, profileLoader)
 	return coreApp, func() {
 		cleanup2()
 		cleanup()
 	}, nil
 }
 
+// InitializeProfile builds the storage, repository and service graph of one
+// profile. The cleanup closes its storage.
+func InitializeProfile(cfg *config.Config, log logger.Logger) (*core.Profile, func(), error) {
+	unifiedNoteStorage, cleanup, err := ProvideUnifiedStorage(log, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	noteStoreAdapter := ProvideNoteStoreAdapter(unifiedNoteStorage)
+	noteRepository := ProvideNoteRepositoryFromUnified(unifiedNoteStorage)
+	noteService := ProvideNoteService(noteRepository, log)
+	profile := &core.Profile{
+		Config:      cfg,
+		Store:       noteStoreAdapter,
+		NoteService: noteService,
+	}
+	return profile, func() {
+		cleanup()
+	}, nil
+}
+
 // wire.go:
 
 // Provider Sets - Organized by concern
@@ -83,6 +103,13 @@ var (
 		ProvideNoteService,
 	)
 
+	// ProfileSet provides the graph of the active profile and builds the
+	// graph of another profile when the user switches to it
+	ProfileSet = wire.NewSet(
+		ProvideActiveProfile,
+		ProvideProfileLoader, wire.FieldsOf(new(*core.Profile), "Store"),
+	)
+
 	// UISet provides user interface components
 	UISet = wire.NewSet(
 		ProvideFyneApp,
@@ -93,8 +120,7 @@ var (
 	CoreSet = wire.NewSet(
 		ConfigSet,
 		LoggingSet,
-		StorageSet,
-		ServiceSet,
+		ProfileSet,
 	)
 
 	// AppSet provides the main application
@@ -102,7 +128,12 @@ var (
 )
 
 // Configuration provider - uses actual config system
-func ProvideConfig() (*config.Config, error) {
+func ProvideConfig(profile ProfileName) (*config.Config, error) {
+	return loadConfig(string(profile))
+}
+
+// loadConfig reads config.yaml over the defaults with the named profile applied
+func loadConfig(profile string) (*config.Config, error) {
 
 	cfg := config.NewDefaultConfig()
 
@@ -117,20 +148,53 @@ func ProvideConfig() (*config.Config, error) {
 
 	v.AddConfigPath(".")
 
-	if err := v.ReadInConfig(); err == nil {
+	readErr := v.ReadInConfig()
+
+	name, err := config.ApplyProfile(v, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if readErr == nil {
 
 		if configErr := v.Unmarshal(cfg); configErr != nil {
 			fmt.Printf("Failed to parse config file, using defaults: %v\n", configErr)
 		} else {
-			fmt.Printf("Config file loaded: %s\n", v.ConfigFileUsed())
+			fmt.Printf("Config file loaded: %s (profile %s)\n", v.ConfigFileUsed(), name)
 		}
 	} else {
-		fmt.Printf("No config file found, using defaults: %v\n", err)
+		fmt.Printf("No config file found, using defaults: %v\n", readErr)
 	}
+	cfg.Profile = name
 
 	return cfg, nil
 }
 
+// Active profile provider
+func ProvideActiveProfile(cfg *config.Config, log logger.Logger) (*core.Profile, func(), error) {
+	profile, cleanup, err := InitializeProfile(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile.WithCleanup(cleanup)
+	return profile, profile.Close, nil
+}
+
+// Profile loader provider, used to switch profiles at runtime
+func ProvideProfileLoader(log logger.Logger) core.ProfileLoader {
+	return func(name string) (*core.Profile, error) {
+		cfg, err := loadConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		profile, cleanup, err := InitializeProfile(cfg, log)
+		if err != nil {
+			return nil, err
+		}
+		return profile.WithCleanup(cleanup), nil
+	}
+}
+
 // Logger provider
 func ProvideLogger(cfg *config.Config) (logger.Logger, func(), error) {
 	if cfg == nil {
@@ -173,16 +237,58 @@ func ProvideUnifiedStorage(log logger.Logger, cfg *config.Config) (storage2.Unif
 	storageConfig := &storage2.StorageConfig{
 		Type: storage2.StorageType(cfg.Storage.Type),
 		SQLite: storage2.SQLiteConfig{
-			FilePath: cfg.Storage.SQLite.FilePath,
+			FilePath:      cfg.Storage.SQLite.FilePath,
+			JournalMode:   cfg.Storage.SQLite.JournalMode,
+			BusyTimeoutMs: cfg.Storage.SQLite.BusyTimeoutMs,
+			Synchronous:   cfg.Storage.SQLite.Synchronous,
+			ForeignKeys:   &cfg.Storage.SQLite.ForeignKeys,
+			CacheSize:     cfg.Storage.SQLite.CacheSize,
+			MaxOpenConns:  cfg.Storage.SQLite.MaxOpenConns,
+			MaxIdleConns:  cfg.Storage.SQLite.MaxIdleConns,
+			Encryption: storage2.EncryptionConfig{
+				Enabled:       cfg.Storage.SQLite.Encryption.Enabled,
+				KeyFile:       cfg.Storage.SQLite.Encryption.KeyFile,
+				PassphraseEnv: cfg.Storage.SQLite.Encryption.PassphraseEnv,
+			},
+			Backup: storage2.BackupConfig{
+				Dir:       cfg.Storage.Backup.Dir,
+				KeepCount: cfg.Storage.Backup.KeepCount,
+				KeepDays:  cfg.Storage.Backup.KeepDays,
+			},
+			Attachments: storage2.AttachmentConfig{
+				Dir:       cfg.Storage.Attachments.Dir,
+				MaxSizeMB: cfg.Storage.Attachments.MaxSizeMB,
+			},
 		},
 		API: storage2.APIConfig{
 			BaseURL:    cfg.Storage.API.BaseURL,
 			Timeout:    cfg.Storage.API.Timeout,
 			RetryCount: cfg.Storage.API.RetryCount,
 			RetryDelay: cfg.Storage.API.RetryDelay,
+			OutboxPath: cfg.Storage.API.OutboxPath,
 			TLSInsecureSkipVerify: cfg.Storage.API.TLSInsecureSkipVerify ||
 				cfg.Storage.API.InsecureSkipVerify,
 		},
+		Memory: storage2.MemoryConfig{
+			SnapshotPath: cfg.Storage.Memory.SnapshotPath,
+			SaveOnClose:  cfg.Storage.Memory.SaveOnClose,
+		},
+		Markdown: storage2.MarkdownConfig{
+			Dir: cfg.Storage.Markdown.Dir,
+		},
+		Sync: storage2.SyncConfig{
+			Enabled:         cfg.Storage.Sync.Enabled,
+			IntervalSeconds: cfg.Storage.Sync.IntervalSeconds,
+			ConflictPolicy:  cfg.Storage.Sync.ConflictPolicy,
+		},
+		Events: storage2.EventsConfig{
+			BufferSize:  cfg.Storage.Events.BufferSize,
+			HistorySize: cfg.Storage.Events.HistorySize,
+		},
+		Middleware: make([]storage2.MiddlewareConfig, 0, len(cfg.Storage.Middleware)),
+	}
+	for _, m := range cfg.Storage.Middleware {
+		storageConfig.Middleware = append(storageConfig.Middleware, storage2.MiddlewareConfig(m))
 	}
 
 	if cfg.Storage.Type == "" {
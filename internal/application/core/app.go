@@ -14,7 +14,9 @@ import (
 	"fyne.io/fyne/v2/driver/desktop"
 
 	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/model"
 	"github.com/jonesrussell/godo/internal/domain/service"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/jonesrussell/godo/internal/infrastructure/api"
 	"github.com/jonesrussell/godo/internal/infrastructure/gui"
 	"github.com/jonesrussell/godo/internal/infrastructure/gui/quicknote"
@@ -31,6 +33,11 @@ type App struct {
 	mainWindow  gui.MainWindow
 	hotkey      hotkey.Manager
 	apiRunner   *api.Runner
+	purger      *trashPurger
+	retention   *retentionScheduler
+	backups     *backupScheduler
+	maintenance *maintenanceScheduler
+	syncer      *syncScheduler
 	config      *config.Config
 	logger      logger.Logger
 	noteService service.NoteService
@@ -39,29 +46,30 @@ type App struct {
 	// Quick note window management
 	quickNoteWindow quicknote.Interface
 	quickNoteMu     sync.Mutex
+
+	// profile owns the storage the services above were built on
+	profile     *Profile
+	loadProfile ProfileLoader
+	profileMu   sync.Mutex
 }
 
-// New creates a new application instance
+// New creates a new application instance around the active profile. load
+// builds the graph of another profile when the user switches to it.
 func New(
 	fyneApp fyne.App,
-	cfg *config.Config,
 	log logger.Logger,
-	noteService service.NoteService,
 	mainWindow gui.MainWindow,
-	store storage.NoteStore,
+	profile *Profile,
+	load ProfileLoader,
 ) *App {
-	apiRunner := api.NewRunner(noteService, log, &cfg.HTTP)
+	cfg := profile.Config
 
 	// Create the App instance first
 	app := &App{
 		fyneApp:     fyneApp,
 		mainWindow:  mainWindow,
-		hotkey:      nil, // Will be set up below
-		apiRunner:   apiRunner,
-		config:      cfg,
 		logger:      log,
-		noteService: noteService,
-		store:       store,
+		loadProfile: load,
 	}
 
 	// Create quick note window during initialization
@@ -72,29 +80,43 @@ func New(
 		Height: cfg.UI.QuickNote.Height,
 	}
 
-	app.quickNoteWindow = quicknote.New(fyneApp, store, log, windowConfig)
+	app.quickNoteWindow = quicknote.New(fyneApp, profile.Store, log, windowConfig)
 	app.quickNoteWindow.Initialize(fyneApp, log)
 	log.Debug("Quick note window created during initialization")
 
+	// The API server, background jobs and hotkeys follow the profile
+	app.useProfile(profile)
+
+	return app
+}
+
+// newAPIRunner creates the API server for a profile
+func newAPIRunner(profile *Profile, log logger.Logger) *api.Runner {
+	return api.NewRunner(profile.NoteService, log, &profile.Config.HTTP)
+}
+
+// newHotkeyManager creates a hotkey manager for the active profile's
+// bindings, or returns nil when hotkeys are unavailable
+func (a *App) newHotkeyManager() hotkey.Manager {
+	cfg := a.config
+
 	// Now set up hotkey manager with the simplified interface
-	log.Info("Creating hotkey manager", "config", fmt.Sprintf("%+v", cfg.Hotkeys))
-	if hkm, err := hotkey.NewManager(log, &cfg.Hotkeys); err != nil {
-		log.Warn("Failed to create hotkey manager, continuing without hotkeys", "error", err)
-		app.hotkey = nil
-	} else {
-		log.Info("Hotkey manager created successfully")
-		app.hotkey = hkm
-
-		// Use the simplified interface - pass the quick note service directly
-		app.hotkey.SetQuickNote(app.quickNoteWindow, &cfg.Hotkeys.QuickNote)
-
-		// Set up main window hotkey if configured
-		if cfg.Hotkeys.MainWindow.Key != "" {
-			app.hotkey.SetMainWindow(app.mainWindow, &cfg.Hotkeys.MainWindow)
-		}
+	a.logger.Info("Creating hotkey manager", "config", fmt.Sprintf("%+v", cfg.Hotkeys))
+	hkm, err := hotkey.NewManager(a.logger, &cfg.Hotkeys)
+	if err != nil {
+		a.logger.Warn("Failed to create hotkey manager, continuing without hotkeys", "error", err)
+		return nil
 	}
+	a.logger.Info("Hotkey manager created successfully")
 
-	return app
+	// Use the simplified interface - pass the quick note service directly
+	hkm.SetQuickNote(a.quickNoteWindow, &cfg.Hotkeys.QuickNote)
+
+	// Set up main window hotkey if configured
+	if cfg.Hotkeys.MainWindow.Key != "" {
+		hkm.SetMainWindow(a.mainWindow, &cfg.Hotkeys.MainWindow)
+	}
+	return hkm
 }
 
 // setupSystray initializes the system tray using the simplified Fyne approach
@@ -129,6 +151,27 @@ func (a *App) setupSystray() error {
 			})
 		}),
 		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Back Up Now", func() {
+			a.logger.Debug("Systray Back Up Now menu item tapped")
+			go a.backupNow()
+		}),
+		fyne.NewMenuItem("Restore Backup…", func() {
+			a.logger.Debug("Systray Restore Backup menu item tapped")
+			fyne.Do(func() {
+				a.mainWindow.Show()
+				a.mainWindow.GetWindow().RequestFocus()
+				a.mainWindow.ShowBackups()
+			})
+		}),
+		fyne.NewMenuItemSeparator(),
+	)
+	if a.syncer != nil {
+		a.addSyncItems(m)
+	}
+	if profiles := a.profileMenuItem(); profiles != nil {
+		m.Items = append(m.Items, profiles, fyne.NewMenuItemSeparator())
+	}
+	m.Items = append(m.Items,
 		fyne.NewMenuItem("Quit", func() {
 			a.logger.Debug("Systray Quit menu item tapped")
 			// Quit can be called from any thread
@@ -154,6 +197,69 @@ func (a *App) setupSystray() error {
 	return nil
 }
 
+// addSyncItems adds the sync status line and a Sync Now item to the tray
+// menu. The status line is updated after every pass.
+func (a *App) addSyncItems(m *fyne.Menu) {
+	current, err := a.noteService.SyncStatus(context.Background())
+	if err != nil {
+		current = &model.SyncStatus{State: model.SyncIdle}
+	}
+	status := fyne.NewMenuItem(syncStatusLabel(current), nil)
+	status.Disabled = true
+
+	a.syncer.SetStatusHandler(func(s *model.SyncStatus) {
+		label := syncStatusLabel(s)
+		fyne.Do(func() {
+			status.Label = label
+			m.Refresh()
+		})
+	})
+
+	m.Items = append(m.Items,
+		status,
+		fyne.NewMenuItem("Sync Now", func() {
+			a.logger.Debug("Systray Sync Now menu item tapped")
+			fyne.Do(func() {
+				status.Label = syncStatusLabel(&model.SyncStatus{State: model.SyncRunning})
+				m.Refresh()
+			})
+			a.syncer.SyncNow()
+		}),
+		fyne.NewMenuItemSeparator(),
+	)
+}
+
+// backupNow takes a snapshot and reports the outcome as a desktop notification
+func (a *App) backupNow() {
+	backup, err := a.noteService.CreateBackup(context.Background())
+	notification := fyne.NewNotification("Godo backup", "")
+	switch {
+	case errors.Is(err, domainstorage.ErrNotSupported):
+		notification.Content = "Backups are not supported by this storage"
+	case err != nil:
+		a.logger.Error("Manual backup failed", "error", err)
+		notification.Content = "Backup failed: " + err.Error()
+	default:
+		notification.Content = "Saved " + backup.Name
+	}
+	a.fyneApp.SendNotification(notification)
+}
+
+// warnIfDegraded shows the main window, with its restore banner, and a
+// notification when the startup integrity check found corruption
+func (a *App) warnIfDegraded() {
+	health, err := a.noteService.StorageHealth(context.Background())
+	if err != nil || health.Healthy() {
+		return
+	}
+	a.logger.Warn("Database is damaged and read-only", "problems", len(health.Problems))
+	a.fyneApp.SendNotification(fyne.NewNotification("Godo database damaged",
+		"Notes are read-only. Open Godo to restore a backup."))
+	fyne.Do(func() {
+		a.mainWindow.Show()
+	})
+}
+
 // setupHotkey sets up the global hotkey
 func (a *App) setupHotkey() error {
 	if a.hotkey == nil {
@@ -214,15 +320,8 @@ func (a *App) Run() {
 		"wsl2", platform.IsWSL2(),
 		"supports_gui", platform.SupportsGUI())
 
-	// Start API server (this is safe to do from any thread)
-	a.apiRunner.Start(a.config.HTTP.Port)
-
-	// Wait for API to be ready
-	timeout := time.Duration(a.config.HTTP.StartupTimeout) * time.Second
-	if timeout <= 0 {
-		timeout = 5 * time.Second
-	}
-	if !a.apiRunner.WaitForReady(timeout) {
+	// Start the API server and background jobs
+	if !a.startServices() {
 		a.logger.Error("API server failed to start within timeout")
 		return
 	}
@@ -233,6 +332,9 @@ func (a *App) Run() {
 		return
 	}
 
+	// Tell the user right away if the database opened read-only
+	a.warnIfDegraded()
+
 	// Setup hotkey (this is safe to do from any thread)
 	if err := a.setupHotkey(); err != nil {
 		a.logger.Error("Failed to setup hotkey", "error", err)
@@ -247,10 +349,55 @@ func (a *App) Run() {
 	a.logger.Info("Application shutting down")
 }
 
+// startServices starts the API server and, once it is ready, the
+// background jobs. It reports whether the server started in time.
+func (a *App) startServices() bool {
+	// Start API server (this is safe to do from any thread)
+	a.apiRunner.Start(a.config.HTTP.Port)
+
+	// Wait for API to be ready
+	timeout := time.Duration(a.config.HTTP.StartupTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if !a.apiRunner.WaitForReady(timeout) {
+		return false
+	}
+
+	// Purge expired trash in the background
+	if a.purger != nil {
+		a.purger.Start()
+	}
+
+	// Archive and delete aging notes in the background
+	if a.retention != nil {
+		a.retention.Start()
+	}
+
+	// Take scheduled snapshots in the background
+	if a.backups != nil {
+		a.backups.Start()
+	}
+
+	// Keep the database tidy in the background
+	if a.maintenance != nil {
+		a.maintenance.Start()
+	}
+
+	// Sync with the remote in the background
+	if a.syncer != nil {
+		a.syncer.Start()
+	}
+	return true
+}
+
 // Cleanup performs cleanup operations before shutdown
 func (a *App) Cleanup() {
 	a.logger.Info("Cleaning up application")
 
+	a.profileMu.Lock()
+	defer a.profileMu.Unlock()
+
 	// Clean up quick note window
 	if a.quickNoteWindow != nil {
 		a.logger.Info("Cleaning up quick note window")
@@ -260,6 +407,13 @@ func (a *App) Cleanup() {
 		})
 	}
 
+	a.stopServices()
+	a.profile.Close()
+}
+
+// stopServices stops the hotkeys, background jobs and API server of the
+// active profile
+func (a *App) stopServices() {
 	// Stop hotkey manager with timeout
 	if a.hotkey != nil {
 		a.logger.Info("Stopping hotkey manager")
@@ -284,17 +438,29 @@ func (a *App) Cleanup() {
 		}
 	}
 
-	// Stop API server with timeout
-	timeout := time.Duration(a.config.HTTP.ShutdownTimeout) * time.Second
-	if timeout <= 0 {
-		timeout = 5 * time.Second
+	// Stop the trash purge loop
+	if a.purger != nil {
+		a.purger.Stop()
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
 
-	if err := a.apiRunner.Shutdown(ctx); err != nil {
-		a.logger.Error("Failed to stop API server", "error", err)
+	// Stop the retention loop
+	if a.retention != nil {
+		a.retention.Stop()
 	}
+
+	// Stop the backup and maintenance loops
+	if a.backups != nil {
+		a.backups.Stop()
+	}
+	if a.maintenance != nil {
+		a.maintenance.Stop()
+	}
+	if a.syncer != nil {
+		a.syncer.Stop()
+	}
+
+	// Stop API server with timeout
+	a.stopAPI()
 }
 
 // Quit performs cleanup and quits the application
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/service"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// backupScheduler periodically snapshots the note store
+type backupScheduler struct {
+	service  service.NoteService
+	logger   logger.Logger
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newBackupScheduler creates a scheduler from config, or returns nil when
+// scheduled backups are disabled
+func newBackupScheduler(noteService service.NoteService, log logger.Logger, cfg config.BackupConfig) *backupScheduler {
+	if cfg.IntervalHours <= 0 {
+		return nil
+	}
+	return &backupScheduler{
+		service:  noteService,
+		logger:   log,
+		interval: time.Duration(cfg.IntervalHours) * time.Hour,
+	}
+}
+
+// Start takes a snapshot once per interval until Stop is called. The first
+// snapshot is due one interval after the newest existing one, so restarting
+// the application does not reset the schedule.
+func (b *backupScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		wait, ok := b.firstDelay(ctx)
+		if !ok {
+			return
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if !b.backup(ctx) {
+				return
+			}
+			timer.Reset(b.interval)
+		}
+	}()
+
+	b.logger.Info("Backups scheduled", "interval", b.interval)
+}
+
+// Stop cancels the backup loop and waits for it to exit
+func (b *backupScheduler) Stop() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	<-b.done
+}
+
+// firstDelay returns how long to wait before the first snapshot and whether
+// the backend supports backups at all
+func (b *backupScheduler) firstDelay(ctx context.Context) (time.Duration, bool) {
+	backups, err := b.service.ListBackups(ctx)
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		b.logger.Info("Storage backend has no backups, disabling scheduled backups")
+		return 0, false
+	}
+	if err != nil {
+		b.logger.Warn("Failed to list backups, backing up now", "error", err)
+		return 0, true
+	}
+	if len(backups) == 0 {
+		return 0, true
+	}
+	remaining := b.interval - time.Since(backups[0].CreatedAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// backup takes a single snapshot and reports whether the loop should continue
+func (b *backupScheduler) backup(ctx context.Context) bool {
+	if _, err := b.service.CreateBackup(ctx); err != nil {
+		if errors.Is(err, domainstorage.ErrNotSupported) {
+			b.logger.Info("Storage backend has no backups, disabling scheduled backups")
+			return false
+		}
+		if ctx.Err() == nil {
+			b.logger.Error("Scheduled backup failed", "error", err)
+		}
+	}
+	return true
+}
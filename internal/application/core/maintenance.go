@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/service"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// maintenanceScheduler periodically runs the storage backend's routine upkeep,
+// including pruning attachment content nothing refers to any more
+type maintenanceScheduler struct {
+	service  service.NoteService
+	logger   logger.Logger
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newMaintenanceScheduler creates a scheduler from config, or returns nil when
+// maintenance is disabled
+func newMaintenanceScheduler(noteService service.NoteService, log logger.Logger, cfg config.MaintenanceConfig) *maintenanceScheduler {
+	if cfg.IntervalHours <= 0 {
+		return nil
+	}
+	return &maintenanceScheduler{
+		service:  noteService,
+		logger:   log,
+		interval: time.Duration(cfg.IntervalHours) * time.Hour,
+	}
+}
+
+// Start runs maintenance once per interval until Stop is called
+func (m *maintenanceScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !m.optimize(ctx) {
+				return
+			}
+		}
+	}()
+
+	m.logger.Info("Storage maintenance scheduled", "interval", m.interval)
+}
+
+// Stop cancels the maintenance loop and waits for it to exit
+func (m *maintenanceScheduler) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+}
+
+// optimize runs a single maintenance pass and reports whether the loop should
+// continue. The pass also removes attachment content no note refers to any more.
+func (m *maintenanceScheduler) optimize(ctx context.Context) bool {
+	if _, err := m.service.PruneAttachments(ctx); err != nil &&
+		!errors.Is(err, domainstorage.ErrNotSupported) && ctx.Err() == nil {
+		m.logger.Warn("Pruning attachments failed", "error", err)
+	}
+	if err := m.service.OptimizeStorage(ctx); err != nil {
+		if errors.Is(err, domainstorage.ErrNotSupported) {
+			m.logger.Info("Storage backend has no maintenance, disabling it")
+			return false
+		}
+		if ctx.Err() == nil {
+			m.logger.Warn("Storage maintenance failed", "error", err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/service"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage"
+)
+
+// Profile is the storage, repository and service graph built for one
+// configuration profile
+type Profile struct {
+	Config      *config.Config
+	Store       storage.NoteStore
+	NoteService service.NoteService
+
+	close func()
+}
+
+// WithCleanup sets the function Close calls to release the profile's
+// storage. Close runs it at most once.
+func (p *Profile) WithCleanup(cleanup func()) *Profile {
+	p.close = sync.OnceFunc(cleanup)
+	return p
+}
+
+// Close releases the profile's storage
+func (p *Profile) Close() {
+	if p.close != nil {
+		p.close()
+	}
+}
+
+// ProfileLoader builds the graph of the named profile
+type ProfileLoader func(name string) (*Profile, error)
+
+// SwitchProfile replaces the active profile's storage and services with
+// those of the named profile. The windows, tray and Fyne app stay up; the
+// API server, background jobs and hotkeys are restarted with the new
+// profile's settings. The active profile is kept if the new one cannot be
+// opened.
+func (a *App) SwitchProfile(name string) error {
+	a.profileMu.Lock()
+	defer a.profileMu.Unlock()
+
+	if name == a.config.Profile {
+		return nil
+	}
+	if a.loadProfile == nil {
+		return fmt.Errorf("profiles cannot be switched at runtime")
+	}
+
+	next, err := a.loadProfile(name)
+	if err != nil {
+		return fmt.Errorf("failed to open profile %q: %w", name, err)
+	}
+	a.logger.Info("Switching profile", "from", a.config.Profile, "to", next.Config.Profile)
+
+	a.stopServices()
+	a.profile.Close()
+
+	a.useProfile(next)
+	a.mainWindow.SetStore(next.Store)
+	a.quickNoteWindow.SetStore(next.Store)
+	if a.hotkey != nil {
+		if err = a.setupHotkey(); err != nil {
+			a.logger.Error("Failed to set up hotkeys for profile", "profile", name, "error", err)
+		}
+	}
+	if !a.startServices() {
+		a.logger.Error("API server failed to start within timeout", "profile", name)
+	}
+	if err = a.setupSystray(); err != nil {
+		a.logger.Warn("Failed to rebuild systray", "error", err)
+	}
+	return nil
+}
+
+// useProfile points the app at profile and creates the services that depend
+// on its configuration. The services are not started.
+func (a *App) useProfile(profile *Profile) {
+	cfg := profile.Config
+	a.profile = profile
+	a.config = cfg
+	a.noteService = profile.NoteService
+	a.store = profile.Store
+	a.apiRunner = newAPIRunner(profile, a.logger)
+	a.purger = newTrashPurger(profile.NoteService, a.logger, cfg.Storage.Trash)
+	a.retention = newRetentionScheduler(profile.NoteService, a.logger, cfg.Storage.Retention)
+	a.backups = newBackupScheduler(profile.NoteService, a.logger, cfg.Storage.Backup)
+	a.maintenance = newMaintenanceScheduler(profile.NoteService, a.logger, cfg.Storage.Maintenance)
+	a.syncer = newSyncScheduler(profile.NoteService, a.logger, cfg.Storage.Sync)
+	a.hotkey = a.newHotkeyManager()
+}
+
+// profileMenuItem returns the tray submenu listing the profiles, with the
+// active one checked, or nil when no profiles are configured
+func (a *App) profileMenuItem() *fyne.MenuItem {
+	if len(a.config.Profiles) == 0 {
+		return nil
+	}
+
+	names := a.config.ProfileNames()
+	items := make([]*fyne.MenuItem, 0, len(names))
+	for _, name := range names {
+		item := fyne.NewMenuItem(name, func() {
+			a.logger.Debug("Systray profile menu item tapped", "profile", name)
+			go a.switchProfileFromTray(name)
+		})
+		item.Checked = name == a.config.Profile
+		items = append(items, item)
+	}
+
+	parent := fyne.NewMenuItem("Profile", nil)
+	parent.ChildMenu = fyne.NewMenu("Profile", items...)
+	return parent
+}
+
+// switchProfileFromTray switches profile and reports a failure as a desktop
+// notification
+func (a *App) switchProfileFromTray(name string) {
+	if err := a.SwitchProfile(name); err != nil {
+		a.logger.Error("Failed to switch profile", "profile", name, "error", err)
+		a.fyneApp.SendNotification(fyne.NewNotification("Godo profile", err.Error()))
+		return
+	}
+	a.fyneApp.SendNotification(fyne.NewNotification("Godo profile", "Switched to "+name))
+}
+
+// shutdownTimeout is how long the API server gets to stop
+func (a *App) shutdownTimeout() time.Duration {
+	timeout := time.Duration(a.config.HTTP.ShutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return timeout
+}
+
+// stopAPI stops the API server, waiting at most the shutdown timeout
+func (a *App) stopAPI() {
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout())
+	defer cancel()
+
+	if err := a.apiRunner.Shutdown(ctx); err != nil {
+		a.logger.Error("Failed to stop API server", "error", err)
+	}
+}
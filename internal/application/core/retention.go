@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/domain/service"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// retentionScheduler periodically applies the configured retention rules
+type retentionScheduler struct {
+	service  service.NoteService
+	logger   logger.Logger
+	rules    []model.RetentionRule
+	interval time.Duration
+	dryRun   bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newRetentionScheduler creates a scheduler from config, or returns nil when
+// there are no rules
+func newRetentionScheduler(noteService service.NoteService, log logger.Logger, cfg config.RetentionConfig) *retentionScheduler {
+	if len(cfg.Rules) == 0 || cfg.IntervalMinutes <= 0 {
+		return nil
+	}
+	rules := make([]model.RetentionRule, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		rules[i] = model.RetentionRule{
+			Action: model.RetentionAction(rule.Action),
+			After:  time.Duration(rule.AfterDays) * 24 * time.Hour,
+		}
+	}
+	return &retentionScheduler{
+		service:  noteService,
+		logger:   log,
+		rules:    rules,
+		interval: time.Duration(cfg.IntervalMinutes) * time.Minute,
+		dryRun:   cfg.DryRun,
+	}
+}
+
+// Start applies the rules immediately and then once per interval until Stop is called
+func (r *retentionScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			if !r.apply(ctx) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	r.logger.Info("Retention scheduled", "rules", len(r.rules), "interval", r.interval, "dry_run", r.dryRun)
+}
+
+// Stop cancels the retention loop and waits for it to exit
+func (r *retentionScheduler) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// apply runs a single retention pass and reports whether the loop should continue
+func (r *retentionScheduler) apply(ctx context.Context) bool {
+	report, err := r.service.ApplyRetention(ctx, r.rules, r.dryRun)
+	if err != nil {
+		if errors.Is(err, domainstorage.ErrNotSupported) {
+			r.logger.Info("Storage backend cannot archive notes, disabling retention")
+			return false
+		}
+		if ctx.Err() == nil {
+			r.logger.Error("Retention pass failed", "error", err)
+		}
+		return true
+	}
+	if r.dryRun && (len(report.Archived) > 0 || len(report.Deleted) > 0) {
+		r.logger.Info("Retention dry run, nothing changed",
+			"would_archive", report.Archived, "would_delete", report.Deleted)
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/domain/service"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// syncScheduler runs a sync pass at startup, once per interval and on demand
+type syncScheduler struct {
+	service  service.NoteService
+	logger   logger.Logger
+	interval time.Duration
+
+	// onStatus is called with the outcome of every pass
+	onStatus func(*model.SyncStatus)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	now    chan struct{}
+	mu     sync.Mutex
+}
+
+// newSyncScheduler creates a scheduler from config, or returns nil when sync
+// is disabled
+func newSyncScheduler(noteService service.NoteService, log logger.Logger, cfg config.SyncConfig) *syncScheduler {
+	if !cfg.Enabled || cfg.IntervalSeconds <= 0 {
+		return nil
+	}
+	return &syncScheduler{
+		service:  noteService,
+		logger:   log,
+		interval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		now:      make(chan struct{}, 1),
+	}
+}
+
+// SetStatusHandler sets the function called with the outcome of every pass
+func (s *syncScheduler) SetStatusHandler(fn func(*model.SyncStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStatus = fn
+}
+
+// Start syncs immediately, then once per interval until Stop is called
+func (s *syncScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if !s.sync(ctx) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.now:
+			}
+		}
+	}()
+
+	s.logger.Info("Sync scheduled", "interval", s.interval)
+}
+
+// SyncNow asks the loop for a pass without waiting for the interval. A
+// request made while a pass is already queued is dropped.
+func (s *syncScheduler) SyncNow() {
+	select {
+	case s.now <- struct{}{}:
+	default:
+	}
+}
+
+// Stop cancels the sync loop and waits for it to exit
+func (s *syncScheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// sync runs a single pass and reports whether the loop should continue
+func (s *syncScheduler) sync(ctx context.Context) bool {
+	status, err := s.service.SyncNow(ctx)
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		s.logger.Info("Storage backend does not sync, disabling it")
+		return false
+	}
+	if err != nil && ctx.Err() == nil {
+		s.logger.Debug("Sync pass did not complete", "error", err)
+	}
+
+	s.mu.Lock()
+	onStatus := s.onStatus
+	s.mu.Unlock()
+	if status != nil && onStatus != nil {
+		onStatus(status)
+	}
+	return true
+}
+
+// syncStatusLabel describes a sync status for the tray menu
+func syncStatusLabel(status *model.SyncStatus) string {
+	var label string
+	switch status.State {
+	case model.SyncRunning:
+		label = "Sync: syncing…"
+	case model.SyncOK:
+		label = "Sync: up to date"
+		if status.LastSyncAt != nil {
+			label = "Sync: up to date at " + status.LastSyncAt.Format("15:04")
+		}
+	case model.SyncOffline:
+		label = "Sync: offline"
+	case model.SyncFailed:
+		label = "Sync: failed"
+	default:
+		label = "Sync: not run yet"
+	}
+	if status.Pending > 0 {
+		label += fmt.Sprintf(" (%d pending)", status.Pending)
+	}
+	return label
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/service"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// trashPurger periodically removes notes that have outlived the trash retention period
+type trashPurger struct {
+	service   service.NoteService
+	logger    logger.Logger
+	retention time.Duration
+	interval  time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newTrashPurger creates a purger from config, or returns nil when retention is disabled
+func newTrashPurger(noteService service.NoteService, log logger.Logger, cfg config.TrashConfig) *trashPurger {
+	if cfg.RetentionDays <= 0 || cfg.PurgeIntervalMinutes <= 0 {
+		return nil
+	}
+	return &trashPurger{
+		service:   noteService,
+		logger:    log,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		interval:  time.Duration(cfg.PurgeIntervalMinutes) * time.Minute,
+	}
+}
+
+// Start runs a purge immediately and then once per interval until Stop is called
+func (p *trashPurger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			if !p.purge(ctx) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	p.logger.Info("Trash purge scheduled", "retention", p.retention, "interval", p.interval)
+}
+
+// Stop cancels the purge loop and waits for it to exit
+func (p *trashPurger) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+// purge runs a single purge pass and reports whether the loop should continue
+func (p *trashPurger) purge(ctx context.Context) bool {
+	if _, err := p.service.PurgeTrash(ctx, p.retention); err != nil {
+		if errors.Is(err, domainstorage.ErrNotSupported) {
+			p.logger.Info("Storage backend has no trash, disabling purge")
+			return false
+		}
+		if ctx.Err() == nil {
+			p.logger.Error("Trash purge failed", "error", err)
+		}
+	}
+	return true
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/jonesrussell/godo/internal/domain/model"
 	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/spf13/viper"
 )
@@ -55,6 +56,12 @@ type Config struct {
 	Storage  StorageConfig  `mapstructure:"storage"`
 	UI       UIConfig       `mapstructure:"ui"`
 	HTTP     HTTPConfig     `mapstructure:"http"`
+
+	// Profile is the name of the active profile
+	Profile string `mapstructure:"profile"`
+	// Profiles are named overrides of the storage, hotkeys and http
+	// sections; see ApplyProfile
+	Profiles map[string]map[string]any `mapstructure:"profiles"`
 }
 
 // AppConfig holds application-specific configuration
@@ -86,14 +93,158 @@ type DatabaseConfig struct {
 
 // StorageConfig holds storage configuration for different backends
 type StorageConfig struct {
-	Type   string       `mapstructure:"type"`
-	SQLite SQLiteConfig `mapstructure:"sqlite"`
-	API    APIConfig    `mapstructure:"api"`
+	Type        string            `mapstructure:"type"`
+	SQLite      SQLiteConfig      `mapstructure:"sqlite"`
+	API         APIConfig         `mapstructure:"api"`
+	Memory      MemoryConfig      `mapstructure:"memory"`
+	Markdown    MarkdownConfig    `mapstructure:"markdown"`
+	Trash       TrashConfig       `mapstructure:"trash"`
+	Retention   RetentionConfig   `mapstructure:"retention"`
+	Backup      BackupConfig      `mapstructure:"backup"`
+	Attachments AttachmentsConfig `mapstructure:"attachments"`
+	Maintenance MaintenanceConfig `mapstructure:"maintenance"`
+	Sync        SyncConfig        `mapstructure:"sync"`
+	Events      EventsConfig      `mapstructure:"events"`
+	// Middleware wraps the backend in decorators, the first outermost
+	Middleware []MiddlewareConfig `mapstructure:"middleware"`
+}
+
+// MemoryConfig holds configuration for the in-memory backend, used for demos and tests
+type MemoryConfig struct {
+	// SnapshotPath is a JSON file the notes are loaded from at startup; empty starts empty
+	SnapshotPath string `mapstructure:"snapshot_path"`
+	// SaveOnClose writes the notes back to SnapshotPath on shutdown
+	SaveOnClose bool `mapstructure:"save_on_close"`
+}
+
+// MarkdownConfig holds configuration for the Markdown directory backend
+type MarkdownConfig struct {
+	// Dir holds one .md file per note, with YAML front matter for its metadata
+	Dir string `mapstructure:"dir"`
+}
+
+// TrashConfig controls how long deleted notes are kept before being purged
+type TrashConfig struct {
+	// RetentionDays is how long a note stays in the trash; 0 keeps notes until restored
+	RetentionDays int `mapstructure:"retention_days"`
+	// PurgeIntervalMinutes is how often the background purge runs
+	PurgeIntervalMinutes int `mapstructure:"purge_interval_minutes"`
+}
+
+// RetentionConfig holds rules that archive and delete notes as they age.
+// The rules are evaluated at startup and then every IntervalMinutes.
+type RetentionConfig struct {
+	// Rules run in order; none disables retention
+	Rules []RetentionRuleConfig `mapstructure:"rules"`
+	// IntervalMinutes is how often the rules are evaluated
+	IntervalMinutes int `mapstructure:"interval_minutes"`
+	// DryRun logs what the rules would change without changing it
+	DryRun bool `mapstructure:"dry_run"`
+}
+
+// RetentionRuleConfig is one retention rule
+type RetentionRuleConfig struct {
+	// Action is archive, for done notes not updated within AfterDays, or
+	// delete, for notes archived more than AfterDays ago. Deleted notes go
+	// to the trash and are purged by storage.trash.
+	Action string `mapstructure:"action"`
+	// AfterDays is the age at which the rule applies
+	AfterDays int `mapstructure:"after_days"`
+}
+
+// BackupConfig controls snapshots of the SQLite database
+type BackupConfig struct {
+	// Dir holds the snapshots; relative paths are resolved against the database's directory
+	Dir string `mapstructure:"dir"`
+	// IntervalHours is how often a snapshot is taken; 0 disables scheduled snapshots
+	IntervalHours int `mapstructure:"interval_hours"`
+	// KeepCount is how many snapshots rotation keeps; 0 keeps any number
+	KeepCount int `mapstructure:"keep_count"`
+	// KeepDays removes snapshots older than this many days; 0 keeps them regardless of age
+	KeepDays int `mapstructure:"keep_days"`
+}
+
+// AttachmentsConfig controls where files attached to notes are kept
+type AttachmentsConfig struct {
+	// Dir holds the attachment content; relative paths are resolved against the database's directory
+	Dir string `mapstructure:"dir"`
+	// MaxSizeMB is the largest file that can be attached; 0 accepts any size
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+}
+
+// MaintenanceConfig controls routine database upkeep
+type MaintenanceConfig struct {
+	// IntervalHours is how often planner statistics are refreshed and free
+	// pages reclaimed; 0 disables it
+	IntervalHours int `mapstructure:"interval_hours"`
 }
 
-// SQLiteConfig holds SQLite-specific configuration
+// SyncConfig controls two-way sync between the SQLite database and the API
+// backend. Notes stay in SQLite, so they remain available while offline.
+type SyncConfig struct {
+	// Enabled syncs the SQLite store with storage.api.base_url
+	Enabled bool `mapstructure:"enabled"`
+	// IntervalSeconds is how often a sync pass runs in the background
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// ConflictPolicy is last-writer-wins, local-wins or keep-both
+	ConflictPolicy string `mapstructure:"conflict_policy"`
+}
+
+// EventsConfig sizes the change feed that reports note changes to the main
+// window and to /api/v1/events subscribers
+type EventsConfig struct {
+	// BufferSize is how many events a subscriber may fall behind by before
+	// its slow consumer policy applies
+	BufferSize int `mapstructure:"buffer_size"`
+	// HistorySize is how many recent events are kept so a subscriber can
+	// resume after reconnecting
+	HistorySize int `mapstructure:"history_size"`
+}
+
+// MiddlewareConfig adds one decorator around the storage backend. Each type
+// reads only its own settings; zero values take the defaults.
+type MiddlewareConfig struct {
+	// Type is metrics, logging, cache, retry or timeout
+	Type string `mapstructure:"type"`
+	// SlowMs makes logging warn about operations slower than this
+	SlowMs int `mapstructure:"slow_ms"`
+	// Size is how many notes the cache holds
+	Size int `mapstructure:"size"`
+	// TTLSeconds is how long the cache trusts what it holds; 0 until written
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// Attempts is how many times retry tries an operation in total
+	Attempts int `mapstructure:"attempts"`
+	// DelayMs is the wait before the first retry; it doubles after each
+	DelayMs int `mapstructure:"delay_ms"`
+	// TimeoutMs is the deadline timeout gives each operation
+	TimeoutMs int `mapstructure:"timeout_ms"`
+}
+
+// SQLiteConfig holds SQLite-specific configuration. The connection settings
+// are applied to every pooled connection.
 type SQLiteConfig struct {
 	FilePath string `mapstructure:"file_path"`
+	// JournalMode is DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF
+	JournalMode string `mapstructure:"journal_mode"`
+	// BusyTimeoutMs is how long a connection waits for a lock before failing
+	BusyTimeoutMs int `mapstructure:"busy_timeout_ms"`
+	// Synchronous is OFF, NORMAL, FULL or EXTRA
+	Synchronous string `mapstructure:"synchronous"`
+	ForeignKeys bool   `mapstructure:"foreign_keys"`
+	// CacheSize is in pages when positive and KiB when negative, as in PRAGMA cache_size
+	CacheSize    int              `mapstructure:"cache_size"`
+	MaxOpenConns int              `mapstructure:"max_open_conns"`
+	MaxIdleConns int              `mapstructure:"max_idle_conns"`
+	Encryption   EncryptionConfig `mapstructure:"encryption"`
+}
+
+// EncryptionConfig controls encryption of note content in the SQLite database
+type EncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// KeyFile holds a 32-byte key; when empty the key is derived from a passphrase
+	KeyFile string `mapstructure:"key_file"`
+	// PassphraseEnv names the environment variable that holds the passphrase
+	PassphraseEnv string `mapstructure:"passphrase_env"`
 }
 
 // APIConfig holds API-specific configuration
@@ -102,6 +253,10 @@ type APIConfig struct {
 	Timeout    int    `mapstructure:"timeout_seconds"`
 	RetryCount int    `mapstructure:"retry_count"`
 	RetryDelay int    `mapstructure:"retry_delay_ms"`
+	// OutboxPath is a SQLite file that queues writes while the server is
+	// unreachable; relative paths are kept next to the database. Empty
+	// disables the outbox, so writes fail while offline.
+	OutboxPath string `mapstructure:"outbox_path"`
 	// TLSInsecureSkipVerify opts out of TLS certificate verification (development only).
 	// Prefer storage.api.tls_insecure_skip_verify in YAML; insecure_skip_verify is deprecated.
 	TLSInsecureSkipVerify bool `mapstructure:"tls_insecure_skip_verify"`
@@ -174,6 +329,12 @@ func (p *Provider) Load() (*Config, error) {
 		p.log.Info("config file loaded", "file", v.ConfigFileUsed())
 	}
 
+	profile, err := ApplyProfile(v, "")
+	if err != nil {
+		return nil, err
+	}
+	p.log.Debug("profile applied", "profile", profile)
+
 	// Bind environment variables explicitly
 	if err := p.bindEnvironmentVariables(v); err != nil {
 		return nil, err
@@ -234,6 +395,36 @@ func (p *Provider) setDefaults(v *viper.Viper, cfg *Config) {
 	v.SetDefault("app.force_kill_timeout", cfg.App.ForceKillTimeout)
 	v.SetDefault("http.startup_timeout", cfg.HTTP.StartupTimeout)
 	v.SetDefault("http.shutdown_timeout", cfg.HTTP.ShutdownTimeout)
+	v.SetDefault("storage.sqlite.journal_mode", cfg.Storage.SQLite.JournalMode)
+	v.SetDefault("storage.sqlite.busy_timeout_ms", cfg.Storage.SQLite.BusyTimeoutMs)
+	v.SetDefault("storage.sqlite.synchronous", cfg.Storage.SQLite.Synchronous)
+	v.SetDefault("storage.sqlite.foreign_keys", cfg.Storage.SQLite.ForeignKeys)
+	v.SetDefault("storage.sqlite.cache_size", cfg.Storage.SQLite.CacheSize)
+	v.SetDefault("storage.sqlite.max_open_conns", cfg.Storage.SQLite.MaxOpenConns)
+	v.SetDefault("storage.sqlite.max_idle_conns", cfg.Storage.SQLite.MaxIdleConns)
+	v.SetDefault("storage.memory.snapshot_path", cfg.Storage.Memory.SnapshotPath)
+	v.SetDefault("storage.memory.save_on_close", cfg.Storage.Memory.SaveOnClose)
+	v.SetDefault("storage.api.outbox_path", cfg.Storage.API.OutboxPath)
+	v.SetDefault("storage.markdown.dir", cfg.Storage.Markdown.Dir)
+	v.SetDefault("storage.sqlite.encryption.enabled", cfg.Storage.SQLite.Encryption.Enabled)
+	v.SetDefault("storage.sqlite.encryption.key_file", cfg.Storage.SQLite.Encryption.KeyFile)
+	v.SetDefault("storage.sqlite.encryption.passphrase_env", cfg.Storage.SQLite.Encryption.PassphraseEnv)
+	v.SetDefault("storage.trash.retention_days", cfg.Storage.Trash.RetentionDays)
+	v.SetDefault("storage.trash.purge_interval_minutes", cfg.Storage.Trash.PurgeIntervalMinutes)
+	v.SetDefault("storage.retention.interval_minutes", cfg.Storage.Retention.IntervalMinutes)
+	v.SetDefault("storage.retention.dry_run", cfg.Storage.Retention.DryRun)
+	v.SetDefault("storage.backup.dir", cfg.Storage.Backup.Dir)
+	v.SetDefault("storage.backup.interval_hours", cfg.Storage.Backup.IntervalHours)
+	v.SetDefault("storage.backup.keep_count", cfg.Storage.Backup.KeepCount)
+	v.SetDefault("storage.backup.keep_days", cfg.Storage.Backup.KeepDays)
+	v.SetDefault("storage.attachments.dir", cfg.Storage.Attachments.Dir)
+	v.SetDefault("storage.attachments.max_size_mb", cfg.Storage.Attachments.MaxSizeMB)
+	v.SetDefault("storage.maintenance.interval_hours", cfg.Storage.Maintenance.IntervalHours)
+	v.SetDefault("storage.sync.enabled", cfg.Storage.Sync.Enabled)
+	v.SetDefault("storage.sync.interval_seconds", cfg.Storage.Sync.IntervalSeconds)
+	v.SetDefault("storage.sync.conflict_policy", cfg.Storage.Sync.ConflictPolicy)
+	v.SetDefault("storage.events.buffer_size", cfg.Storage.Events.BufferSize)
+	v.SetDefault("storage.events.history_size", cfg.Storage.Events.HistorySize)
 }
 
 // configureConfigFile sets up the config file configuration
@@ -305,6 +496,7 @@ func (p *Provider) ResolvePaths(cfg *Config) error {
 	originalPath := cfg.Database.Path
 	cfg.Database.Path = os.ExpandEnv(cfg.Database.Path)
 	cfg.Logger.FilePath = os.ExpandEnv(cfg.Logger.FilePath)
+	cfg.Storage.Markdown.Dir = os.ExpandEnv(cfg.Storage.Markdown.Dir)
 
 	p.log.Debug("Database path resolution",
 		"original", originalPath,
@@ -319,6 +511,12 @@ func (p *Provider) ResolvePaths(cfg *Config) error {
 		cfg.Database.Path = filepath.Join(userConfigDir, "godo", cfg.Database.Path)
 		p.log.Debug("Made database path absolute", "final_path", cfg.Database.Path)
 	}
+
+	// The outbox lives in the data directory unless placed elsewhere
+	cfg.Storage.API.OutboxPath = os.ExpandEnv(cfg.Storage.API.OutboxPath)
+	if cfg.Storage.API.OutboxPath != "" && !filepath.IsAbs(cfg.Storage.API.OutboxPath) {
+		cfg.Storage.API.OutboxPath = filepath.Join(filepath.Dir(cfg.Database.Path), cfg.Storage.API.OutboxPath)
+	}
 	return nil
 }
 
@@ -349,6 +547,41 @@ func ValidateConfig(cfg *Config) error {
 	if err := validateStorageAPI(&cfg.Storage.API); err != nil {
 		validationErrors = append(validationErrors, err.Error())
 	}
+	if cfg.Storage.Trash.RetentionDays < 0 {
+		validationErrors = append(validationErrors, "storage.trash.retention_days must not be negative")
+	}
+	if cfg.Storage.Trash.RetentionDays > 0 && cfg.Storage.Trash.PurgeIntervalMinutes <= 0 {
+		validationErrors = append(validationErrors, "storage.trash.purge_interval_minutes must be positive")
+	}
+	validationErrors = append(validationErrors, validateRetention(cfg.Storage.Retention)...)
+	if cfg.Storage.Backup.IntervalHours < 0 || cfg.Storage.Backup.KeepCount < 0 || cfg.Storage.Backup.KeepDays < 0 {
+		validationErrors = append(validationErrors, "storage.backup interval_hours, keep_count and keep_days must not be negative")
+	}
+	if cfg.Storage.Attachments.MaxSizeMB < 0 {
+		validationErrors = append(validationErrors, "storage.attachments.max_size_mb must not be negative")
+	}
+	if cfg.Storage.SQLite.BusyTimeoutMs < 0 || cfg.Storage.SQLite.MaxOpenConns < 0 || cfg.Storage.SQLite.MaxIdleConns < 0 {
+		validationErrors = append(validationErrors, "storage.sqlite busy_timeout_ms, max_open_conns and max_idle_conns must not be negative")
+	}
+	if cfg.Storage.Memory.SaveOnClose && cfg.Storage.Memory.SnapshotPath == "" {
+		validationErrors = append(validationErrors, "storage.memory.save_on_close needs snapshot_path")
+	}
+	if strings.EqualFold(cfg.Storage.Type, "markdown") && cfg.Storage.Markdown.Dir == "" {
+		validationErrors = append(validationErrors, "storage.markdown.dir is required for markdown storage")
+	}
+	if enc := cfg.Storage.SQLite.Encryption; enc.Enabled && enc.KeyFile == "" && enc.PassphraseEnv == "" {
+		validationErrors = append(validationErrors, "storage.sqlite.encryption needs key_file or passphrase_env when enabled")
+	}
+	if cfg.Storage.Maintenance.IntervalHours < 0 {
+		validationErrors = append(validationErrors, "storage.maintenance.interval_hours must not be negative")
+	}
+	if cfg.Storage.Sync.Enabled {
+		validationErrors = append(validationErrors, validateSync(cfg)...)
+	}
+	if cfg.Storage.Events.BufferSize < 0 || cfg.Storage.Events.HistorySize < 0 {
+		validationErrors = append(validationErrors, "storage.events buffer_size and history_size must not be negative")
+	}
+	validationErrors = append(validationErrors, validateMiddleware(cfg.Storage.Middleware)...)
 
 	if len(validationErrors) > 0 {
 		return &Error{
@@ -360,6 +593,60 @@ func ValidateConfig(cfg *Config) error {
 	return nil
 }
 
+// validateSync checks the settings sync needs once it is enabled
+func validateSync(cfg *Config) []string {
+	var problems []string
+	if !strings.EqualFold(cfg.Storage.Type, "sqlite") {
+		problems = append(problems, "storage.sync needs sqlite storage")
+	}
+	if err := domainstorage.ValidateAPIBaseURL(cfg.Storage.API.BaseURL); err != nil {
+		problems = append(problems, "storage.sync needs storage.api.base_url: "+err.Error())
+	}
+	if cfg.Storage.Sync.IntervalSeconds <= 0 {
+		problems = append(problems, "storage.sync.interval_seconds must be positive")
+	}
+	if !model.SyncConflictPolicy(cfg.Storage.Sync.ConflictPolicy).IsValid() {
+		problems = append(problems, "storage.sync.conflict_policy must be last-writer-wins, local-wins or keep-both")
+	}
+	return problems
+}
+
+// validateRetention checks the retention rules and, when there are any, how
+// often they run
+func validateRetention(retention RetentionConfig) []string {
+	var problems []string
+	for i, rule := range retention.Rules {
+		if !model.RetentionAction(rule.Action).IsValid() {
+			problems = append(problems, fmt.Sprintf("storage.retention.rules[%d].action must be archive or delete", i))
+		}
+		if rule.AfterDays <= 0 {
+			problems = append(problems, fmt.Sprintf("storage.retention.rules[%d].after_days must be positive", i))
+		}
+	}
+	if len(retention.Rules) > 0 && retention.IntervalMinutes <= 0 {
+		problems = append(problems, "storage.retention.interval_minutes must be positive")
+	}
+	return problems
+}
+
+// validateMiddleware checks the storage decorator list
+func validateMiddleware(middleware []MiddlewareConfig) []string {
+	var problems []string
+	for i, m := range middleware {
+		switch m.Type {
+		case domainstorage.MiddlewareMetrics, domainstorage.MiddlewareLogging, domainstorage.MiddlewareCache,
+			domainstorage.MiddlewareRetry, domainstorage.MiddlewareTimeout:
+		default:
+			problems = append(problems, fmt.Sprintf(
+				"storage.middleware[%d].type must be metrics, logging, cache, retry or timeout", i))
+		}
+		if m.SlowMs < 0 || m.Size < 0 || m.TTLSeconds < 0 || m.Attempts < 0 || m.DelayMs < 0 || m.TimeoutMs < 0 {
+			problems = append(problems, fmt.Sprintf("storage.middleware[%d] settings must not be negative", i))
+		}
+	}
+	return problems
+}
+
 func isValidLogLevel(level string) bool {
 	validLevels := map[string]bool{
 		"debug": true,
@@ -436,18 +723,58 @@ func NewDefaultConfig() *Config {
 			ShutdownTimeout:   5,
 			JWTSecret:         "",
 		},
+		Profile: DefaultProfile,
 		Storage: StorageConfig{
 			Type: "sqlite",
 			SQLite: SQLiteConfig{
-				FilePath: "godo.db",
+				FilePath:      "godo.db",
+				JournalMode:   "WAL",
+				BusyTimeoutMs: 5000,
+				Synchronous:   "NORMAL",
+				ForeignKeys:   true,
+				CacheSize:     -2000,
+				MaxOpenConns:  4,
+				MaxIdleConns:  4,
+				Encryption: EncryptionConfig{
+					PassphraseEnv: "GODO_PASSPHRASE",
+				},
 			},
 			API: APIConfig{
 				BaseURL:            "http://localhost:8000/api",
 				Timeout:            30,
 				RetryCount:         3,
 				RetryDelay:         1000,
+				OutboxPath:         "outbox.db",
 				InsecureSkipVerify: false,
 			},
+			Trash: TrashConfig{
+				RetentionDays:        30,
+				PurgeIntervalMinutes: 60,
+			},
+			Retention: RetentionConfig{
+				IntervalMinutes: 60,
+			},
+			Backup: BackupConfig{
+				Dir:           "backups",
+				IntervalHours: 24,
+				KeepCount:     7,
+				KeepDays:      30,
+			},
+			Attachments: AttachmentsConfig{
+				Dir:       "attachments",
+				MaxSizeMB: 25,
+			},
+			Maintenance: MaintenanceConfig{
+				IntervalHours: 24,
+			},
+			Sync: SyncConfig{
+				IntervalSeconds: 300,
+				ConflictPolicy:  string(model.SyncLastWriterWins),
+			},
+			Events: EventsConfig{
+				BufferSize:  64,
+				HistorySize: 1000,
+			},
 		},
 	}
 }
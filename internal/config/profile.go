@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/spf13/viper"
+)
+
+// Profile settings
+const (
+	// KeyProfile selects the profile used when none is given on the command line
+	KeyProfile = "profile"
+	// KeyProfiles holds the named profiles
+	KeyProfiles = "profiles"
+
+	// DefaultProfile is the top-level configuration without overrides. A
+	// profile of the same name may still be defined to override it.
+	DefaultProfile = "default"
+)
+
+// profileSections are the top-level sections a profile may override
+var profileSections = []string{"storage", "hotkeys", "http"}
+
+// ApplyProfile merges the storage, hotkeys and http sections of the named
+// profile over the top-level ones in v, so each profile can keep its own
+// notes, hotkeys and HTTP port. An empty name selects the profile set by the
+// "profile" key, or DefaultProfile. It returns the name of the profile applied.
+// Profile names are case-insensitive.
+func ApplyProfile(v *viper.Viper, name string) (string, error) {
+	if name == "" {
+		name = v.GetString(KeyProfile)
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	profile := v.Sub(KeyProfiles + "." + name)
+	if profile == nil {
+		if name != DefaultProfile {
+			return "", fmt.Errorf("unknown profile %q", name)
+		}
+		v.Set(KeyProfile, name)
+		return name, nil
+	}
+
+	overrides := profile.AllSettings()
+	for section := range overrides {
+		if !slices.Contains(profileSections, section) {
+			return "", fmt.Errorf("profile %q: %s cannot be set per profile", name, section)
+		}
+	}
+	if err := v.MergeConfigMap(overrides); err != nil {
+		return "", fmt.Errorf("failed to apply profile %q: %w", name, err)
+	}
+	v.Set(KeyProfile, name)
+	return name, nil
+}
+
+// ProfileNames returns DefaultProfile followed by the other defined
+// profiles in alphabetical order
+func (c *Config) ProfileNames() []string {
+	names := []string{DefaultProfile}
+	for name := range c.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names[1:])
+	return names
+}
@@ -0,0 +1,111 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/jonesrussell/godo/internal/config"
+)
+
+const profilesYAML = `
+profile: work
+storage:
+  type: sqlite
+  sqlite:
+    file_path: personal.db
+    journal_mode: WAL
+hotkeys:
+  quick_note:
+    modifiers: [Ctrl, Shift]
+    key: "1"
+http:
+  port: 8008
+  startup_timeout: 5
+profiles:
+  work:
+    storage:
+      sqlite:
+        file_path: work.db
+    hotkeys:
+      quick_note:
+        modifiers: [Ctrl, Alt]
+    http:
+      port: 8010
+  demo:
+    storage:
+      type: memory
+`
+
+func loadProfile(t *testing.T, yaml, name string) (*config.Config, error) {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(yaml)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.ApplyProfile(v, name); err != nil {
+		return nil, err
+	}
+	cfg := config.NewDefaultConfig()
+	if err := v.Unmarshal(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg, nil
+}
+
+func TestApplyProfile_MergesOverTopLevel(t *testing.T) {
+	t.Parallel()
+	cfg, err := loadProfile(t, profilesYAML, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Profile != "work" {
+		t.Fatalf("Profile = %q, want the configured default", cfg.Profile)
+	}
+	if cfg.Storage.SQLite.FilePath != "work.db" || cfg.Storage.SQLite.JournalMode != "WAL" {
+		t.Fatalf("storage not merged: %+v", cfg.Storage.SQLite)
+	}
+	if got := cfg.Hotkeys.QuickNote; got.Key != "1" || strings.Join(got.Modifiers, "+") != "Ctrl+Alt" {
+		t.Fatalf("hotkeys not merged: %+v", got)
+	}
+	if cfg.HTTP.Port != 8010 || cfg.HTTP.StartupTimeout != 5 {
+		t.Fatalf("http not merged: %+v", cfg.HTTP)
+	}
+}
+
+func TestApplyProfile_NameOverridesConfiguredProfile(t *testing.T) {
+	t.Parallel()
+	cfg, err := loadProfile(t, profilesYAML, "demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "demo" || cfg.Storage.Type != "memory" || cfg.HTTP.Port != 8008 {
+		t.Fatalf("profile=%q type=%q port=%d", cfg.Profile, cfg.Storage.Type, cfg.HTTP.Port)
+	}
+
+	cfg, err = loadProfile(t, profilesYAML, config.DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.SQLite.FilePath != "personal.db" || cfg.HTTP.Port != 8008 {
+		t.Fatalf("default profile should keep the top-level settings: %+v", cfg.Storage.SQLite)
+	}
+	if got := cfg.ProfileNames(); strings.Join(got, ",") != "default,demo,work" {
+		t.Fatalf("ProfileNames = %v", got)
+	}
+}
+
+func TestApplyProfile_RejectsUnknownProfilesAndSections(t *testing.T) {
+	t.Parallel()
+	if _, err := loadProfile(t, profilesYAML, "missing"); err == nil {
+		t.Fatal("expected an error for an unknown profile")
+	}
+
+	yaml := "profiles:\n  work:\n    logger:\n      level: debug\n"
+	if _, err := loadProfile(t, yaml, "work"); err == nil {
+		t.Fatal("expected an error for a section profiles cannot override")
+	}
+}
@@ -0,0 +1,64 @@
+// Package audit defines the append-only audit trail recorded for note mutations
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// Operation identifies the kind of mutation recorded in the audit trail
+type Operation string
+
+const (
+	OperationCreate  Operation = "create"
+	OperationUpdate  Operation = "update"
+	OperationDelete  Operation = "delete"
+	OperationRestore Operation = "restore"
+	OperationPurge   Operation = "purge"
+)
+
+// Well-known actors for mutations that do not come from an authenticated API user
+const (
+	ActorGUI    = "gui"
+	ActorHotkey = "hotkey"
+	ActorSystem = "system"
+	// ActorSync attributes changes pulled from a sync remote
+	ActorSync = "sync"
+)
+
+// Event is a single immutable audit record
+type Event struct {
+	ID        int64       `json:"id"`
+	Actor     string      `json:"actor"`
+	Operation Operation   `json:"operation"`
+	NoteID    string      `json:"note_id"`
+	Before    *model.Note `json:"before,omitempty"`
+	After     *model.Note `json:"after,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Filter narrows audit queries; zero values mean "no constraint"
+type Filter struct {
+	NoteID string
+	Since  *time.Time
+	Limit  int
+}
+
+// actorKey is the context key for the acting user
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor responsible for subsequent mutations
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or ActorSystem when none is set
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && strings.TrimSpace(actor) != "" {
+		return actor
+	}
+	return ActorSystem
+}
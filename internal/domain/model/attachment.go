@@ -0,0 +1,57 @@
+package model
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAttachmentNotFound is returned when a requested attachment does not exist
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentTooLarge is returned when an attachment exceeds the size limit
+	ErrAttachmentTooLarge = errors.New("attachment is larger than the size limit")
+)
+
+// MaxAttachmentNameLength is the longest attachment file name accepted
+const MaxAttachmentNameLength = 255
+
+// Attachment is a file attached to a note. Its content is stored once per
+// distinct SHA256, however many notes it is attached to.
+type Attachment struct {
+	ID          string    `json:"id"`
+	NoteID      string    `json:"note_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentName reduces a client-supplied file name to its last path
+// element, so a name can never point outside the note it is attached to
+func AttachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// DetectContentType works out the media type of an attachment from its name
+// or, failing that, its first bytes. It returns a reader that still yields
+// the whole content.
+func DetectContentType(name string, content io.Reader) (string, io.Reader) {
+	if byName := mime.TypeByExtension(filepath.Ext(name)); byName != "" {
+		return byName, content
+	}
+	buffered := bufio.NewReaderSize(content, 512)
+	// A short read only means the content is smaller than the sniff window
+	head, _ := buffered.Peek(512)
+	return http.DetectContentType(head), buffered
+}
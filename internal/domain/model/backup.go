@@ -0,0 +1,20 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrBackupNotFound is returned when a requested backup does not exist
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrBackupCorrupt is returned when a backup fails its integrity check
+	ErrBackupCorrupt = errors.New("backup failed integrity check")
+)
+
+// Backup describes a point-in-time snapshot of the note database
+type Backup struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// NoteEventType is the kind of change a NoteEvent reports
+type NoteEventType string
+
+// Note event types
+const (
+	NoteCreated NoteEventType = "created"
+	NoteUpdated NoteEventType = "updated"
+	NoteDeleted NoteEventType = "deleted"
+	// NoteEventsLost tells a subscriber that events it asked for are no
+	// longer available; it should reload the notes rather than resume
+	NoteEventsLost NoteEventType = "reset"
+)
+
+// NoteEvent is a committed change to a note. Seq increases with every event,
+// across restarts too, so a subscriber can resume after the last one it saw.
+type NoteEvent struct {
+	Seq    uint64        `json:"seq"`
+	Type   NoteEventType `json:"type"`
+	NoteID string        `json:"note_id,omitempty"`
+	// Before is the note as it was; nil for created notes or when unknown
+	Before *Note `json:"before,omitempty"`
+	// After is the note as it is now; nil for deleted notes
+	After *Note `json:"after,omitempty"`
+	// Actor is who made the change, as recorded in the audit trail
+	Actor string    `json:"actor,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// SlowConsumerPolicy decides what happens to a subscriber whose buffer is full
+type SlowConsumerPolicy string
+
+// Slow consumer policies
+const (
+	// SlowConsumerDisconnect closes the subscriber's channel; it can
+	// subscribe again with Since set to the last Seq it received
+	SlowConsumerDisconnect SlowConsumerPolicy = "disconnect"
+	// SlowConsumerDropOldest discards the oldest buffered event to make
+	// room, leaving a gap in Seq
+	SlowConsumerDropOldest SlowConsumerPolicy = "drop-oldest"
+)
+
+// NoteEventFilter selects the events a subscriber receives
+type NoteEventFilter struct {
+	// Types limits the events to these types; empty means all
+	Types []NoteEventType
+	// NoteID limits the events to one note
+	NoteID string
+	// Since replays the retained events after this Seq before live ones
+	Since uint64
+	// SlowConsumer defaults to SlowConsumerDisconnect
+	SlowConsumer SlowConsumerPolicy
+}
+
+// Matches reports whether e passes the filter. NoteEventsLost always does.
+func (f NoteEventFilter) Matches(e NoteEvent) bool {
+	if e.Type == NoteEventsLost {
+		return true
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	return f.NoteID == "" || f.NoteID == e.NoteID
+}
@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+// NoteSort selects the order of a filtered note list. A leading "-" sorts
+// descending. Ties are broken by note ID in the same direction.
+type NoteSort string
+
+// Supported sort orders
+const (
+	SortCreatedDesc NoteSort = "-created_at"
+	SortCreatedAsc  NoteSort = "created_at"
+	SortUpdatedDesc NoteSort = "-updated_at"
+	SortUpdatedAsc  NoteSort = "updated_at"
+)
+
+// DefaultNoteSort is used when NoteFilter.Sort is empty
+const DefaultNoteSort = SortCreatedDesc
+
+// IsValid reports whether s is empty or one of the supported sort orders
+func (s NoteSort) IsValid() bool {
+	switch s {
+	case "", SortCreatedDesc, SortCreatedAsc, SortUpdatedDesc, SortUpdatedAsc:
+		return true
+	default:
+		return false
+	}
+}
+
+// ArchiveScope selects archived notes in a NoteFilter
+type ArchiveScope string
+
+// Supported archive scopes
+const (
+	// ArchiveExclude leaves archived notes out. It is the zero value, so
+	// lists hide archived notes unless asked for them.
+	ArchiveExclude ArchiveScope = ""
+	// ArchiveOnly lists archived notes only
+	ArchiveOnly ArchiveScope = "only"
+	// ArchiveInclude lists archived notes along with the others
+	ArchiveInclude ArchiveScope = "include"
+)
+
+// IsValid reports whether s is one of the supported archive scopes
+func (s ArchiveScope) IsValid() bool {
+	switch s {
+	case ArchiveExclude, ArchiveOnly, ArchiveInclude:
+		return true
+	default:
+		return false
+	}
+}
+
+// Matches reports whether a note archived or not, as given, is in scope
+func (s ArchiveScope) Matches(archived bool) bool {
+	switch s {
+	case ArchiveOnly:
+		return archived
+	case ArchiveInclude:
+		return true
+	default:
+		return !archived
+	}
+}
+
+// NoteFilter represents filtering options for note queries. Nil fields do not
+// constrain the result; archived notes are left out unless Archived asks for them.
+type NoteFilter struct {
+	Done          *bool      `json:"done,omitempty"`
+	Content       *string    `json:"content,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	Limit         *int       `json:"limit,omitempty"`
+	Offset        *int       `json:"offset,omitempty"`
+	Sort          NoteSort   `json:"sort,omitempty"`
+	// Archived selects archived notes; see ArchiveScope
+	Archived ArchiveScope `json:"archived,omitempty"`
+	// Tags keeps notes carrying every one of these tags; ExcludeTags drops
+	// notes carrying any of those
+	Tags        []string `json:"tags,omitempty"`
+	ExcludeTags []string `json:"exclude_tags,omitempty"`
+	// Cursor continues a listing after the last note of a previous page. It
+	// is only valid with created_at sort orders and without Offset.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// UsesKeyset reports whether the filter's sort order supports cursor pagination
+func (f NoteFilter) UsesKeyset() bool {
+	return (f.Sort == "" || f.Sort == SortCreatedDesc || f.Sort == SortCreatedAsc) &&
+		(f.Offset == nil || *f.Offset == 0)
+}
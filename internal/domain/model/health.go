@@ -0,0 +1,35 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrReadOnly is returned for writes while storage is in degraded, read-only mode
+var ErrReadOnly = errors.New("storage is read-only")
+
+// HealthStatus summarizes the outcome of a storage integrity check
+type HealthStatus string
+
+const (
+	// HealthOK means the last check found no problems
+	HealthOK HealthStatus = "ok"
+	// HealthDegraded means the last check found corruption; storage is read-only
+	HealthDegraded HealthStatus = "degraded"
+)
+
+// StorageHealth is the result of the most recent storage integrity check
+type StorageHealth struct {
+	Status HealthStatus `json:"status"`
+	// ReadOnly is set while writes are refused because of detected corruption
+	ReadOnly bool `json:"read_only"`
+	// Check names the check that produced this result, e.g. "quick_check"
+	Check     string    `json:"check"`
+	Problems  []string  `json:"problems,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Healthy reports whether the check found no problems
+func (h StorageHealth) Healthy() bool {
+	return h.Status == HealthOK
+}
@@ -0,0 +1,104 @@
+package model
+
+// ConflictPolicy decides what an import does with a note whose ID is already
+// stored
+type ConflictPolicy string
+
+// Supported import conflict policies
+const (
+	// ConflictSkip keeps the stored note and ignores the imported one
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the stored note with the imported one
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictNewerWins replaces the stored note only when the imported one
+	// was updated later
+	ConflictNewerWins ConflictPolicy = "newer-wins"
+)
+
+// IsValid reports whether p is one of the supported conflict policies
+func (p ConflictPolicy) IsValid() bool {
+	switch p {
+	case ConflictSkip, ConflictOverwrite, ConflictNewerWins:
+		return true
+	default:
+		return false
+	}
+}
+
+// Replaces reports whether importing incoming under p replaces stored, a
+// note with the same ID
+func (p ConflictPolicy) Replaces(stored, incoming *Note) bool {
+	switch p {
+	case ConflictOverwrite:
+		return true
+	case ConflictNewerWins:
+		return incoming.UpdatedAt.After(stored.UpdatedAt)
+	default:
+		return false
+	}
+}
+
+// ImportOutcome says what an import did with one note
+type ImportOutcome string
+
+// Import outcomes
+const (
+	// ImportCreated means the note was new and has been added
+	ImportCreated ImportOutcome = "created"
+	// ImportUpdated means a stored note was replaced
+	ImportUpdated ImportOutcome = "updated"
+	// ImportSkipped means a stored note was kept under the conflict policy
+	ImportSkipped ImportOutcome = "skipped"
+	// ImportFailed means the note could not be imported; Error says why
+	ImportFailed ImportOutcome = "failed"
+)
+
+// ImportResult is the outcome of importing one note
+type ImportResult struct {
+	ID      string        `json:"id"`
+	Outcome ImportOutcome `json:"outcome"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// ImportReport lists the outcome of every note in an import, in the order
+// the notes were given
+type ImportReport struct {
+	Results []ImportResult `json:"results"`
+	// Created, Updated, Skipped and Failed count the results by outcome
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// Add records the outcome of importing the note with the given ID; err is
+// only used for ImportFailed
+func (r *ImportReport) Add(id string, outcome ImportOutcome, err error) {
+	result := ImportResult{ID: id, Outcome: outcome}
+	switch outcome {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+	r.Results = append(r.Results, result)
+}
+
+// CheckImport reports why note cannot be imported as it is. Imported notes
+// bring their own ID and timestamps, so unlike new notes they must be set.
+func CheckImport(note *Note) error {
+	if note.ID == "" {
+		return &ValidationError{Field: "id", Message: "imported notes need an ID"}
+	}
+	if note.CreatedAt.IsZero() || note.UpdatedAt.IsZero() {
+		return &ValidationError{Field: "created_at", Message: "imported notes need created_at and updated_at"}
+	}
+	return note.IsValid()
+}
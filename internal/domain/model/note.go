@@ -15,6 +15,16 @@ type Note struct {
 	Done      bool      `json:"done"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set while the note is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ArchivedAt is set while the note is archived; archived notes are left
+	// out of filtered lists unless the filter asks for them
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// Tags are normalized tag names in sorted order; see NormalizeTags
+	Tags []string `json:"tags,omitempty"`
+	// Version increases with every write. Storage treats a non-zero Version on
+	// an update as the version the caller expects to replace; zero skips the check.
+	Version int64 `json:"version"`
 }
 
 // NewNote creates a new Note item
@@ -26,6 +36,7 @@ func NewNote(content string) *Note {
 		Done:      false,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Version:   1,
 	}
 }
 
@@ -67,7 +78,7 @@ func (n *Note) IsValid() error {
 			Message: "note content cannot exceed 1000 characters",
 		}
 	}
-	return nil
+	return CheckTags(n.Tags)
 }
 
 // ValidationError represents a validation error
@@ -83,4 +94,7 @@ func (e *ValidationError) Error() string {
 var (
 	ErrNoteNotFound = errors.New("note not found")
 	ErrDuplicateID  = errors.New("note ID already exists")
+	// ErrVersionConflict is returned when a conditional write targets a
+	// version of the note that has since been replaced
+	ErrVersionConflict = errors.New("note was modified by another writer")
 )
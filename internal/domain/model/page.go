@@ -0,0 +1,14 @@
+package model
+
+import "errors"
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// does not apply to the requested sort order
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// NotePage is one page of a cursor-paginated note listing
+type NotePage struct {
+	Notes []*Note `json:"notes"`
+	// NextCursor fetches the following page; it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// StorageProblem describes an entry a backend found but could not read
+// cleanly, such as a note file whose front matter was broken by hand
+type StorageProblem struct {
+	// Source identifies the entry, for example a file name
+	Source string `json:"source"`
+	// NoteID is the ID the entry is listed under, or empty if it is not listed
+	NoteID string `json:"note_id,omitempty"`
+	// Message explains what is wrong
+	Message string `json:"message"`
+}
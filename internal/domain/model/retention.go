@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// RetentionAction is what a retention rule does to the notes it selects
+type RetentionAction string
+
+// Supported retention actions
+const (
+	// RetentionArchive archives done notes that have not changed for the
+	// rule's age
+	RetentionArchive RetentionAction = "archive"
+	// RetentionDelete permanently deletes notes that have been archived for
+	// the rule's age, bypassing the trash.
+	RetentionDelete RetentionAction = "delete"
+)
+
+// IsValid reports whether a is one of the supported retention actions
+func (a RetentionAction) IsValid() bool {
+	switch a {
+	case RetentionArchive, RetentionDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// RetentionRule applies an action to the notes that have been idle for
+// longer than After
+type RetentionRule struct {
+	Action RetentionAction
+	After  time.Duration
+}
+
+// Selects reports whether the rule applies to note at now
+func (r RetentionRule) Selects(note *Note, now time.Time) bool {
+	cutoff := now.Add(-r.After)
+	switch r.Action {
+	case RetentionArchive:
+		return note.Done && note.ArchivedAt == nil && !note.UpdatedAt.After(cutoff)
+	case RetentionDelete:
+		return note.ArchivedAt != nil && !note.ArchivedAt.After(cutoff)
+	default:
+		return false
+	}
+}
+
+// RetentionReport lists the notes a retention pass changed or, in a dry
+// run, would have changed
+type RetentionReport struct {
+	DryRun bool `json:"dry_run"`
+	// Archived and Deleted hold note IDs
+	Archived []string `json:"archived"`
+	Deleted  []string `json:"deleted"`
+}
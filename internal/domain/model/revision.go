@@ -0,0 +1,20 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRevisionNotFound is returned when a requested note revision does not exist
+var ErrRevisionNotFound = errors.New("note revision not found")
+
+// NoteRevision is a snapshot of a note's content and done state before an update
+type NoteRevision struct {
+	NoteID     string    `json:"note_id"`
+	Revision   int       `json:"revision"`
+	Content    string    `json:"content"`
+	Done       bool      `json:"done"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Actor      string    `json:"actor"`
+}
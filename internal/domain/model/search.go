@@ -0,0 +1,17 @@
+package model
+
+// Markers wrapped around matched terms in SearchHit.Snippet
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// SearchHit is a note matched by a full-text search
+type SearchHit struct {
+	Note Note `json:"note"`
+	// Rank is the bm25 score; lower values are better matches
+	Rank float64 `json:"rank"`
+	// Snippet is an excerpt of the content with matched terms wrapped in
+	// HighlightStart and HighlightEnd
+	Snippet string `json:"snippet"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// SyncConflictPolicy decides which side wins when a note changed both
+// locally and on the sync remote since they last agreed
+type SyncConflictPolicy string
+
+// Supported conflict policies
+const (
+	// SyncLastWriterWins keeps whichever side was updated most recently
+	SyncLastWriterWins SyncConflictPolicy = "last-writer-wins"
+	// SyncLocalWins always keeps the local note and overwrites the remote one
+	SyncLocalWins SyncConflictPolicy = "local-wins"
+	// SyncKeepBoth takes the remote note and keeps the local edit as a new note
+	SyncKeepBoth SyncConflictPolicy = "keep-both"
+)
+
+// IsValid reports whether p is one of the supported conflict policies
+func (p SyncConflictPolicy) IsValid() bool {
+	switch p {
+	case SyncLastWriterWins, SyncLocalWins, SyncKeepBoth:
+		return true
+	default:
+		return false
+	}
+}
+
+// SyncState summarizes how the last sync run went
+type SyncState string
+
+// Sync states
+const (
+	// SyncIdle means no sync has run yet
+	SyncIdle SyncState = "idle"
+	// SyncRunning means a sync run is in progress
+	SyncRunning SyncState = "syncing"
+	// SyncOK means the last run pushed and pulled everything
+	SyncOK SyncState = "ok"
+	// SyncOffline means the remote could not be reached; local edits are kept
+	// and pushed on a later run
+	SyncOffline SyncState = "offline"
+	// SyncFailed means the remote answered but the run did not complete
+	SyncFailed SyncState = "error"
+)
+
+// SyncStatus reports the state of two-way sync with a remote
+type SyncStatus struct {
+	State SyncState `json:"state"`
+	// LastSyncAt is when a run last completed, nil before the first one
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
+	// LastError describes why the last run failed
+	LastError string `json:"last_error,omitempty"`
+	// Pending is the number of notes with local changes not yet pushed
+	Pending int `json:"pending"`
+	// Pushed, Pulled and Conflicts count what the last run did
+	Pushed    int `json:"pushed"`
+	Pulled    int `json:"pulled"`
+	Conflicts int `json:"conflicts"`
+}
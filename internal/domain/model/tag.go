@@ -0,0 +1,127 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrTagNotFound is returned when a tag to rename is not on any note
+var ErrTagNotFound = errors.New("tag not found")
+
+// Tag limits
+const (
+	// MaxTagLength is the longest tag name accepted, in characters
+	MaxTagLength = 50
+	// MaxNoteTags is the most tags one note may carry
+	MaxNoteTags = 20
+)
+
+// TagCount is a tag together with the number of notes carrying it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTag returns the stored form of a tag name: trimmed, without a
+// leading "#" and in lower case, so "#Work" and "work" are the same tag
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
+
+// NormalizeTags normalizes each tag and returns them sorted without
+// duplicates in a new slice. Empty names are dropped, and a note without
+// tags gets nil.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// CheckTag reports why a normalized tag name cannot be used. Names are made
+// of letters, digits, "-" and "_", so they fit in a URL path segment and
+// read the same as a "#tag" in note text.
+func CheckTag(name string) error {
+	if name == "" {
+		return &ValidationError{Field: "tags", Message: "tag name cannot be empty"}
+	}
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return &ValidationError{Field: "tags", Message: "tag name cannot exceed 50 characters"}
+	}
+	for _, r := range name {
+		if !isTagRune(r) {
+			return &ValidationError{Field: "tags", Message: "tag names may only contain letters, digits, '-' and '_'"}
+		}
+	}
+	return nil
+}
+
+// CheckTags reports why a note cannot carry the given normalized tags
+func CheckTags(tags []string) error {
+	if len(tags) > MaxNoteTags {
+		return &ValidationError{Field: "tags", Message: "a note cannot have more than 20 tags"}
+	}
+	for _, tag := range tags {
+		if err := CheckTag(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseTags takes "#tag" words out of note text, as typed in the quick-note
+// entry, and returns the remaining text with the tags they named. A word
+// counts as a tag when it is a valid tag name with at least one letter, so
+// "#42" stays in the text as an issue reference. Lines that held only tags
+// are dropped and the spacing of lines that held some is collapsed.
+func ParseTags(text string) (string, []string) {
+	var tags []string
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		words := strings.Fields(line)
+		rest := words[:0]
+		found := false
+		for _, word := range words {
+			if tag, ok := hashtag(word); ok {
+				tags = append(tags, tag)
+				found = true
+				continue
+			}
+			rest = append(rest, word)
+		}
+		if !found {
+			kept = append(kept, line)
+			continue
+		}
+		if len(rest) > 0 {
+			kept = append(kept, strings.Join(rest, " "))
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")), NormalizeTags(tags)
+}
+
+// hashtag returns the tag a "#tag" word names
+func hashtag(word string) (string, bool) {
+	name, ok := strings.CutPrefix(word, "#")
+	if !ok {
+		return "", false
+	}
+	name = NormalizeTag(name)
+	if CheckTag(name) != nil || !strings.ContainsFunc(name, unicode.IsLetter) {
+		return "", false
+	}
+	return name, true
+}
+
+// isTagRune reports whether r may appear in a tag name
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_'
+}
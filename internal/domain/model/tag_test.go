@@ -0,0 +1,54 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		text        string
+		wantContent string
+		wantTags    []string
+	}{
+		{
+			name:        "no tags",
+			text:        "buy milk",
+			wantContent: "buy milk",
+		},
+		{
+			name:        "tags between words",
+			text:        "call  #Work the bank #errands today",
+			wantContent: "call the bank today",
+			wantTags:    []string{"errands", "work"},
+		},
+		{
+			name:        "issue numbers stay",
+			text:        "fix #42 #bug",
+			wantContent: "fix #42",
+			wantTags:    []string{"bug"},
+		},
+		{
+			name:        "line of only tags is dropped",
+			text:        "draft the report\n#work #q3\n  indented stays",
+			wantContent: "draft the report\n  indented stays",
+			wantTags:    []string{"q3", "work"},
+		},
+		{
+			name:        "invalid names stay",
+			text:        "see #a/b and # alone",
+			wantContent: "see #a/b and # alone",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			content, tags := ParseTags(tt.text)
+			if content != tt.wantContent || !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Fatalf("ParseTags(%q) = %q, %v, want %q, %v", tt.text, content, tags, tt.wantContent, tt.wantTags)
+			}
+		})
+	}
+}
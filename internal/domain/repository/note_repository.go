@@ -2,7 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
 	"github.com/jonesrussell/godo/internal/domain/storage"
 )
@@ -12,7 +17,40 @@ type NoteRepository interface {
 	GetByID(ctx context.Context, id string) (*model.Note, error)
 	Update(ctx context.Context, note *model.Note) error
 	Delete(ctx context.Context, id string) error
+	DeleteIfVersion(ctx context.Context, id string, version int64) error
 	List(ctx context.Context) ([]*model.Note, error)
+	Query(ctx context.Context, filter model.NoteFilter) ([]*model.Note, error)
+	Page(ctx context.Context, filter model.NoteFilter) (*model.NotePage, error)
+	ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error)
+	ListRevisions(ctx context.Context, noteID string) ([]model.NoteRevision, error)
+	GetRevision(ctx context.Context, noteID string, revision int) (*model.NoteRevision, error)
+	ListTrash(ctx context.Context) ([]*model.Note, error)
+	Restore(ctx context.Context, id string) (*model.Note, error)
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error)
+	PurgeNote(ctx context.Context, id string) error
+	Archive(ctx context.Context, id string) (*model.Note, error)
+	Unarchive(ctx context.Context, id string) (*model.Note, error)
+	SetTags(ctx context.Context, id string, tags []string) (*model.Note, error)
+	ListTags(ctx context.Context) ([]model.TagCount, error)
+	RenameTag(ctx context.Context, from, to string) (int, error)
+	AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error)
+	ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error)
+	OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, id string) error
+	PruneAttachments(ctx context.Context) (int, error)
+	Search(ctx context.Context, query string, limit int) ([]model.SearchHit, error)
+	ImportNotes(ctx context.Context, notes []model.Note, policy model.ConflictPolicy) (*model.ImportReport, error)
+	WithinTx(ctx context.Context, fn func(repo NoteRepository) error) error
+	Backup(ctx context.Context) (*model.Backup, error)
+	ListBackups(ctx context.Context) ([]model.Backup, error)
+	RestoreBackup(ctx context.Context, name string) error
+	Health(ctx context.Context) (*model.StorageHealth, error)
+	CheckIntegrity(ctx context.Context) (*model.StorageHealth, error)
+	Optimize(ctx context.Context) error
+	Sync(ctx context.Context) (*model.SyncStatus, error)
+	SyncStatus(ctx context.Context) (*model.SyncStatus, error)
+	QueueDepth(ctx context.Context) (int, error)
+	Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent
 }
 
 type noteRepository struct {
@@ -23,19 +61,69 @@ func NewNoteRepository(store storage.UnifiedNoteStorage) NoteRepository {
 	return &noteRepository{store: store}
 }
 
+// Add stores a new note. Backends that can import keep the note's own ID,
+// done state and timestamps; others assign their own, which are copied back,
+// and are given the note's tags once it is created. A note with tags fails
+// with ErrNotSupported, before anything is written, when the backend cannot
+// keep tags.
 func (r *noteRepository) Add(ctx context.Context, note *model.Note) error {
 	if err := note.IsValid(); err != nil {
 		return err
 	}
-	// Use the unified storage CreateNote method
+	if importer, ok := storage.As[storage.Importer](r.store); ok && note.ID != "" {
+		err := r.addImported(ctx, importer, note)
+		// Also reached when a decorator offers imports the backend lacks
+		if !errors.Is(err, storage.ErrNotSupported) {
+			return err
+		}
+	}
+	if len(note.Tags) > 0 && !storage.Supports[storage.Tagger](r.store) {
+		return storage.ErrNotSupported
+	}
 	createdNote, err := r.store.CreateNote(ctx, note.Content)
 	if err != nil {
 		return err
 	}
+	if len(note.Tags) > 0 {
+		if createdNote, err = r.SetTags(ctx, createdNote.ID, note.Tags); err != nil {
+			return err
+		}
+	}
 	// Copy the generated ID and timestamps back to the original note
 	note.ID = createdNote.ID
+	note.Tags = createdNote.Tags
 	note.CreatedAt = createdNote.CreatedAt
 	note.UpdatedAt = createdNote.UpdatedAt
+	note.Version = createdNote.Version
+	return nil
+}
+
+// addImported stores note as a one-note import that never replaces a stored
+// note, then reads it back for its version
+func (r *noteRepository) addImported(ctx context.Context, importer storage.Importer, note *model.Note) error {
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = time.Now()
+	}
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = note.CreatedAt
+	}
+	report, err := importer.ImportNotes(ctx, []model.Note{*note}, model.ConflictSkip)
+	if err != nil {
+		return err
+	}
+	switch result := report.Results[0]; result.Outcome {
+	case model.ImportCreated:
+	case model.ImportSkipped:
+		return model.ErrDuplicateID
+	default:
+		return fmt.Errorf("failed to add note %s: %s", note.ID, result.Error)
+	}
+	stored, err := r.store.GetNote(ctx, note.ID)
+	if err != nil {
+		return mapStorageError(err)
+	}
+	note.Version = stored.Version
+	note.Tags = stored.Tags
 	return nil
 }
 
@@ -51,13 +139,22 @@ func (r *noteRepository) Update(ctx context.Context, note *model.Note) error {
 	if err := note.IsValid(); err != nil {
 		return err
 	}
-	// Use the unified storage UpdateNote method
-	updatedNote, err := r.store.UpdateNote(ctx, note.ID, note.Content, note.Done)
+	// A versioned note is only written over the version it was read at
+	var updatedNote *model.Note
+	err := storage.ErrNotSupported
+	if writer, ok := storage.As[storage.ConditionalWriter](r.store); ok && note.Version != 0 {
+		updatedNote, err = writer.UpdateNoteIfVersion(ctx, note.ID, note.Content, note.Done, note.Version)
+	}
+	// Also reached when a decorator offers conditional writes the backend lacks
+	if errors.Is(err, storage.ErrNotSupported) {
+		updatedNote, err = r.store.UpdateNote(ctx, note.ID, note.Content, note.Done)
+	}
 	if err != nil {
 		return err
 	}
-	// Copy the updated timestamp back to the original note
+	// Copy the updated timestamp and version back to the original note
 	note.UpdatedAt = updatedNote.UpdatedAt
+	note.Version = updatedNote.Version
 	return nil
 }
 
@@ -65,10 +162,336 @@ func (r *noteRepository) Delete(ctx context.Context, id string) error {
 	return r.store.DeleteNote(ctx, id)
 }
 
+// DeleteIfVersion deletes a note only while it is at version. Backends that
+// cannot delete conditionally are checked with a read first, which narrows
+// but does not close the race.
+func (r *noteRepository) DeleteIfVersion(ctx context.Context, id string, version int64) error {
+	if version == 0 {
+		return r.store.DeleteNote(ctx, id)
+	}
+	if writer, ok := storage.As[storage.ConditionalWriter](r.store); ok {
+		err := writer.DeleteNoteIfVersion(ctx, id, version)
+		if !errors.Is(err, storage.ErrNotSupported) {
+			return err
+		}
+	}
+	note, err := r.store.GetNote(ctx, id)
+	if err != nil {
+		return mapStorageError(err)
+	}
+	if note.Version != version {
+		return model.ErrVersionConflict
+	}
+	return r.store.DeleteNote(ctx, id)
+}
+
 func (r *noteRepository) List(ctx context.Context) ([]*model.Note, error) {
 	return r.store.GetAllNotes(ctx)
 }
 
+// Query returns the notes matching filter, delegating to the backend when it
+// supports queries and filtering in memory otherwise
+func (r *noteRepository) Query(ctx context.Context, filter model.NoteFilter) ([]*model.Note, error) {
+	if querier, ok := storage.As[storage.NoteQuerier](r.store); ok {
+		return querier.QueryNotes(ctx, filter)
+	}
+	notes, err := r.store.GetAllNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ApplyFilter(notes, filter), nil
+}
+
+// Page returns one page of the notes matching filter. Backends without
+// native pagination are paged in memory.
+func (r *noteRepository) Page(ctx context.Context, filter model.NoteFilter) (*model.NotePage, error) {
+	if pager, ok := storage.As[storage.NotePager](r.store); ok {
+		return pager.PageNotes(ctx, filter)
+	}
+	notes, err := r.store.GetAllNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.PageFilter(notes, filter)
+}
+
+func (r *noteRepository) ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+	auditLog, ok := storage.As[storage.AuditLog](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return auditLog.ListAuditEvents(ctx, filter)
+}
+
+func (r *noteRepository) ListRevisions(ctx context.Context, noteID string) ([]model.NoteRevision, error) {
+	revisions, ok := storage.As[storage.RevisionStore](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return revisions.ListRevisions(ctx, noteID)
+}
+
+func (r *noteRepository) GetRevision(ctx context.Context, noteID string, revision int) (*model.NoteRevision, error) {
+	revisions, ok := storage.As[storage.RevisionStore](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return revisions.GetRevision(ctx, noteID, revision)
+}
+
+func (r *noteRepository) ListTrash(ctx context.Context) ([]*model.Note, error) {
+	trash, ok := storage.As[storage.Trash](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return trash.ListTrash(ctx)
+}
+
+func (r *noteRepository) Restore(ctx context.Context, id string) (*model.Note, error) {
+	trash, ok := storage.As[storage.Trash](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	note, err := trash.RestoreNote(ctx, id)
+	if err != nil {
+		return nil, mapStorageError(err)
+	}
+	return note, nil
+}
+
+func (r *noteRepository) Archive(ctx context.Context, id string) (*model.Note, error) {
+	archiver, ok := storage.As[storage.Archiver](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	note, err := archiver.ArchiveNote(ctx, id)
+	if err != nil {
+		return nil, mapStorageError(err)
+	}
+	return note, nil
+}
+
+func (r *noteRepository) Unarchive(ctx context.Context, id string) (*model.Note, error) {
+	archiver, ok := storage.As[storage.Archiver](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	note, err := archiver.UnarchiveNote(ctx, id)
+	if err != nil {
+		return nil, mapStorageError(err)
+	}
+	return note, nil
+}
+
+func (r *noteRepository) SetTags(ctx context.Context, id string, tags []string) (*model.Note, error) {
+	tagger, ok := storage.As[storage.Tagger](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	note, err := tagger.SetNoteTags(ctx, id, tags)
+	if err != nil {
+		return nil, mapStorageError(err)
+	}
+	return note, nil
+}
+
+func (r *noteRepository) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	tagger, ok := storage.As[storage.Tagger](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return tagger.ListTags(ctx)
+}
+
+func (r *noteRepository) RenameTag(ctx context.Context, from, to string) (int, error) {
+	tagger, ok := storage.As[storage.Tagger](r.store)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return tagger.RenameTag(ctx, from, to)
+}
+
+func (r *noteRepository) AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error) {
+	attacher, ok := storage.As[storage.Attacher](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	attachment, err := attacher.AddAttachment(ctx, noteID, name, contentType, content)
+	if err != nil {
+		return nil, mapStorageError(err)
+	}
+	return attachment, nil
+}
+
+func (r *noteRepository) ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error) {
+	attacher, ok := storage.As[storage.Attacher](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	attachments, err := attacher.ListAttachments(ctx, noteID)
+	if err != nil {
+		return nil, mapStorageError(err)
+	}
+	return attachments, nil
+}
+
+func (r *noteRepository) OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error) {
+	attacher, ok := storage.As[storage.Attacher](r.store)
+	if !ok {
+		return nil, nil, storage.ErrNotSupported
+	}
+	return attacher.OpenAttachment(ctx, id)
+}
+
+func (r *noteRepository) DeleteAttachment(ctx context.Context, id string) error {
+	attacher, ok := storage.As[storage.Attacher](r.store)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return attacher.DeleteAttachment(ctx, id)
+}
+
+func (r *noteRepository) PruneAttachments(ctx context.Context) (int, error) {
+	attacher, ok := storage.As[storage.Attacher](r.store)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return attacher.PruneAttachments(ctx)
+}
+
+func (r *noteRepository) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error) {
+	trash, ok := storage.As[storage.Trash](r.store)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return trash.PurgeTrash(ctx, deletedBefore)
+}
+
+func (r *noteRepository) PurgeNote(ctx context.Context, id string) error {
+	trash, ok := storage.As[storage.Trash](r.store)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return trash.PurgeNote(ctx, id)
+}
+
+func (r *noteRepository) Search(ctx context.Context, query string, limit int) ([]model.SearchHit, error) {
+	searcher, ok := storage.As[storage.Searcher](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return searcher.Search(ctx, query, limit)
+}
+
+// ImportNotes stores notes with their own IDs and timestamps, keeping or
+// replacing stored notes according to policy
+func (r *noteRepository) ImportNotes(
+	ctx context.Context, notes []model.Note, policy model.ConflictPolicy,
+) (*model.ImportReport, error) {
+	importer, ok := storage.As[storage.Importer](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return importer.ImportNotes(ctx, notes, policy)
+}
+
+// WithinTx runs fn with a repository whose operations share one transaction,
+// committing when fn returns nil. It returns storage.ErrNotSupported without
+// calling fn when the backend has no transactions.
+func (r *noteRepository) WithinTx(ctx context.Context, fn func(repo NoteRepository) error) error {
+	transactor, ok := storage.As[storage.Transactor](r.store)
+	if !ok {
+		return fmt.Errorf("transactions: %w", storage.ErrNotSupported)
+	}
+	return transactor.WithinTx(ctx, func(tx storage.UnifiedNoteStorage) error {
+		return fn(NewNoteRepository(tx))
+	})
+}
+
+func (r *noteRepository) Backup(ctx context.Context) (*model.Backup, error) {
+	backupper, ok := storage.As[storage.Backupper](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return backupper.Backup(ctx)
+}
+
+func (r *noteRepository) ListBackups(ctx context.Context) ([]model.Backup, error) {
+	backupper, ok := storage.As[storage.Backupper](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return backupper.ListBackups(ctx)
+}
+
+func (r *noteRepository) RestoreBackup(ctx context.Context, name string) error {
+	backupper, ok := storage.As[storage.Backupper](r.store)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return backupper.RestoreBackup(ctx, name)
+}
+
+func (r *noteRepository) Health(ctx context.Context) (*model.StorageHealth, error) {
+	checker, ok := storage.As[storage.HealthChecker](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return checker.Health(ctx)
+}
+
+func (r *noteRepository) CheckIntegrity(ctx context.Context) (*model.StorageHealth, error) {
+	checker, ok := storage.As[storage.HealthChecker](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return checker.CheckIntegrity(ctx)
+}
+
+func (r *noteRepository) Optimize(ctx context.Context) error {
+	checker, ok := storage.As[storage.HealthChecker](r.store)
+	if !ok {
+		return storage.ErrNotSupported
+	}
+	return checker.Optimize(ctx)
+}
+
+func (r *noteRepository) Sync(ctx context.Context) (*model.SyncStatus, error) {
+	syncer, ok := storage.As[storage.Syncer](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return syncer.Sync(ctx)
+}
+
+func (r *noteRepository) SyncStatus(ctx context.Context) (*model.SyncStatus, error) {
+	syncer, ok := storage.As[storage.Syncer](r.store)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+	return syncer.SyncStatus(ctx)
+}
+
+func (r *noteRepository) QueueDepth(ctx context.Context) (int, error) {
+	queue, ok := storage.As[storage.WriteQueue](r.store)
+	if !ok {
+		return 0, storage.ErrNotSupported
+	}
+	return queue.QueueDepth(ctx)
+}
+
+// Subscribe returns the storage's change events. Storage without a change
+// feed never sends any; the channel is closed straight away.
+func (r *noteRepository) Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent {
+	feed, ok := storage.As[storage.ChangeFeed](r.store)
+	if !ok {
+		ch := make(chan model.NoteEvent)
+		close(ch)
+		return ch
+	}
+	return feed.Subscribe(ctx, filter)
+}
+
 // mapStorageError maps storage errors to domain errors (expand as needed)
 func mapStorageError(err error) error {
 	// Check for NotFoundError from infrastructure layer
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/jonesrussell/godo/internal/domain/model"
@@ -82,3 +83,31 @@ func TestNoteRepository_SQLite_ListMultiple(t *testing.T) {
 		t.Fatalf("len=%d", len(list))
 	}
 }
+
+func TestNoteRepository_SQLite_AddKeepsCallerID(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := NewNoteRepository(sqlite.NewUnifiedAdapter(testfixtures.NewTempSQLiteStore(t)))
+
+	n := model.NewNote("keep my ID")
+	n.Done = true
+	id, createdAt := n.ID, n.CreatedAt
+	if err := repo.Add(ctx, n); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if n.ID != id {
+		t.Fatalf("Add replaced ID %s with %s", id, n.ID)
+	}
+
+	got, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if !got.Done || !got.CreatedAt.Equal(createdAt) || got.Version != n.Version {
+		t.Fatalf("stored note differs from the one added: %+v", got)
+	}
+
+	if err = repo.Add(ctx, n); !errors.Is(err, model.ErrDuplicateID) {
+		t.Fatalf("adding the same ID again: want ErrDuplicateID, got %v", err)
+	}
+}
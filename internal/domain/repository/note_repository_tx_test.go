@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/domain/testfixtures"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/changefeed"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
+)
+
+// plainStorage hides every optional capability of the wrapped storage
+type plainStorage struct {
+	storage.UnifiedNoteStorage
+}
+
+func TestNoteRepository_WithinTx_RollsBack(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := NewNoteRepository(sqlite.NewUnifiedAdapter(testfixtures.NewTempSQLiteStore(t)))
+
+	kept := model.NewNote("kept")
+	if err := repo.Add(ctx, kept); err != nil {
+		t.Fatal(err)
+	}
+
+	errAbort := errors.New("abort")
+	err := repo.WithinTx(ctx, func(tx NoteRepository) error {
+		added := model.NewNote("rolled back")
+		if err := tx.Add(ctx, added); err != nil {
+			return err
+		}
+		kept.Content = "changed"
+		if err := tx.Update(ctx, kept); err != nil {
+			return err
+		}
+		// Reads inside the transaction see its own writes
+		got, err := tx.GetByID(ctx, kept.ID)
+		if err != nil {
+			return err
+		}
+		if got.Content != "changed" {
+			t.Errorf("read inside tx got %q", got.Content)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected errAbort, got %v", err)
+	}
+
+	notes, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].Content != "kept" {
+		t.Fatalf("rolled back writes are visible: %+v", notes)
+	}
+	events, err := repo.ListAuditEvents(ctx, audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("audit rows of a rolled back transaction must not persist, got %d events", len(events))
+	}
+}
+
+func TestNoteRepository_WithinTx_Commits(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := NewNoteRepository(sqlite.NewUnifiedAdapter(testfixtures.NewTempSQLiteStore(t)))
+
+	err := repo.WithinTx(ctx, func(tx NoteRepository) error {
+		for _, content := range []string{"one", "two"} {
+			if err := tx.Add(ctx, model.NewNote(content)); err != nil {
+				return err
+			}
+		}
+		// Nested units of work join the outer transaction
+		return tx.WithinTx(ctx, func(inner NoteRepository) error {
+			return inner.Add(ctx, model.NewNote("three"))
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	notes, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 3 {
+		t.Fatalf("expected 3 notes, got %d", len(notes))
+	}
+}
+
+func TestNoteRepository_WithinTx_NotSupported(t *testing.T) {
+	t.Parallel()
+	store := plainStorage{sqlite.NewUnifiedAdapter(testfixtures.NewTempSQLiteStore(t))}
+	repo := NewNoteRepository(store)
+
+	called := false
+	err := repo.WithinTx(context.Background(), func(NoteRepository) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+	if called {
+		t.Fatal("fn must not run without a transaction")
+	}
+}
+
+func TestNoteRepository_AddWithTagsNotSupported(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// The change feed offers tags but the storage under it has none
+	backend := plainStorage{sqlite.NewUnifiedAdapter(testfixtures.NewTempSQLiteStore(t))}
+	store := changefeed.New(backend, changefeed.NewFeed(0, 0))
+	repo := NewNoteRepository(store)
+
+	note := model.NewNote("tagged")
+	note.Tags = []string{"work"}
+	if err := repo.Add(ctx, note); !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+	notes, err := store.GetAllNotes(ctx)
+	if err != nil || len(notes) != 0 {
+		t.Fatalf("expected nothing written, got %+v, %v", notes, err)
+	}
+}
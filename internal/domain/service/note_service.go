@@ -3,42 +3,85 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
 	"github.com/jonesrussell/godo/internal/domain/repository"
+	"github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
 )
 
 //go:generate mockgen -destination=../../test/mocks/mock_noteservice.go -package=mocks github.com/jonesrussell/godo/internal/domain/service NoteService
 
 // NoteFilter represents filtering options for note queries
-type NoteFilter struct {
-	Done          *bool      `json:"done,omitempty"`
-	Content       *string    `json:"content,omitempty"`
-	CreatedAfter  *time.Time `json:"created_after,omitempty"`
-	CreatedBefore *time.Time `json:"created_before,omitempty"`
-	Limit         *int       `json:"limit,omitempty"`
-	Offset        *int       `json:"offset,omitempty"`
-}
+type NoteFilter = model.NoteFilter
+
+// Search result limits
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+)
+
+// MaxBulkUpdate caps the number of notes a single UpdateNotes call may change
+const MaxBulkUpdate = 500
 
 // NoteUpdateRequest represents a request to update a note
 type NoteUpdateRequest struct {
 	Content *string `json:"content,omitempty"`
 	Done    *bool   `json:"done,omitempty"`
+	// Tags, when set, replaces the note's tags; an empty slice clears them
+	Tags *[]string `json:"tags,omitempty"`
+	// ExpectedVersion, when non-zero, makes the update fail with
+	// model.ErrVersionConflict unless the note is still at this version
+	ExpectedVersion int64 `json:"-"`
 }
 
 // NoteService defines the interface for note business logic operations
 type NoteService interface {
-	CreateNote(ctx context.Context, content string) (*model.Note, error)
+	CreateNote(ctx context.Context, content string, tags ...string) (*model.Note, error)
 	GetNote(ctx context.Context, id string) (*model.Note, error)
 	UpdateNote(ctx context.Context, id string, updates NoteUpdateRequest) (*model.Note, error)
+	UpdateNotes(ctx context.Context, ids []string, updates NoteUpdateRequest) ([]*model.Note, error)
 	DeleteNote(ctx context.Context, id string) error
+	DeleteNoteIfVersion(ctx context.Context, id string, version int64) error
 	ListNotes(ctx context.Context, filter *NoteFilter) ([]*model.Note, error)
+	ListNotesPage(ctx context.Context, filter *NoteFilter) (*model.NotePage, error)
+	ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error)
+	ListRevisions(ctx context.Context, id string) ([]model.NoteRevision, error)
+	RestoreRevision(ctx context.Context, id string, revision int) (*model.Note, error)
+	ListTrash(ctx context.Context) ([]*model.Note, error)
+	RestoreNote(ctx context.Context, id string) (*model.Note, error)
+	PurgeTrash(ctx context.Context, retention time.Duration) (int, error)
+	ArchiveNote(ctx context.Context, id string) (*model.Note, error)
+	UnarchiveNote(ctx context.Context, id string) (*model.Note, error)
+	ListTags(ctx context.Context) ([]model.TagCount, error)
+	RenameTag(ctx context.Context, from, to string) (int, error)
+	ApplyRetention(ctx context.Context, rules []model.RetentionRule, dryRun bool) (*model.RetentionReport, error)
+	AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error)
+	ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error)
+	OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, id string) error
+	PruneAttachments(ctx context.Context) (int, error)
+	SearchNotes(ctx context.Context, query string, limit int) ([]model.SearchHit, error)
+	ImportNotes(ctx context.Context, notes []model.Note, policy model.ConflictPolicy) (*model.ImportReport, error)
+	CreateBackup(ctx context.Context) (*model.Backup, error)
+	ListBackups(ctx context.Context) ([]model.Backup, error)
+	RestoreBackup(ctx context.Context, name string) error
+	StorageHealth(ctx context.Context) (*model.StorageHealth, error)
+	CheckIntegrity(ctx context.Context) (*model.StorageHealth, error)
+	OptimizeStorage(ctx context.Context) error
+	SyncNow(ctx context.Context) (*model.SyncStatus, error)
+	SyncStatus(ctx context.Context) (*model.SyncStatus, error)
+	QueuedWrites(ctx context.Context) (int, error)
+	Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent
 }
 
 // noteService implements NoteService
@@ -87,16 +130,114 @@ func (s *noteService) validateNoteID(id string) error {
 	return nil
 }
 
-func (s *noteService) CreateNote(ctx context.Context, content string) (*model.Note, error) {
-	s.logger.Info("Creating new note", "content_length", len(content))
+// validateImport checks a note about to be imported, normalizes its tags
+// and fills in missing timestamps
+func (s *noteService) validateImport(note *model.Note, now time.Time) error {
+	if err := s.validateNoteID(note.ID); err != nil {
+		return err
+	}
+	if err := s.validateNoteContent(note.Content); err != nil {
+		return err
+	}
+	note.Tags = model.NormalizeTags(note.Tags)
+	if err := model.CheckTags(note.Tags); err != nil {
+		return err
+	}
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = now
+	}
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = note.CreatedAt
+	}
+	return nil
+}
+
+func (s *noteService) validateUpdates(updates NoteUpdateRequest) error {
+	if updates.Content != nil {
+		if err := s.validateNoteContent(*updates.Content); err != nil {
+			return err
+		}
+	}
+	if updates.Tags != nil {
+		return model.CheckTags(model.NormalizeTags(*updates.Tags))
+	}
+	return nil
+}
+
+func (s *noteService) validateBulkUpdate(ids []string, updates NoteUpdateRequest) error {
+	if len(ids) == 0 {
+		return &model.ValidationError{Field: "ids", Message: "at least one note ID is required"}
+	}
+	if len(ids) > MaxBulkUpdate {
+		return &model.ValidationError{Field: "ids", Message: fmt.Sprintf("cannot update more than %d notes at once", MaxBulkUpdate)}
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if err := s.validateNoteID(id); err != nil {
+			return err
+		}
+		if seen[id] {
+			return &model.ValidationError{Field: "ids", Message: "duplicate note ID: " + id}
+		}
+		seen[id] = true
+	}
+	if updates.ExpectedVersion != 0 {
+		return &model.ValidationError{Field: "version", Message: "bulk updates cannot be conditional"}
+	}
+	return s.validateUpdates(updates)
+}
+
+func (s *noteService) validateFilter(filter NoteFilter) error {
+	if !filter.Sort.IsValid() {
+		return &model.ValidationError{
+			Field:   "sort",
+			Message: "unsupported sort order: " + string(filter.Sort),
+		}
+	}
+	if !filter.Archived.IsValid() {
+		return &model.ValidationError{
+			Field:   "archived",
+			Message: "unsupported archive scope: " + string(filter.Archived),
+		}
+	}
+	if filter.Limit != nil && *filter.Limit < 0 {
+		return &model.ValidationError{
+			Field:   "limit",
+			Message: "limit cannot be negative",
+		}
+	}
+	if filter.Offset != nil && *filter.Offset < 0 {
+		return &model.ValidationError{
+			Field:   "offset",
+			Message: "offset cannot be negative",
+		}
+	}
+	for _, tag := range append(slices.Clone(filter.Tags), filter.ExcludeTags...) {
+		if err := model.CheckTag(model.NormalizeTag(tag)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateNote stores a new note with the given tags. Tags are normalized, so
+// "#Work" and "work" name the same tag.
+func (s *noteService) CreateNote(ctx context.Context, content string, tags ...string) (*model.Note, error) {
+	s.logger.Info("Creating new note", "content_length", len(content), "tags", len(tags))
 	if err := s.validateNoteContent(content); err != nil {
 		s.logger.Error("Note content validation failed", "error", err)
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
+	normalized := model.NormalizeTags(tags)
+	if err := model.CheckTags(normalized); err != nil {
+		s.logger.Error("Note tags validation failed", "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
 	note := model.Note{
 		ID:        uuid.New().String(),
 		Content:   strings.TrimSpace(content),
 		Done:      false,
+		Tags:      normalized,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -129,37 +270,115 @@ func (s *noteService) UpdateNote(ctx context.Context, id string, updates NoteUpd
 		s.logger.Error("Note ID validation failed", "note_id", id, "error", err)
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
-	existingNote, err := s.repo.GetByID(ctx, id)
+	if err := s.validateUpdates(updates); err != nil {
+		s.logger.Error("Note content validation failed", "note_id", id, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	var note *model.Note
+	err := s.atomically(ctx, func(repo repository.NoteRepository) error {
+		var applyErr error
+		note, applyErr = s.applyUpdates(ctx, repo, id, updates)
+		return applyErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Note updated successfully", "note_id", id)
+	return note, nil
+}
+
+// UpdateNotes applies the same updates to every listed note in one
+// transaction, so either all of them change or none do. Backends without
+// transactions fail with storage.ErrNotSupported.
+func (s *noteService) UpdateNotes(ctx context.Context, ids []string, updates NoteUpdateRequest) ([]*model.Note, error) {
+	s.logger.Info("Updating notes", "count", len(ids), "updates", updates)
+	if err := s.validateBulkUpdate(ids, updates); err != nil {
+		s.logger.Error("Bulk update validation failed", "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	notes := make([]*model.Note, 0, len(ids))
+	err := s.repo.WithinTx(ctx, func(repo repository.NoteRepository) error {
+		for _, id := range ids {
+			note, applyErr := s.applyUpdates(ctx, repo, id, updates)
+			if applyErr != nil {
+				return applyErr
+			}
+			notes = append(notes, note)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to update notes", "error", err)
+		return nil, fmt.Errorf("failed to update notes: %w", err)
+	}
+	s.logger.Info("Notes updated successfully", "count", len(notes))
+	return notes, nil
+}
+
+// applyUpdates reads a note through repo, applies updates and writes it back
+func (s *noteService) applyUpdates(ctx context.Context, repo repository.NoteRepository, id string, updates NoteUpdateRequest) (*model.Note, error) {
+	existingNote, err := repo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.Error("Failed to retrieve existing note", "note_id", id, "error", err)
 		return nil, fmt.Errorf("failed to retrieve note: %w", err)
 	}
+	if updates.ExpectedVersion != 0 && updates.ExpectedVersion != existingNote.Version {
+		s.logger.Info("Note version conflict", "note_id", id, "expected", updates.ExpectedVersion, "current", existingNote.Version)
+		return nil, fmt.Errorf("failed to update note: %w", model.ErrVersionConflict)
+	}
 	if updates.Content != nil {
-		if validErr := s.validateNoteContent(*updates.Content); validErr != nil {
-			s.logger.Error("Note content validation failed", "note_id", id, "error", validErr)
-			return nil, fmt.Errorf("validation failed: %w", validErr)
-		}
 		existingNote.Content = strings.TrimSpace(*updates.Content)
 	}
 	if updates.Done != nil {
 		existingNote.Done = *updates.Done
 	}
-	existingNote.UpdatedAt = time.Now()
-	if updateErr := s.repo.Update(ctx, existingNote); updateErr != nil {
-		s.logger.Error("Failed to update note", "note_id", id, "error", updateErr)
-		return nil, fmt.Errorf("failed to update note: %w", updateErr)
+	// A change to tags alone is written without touching content
+	if updates.Tags == nil || updates.Content != nil || updates.Done != nil {
+		existingNote.UpdatedAt = time.Now()
+		if updateErr := repo.Update(ctx, existingNote); updateErr != nil {
+			s.logger.Error("Failed to update note", "note_id", id, "error", updateErr)
+			return nil, fmt.Errorf("failed to update note: %w", updateErr)
+		}
+	}
+	if updates.Tags != nil {
+		tagged, tagErr := repo.SetTags(ctx, id, *updates.Tags)
+		if tagErr != nil {
+			s.logger.Error("Failed to set note tags", "note_id", id, "error", tagErr)
+			return nil, fmt.Errorf("failed to update note: %w", tagErr)
+		}
+		existingNote = tagged
 	}
-	s.logger.Info("Note updated successfully", "note_id", id)
 	return existingNote, nil
 }
 
+// atomically runs fn in a transaction when the backend supports them and
+// directly against the repository otherwise. Versioned backends still reject
+// a write that races fn's read without a transaction.
+func (s *noteService) atomically(ctx context.Context, fn func(repo repository.NoteRepository) error) error {
+	ran := false
+	err := s.repo.WithinTx(ctx, func(repo repository.NoteRepository) error {
+		ran = true
+		return fn(repo)
+	})
+	if !ran && errors.Is(err, storage.ErrNotSupported) {
+		return fn(s.repo)
+	}
+	return err
+}
+
 func (s *noteService) DeleteNote(ctx context.Context, id string) error {
-	s.logger.Info("Deleting note", "note_id", id)
+	return s.DeleteNoteIfVersion(ctx, id, 0)
+}
+
+// DeleteNoteIfVersion moves a note to the trash only while it is at version.
+// A zero version deletes unconditionally.
+func (s *noteService) DeleteNoteIfVersion(ctx context.Context, id string, version int64) error {
+	s.logger.Info("Deleting note", "note_id", id, "version", version)
 	if err := s.validateNoteID(id); err != nil {
 		s.logger.Error("Note ID validation failed", "note_id", id, "error", err)
 		return fmt.Errorf("validation failed: %w", err)
 	}
-	if err := s.repo.Delete(ctx, id); err != nil {
+	if err := s.repo.DeleteIfVersion(ctx, id, version); err != nil {
 		s.logger.Error("Failed to delete note", "note_id", id, "error", err)
 		return fmt.Errorf("failed to delete note: %w", err)
 	}
@@ -169,73 +388,614 @@ func (s *noteService) DeleteNote(ctx context.Context, id string) error {
 
 func (s *noteService) ListNotes(ctx context.Context, filter *NoteFilter) ([]*model.Note, error) {
 	s.logger.Info("Retrieving notes", "filter", filter)
-	notes, err := s.repo.List(ctx)
+	var query NoteFilter
+	if filter != nil {
+		query = *filter
+	}
+	if err := s.validateFilter(query); err != nil {
+		s.logger.Error("Note filter validation failed", "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	notes, err := s.repo.Query(ctx, query)
 	if err != nil {
 		s.logger.Error("Failed to retrieve notes", "error", err)
 		return nil, fmt.Errorf("failed to retrieve notes: %w", err)
 	}
+	s.logger.Info("Notes retrieved successfully", "count", len(notes))
+	return notes, nil
+}
+
+// ListNotesPage returns one page of notes. Pass the previous page's
+// NextCursor as filter.Cursor to continue; the cursor is tied to the sort
+// order and filter of the request that produced it.
+func (s *noteService) ListNotesPage(ctx context.Context, filter *NoteFilter) (*model.NotePage, error) {
+	s.logger.Info("Retrieving page of notes", "filter", filter)
+	var query NoteFilter
 	if filter != nil {
-		notes = s.applyFilters(notes, filter)
+		query = *filter
 	}
-	s.logger.Info("Notes retrieved successfully", "count", len(notes))
+	if err := s.validateFilter(query); err != nil {
+		s.logger.Error("Note filter validation failed", "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if query.Cursor != "" && !query.UsesKeyset() {
+		s.logger.Error("Cursor used with unsupported sort or offset", "sort", query.Sort)
+		return nil, fmt.Errorf("validation failed: %w", model.ErrInvalidCursor)
+	}
+	page, err := s.repo.Page(ctx, query)
+	if err != nil {
+		s.logger.Error("Failed to retrieve notes", "error", err)
+		return nil, fmt.Errorf("failed to retrieve notes: %w", err)
+	}
+	s.logger.Info("Page of notes retrieved successfully", "count", len(page.Notes), "has_more", page.NextCursor != "")
+	return page, nil
+}
+
+func (s *noteService) ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+	s.logger.Info("Retrieving audit events", "note_id", filter.NoteID, "since", filter.Since)
+	if filter.NoteID != "" {
+		if err := s.validateNoteID(filter.NoteID); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+	events, err := s.repo.ListAuditEvents(ctx, filter)
+	if err != nil {
+		s.logger.Error("Failed to retrieve audit events", "error", err)
+		return nil, fmt.Errorf("failed to retrieve audit events: %w", err)
+	}
+	s.logger.Info("Audit events retrieved successfully", "count", len(events))
+	return events, nil
+}
+
+func (s *noteService) ListRevisions(ctx context.Context, id string) ([]model.NoteRevision, error) {
+	s.logger.Info("Retrieving note revisions", "note_id", id)
+	if err := s.validateNoteID(id); err != nil {
+		s.logger.Error("Note ID validation failed", "note_id", id, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		s.logger.Error("Failed to retrieve note", "note_id", id, "error", err)
+		return nil, fmt.Errorf("failed to retrieve note: %w", err)
+	}
+	revisions, err := s.repo.ListRevisions(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to retrieve note revisions", "note_id", id, "error", err)
+		return nil, fmt.Errorf("failed to retrieve note revisions: %w", err)
+	}
+	s.logger.Info("Note revisions retrieved successfully", "note_id", id, "count", len(revisions))
+	return revisions, nil
+}
+
+// RestoreRevision writes a revision's content and done state back to the note.
+// The state being replaced is itself kept as a new revision.
+func (s *noteService) RestoreRevision(ctx context.Context, id string, revision int) (*model.Note, error) {
+	s.logger.Info("Restoring note revision", "note_id", id, "revision", revision)
+	if err := s.validateNoteID(id); err != nil {
+		s.logger.Error("Note ID validation failed", "note_id", id, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	var note *model.Note
+	err := s.atomically(ctx, func(repo repository.NoteRepository) error {
+		rev, err := repo.GetRevision(ctx, id, revision)
+		if err != nil {
+			s.logger.Error("Failed to retrieve note revision", "note_id", id, "revision", revision, "error", err)
+			return fmt.Errorf("failed to retrieve note revision: %w", err)
+		}
+		note, err = s.applyUpdates(ctx, repo, id, NoteUpdateRequest{Content: &rev.Content, Done: &rev.Done})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Note revision restored successfully", "note_id", id, "revision", revision)
+	return note, nil
+}
+
+func (s *noteService) ListTrash(ctx context.Context) ([]*model.Note, error) {
+	s.logger.Info("Listing trashed notes")
+	notes, err := s.repo.ListTrash(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list trashed notes", "error", err)
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	s.logger.Info("Trashed notes listed successfully", "count", len(notes))
 	return notes, nil
 }
 
-// applyFilters applies the given filters to the note list
-func (s *noteService) applyFilters(notes []*model.Note, filter *NoteFilter) []*model.Note {
-	if filter == nil {
-		return notes
+func (s *noteService) RestoreNote(ctx context.Context, id string) (*model.Note, error) {
+	s.logger.Info("Restoring note from trash", "note_id", id)
+	if err := s.validateNoteID(id); err != nil {
+		s.logger.Error("Note ID validation failed", "note_id", id, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	note, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to restore note", "note_id", id, "error", err)
+		return nil, fmt.Errorf("failed to restore note: %w", err)
+	}
+	s.logger.Info("Note restored successfully", "note_id", id)
+	return note, nil
+}
+
+// PurgeTrash permanently removes notes that have been in the trash for longer than retention
+func (s *noteService) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, &model.ValidationError{
+			Field:   "retention",
+			Message: "retention must be positive",
+		}
+	}
+	purged, err := s.repo.PurgeTrash(ctx, time.Now().Add(-retention))
+	if err != nil {
+		s.logger.Error("Failed to purge trash", "error", err)
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	if purged > 0 {
+		s.logger.Info("Purged notes from trash", "count", purged, "retention", retention)
+	}
+	return purged, nil
+}
+
+func (s *noteService) ArchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	s.logger.Info("Archiving note", "note_id", id)
+	if err := s.validateNoteID(id); err != nil {
+		s.logger.Error("Note ID validation failed", "note_id", id, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	note, err := s.repo.Archive(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to archive note", "note_id", id, "error", err)
+		return nil, fmt.Errorf("failed to archive note: %w", err)
+	}
+	s.logger.Info("Note archived successfully", "note_id", id)
+	return note, nil
+}
+
+func (s *noteService) UnarchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	s.logger.Info("Unarchiving note", "note_id", id)
+	if err := s.validateNoteID(id); err != nil {
+		s.logger.Error("Note ID validation failed", "note_id", id, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	note, err := s.repo.Unarchive(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to unarchive note", "note_id", id, "error", err)
+		return nil, fmt.Errorf("failed to unarchive note: %w", err)
+	}
+	s.logger.Info("Note unarchived successfully", "note_id", id)
+	return note, nil
+}
+
+// ListTags returns every tag on a note outside the trash with its note count
+func (s *noteService) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	s.logger.Info("Retrieving tags")
+	tags, err := s.repo.ListTags(ctx)
+	if err != nil {
+		s.logger.Error("Failed to retrieve tags", "error", err)
+		return nil, fmt.Errorf("failed to retrieve tags: %w", err)
+	}
+	s.logger.Info("Tags retrieved successfully", "count", len(tags))
+	return tags, nil
+}
+
+// RenameTag moves every note from one tag to another in one transaction,
+// merging the two when to is already in use. It returns the number of notes
+// that changed.
+func (s *noteService) RenameTag(ctx context.Context, from, to string) (int, error) {
+	from, to = model.NormalizeTag(from), model.NormalizeTag(to)
+	s.logger.Info("Renaming tag", "from", from, "to", to)
+	for _, name := range []string{from, to} {
+		if err := model.CheckTag(name); err != nil {
+			s.logger.Error("Tag name validation failed", "tag", name, "error", err)
+			return 0, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+	if from == to {
+		return 0, fmt.Errorf("validation failed: %w", &model.ValidationError{
+			Field:   "name",
+			Message: "new tag name must differ from the old one",
+		})
+	}
+	renamed, err := s.repo.RenameTag(ctx, from, to)
+	if err != nil {
+		s.logger.Error("Failed to rename tag", "from", from, "to", to, "error", err)
+		return 0, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	s.logger.Info("Tag renamed successfully", "from", from, "to", to, "notes", renamed)
+	return renamed, nil
+}
+
+// ApplyRetention runs each rule in turn over the notes it selects now:
+// archive rules archive done notes not updated within the rule's age, and
+// delete rules permanently delete notes archived for longer than it, purging
+// them from the trash where the storage keeps one. A dry run only
+// reports the notes that would change. A failed write stops the pass; the
+// notes changed before it stay changed.
+func (s *noteService) ApplyRetention(
+	ctx context.Context, rules []model.RetentionRule, dryRun bool,
+) (*model.RetentionReport, error) {
+	for _, rule := range rules {
+		if err := validateRetentionRule(rule); err != nil {
+			s.logger.Error("Retention rule validation failed", "error", err)
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	report := &model.RetentionReport{DryRun: dryRun, Archived: []string{}, Deleted: []string{}}
+	now := time.Now()
+	for _, rule := range rules {
+		filter := model.NoteFilter{Archived: model.ArchiveOnly}
+		if rule.Action == model.RetentionArchive {
+			done := true
+			filter = model.NoteFilter{Done: &done}
+		}
+		notes, err := s.repo.Query(ctx, filter)
+		if err != nil {
+			s.logger.Error("Failed to list notes for retention", "action", rule.Action, "error", err)
+			return nil, fmt.Errorf("failed to apply retention: %w", err)
+		}
+
+		for _, note := range notes {
+			if !rule.Selects(note, now) {
+				continue
+			}
+			if rule.Action == model.RetentionArchive {
+				if !dryRun {
+					if _, err = s.repo.Archive(ctx, note.ID); err != nil {
+						s.logger.Error("Failed to archive note", "note_id", note.ID, "error", err)
+						return nil, fmt.Errorf("failed to apply retention: %w", err)
+					}
+				}
+				report.Archived = append(report.Archived, note.ID)
+				continue
+			}
+			if !dryRun {
+				if err = s.purgeNote(ctx, note.ID); err != nil {
+					s.logger.Error("Failed to delete note", "note_id", note.ID, "error", err)
+					return nil, fmt.Errorf("failed to apply retention: %w", err)
+				}
+			}
+			report.Deleted = append(report.Deleted, note.ID)
+		}
+	}
+	s.logger.Info("Retention applied", "dry_run", dryRun,
+		"archived", len(report.Archived), "deleted", len(report.Deleted))
+	return report, nil
+}
+
+// purgeNote deletes a note and, on storage with a trash, purges it from there
+func (s *noteService) purgeNote(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.PurgeNote(ctx, id); err != nil && !errors.Is(err, storage.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+func validateRetentionRule(rule model.RetentionRule) error {
+	if !rule.Action.IsValid() {
+		return &model.ValidationError{
+			Field:   "action",
+			Message: "unsupported retention action: " + string(rule.Action),
+		}
+	}
+	if rule.After <= 0 {
+		return &model.ValidationError{
+			Field:   "after",
+			Message: "retention age must be positive",
+		}
+	}
+	return nil
+}
+
+// AddAttachment attaches content to a note under the given file name. An
+// empty content type is worked out from the name or the content itself.
+func (s *noteService) AddAttachment(
+	ctx context.Context, noteID, name, contentType string, content io.Reader,
+) (*model.Attachment, error) {
+	s.logger.Info("Adding attachment", "note_id", noteID, "name", name)
+	if err := s.validateNoteID(noteID); err != nil {
+		s.logger.Error("Note ID validation failed", "note_id", noteID, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	name = model.AttachmentName(name)
+	if err := validateAttachmentName(name); err != nil {
+		s.logger.Error("Attachment name validation failed", "note_id", noteID, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if strings.TrimSpace(contentType) == "" {
+		contentType, content = model.DetectContentType(name, content)
+	}
+	attachment, err := s.repo.AddAttachment(ctx, noteID, name, contentType, content)
+	if err != nil {
+		s.logger.Error("Failed to add attachment", "note_id", noteID, "name", name, "error", err)
+		return nil, fmt.Errorf("failed to add attachment: %w", err)
+	}
+	s.logger.Info("Attachment added successfully",
+		"note_id", noteID, "attachment_id", attachment.ID, "size", attachment.Size)
+	return attachment, nil
+}
+
+func (s *noteService) ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error) {
+	s.logger.Info("Listing attachments", "note_id", noteID)
+	if err := s.validateNoteID(noteID); err != nil {
+		s.logger.Error("Note ID validation failed", "note_id", noteID, "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	attachments, err := s.repo.ListAttachments(ctx, noteID)
+	if err != nil {
+		s.logger.Error("Failed to list attachments", "note_id", noteID, "error", err)
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	s.logger.Info("Attachments listed successfully", "note_id", noteID, "count", len(attachments))
+	return attachments, nil
+}
+
+// OpenAttachment returns an attachment and a reader over its content, which
+// the caller closes
+func (s *noteService) OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error) {
+	s.logger.Info("Opening attachment", "attachment_id", id)
+	if err := validateAttachmentID(id); err != nil {
+		s.logger.Error("Attachment ID validation failed", "attachment_id", id, "error", err)
+		return nil, nil, fmt.Errorf("validation failed: %w", err)
+	}
+	attachment, content, err := s.repo.OpenAttachment(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to open attachment", "attachment_id", id, "error", err)
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+func (s *noteService) DeleteAttachment(ctx context.Context, id string) error {
+	s.logger.Info("Deleting attachment", "attachment_id", id)
+	if err := validateAttachmentID(id); err != nil {
+		s.logger.Error("Attachment ID validation failed", "attachment_id", id, "error", err)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.repo.DeleteAttachment(ctx, id); err != nil {
+		s.logger.Error("Failed to delete attachment", "attachment_id", id, "error", err)
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	s.logger.Info("Attachment deleted successfully", "attachment_id", id)
+	return nil
+}
+
+// PruneAttachments removes stored attachment content that no attachment
+// refers to any more
+func (s *noteService) PruneAttachments(ctx context.Context) (int, error) {
+	removed, err := s.repo.PruneAttachments(ctx)
+	if err != nil {
+		s.logger.Error("Failed to prune attachments", "error", err)
+		return 0, fmt.Errorf("failed to prune attachments: %w", err)
 	}
-	filtered := s.filterByCriteria(notes, filter)
-	return s.applyPagination(filtered, filter)
+	if removed > 0 {
+		s.logger.Info("Pruned attachment files", "count", removed)
+	}
+	return removed, nil
 }
 
-// filterByCriteria applies content and date filters
-func (s *noteService) filterByCriteria(notes []*model.Note, filter *NoteFilter) []*model.Note {
-	var filtered []*model.Note
-	for _, note := range notes {
-		if s.matchesFilter(note, filter) {
-			filtered = append(filtered, note)
+func validateAttachmentID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return &model.ValidationError{
+			Field:   "id",
+			Message: "invalid attachment ID format",
 		}
 	}
-	return filtered
+	return nil
 }
 
-// applyPagination applies limit and offset to the filtered results
-func (s *noteService) applyPagination(notes []*model.Note, filter *NoteFilter) []*model.Note {
-	if filter.Limit == nil || *filter.Limit <= 0 {
-		return notes
+func validateAttachmentName(name string) error {
+	if name == "" {
+		return &model.ValidationError{
+			Field:   "name",
+			Message: "attachment name cannot be empty",
+		}
 	}
-	limit := *filter.Limit
-	offset := 0
-	if filter.Offset != nil && *filter.Offset > 0 {
-		offset = *filter.Offset
+	if len(name) > model.MaxAttachmentNameLength {
+		return &model.ValidationError{
+			Field:   "name",
+			Message: fmt.Sprintf("attachment name cannot exceed %d bytes", model.MaxAttachmentNameLength),
+		}
 	}
-	return s.sliceWithBounds(notes, offset, limit)
+	return nil
 }
 
-// sliceWithBounds safely slices the notes array with offset and limit
-func (s *noteService) sliceWithBounds(notes []*model.Note, offset, limit int) []*model.Note {
-	if offset >= len(notes) {
-		return []*model.Note{}
+// SearchNotes runs a full-text search over note content. A non-positive limit
+// selects DefaultSearchLimit; larger limits are capped at MaxSearchLimit.
+func (s *noteService) SearchNotes(ctx context.Context, query string, limit int) ([]model.SearchHit, error) {
+	s.logger.Info("Searching notes", "query_length", len(query), "limit", limit)
+	if strings.TrimSpace(query) == "" {
+		err := &model.ValidationError{
+			Field:   "q",
+			Message: "search query cannot be empty",
+		}
+		s.logger.Error("Search query validation failed", "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	hits, err := s.repo.Search(ctx, query, limit)
+	if err != nil {
+		s.logger.Error("Failed to search notes", "error", err)
+		return nil, fmt.Errorf("failed to search notes: %w", err)
 	}
-	end := min(offset+limit, len(notes))
-	return notes[offset:end]
+	s.logger.Info("Notes searched successfully", "count", len(hits))
+	return hits, nil
 }
 
-// matchesFilter checks if a note matches the given filter criteria
-func (s *noteService) matchesFilter(note *model.Note, filter *NoteFilter) bool {
-	if filter.Done != nil && note.Done != *filter.Done {
-		return false
+// ImportNotes stores notes exported from Godo or elsewhere, keeping their
+// IDs, done state and timestamps. A stored note with the same ID is kept or
+// replaced according to policy. Notes with an invalid ID or content are
+// reported as failed and the rest are still imported; a missing created_at
+// is taken as now and a missing updated_at as created_at.
+func (s *noteService) ImportNotes(
+	ctx context.Context, notes []model.Note, policy model.ConflictPolicy,
+) (*model.ImportReport, error) {
+	s.logger.Info("Importing notes", "count", len(notes), "policy", policy)
+	if !policy.IsValid() {
+		err := &model.ValidationError{
+			Field:   "policy",
+			Message: "unsupported conflict policy: " + string(policy),
+		}
+		s.logger.Error("Import policy validation failed", "error", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Rejected notes are left out of the import; their results are merged
+	// back in order afterwards
+	rejected := make([]error, len(notes))
+	valid := make([]model.Note, 0, len(notes))
+	now := time.Now()
+	for i, note := range notes {
+		if err := s.validateImport(&note, now); err != nil {
+			rejected[i] = err
+			continue
+		}
+		valid = append(valid, note)
+	}
+
+	imported := &model.ImportReport{}
+	if len(valid) > 0 {
+		var err error
+		if imported, err = s.repo.ImportNotes(ctx, valid, policy); err != nil {
+			s.logger.Error("Failed to import notes", "error", err)
+			return nil, fmt.Errorf("failed to import notes: %w", err)
+		}
 	}
-	if filter.Content != nil && !strings.Contains(strings.ToLower(note.Content), strings.ToLower(*filter.Content)) {
-		return false
+
+	report := &model.ImportReport{}
+	next := 0
+	for i, note := range notes {
+		if rejected[i] != nil {
+			report.Add(note.ID, model.ImportFailed, rejected[i])
+			continue
+		}
+		result := imported.Results[next]
+		next++
+		var err error
+		if result.Error != "" {
+			err = errors.New(result.Error)
+		}
+		report.Add(result.ID, result.Outcome, err)
 	}
-	if filter.CreatedAfter != nil && note.CreatedAt.Before(*filter.CreatedAfter) {
-		return false
+	s.logger.Info("Notes imported",
+		"created", report.Created, "updated", report.Updated,
+		"skipped", report.Skipped, "failed", report.Failed)
+	return report, nil
+}
+
+// CreateBackup writes a snapshot of the note store
+func (s *noteService) CreateBackup(ctx context.Context) (*model.Backup, error) {
+	s.logger.Info("Creating backup")
+	backup, err := s.repo.Backup(ctx)
+	if err != nil {
+		s.logger.Error("Failed to create backup", "error", err)
+		return nil, fmt.Errorf("failed to create backup: %w", err)
 	}
-	if filter.CreatedBefore != nil && note.CreatedAt.After(*filter.CreatedBefore) {
-		return false
+	s.logger.Info("Backup created successfully", "backup", backup.Name, "size", backup.Size)
+	return backup, nil
+}
+
+func (s *noteService) ListBackups(ctx context.Context) ([]model.Backup, error) {
+	s.logger.Info("Listing backups")
+	backups, err := s.repo.ListBackups(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list backups", "error", err)
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	s.logger.Info("Backups listed successfully", "count", len(backups))
+	return backups, nil
+}
+
+// RestoreBackup replaces the note store's data with the named snapshot
+func (s *noteService) RestoreBackup(ctx context.Context, name string) error {
+	s.logger.Info("Restoring backup", "backup", name)
+	if strings.TrimSpace(name) == "" {
+		err := &model.ValidationError{
+			Field:   "name",
+			Message: "backup name cannot be empty",
+		}
+		s.logger.Error("Backup name validation failed", "error", err)
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.repo.RestoreBackup(ctx, name); err != nil {
+		s.logger.Error("Failed to restore backup", "backup", name, "error", err)
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	s.logger.Info("Backup restored successfully", "backup", name)
+	return nil
+}
+
+// StorageHealth returns the result of the storage backend's last integrity check
+func (s *noteService) StorageHealth(ctx context.Context) (*model.StorageHealth, error) {
+	health, err := s.repo.Health(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage health: %w", err)
+	}
+	return health, nil
+}
+
+// CheckIntegrity runs a full integrity check of the storage backend
+func (s *noteService) CheckIntegrity(ctx context.Context) (*model.StorageHealth, error) {
+	s.logger.Info("Checking storage integrity")
+	health, err := s.repo.CheckIntegrity(ctx)
+	if err != nil {
+		s.logger.Error("Failed to check storage integrity", "error", err)
+		return nil, fmt.Errorf("failed to check storage integrity: %w", err)
+	}
+	s.logger.Info("Storage integrity checked", "status", health.Status, "problems", len(health.Problems))
+	return health, nil
+}
+
+// OptimizeStorage runs the storage backend's routine maintenance
+func (s *noteService) OptimizeStorage(ctx context.Context) error {
+	if err := s.repo.Optimize(ctx); err != nil {
+		s.logger.Error("Failed to optimize storage", "error", err)
+		return fmt.Errorf("failed to optimize storage: %w", err)
+	}
+	s.logger.Debug("Storage optimized")
+	return nil
+}
+
+// SyncNow runs a sync pass with the remote. The status is returned even when
+// the pass fails, so callers can show whether the remote was unreachable.
+func (s *noteService) SyncNow(ctx context.Context) (*model.SyncStatus, error) {
+	status, err := s.repo.Sync(ctx)
+	if err != nil {
+		return status, fmt.Errorf("failed to sync notes: %w", err)
+	}
+	s.logger.Debug("Notes synced", "pushed", status.Pushed, "pulled", status.Pulled, "conflicts", status.Conflicts)
+	return status, nil
+}
+
+// QueuedWrites returns the number of writes waiting for an unreachable server
+func (s *noteService) QueuedWrites(ctx context.Context) (int, error) {
+	depth, err := s.repo.QueueDepth(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read write queue: %w", err)
+	}
+	return depth, nil
+}
+
+// Subscribe returns the committed note changes matching filter until ctx is
+// done. A closed channel means the subscriber fell behind or the storage
+// closed; subscribe again with filter.Since set to the last Seq received.
+func (s *noteService) Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent {
+	s.logger.Debug("Subscribing to note events", "since", filter.Since, "note_id", filter.NoteID)
+	return s.repo.Subscribe(ctx, filter)
+}
+
+// SyncStatus reports the state of sync with the remote without running a pass
+func (s *noteService) SyncStatus(ctx context.Context) (*model.SyncStatus, error) {
+	status, err := s.repo.SyncStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync status: %w", err)
 	}
-	return true
+	return status, nil
 }
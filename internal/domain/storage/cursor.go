@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// cursorPayload is the decoded form of an opaque page cursor. Key is the
+// backend's representation of the last note's created_at; each backend
+// decides its format and only ever decodes cursors it issued.
+type cursorPayload struct {
+	Key string `json:"k"`
+	ID  string `json:"i"`
+}
+
+// EncodeCursor builds an opaque cursor positioned after the note with the
+// given sort key and ID
+func EncodeCursor(key, id string) string {
+	data, _ := json.Marshal(cursorPayload{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reverses EncodeCursor. Malformed cursors yield model.ErrInvalidCursor.
+func DecodeCursor(cursor string) (key, id string, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", model.ErrInvalidCursor, err)
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == "" {
+		return "", "", model.ErrInvalidCursor
+	}
+	return payload.Key, payload.ID, nil
+}
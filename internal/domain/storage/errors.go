@@ -0,0 +1,6 @@
+package storage
+
+import "errors"
+
+// ErrNotSupported is returned when a storage backend does not implement an optional capability
+var ErrNotSupported = errors.New("operation not supported by storage backend")
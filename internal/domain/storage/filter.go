@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// ApplyFilter filters, sorts and pages notes in memory following the same
+// semantics backends implement for NoteQuerier. It is the fallback for
+// backends that can only list every note.
+func ApplyFilter(notes []*model.Note, filter model.NoteFilter) []*model.Note {
+	matched := make([]*model.Note, 0, len(notes))
+	for _, note := range notes {
+		if matchesFilter(note, filter) {
+			matched = append(matched, note)
+		}
+	}
+
+	sortNotes(matched, filter.Sort)
+
+	offset := 0
+	if filter.Offset != nil && *filter.Offset > 0 {
+		offset = *filter.Offset
+	}
+	if offset >= len(matched) {
+		return []*model.Note{}
+	}
+	matched = matched[offset:]
+
+	if filter.Limit != nil && *filter.Limit > 0 && *filter.Limit < len(matched) {
+		matched = matched[:*filter.Limit]
+	}
+	return matched
+}
+
+// PageFilter pages notes in memory with the same cursor semantics NotePager
+// backends implement. Cursor keys issued here are RFC 3339 timestamps.
+func PageFilter(notes []*model.Note, filter model.NoteFilter) (*model.NotePage, error) {
+	if !filter.UsesKeyset() {
+		if filter.Cursor != "" {
+			return nil, model.ErrInvalidCursor
+		}
+		return &model.NotePage{Notes: ApplyFilter(notes, filter)}, nil
+	}
+
+	unpaged := filter
+	unpaged.Limit = nil
+	unpaged.Cursor = ""
+	matched := ApplyFilter(notes, unpaged)
+
+	if filter.Cursor != "" {
+		key, id, err := DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after, err := time.Parse(time.RFC3339Nano, key)
+		if err != nil {
+			return nil, model.ErrInvalidCursor
+		}
+		desc := filter.Sort != model.SortCreatedAsc
+		start := len(matched)
+		for i, note := range matched {
+			if followsCursor(note, after, id, desc) {
+				start = i
+				break
+			}
+		}
+		matched = matched[start:]
+	}
+
+	page := &model.NotePage{Notes: matched}
+	if filter.Limit != nil && *filter.Limit > 0 && *filter.Limit < len(matched) {
+		page.Notes = matched[:*filter.Limit]
+		last := page.Notes[len(page.Notes)-1]
+		page.NextCursor = EncodeCursor(last.CreatedAt.Format(time.RFC3339Nano), last.ID)
+	}
+	return page, nil
+}
+
+// followsCursor reports whether note sorts after the cursor position
+func followsCursor(note *model.Note, createdAt time.Time, id string, desc bool) bool {
+	if !note.CreatedAt.Equal(createdAt) {
+		return note.CreatedAt.Before(createdAt) == desc
+	}
+	if desc {
+		return note.ID < id
+	}
+	return note.ID > id
+}
+
+// matchesFilter reports whether a note satisfies the filter's criteria
+func matchesFilter(note *model.Note, filter model.NoteFilter) bool {
+	if !filter.Archived.Matches(note.ArchivedAt != nil) {
+		return false
+	}
+	if filter.Done != nil && note.Done != *filter.Done {
+		return false
+	}
+	if filter.Content != nil && !strings.Contains(strings.ToLower(note.Content), strings.ToLower(*filter.Content)) {
+		return false
+	}
+	if filter.CreatedAfter != nil && note.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && note.CreatedAt.After(*filter.CreatedBefore) {
+		return false
+	}
+	for _, tag := range filter.Tags {
+		if !slices.Contains(note.Tags, model.NormalizeTag(tag)) {
+			return false
+		}
+	}
+	for _, tag := range filter.ExcludeTags {
+		if slices.Contains(note.Tags, model.NormalizeTag(tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortNotes orders notes in place, breaking ties by ID
+func sortNotes(notes []*model.Note, order model.NoteSort) {
+	if order == "" {
+		order = model.DefaultNoteSort
+	}
+	desc := strings.HasPrefix(string(order), "-")
+	byUpdated := strings.TrimPrefix(string(order), "-") == string(model.SortUpdatedAsc)
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		ta, tb := a.CreatedAt, b.CreatedAt
+		if byUpdated {
+			ta, tb = a.UpdatedAt, b.UpdatedAt
+		}
+		if !ta.Equal(tb) {
+			if desc {
+				return ta.After(tb)
+			}
+			return ta.Before(tb)
+		}
+		if desc {
+			return a.ID > b.ID
+		}
+		return a.ID < b.ID
+	})
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestApplyFilter(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	notes := []*model.Note{
+		{ID: "a", Content: "Buy milk", Done: false, CreatedAt: base, UpdatedAt: base.Add(3 * time.Hour), Tags: []string{"errands"}},
+		{ID: "b", Content: "call mum", Done: true, CreatedAt: base.Add(time.Hour), UpdatedAt: base.Add(time.Hour), Tags: []string{"family", "phone"}},
+		{ID: "c", Content: "milk the cow", Done: true, CreatedAt: base.Add(2 * time.Hour), UpdatedAt: base.Add(2 * time.Hour), Tags: []string{"family"}},
+	}
+	done := true
+	content := "MILK"
+	after := base.Add(30 * time.Minute)
+	one, two := 1, 2
+
+	tests := []struct {
+		name   string
+		filter model.NoteFilter
+		want   []string
+	}{
+		{name: "default newest first", filter: model.NoteFilter{}, want: []string{"c", "b", "a"}},
+		{name: "done", filter: model.NoteFilter{Done: &done}, want: []string{"c", "b"}},
+		{name: "content case-insensitive", filter: model.NoteFilter{Content: &content}, want: []string{"c", "a"}},
+		{name: "created after", filter: model.NoteFilter{CreatedAfter: &after, Sort: model.SortCreatedAsc}, want: []string{"b", "c"}},
+		{name: "updated desc", filter: model.NoteFilter{Sort: model.SortUpdatedDesc}, want: []string{"a", "c", "b"}},
+		{name: "limit and offset", filter: model.NoteFilter{Limit: &one, Offset: &one}, want: []string{"b"}},
+		{name: "offset near end", filter: model.NoteFilter{Offset: &two, Limit: &two}, want: []string{"a"}},
+		{name: "tags all required", filter: model.NoteFilter{Tags: []string{"family", "#Phone"}}, want: []string{"b"}},
+		{name: "exclude tags", filter: model.NoteFilter{ExcludeTags: []string{"phone"}}, want: []string{"c", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := ApplyFilter(notes, tt.filter)
+			ids := make([]string, len(got))
+			for i, n := range got {
+				ids[i] = n.ID
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("got %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPageFilter_CursorWalk(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var notes []*model.Note
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		// b and c share a timestamp so the ID tie-breaker is exercised
+		offset := time.Duration(i) * time.Minute
+		if id == "c" {
+			offset = time.Minute
+		}
+		notes = append(notes, &model.Note{ID: id, CreatedAt: base.Add(offset)})
+	}
+
+	limit := 2
+	filter := model.NoteFilter{Limit: &limit}
+	var got []string
+	for range 5 {
+		page, err := PageFilter(notes, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range page.Notes {
+			got = append(got, n.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	want := []string{"e", "d", "c", "b", "a"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPageFilter_InvalidCursor(t *testing.T) {
+	t.Parallel()
+	if _, err := PageFilter(nil, model.NoteFilter{Cursor: "%%%"}); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+	one := 1
+	if _, err := PageFilter(nil, model.NoteFilter{Cursor: EncodeCursor("x", "y"), Offset: &one}); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor with offset, got %v", err)
+	}
+}
@@ -3,7 +3,10 @@ package storage
 
 import (
 	"context"
+	"io"
+	"time"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
 )
 
@@ -28,24 +31,292 @@ type UnifiedNoteStorage interface {
 	Close() error
 }
 
+// Transactor is implemented by backends that can make several operations
+// atomic. fn receives a storage bound to one transaction, including the audit
+// rows and revisions its writes produce; the transaction commits when fn
+// returns nil and rolls back otherwise. Bound storages also implement every
+// optional interface the backend does, and nested WithinTx calls join the
+// outer transaction. Backends without transactions do not implement
+// Transactor; callers report ErrNotSupported for them.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(tx UnifiedNoteStorage) error) error
+}
+
+// ConditionalWriter is implemented by backends that version notes for
+// optimistic concurrency. Writes fail with model.ErrVersionConflict when the
+// stored version is no longer version; a zero version skips the check.
+type ConditionalWriter interface {
+	UpdateNoteIfVersion(ctx context.Context, id string, content string, done bool, version int64) (*model.Note, error)
+	DeleteNoteIfVersion(ctx context.Context, id string, version int64) error
+}
+
+// AuditLog is implemented by backends that keep an append-only audit trail.
+// Audit rows are written by the backend as part of each mutation; this
+// interface only exposes reads.
+type AuditLog interface {
+	ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error)
+}
+
+// RevisionStore is implemented by backends that keep per-note revision history
+type RevisionStore interface {
+	ListRevisions(ctx context.Context, noteID string) ([]model.NoteRevision, error)
+	GetRevision(ctx context.Context, noteID string, revision int) (*model.NoteRevision, error)
+}
+
+// Trash is implemented by backends that soft-delete notes. DeleteNote moves a
+// note to the trash, where it is hidden from GetAllNotes until restored or purged.
+// PurgeNote permanently removes one note that is in the trash.
+type Trash interface {
+	ListTrash(ctx context.Context) ([]*model.Note, error)
+	RestoreNote(ctx context.Context, id string) (*model.Note, error)
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error)
+	PurgeNote(ctx context.Context, id string) error
+}
+
+// Searcher is implemented by backends with a full-text index over note content.
+// Hits are ordered best match first and exclude notes in the trash.
+type Searcher interface {
+	Search(ctx context.Context, query string, limit int) ([]model.SearchHit, error)
+}
+
+// NoteQuerier is implemented by backends that filter, sort and page notes
+// themselves instead of returning every note. Notes in the trash are excluded.
+type NoteQuerier interface {
+	QueryNotes(ctx context.Context, filter model.NoteFilter) ([]*model.Note, error)
+}
+
+// NotePager is implemented by backends with keyset pagination over
+// (created_at, id). Filters that cannot use a keyset are served as a single
+// page without a next cursor.
+type NotePager interface {
+	PageNotes(ctx context.Context, filter model.NoteFilter) (*model.NotePage, error)
+}
+
+// Archiver is implemented by backends that can archive notes. An archived
+// note is still read and written by ID as usual but is left out of filtered
+// lists unless the filter asks for archived notes. Archiving an archived note,
+// or unarchiving one that is not archived, returns it unchanged.
+type Archiver interface {
+	ArchiveNote(ctx context.Context, id string) (*model.Note, error)
+	UnarchiveNote(ctx context.Context, id string) (*model.Note, error)
+}
+
+// Attacher is implemented by backends that store files attached to notes.
+// Content is kept by its SHA-256, so a file attached twice is stored once.
+// Adding fails with model.ErrAttachmentTooLarge past the backend's size limit;
+// attachments are removed with their note when it is purged from the trash.
+// PruneAttachments removes stored content no attachment refers to any more and
+// returns how many files it removed.
+type Attacher interface {
+	AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error)
+	ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error)
+	OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, id string) error
+	PruneAttachments(ctx context.Context) (int, error)
+}
+
+// Tagger is implemented by backends that keep tags apart from note content.
+// Tags are given and returned in normalized form (see model.NormalizeTags).
+// SetNoteTags replaces a note's tags and counts as a write to it. ListTags
+// counts the notes outside the trash carrying each tag, ordered by name.
+// RenameTag moves every note from one tag to another, merging the two when
+// to is already in use, and returns how many notes changed; it fails with
+// model.ErrTagNotFound when no note carries from. Backends with transactions
+// rename in one, so no note is left carrying the old name.
+type Tagger interface {
+	SetNoteTags(ctx context.Context, id string, tags []string) (*model.Note, error)
+	ListTags(ctx context.Context) ([]model.TagCount, error)
+	RenameTag(ctx context.Context, from, to string) (int, error)
+}
+
+// Importer is implemented by backends that can store notes exactly as they
+// are given, keeping their ID, done state and timestamps, as migration,
+// restore and sync need. A stored note with the same ID is kept or replaced
+// according to policy, and a note in the trash counts as stored. Notes that
+// fail model.CheckImport are reported as failed without stopping the import;
+// an error means the import as a whole did not complete.
+type Importer interface {
+	ImportNotes(ctx context.Context, notes []model.Note, policy model.ConflictPolicy) (*model.ImportReport, error)
+}
+
+// Backupper is implemented by backends that can snapshot their data while in
+// use. Backup writes a consistent snapshot and rotates old ones. RestoreBackup
+// verifies a snapshot and brings it up to the current schema before it
+// replaces the live data; the data being replaced is snapshotted first.
+type Backupper interface {
+	Backup(ctx context.Context) (*model.Backup, error)
+	ListBackups(ctx context.Context) ([]model.Backup, error)
+	RestoreBackup(ctx context.Context, name string) error
+}
+
+// HealthChecker is implemented by backends that verify and maintain their
+// own files. Health returns the last check result without running a new one.
+// A backend that finds corruption refuses writes with model.ErrReadOnly until
+// a clean check or a successful restore.
+type HealthChecker interface {
+	Health(ctx context.Context) (*model.StorageHealth, error)
+	CheckIntegrity(ctx context.Context) (*model.StorageHealth, error)
+	Optimize(ctx context.Context) error
+}
+
+// ProblemReporter is implemented by backends whose data can be edited outside
+// Godo, such as files on disk. Entries that cannot be read cleanly are still
+// listed as notes where possible, and Problems says what is wrong with them.
+type ProblemReporter interface {
+	Problems(ctx context.Context) ([]model.StorageProblem, error)
+}
+
+// Syncer is implemented by backends that keep a local copy of the notes in
+// step with a remote. Sync runs one pass and returns the resulting status,
+// along with an error when the pass did not complete; SyncStatus reports the
+// last pass without running one.
+type Syncer interface {
+	Sync(ctx context.Context) (*model.SyncStatus, error)
+	SyncStatus(ctx context.Context) (*model.SyncStatus, error)
+}
+
+// WriteQueue is implemented by backends that queue writes while their server
+// is unreachable and send them once it is back. QueueDepth is the number of
+// writes still waiting.
+type WriteQueue interface {
+	QueueDepth(ctx context.Context) (int, error)
+}
+
+// ChangeFeed is implemented by storage that publishes an event after each
+// committed change. The channel is closed when ctx is done, or earlier when
+// the subscriber falls too far behind under SlowConsumerDisconnect.
+type ChangeFeed interface {
+	Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent
+}
+
 // StorageType represents the type of storage backend
 type StorageType string
 
 const (
-	StorageTypeSQLite StorageType = "sqlite"
-	StorageTypeAPI    StorageType = "api"
+	StorageTypeSQLite   StorageType = "sqlite"
+	StorageTypeAPI      StorageType = "api"
+	StorageTypeMemory   StorageType = "memory"
+	StorageTypeMarkdown StorageType = "markdown"
 )
 
 // StorageConfig holds configuration for storage backends
 type StorageConfig struct {
-	Type   StorageType  `mapstructure:"type" json:"type"`
-	SQLite SQLiteConfig `mapstructure:"sqlite" json:"sqlite"`
-	API    APIConfig    `mapstructure:"api" json:"api"`
+	Type     StorageType    `mapstructure:"type" json:"type"`
+	SQLite   SQLiteConfig   `mapstructure:"sqlite" json:"sqlite"`
+	API      APIConfig      `mapstructure:"api" json:"api"`
+	Memory   MemoryConfig   `mapstructure:"memory" json:"memory"`
+	Markdown MarkdownConfig `mapstructure:"markdown" json:"markdown"`
+	// Sync keeps a SQLite database in step with the API backend
+	Sync SyncConfig `mapstructure:"sync" json:"sync"`
+	// Events sizes the change feed
+	Events EventsConfig `mapstructure:"events" json:"events"`
+	// Middleware wraps the backend in decorators, the first outermost
+	Middleware []MiddlewareConfig `mapstructure:"middleware" json:"middleware,omitempty"`
+}
+
+// EventsConfig sizes the change feed
+type EventsConfig struct {
+	// BufferSize is how many events a subscriber may fall behind by
+	BufferSize int `mapstructure:"buffer_size" json:"buffer_size"`
+	// HistorySize is how many recent events are kept for resuming
+	HistorySize int `mapstructure:"history_size" json:"history_size"`
+}
+
+// Middleware types
+const (
+	MiddlewareMetrics = "metrics"
+	MiddlewareLogging = "logging"
+	MiddlewareCache   = "cache"
+	MiddlewareRetry   = "retry"
+	MiddlewareTimeout = "timeout"
+)
+
+// MiddlewareConfig configures one decorator around the storage backend.
+// Each type reads only its own settings.
+type MiddlewareConfig struct {
+	// Type is metrics, logging, cache, retry or timeout
+	Type string `mapstructure:"type" json:"type"`
+	// SlowMs makes logging warn about operations slower than this
+	SlowMs int `mapstructure:"slow_ms" json:"slow_ms,omitempty"`
+	// Size is how many notes the cache holds
+	Size int `mapstructure:"size" json:"size,omitempty"`
+	// TTLSeconds is how long the cache trusts what it holds; 0 until written
+	TTLSeconds int `mapstructure:"ttl_seconds" json:"ttl_seconds,omitempty"`
+	// Attempts is how many times retry tries an operation in total
+	Attempts int `mapstructure:"attempts" json:"attempts,omitempty"`
+	// DelayMs is the wait before the first retry; it doubles after each
+	DelayMs int `mapstructure:"delay_ms" json:"delay_ms,omitempty"`
+	// TimeoutMs is the deadline timeout gives each operation
+	TimeoutMs int `mapstructure:"timeout_ms" json:"timeout_ms,omitempty"`
+}
+
+// SyncConfig holds configuration for two-way sync between SQLite and the API
+type SyncConfig struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// IntervalSeconds is how often a sync pass runs in the background
+	IntervalSeconds int `mapstructure:"interval_seconds" json:"interval_seconds"`
+	// ConflictPolicy is last-writer-wins, local-wins or keep-both
+	ConflictPolicy string `mapstructure:"conflict_policy" json:"conflict_policy"`
+}
+
+// MarkdownConfig holds configuration for the Markdown directory backend
+type MarkdownConfig struct {
+	// Dir holds one .md file per note; it is created if missing
+	Dir string `mapstructure:"dir" json:"dir"`
+}
+
+// MemoryConfig holds configuration for the in-memory backend
+type MemoryConfig struct {
+	// SnapshotPath is a JSON file the notes are loaded from at startup; empty starts empty
+	SnapshotPath string `mapstructure:"snapshot_path" json:"snapshot_path"`
+	// SaveOnClose writes the notes back to SnapshotPath on shutdown
+	SaveOnClose bool `mapstructure:"save_on_close" json:"save_on_close"`
 }
 
 // SQLiteConfig holds SQLite-specific configuration
 type SQLiteConfig struct {
-	FilePath string `mapstructure:"file_path" json:"file_path"`
+	FilePath string       `mapstructure:"file_path" json:"file_path"`
+	Backup   BackupConfig `mapstructure:"backup" json:"backup"`
+	// Attachments controls where attached files are kept and how large they may be
+	Attachments AttachmentConfig `mapstructure:"attachments" json:"attachments"`
+	// Connection settings; zero values select the store's defaults
+	JournalMode   string `mapstructure:"journal_mode" json:"journal_mode"`
+	BusyTimeoutMs int    `mapstructure:"busy_timeout_ms" json:"busy_timeout_ms"`
+	Synchronous   string `mapstructure:"synchronous" json:"synchronous"`
+	ForeignKeys   *bool  `mapstructure:"foreign_keys" json:"foreign_keys,omitempty"`
+	CacheSize     int    `mapstructure:"cache_size" json:"cache_size"`
+	MaxOpenConns  int    `mapstructure:"max_open_conns" json:"max_open_conns"`
+	MaxIdleConns  int    `mapstructure:"max_idle_conns" json:"max_idle_conns"`
+	// Encryption protects note content at rest
+	Encryption EncryptionConfig `mapstructure:"encryption" json:"encryption"`
+}
+
+// EncryptionConfig selects where the content encryption key comes from. The
+// passphrase itself is never part of the configuration.
+type EncryptionConfig struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// KeyFile holds a 32-byte key, raw or hex or base64 encoded
+	KeyFile string `mapstructure:"key_file" json:"key_file"`
+	// PassphraseEnv names the environment variable that holds the passphrase
+	PassphraseEnv string `mapstructure:"passphrase_env" json:"passphrase_env"`
+}
+
+// BackupConfig controls where database snapshots are kept and how they are rotated
+type BackupConfig struct {
+	// Dir holds the snapshots; relative paths are resolved against the database's directory
+	Dir string `mapstructure:"dir" json:"dir"`
+	// KeepCount is how many snapshots rotation keeps; 0 keeps any number
+	KeepCount int `mapstructure:"keep_count" json:"keep_count"`
+	// KeepDays removes snapshots older than this many days; 0 keeps them regardless of age
+	KeepDays int `mapstructure:"keep_days" json:"keep_days"`
+}
+
+// AttachmentConfig controls where attachment content is kept and the largest file accepted
+type AttachmentConfig struct {
+	// Dir holds the content; relative paths are resolved against the database's directory
+	Dir string `mapstructure:"dir" json:"dir"`
+	// MaxSizeMB is the largest attachment accepted; 0 accepts any size
+	MaxSizeMB int `mapstructure:"max_size_mb" json:"max_size_mb"`
 }
 
 // APIConfig holds API-specific configuration
@@ -54,6 +325,9 @@ type APIConfig struct {
 	Timeout    int    `mapstructure:"timeout_seconds" json:"timeout_seconds"`
 	RetryCount int    `mapstructure:"retry_count" json:"retry_count"`
 	RetryDelay int    `mapstructure:"retry_delay_ms" json:"retry_delay_ms"`
+	// OutboxPath is a SQLite file that queues writes while the server is
+	// unreachable; empty fails writes instead
+	OutboxPath string `mapstructure:"outbox_path" json:"outbox_path"`
 	// TLSInsecureSkipVerify disables TLS certificate verification (dev only).
 	// Opt-in via storage.api.tls_insecure_skip_verify in config (never enable in production without understanding the risk).
 	TLSInsecureSkipVerify bool `mapstructure:"tls_insecure_skip_verify" json:"tls_insecure_skip_verify"`
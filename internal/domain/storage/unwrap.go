@@ -0,0 +1,42 @@
+package storage
+
+// Wrapper is implemented by storage that decorates another backend, such as
+// a cache or a retry policy
+type Wrapper interface {
+	Unwrap() UnifiedNoteStorage
+}
+
+// As returns the outermost layer of store that implements the capability T,
+// following Unwrap through any decorators. Decorators only wrap the
+// UnifiedNoteStorage methods, so optional capabilities are usually found on
+// the backend itself.
+func As[T any](store UnifiedNoteStorage) (T, bool) {
+	for store != nil {
+		if capability, ok := store.(T); ok {
+			return capability, true
+		}
+		wrapper, ok := store.(Wrapper)
+		if !ok {
+			break
+		}
+		store = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Supports reports whether the backend under any decorators implements the
+// capability T. Decorators that only forward a capability still satisfy As
+// when their backend lacks it, so check Supports before a write that would
+// otherwise be left half done.
+func Supports[T any](store UnifiedNoteStorage) bool {
+	for store != nil {
+		wrapper, ok := store.(Wrapper)
+		if !ok {
+			break
+		}
+		store = wrapper.Unwrap()
+	}
+	_, ok := store.(T)
+	return ok
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// attachmentField is the multipart form field that carries an uploaded file
+const attachmentField = "file"
+
+// handleAddAttachment attaches the file in the "file" part of a multipart
+// upload to a note. The part is streamed to storage, so the size limit is
+// enforced without buffering the request body.
+func (s *Server) handleAddAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Request must be multipart/form-data")
+		return
+	}
+	for {
+		part, partErr := reader.NextPart()
+		if errors.Is(partErr, io.EOF) {
+			writeError(w, http.StatusBadRequest, "invalid_request", `Multipart field "file" is required`)
+			return
+		}
+		if partErr != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "Invalid multipart body")
+			return
+		}
+		if part.FormName() != attachmentField {
+			part.Close()
+			continue
+		}
+
+		contentType := part.Header.Get("Content-Type")
+		// Clients send octet-stream when they do not know the type; let the
+		// service work out a better one
+		if contentType == "application/octet-stream" {
+			contentType = ""
+		}
+		attachment, addErr := s.service.AddAttachment(r.Context(), id, part.FileName(), contentType, part)
+		part.Close()
+		if addErr != nil {
+			status, code, msg := mapError(addErr)
+			writeError(w, status, code, msg)
+			return
+		}
+		writeJSON(w, http.StatusCreated, NewAttachmentResponse(*attachment))
+		return
+	}
+}
+
+func (s *Server) handleListAttachments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	attachments, err := s.service.ListAttachments(r.Context(), id)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewAttachmentListResponse(id, attachments))
+}
+
+// handleDownloadAttachment streams an attachment's content with the type it
+// was stored with. Content is immutable, so its SHA-256 serves as the ETag.
+func (s *Server) handleDownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	attachment, content, err := s.service.OpenAttachment(r.Context(), id)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+	defer content.Close()
+
+	etag := `"` + attachment.SHA256 + `"`
+	header := w.Header()
+	header.Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Type", attachment.ContentType)
+	header.Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	// Stored types come from the uploader; never let a browser second-guess them
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, content); err != nil {
+		s.log.Warn("Failed to send attachment", "attachment_id", id, "error", err)
+	}
+}
+
+func (s *Server) handleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := s.service.DeleteAttachment(r.Context(), id); err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
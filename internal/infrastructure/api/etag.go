@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// noteETag returns the strong entity tag for a note's version, or "" when the
+// storage backend does not version notes
+func noteETag(note *model.Note) string {
+	if note.Version == 0 {
+		return ""
+	}
+	return `"` + strconv.FormatInt(note.Version, 10) + `"`
+}
+
+// writeNote writes a single note response with its ETag
+func writeNote(w http.ResponseWriter, status int, note *model.Note) {
+	if etag := noteETag(note); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	writeJSON(w, status, NewNoteResponse(note))
+}
+
+// parseIfMatch returns the note versions listed in an If-Match header. wildcard
+// is true when the header is absent or "*". Weak and malformed tags can never
+// match under the strong comparison If-Match requires, so they are dropped.
+func parseIfMatch(header string) (versions []int64, wildcard bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || version <= 0 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, false
+}
+
+// expectedVersion resolves the request's If-Match header to the version a
+// conditional write must replace. Zero means the write is unconditional. When
+// several tags are listed the one naming the current version is chosen.
+func (s *Server) expectedVersion(ctx context.Context, r *http.Request, id string) (int64, error) {
+	versions, wildcard := parseIfMatch(r.Header.Get("If-Match"))
+	switch {
+	case wildcard:
+		return 0, nil
+	case len(versions) == 0:
+		return 0, model.ErrVersionConflict
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+
+	note, err := s.service.GetNote(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(versions, note.Version) {
+		return 0, model.ErrVersionConflict
+	}
+	return note.Version, nil
+}
+
+// writeWriteError reports a failed write. A version conflict is answered with
+// 412 and the note's current representation so the client can merge and retry.
+func (s *Server) writeWriteError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	if errors.Is(err, model.ErrVersionConflict) {
+		if note, getErr := s.service.GetNote(r.Context(), id); getErr == nil {
+			writeNote(w, http.StatusPreconditionFailed, note)
+			return
+		}
+	}
+	status, code, msg := mapError(err)
+	writeError(w, status, code, msg)
+}
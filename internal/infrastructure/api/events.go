@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// eventsHeartbeat is how often an idle event stream sends a comment, so
+// proxies and clients can tell it is still open
+const eventsHeartbeat = 30 * time.Second
+
+// handleEvents streams note changes as server-sent events. Each message has
+// the event's Seq as its id, so a client that reconnects with Last-Event-ID
+// (or ?since=) receives what it missed, or a "reset" event when that is no
+// longer available. A client that falls too far behind is disconnected and
+// should reconnect the same way.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	if err = rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.log.Warn("Event stream may be cut off by the write timeout", "error", err)
+	}
+
+	events := s.service.Subscribe(r.Context(), filter)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err = rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, marshalErr := json.Marshal(NewNoteEventResponse(e))
+			if marshalErr != nil {
+				s.log.Error("Failed to encode note event", "error", marshalErr)
+				return
+			}
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		if err = rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// parseEventFilter reads the event filter from ?since=, ?type= (a comma
+// separated list), ?note_id= and the Last-Event-ID header
+func parseEventFilter(r *http.Request) (model.NoteEventFilter, error) {
+	query := r.URL.Query()
+	filter := model.NoteEventFilter{
+		NoteID:       query.Get("note_id"),
+		SlowConsumer: model.SlowConsumerDisconnect,
+	}
+
+	since := query.Get("since")
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		since = lastID
+	}
+	if since != "" {
+		seq, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("since must be an event id")
+		}
+		filter.Since = seq
+	}
+
+	if types := query.Get("type"); types != "" {
+		for _, raw := range strings.Split(types, ",") {
+			t := model.NoteEventType(strings.TrimSpace(raw))
+			switch t {
+			case model.NoteCreated, model.NoteUpdated, model.NoteDeleted:
+				filter.Types = append(filter.Types, t)
+			default:
+				return filter, fmt.Errorf("type must be created, updated or deleted")
+			}
+		}
+	}
+	return filter, nil
+}
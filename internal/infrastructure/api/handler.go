@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
 )
 
@@ -26,44 +27,123 @@ type NoteHandler interface {
 
 // CreateNoteRequest represents a request to create a new note
 type CreateNoteRequest struct {
-	Content string `json:"content" validate:"required,max=1000"`
+	Content string   `json:"content" validate:"required,max=1000"`
+	Tags    []string `json:"tags,omitempty"`
 }
 
 // UpdateNoteRequest represents a request to update an existing note
 type UpdateNoteRequest struct {
 	Content string `json:"content" validate:"required,max=1000"`
 	Done    bool   `json:"done"`
+	// Tags replaces the note's tags when present and keeps them when omitted
+	Tags *[]string `json:"tags,omitempty"`
 }
 
 // PatchNoteRequest represents a request to partially update a note
 type PatchNoteRequest struct {
-	Content *string `json:"content,omitempty" validate:"omitempty,max=1000"`
-	Done    *bool   `json:"done,omitempty"`
+	Content *string   `json:"content,omitempty" validate:"omitempty,max=1000"`
+	Done    *bool     `json:"done,omitempty"`
+	Tags    *[]string `json:"tags,omitempty"`
 }
 
-// NoteResponse represents a note in API responses
-type NoteResponse struct {
+// BulkUpdateNotesRequest applies the same partial update to several notes at
+// once; either every note is updated or none is
+type BulkUpdateNotesRequest struct {
+	IDs     []string `json:"ids" validate:"required,min=1,max=500,dive,required"`
+	Content *string  `json:"content,omitempty" validate:"omitempty,max=1000"`
+	Done    *bool    `json:"done,omitempty"`
+}
+
+// ImportNotesRequest imports notes with their own IDs, done state and
+// timestamps. Notes that are invalid are reported in the response rather
+// than failing the request.
+type ImportNotesRequest struct {
+	Notes []ImportNoteRequest `json:"notes" validate:"required,min=1,max=1000"`
+	// Policy is skip (the default), overwrite or newer-wins
+	Policy string `json:"policy,omitempty" validate:"omitempty,oneof=skip overwrite newer-wins"`
+}
+
+// ImportNoteRequest is one note to import
+type ImportNoteRequest struct {
 	ID        string    `json:"id"`
 	Content   string    `json:"content"`
 	Done      bool      `json:"done"`
+	Tags      []string  `json:"tags,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// NoteResponse represents a note in API responses
+type NoteResponse struct {
+	ID        string     `json:"id"`
+	Content   string     `json:"content"`
+	Done      bool       `json:"done"`
+	Tags      []string   `json:"tags"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ArchivedAt is set on archived notes
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// Version is the value carried in the note's ETag; zero when the storage
+	// backend does not version notes
+	Version int64 `json:"version,omitempty"`
+}
+
 // NewNoteResponse creates a NoteResponse from a model.Note
 func NewNoteResponse(note *model.Note) NoteResponse {
+	tags := note.Tags
+	if tags == nil {
+		tags = []string{}
+	}
 	return NoteResponse{
-		ID:        note.ID,
-		Content:   note.Content,
-		Done:      note.Done,
-		CreatedAt: note.CreatedAt,
-		UpdatedAt: note.UpdatedAt,
+		ID:         note.ID,
+		Content:    note.Content,
+		Done:       note.Done,
+		Tags:       tags,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+		DeletedAt:  note.DeletedAt,
+		ArchivedAt: note.ArchivedAt,
+		Version:    note.Version,
+	}
+}
+
+// NoteEventResponse is the data of one /events message
+type NoteEventResponse struct {
+	Seq    uint64        `json:"seq"`
+	Type   string        `json:"type"`
+	NoteID string        `json:"note_id,omitempty"`
+	Before *NoteResponse `json:"before,omitempty"`
+	After  *NoteResponse `json:"after,omitempty"`
+	Actor  string        `json:"actor,omitempty"`
+	At     time.Time     `json:"at"`
+}
+
+// NewNoteEventResponse creates a NoteEventResponse from a model.NoteEvent
+func NewNoteEventResponse(e model.NoteEvent) NoteEventResponse {
+	response := NoteEventResponse{
+		Seq:    e.Seq,
+		Type:   string(e.Type),
+		NoteID: e.NoteID,
+		Actor:  e.Actor,
+		At:     e.At,
+	}
+	if e.Before != nil {
+		before := NewNoteResponse(e.Before)
+		response.Before = &before
 	}
+	if e.After != nil {
+		after := NewNoteResponse(e.After)
+		response.After = &after
+	}
+	return response
 }
 
 // NoteListResponse represents a list of notes in API responses
 type NoteListResponse struct {
 	Notes []NoteResponse `json:"notes"`
+	// NextCursor is set when more notes follow; pass it back as ?cursor=
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // NewNoteListResponse creates a NoteListResponse from a slice of model.Notes
@@ -77,6 +157,95 @@ func NewNoteListResponse(notes []model.Note) NoteListResponse {
 	return response
 }
 
+// NewNotePageResponse creates a NoteListResponse from a page of notes
+func NewNotePageResponse(page *model.NotePage) NoteListResponse {
+	response := NoteListResponse{
+		Notes:      make([]NoteResponse, len(page.Notes)),
+		NextCursor: page.NextCursor,
+	}
+	for i, note := range page.Notes {
+		response.Notes[i] = NewNoteResponse(note)
+	}
+	return response
+}
+
+// ImportReportResponse lists what an import did with each note
+type ImportReportResponse struct {
+	Results []ImportResultResponse `json:"results"`
+	Created int                    `json:"created"`
+	Updated int                    `json:"updated"`
+	Skipped int                    `json:"skipped"`
+	Failed  int                    `json:"failed"`
+}
+
+// ImportResultResponse is the outcome of importing one note
+type ImportResultResponse struct {
+	ID      string `json:"id"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewImportReportResponse creates an ImportReportResponse from a model.ImportReport
+func NewImportReportResponse(report *model.ImportReport) ImportReportResponse {
+	response := ImportReportResponse{
+		Results: make([]ImportResultResponse, len(report.Results)),
+		Created: report.Created,
+		Updated: report.Updated,
+		Skipped: report.Skipped,
+		Failed:  report.Failed,
+	}
+	for i, result := range report.Results {
+		response.Results[i] = ImportResultResponse{
+			ID:      result.ID,
+			Outcome: string(result.Outcome),
+			Error:   result.Error,
+		}
+	}
+	return response
+}
+
+// AuditEventResponse represents an audit trail entry in API responses
+type AuditEventResponse struct {
+	ID        int64         `json:"id"`
+	Actor     string        `json:"actor"`
+	Operation string        `json:"operation"`
+	NoteID    string        `json:"note_id"`
+	Before    *NoteResponse `json:"before,omitempty"`
+	After     *NoteResponse `json:"after,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// AuditListResponse represents a list of audit events in API responses
+type AuditListResponse struct {
+	Events []AuditEventResponse `json:"events"`
+}
+
+// NewAuditListResponse creates an AuditListResponse from audit events
+func NewAuditListResponse(events []audit.Event) AuditListResponse {
+	response := AuditListResponse{
+		Events: make([]AuditEventResponse, len(events)),
+	}
+	for i, event := range events {
+		item := AuditEventResponse{
+			ID:        event.ID,
+			Actor:     event.Actor,
+			Operation: string(event.Operation),
+			NoteID:    event.NoteID,
+			CreatedAt: event.CreatedAt,
+		}
+		if event.Before != nil {
+			before := NewNoteResponse(event.Before)
+			item.Before = &before
+		}
+		if event.After != nil {
+			after := NewNoteResponse(event.After)
+			item.After = &after
+		}
+		response.Events[i] = item
+	}
+	return response
+}
+
 // ErrorResponse represents an error in API responses
 type ErrorResponse struct {
 	Code    string `json:"code"`
@@ -90,3 +259,226 @@ type ValidationErrorResponse struct {
 	Message string            `json:"message"`
 	Fields  map[string]string `json:"fields"`
 }
+
+// RevisionResponse represents a stored note revision in API responses
+type RevisionResponse struct {
+	Revision   int       `json:"revision"`
+	Content    string    `json:"content"`
+	Done       bool      `json:"done"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Actor      string    `json:"actor"`
+}
+
+// RevisionListResponse represents the revision history of a note in API responses
+type RevisionListResponse struct {
+	NoteID    string             `json:"note_id"`
+	Revisions []RevisionResponse `json:"revisions"`
+}
+
+// NewRevisionListResponse creates a RevisionListResponse from note revisions
+func NewRevisionListResponse(noteID string, revisions []model.NoteRevision) RevisionListResponse {
+	response := RevisionListResponse{
+		NoteID:    noteID,
+		Revisions: make([]RevisionResponse, len(revisions)),
+	}
+	for i, rev := range revisions {
+		response.Revisions[i] = RevisionResponse{
+			Revision:   rev.Revision,
+			Content:    rev.Content,
+			Done:       rev.Done,
+			UpdatedAt:  rev.UpdatedAt,
+			RecordedAt: rev.RecordedAt,
+			Actor:      rev.Actor,
+		}
+	}
+	return response
+}
+
+// TagResponse represents a tag and the number of notes carrying it
+type TagResponse struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TagListResponse represents every tag in use, ordered by name
+type TagListResponse struct {
+	Tags []TagResponse `json:"tags"`
+}
+
+// NewTagListResponse creates a TagListResponse from model.TagCounts
+func NewTagListResponse(tags []model.TagCount) TagListResponse {
+	response := TagListResponse{
+		Tags: make([]TagResponse, len(tags)),
+	}
+	for i, tag := range tags {
+		response.Tags[i] = TagResponse{Name: tag.Name, Count: tag.Count}
+	}
+	return response
+}
+
+// RenameTagRequest gives a tag a new name, merging it into the named tag
+// when that is already in use
+type RenameTagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// RenameTagResponse reports a tag rename
+type RenameTagResponse struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Notes is the number of notes moved to the new name
+	Notes int `json:"notes"`
+}
+
+// AttachmentResponse represents a file attached to a note in API responses
+type AttachmentResponse struct {
+	ID          string    `json:"id"`
+	NoteID      string    `json:"note_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewAttachmentResponse creates an AttachmentResponse from a model.Attachment
+func NewAttachmentResponse(attachment model.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          attachment.ID,
+		NoteID:      attachment.NoteID,
+		Name:        attachment.Name,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		SHA256:      attachment.SHA256,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
+
+// AttachmentListResponse represents the attachments of a note, oldest first
+type AttachmentListResponse struct {
+	NoteID      string               `json:"note_id"`
+	Attachments []AttachmentResponse `json:"attachments"`
+}
+
+// NewAttachmentListResponse creates an AttachmentListResponse from model.Attachments
+func NewAttachmentListResponse(noteID string, attachments []model.Attachment) AttachmentListResponse {
+	response := AttachmentListResponse{
+		NoteID:      noteID,
+		Attachments: make([]AttachmentResponse, len(attachments)),
+	}
+	for i, attachment := range attachments {
+		response.Attachments[i] = NewAttachmentResponse(attachment)
+	}
+	return response
+}
+
+// HealthResponse is the body of the unauthenticated health endpoint
+type HealthResponse struct {
+	// Status is "healthy", "degraded" when storage is read-only, or "unhealthy"
+	Status string `json:"status"`
+	Time   string `json:"time"`
+	// Storage is omitted for backends that do not check themselves
+	Storage *StorageHealthSummary `json:"storage,omitempty"`
+	// Outbox is omitted for backends that never queue writes
+	Outbox *OutboxSummary `json:"outbox,omitempty"`
+}
+
+// OutboxSummary reports writes queued while the storage server is unreachable
+type OutboxSummary struct {
+	Pending int `json:"pending"`
+}
+
+// StorageHealthSummary is the storage part of a health response. It leaves
+// out the problem details, which are only available to authenticated callers.
+type StorageHealthSummary struct {
+	Status    string    `json:"status"`
+	ReadOnly  bool      `json:"read_only"`
+	Check     string    `json:"check"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// IntegrityCheckResponse represents the result of an on-demand integrity check
+type IntegrityCheckResponse struct {
+	Status    string    `json:"status"`
+	ReadOnly  bool      `json:"read_only"`
+	Check     string    `json:"check"`
+	Problems  []string  `json:"problems"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// NewIntegrityCheckResponse creates an IntegrityCheckResponse from a model.StorageHealth
+func NewIntegrityCheckResponse(health model.StorageHealth) IntegrityCheckResponse {
+	problems := health.Problems
+	if problems == nil {
+		problems = []string{}
+	}
+	return IntegrityCheckResponse{
+		Status:    string(health.Status),
+		ReadOnly:  health.ReadOnly,
+		Check:     health.Check,
+		Problems:  problems,
+		CheckedAt: health.CheckedAt,
+	}
+}
+
+// BackupResponse represents a database snapshot in API responses
+type BackupResponse struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// NewBackupResponse creates a BackupResponse from a model.Backup
+func NewBackupResponse(backup model.Backup) BackupResponse {
+	return BackupResponse{
+		Name:      backup.Name,
+		CreatedAt: backup.CreatedAt,
+		Size:      backup.Size,
+	}
+}
+
+// BackupListResponse represents the available snapshots, newest first
+type BackupListResponse struct {
+	Backups []BackupResponse `json:"backups"`
+}
+
+// NewBackupListResponse creates a BackupListResponse from model.Backups
+func NewBackupListResponse(backups []model.Backup) BackupListResponse {
+	response := BackupListResponse{
+		Backups: make([]BackupResponse, len(backups)),
+	}
+	for i, backup := range backups {
+		response.Backups[i] = NewBackupResponse(backup)
+	}
+	return response
+}
+
+// SearchHitResponse represents a full-text search match in API responses
+type SearchHitResponse struct {
+	Note    NoteResponse `json:"note"`
+	Rank    float64      `json:"rank"`
+	Snippet string       `json:"snippet"`
+}
+
+// SearchResponse represents the results of a full-text search in API responses
+type SearchResponse struct {
+	Query string              `json:"query"`
+	Hits  []SearchHitResponse `json:"hits"`
+}
+
+// NewSearchResponse creates a SearchResponse from search hits
+func NewSearchResponse(query string, hits []model.SearchHit) SearchResponse {
+	response := SearchResponse{
+		Query: query,
+		Hits:  make([]SearchHitResponse, len(hits)),
+	}
+	for i, hit := range hits {
+		response.Hits[i] = SearchHitResponse{
+			Note:    NewNoteResponse(&hit.Note),
+			Rank:    hit.Rank,
+			Snippet: hit.Snippet,
+		}
+	}
+	return response
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v4"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
 )
 
@@ -144,11 +146,32 @@ func writeValidationError(w http.ResponseWriter, fields map[string]string) {
 
 // mapError maps an error to an HTTP status code and error message
 func mapError(err error) (code int, msg, details string) {
+	var validationErr *model.ValidationError
 	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, "Validation failed", err.Error()
 	case errors.Is(err, model.ErrNoteNotFound):
 		return http.StatusNotFound, "Note not found", err.Error()
+	case errors.Is(err, model.ErrRevisionNotFound):
+		return http.StatusNotFound, "Revision not found", err.Error()
+	case errors.Is(err, model.ErrTagNotFound):
+		return http.StatusNotFound, "Tag not found", err.Error()
+	case errors.Is(err, model.ErrAttachmentNotFound):
+		return http.StatusNotFound, "Attachment not found", err.Error()
+	case errors.Is(err, model.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge, "Attachment too large", err.Error()
+	case errors.Is(err, model.ErrBackupNotFound):
+		return http.StatusNotFound, "Backup not found", err.Error()
+	case errors.Is(err, model.ErrInvalidCursor):
+		return http.StatusBadRequest, "Invalid cursor", err.Error()
+	case errors.Is(err, model.ErrVersionConflict):
+		return http.StatusPreconditionFailed, "Note was modified", err.Error()
 	case errors.Is(err, model.ErrDuplicateID):
 		return http.StatusConflict, "Note ID already exists", err.Error()
+	case errors.Is(err, model.ErrReadOnly):
+		return http.StatusServiceUnavailable, "Storage is read-only", err.Error()
+	case errors.Is(err, domainstorage.ErrNotSupported):
+		return http.StatusNotImplemented, "Not supported by storage backend", err.Error()
 	default:
 		return http.StatusInternalServerError, internalServerErrorMsg, err.Error()
 	}
@@ -230,8 +253,9 @@ func WithJWTAuth(log logger.Logger, jwtSecret string) Middleware {
 					return
 				}
 
-				// Add user ID to context
+				// Add user ID to context; it also attributes mutations in the audit trail
 				ctx := context.WithValue(r.Context(), userIDKey{}, userID)
+				ctx = audit.WithActor(ctx, userID)
 				next(w, r.WithContext(ctx))
 			} else {
 				writeError(w, http.StatusUnauthorized, "invalid_token", "Invalid token claims")
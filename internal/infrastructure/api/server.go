@@ -3,15 +3,21 @@ package api
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
 	"github.com/jonesrussell/godo/internal/domain/service"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
 )
 
@@ -83,6 +89,20 @@ func (s *Server) routes() {
 		WithValidation[CreateNoteRequest](s.log),
 	)).Methods(http.MethodPost)
 
+	api.HandleFunc("/notes", Chain(s.handleBulkUpdateNotes,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+		WithValidation[BulkUpdateNotesRequest](s.log),
+	)).Methods(http.MethodPatch)
+
+	api.HandleFunc("/notes/import", Chain(s.handleImportNotes,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+		WithValidation[ImportNotesRequest](s.log),
+	)).Methods(http.MethodPost)
+
 	api.HandleFunc("/notes/{id}", Chain(s.handleGetNote,
 		WithJWTAuth(s.log, s.jwtSecret),
 		WithLogging(s.log),
@@ -108,23 +128,205 @@ func (s *Server) routes() {
 		WithLogging(s.log),
 		WithErrorHandling(s.log),
 	)).Methods(http.MethodDelete)
+
+	api.HandleFunc("/notes/{id}/revisions", Chain(s.handleListRevisions,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/notes/{id}/revisions/{rev}/restore", Chain(s.handleRestoreRevision,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodPost)
+
+	api.HandleFunc("/notes/{id}/restore", Chain(s.handleRestoreNote,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodPost)
+
+	api.HandleFunc("/notes/{id}/archive", Chain(s.handleArchiveNote,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodPost)
+
+	api.HandleFunc("/notes/{id}/unarchive", Chain(s.handleUnarchiveNote,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodPost)
+
+	api.HandleFunc("/notes/{id}/attachments", Chain(s.handleAddAttachment,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodPost)
+
+	api.HandleFunc("/notes/{id}/attachments", Chain(s.handleListAttachments,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/attachments/{id}", Chain(s.handleDownloadAttachment,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/attachments/{id}", Chain(s.handleDeleteAttachment,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodDelete)
+
+	api.HandleFunc("/tags", Chain(s.handleListTags,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/tags/{name}/rename", Chain(s.handleRenameTag,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+		WithValidation[RenameTagRequest](s.log),
+	)).Methods(http.MethodPost)
+
+	api.HandleFunc("/trash", Chain(s.handleListTrash,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/search", Chain(s.handleSearch,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/audit", Chain(s.handleListAudit,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/events", Chain(s.handleEvents,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/admin/backup", Chain(s.handleCreateBackup,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodPost)
+
+	api.HandleFunc("/admin/backups", Chain(s.handleListBackups,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
+
+	api.HandleFunc("/admin/integrity-check", Chain(s.handleCheckIntegrity,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodPost)
+
+	// Process and storage metrics (expvar), including the counters of the
+	// storage metrics middleware under "godo_storage"
+	api.HandleFunc("/admin/metrics", Chain(expvar.Handler().ServeHTTP,
+		WithJWTAuth(s.log, s.jwtSecret),
+		WithLogging(s.log),
+		WithErrorHandling(s.log),
+	)).Methods(http.MethodGet)
 }
 
 func (s *Server) handleListNotes(w http.ResponseWriter, r *http.Request) {
-	notes, err := s.service.ListNotes(r.Context(), nil)
+	filter, err := parseNoteFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	page, err := s.service.ListNotesPage(r.Context(), filter)
 	if err != nil {
 		status, code, msg := mapError(err)
 		writeError(w, status, code, msg)
 		return
 	}
 
-	// Convert service notes to model notes for response
-	modelNotes := make([]model.Note, len(notes))
-	for i, note := range notes {
-		modelNotes[i] = *note
+	writeJSON(w, http.StatusOK, NewNotePageResponse(page))
+}
+
+// parseNoteFilter reads note filter parameters from a query string
+func parseNoteFilter(query url.Values) (*service.NoteFilter, error) {
+	filter := &service.NoteFilter{
+		Sort:   model.NoteSort(query.Get("sort")),
+		Cursor: query.Get("cursor"),
+	}
+	if !filter.Sort.IsValid() {
+		return nil, fmt.Errorf("sort must be one of created_at, -created_at, updated_at, -updated_at")
 	}
 
-	writeJSON(w, http.StatusOK, NewNoteListResponse(modelNotes))
+	if raw := query.Get("done"); raw != "" {
+		done, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("done must be true or false")
+		}
+		filter.Done = &done
+	}
+	if content := query.Get("content"); content != "" {
+		filter.Content = &content
+	}
+	filter.Tags = query["tag"]
+	filter.ExcludeTags = query["exclude_tag"]
+	switch query.Get("archived") {
+	case "", "false":
+	case "true":
+		filter.Archived = model.ArchiveOnly
+	case "all":
+		filter.Archived = model.ArchiveInclude
+	default:
+		return nil, fmt.Errorf("archived must be true, false or all")
+	}
+
+	for key, dst := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		if raw := query.Get(key); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", key)
+			}
+			*dst = &t
+		}
+	}
+
+	for key, dst := range map[string]**int{
+		"limit":  &filter.Limit,
+		"offset": &filter.Offset,
+	} {
+		if raw := query.Get(key); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%s must be a non-negative integer", key)
+			}
+			*dst = &n
+		}
+	}
+
+	if filter.Cursor != "" && !filter.UsesKeyset() {
+		return nil, fmt.Errorf("cursor requires a created_at sort and no offset")
+	}
+
+	return filter, nil
 }
 
 func (s *Server) handleCreateNote(w http.ResponseWriter, r *http.Request) {
@@ -134,14 +336,14 @@ func (s *Server) handleCreateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	note, err := s.service.CreateNote(r.Context(), req.Content)
+	note, err := s.service.CreateNote(r.Context(), req.Content, req.Tags...)
 	if err != nil {
 		status, code, msg := mapError(err)
 		writeError(w, status, code, msg)
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, NewNoteResponse(note))
+	writeNote(w, http.StatusCreated, note)
 }
 
 func (s *Server) handleGetNote(w http.ResponseWriter, r *http.Request) {
@@ -155,7 +357,7 @@ func (s *Server) handleGetNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, NewNoteResponse(note))
+	writeNote(w, http.StatusOK, note)
 }
 
 func (s *Server) handleUpdateNote(w http.ResponseWriter, r *http.Request) {
@@ -168,19 +370,26 @@ func (s *Server) handleUpdateNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := s.expectedVersion(r.Context(), r, id)
+	if err != nil {
+		s.writeWriteError(w, r, id, err)
+		return
+	}
+
 	updates := service.NoteUpdateRequest{
-		Content: &req.Content,
-		Done:    &req.Done,
+		Content:         &req.Content,
+		Done:            &req.Done,
+		Tags:            req.Tags,
+		ExpectedVersion: version,
 	}
 
 	note, err := s.service.UpdateNote(r.Context(), id, updates)
 	if err != nil {
-		status, code, msg := mapError(err)
-		writeError(w, status, code, msg)
+		s.writeWriteError(w, r, id, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, NewNoteResponse(note))
+	writeNote(w, http.StatusOK, note)
 }
 
 func (s *Server) handlePatchNote(w http.ResponseWriter, r *http.Request) {
@@ -193,40 +402,340 @@ func (s *Server) handlePatchNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := s.expectedVersion(r.Context(), r, id)
+	if err != nil {
+		s.writeWriteError(w, r, id, err)
+		return
+	}
+
+	updates := service.NoteUpdateRequest{
+		Content:         req.Content,
+		Done:            req.Done,
+		Tags:            req.Tags,
+		ExpectedVersion: version,
+	}
+
+	note, err := s.service.UpdateNote(r.Context(), id, updates)
+	if err != nil {
+		s.writeWriteError(w, r, id, err)
+		return
+	}
+
+	writeNote(w, http.StatusOK, note)
+}
+
+func (s *Server) handleBulkUpdateNotes(w http.ResponseWriter, r *http.Request) {
+	req, ok := GetRequest[BulkUpdateNotesRequest](r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
+		return
+	}
+
 	updates := service.NoteUpdateRequest{
 		Content: req.Content,
 		Done:    req.Done,
 	}
 
-	note, err := s.service.UpdateNote(r.Context(), id, updates)
+	notes, err := s.service.UpdateNotes(r.Context(), req.IDs, updates)
 	if err != nil {
 		status, code, msg := mapError(err)
 		writeError(w, status, code, msg)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, NewNoteResponse(note))
+	modelNotes := make([]model.Note, len(notes))
+	for i, note := range notes {
+		modelNotes[i] = *note
+	}
+
+	writeJSON(w, http.StatusOK, NewNoteListResponse(modelNotes))
+}
+
+func (s *Server) handleImportNotes(w http.ResponseWriter, r *http.Request) {
+	req, ok := GetRequest[ImportNotesRequest](r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
+		return
+	}
+
+	policy := model.ConflictSkip
+	if req.Policy != "" {
+		policy = model.ConflictPolicy(req.Policy)
+	}
+	notes := make([]model.Note, len(req.Notes))
+	for i, note := range req.Notes {
+		notes[i] = model.Note{
+			ID:        note.ID,
+			Content:   note.Content,
+			Done:      note.Done,
+			Tags:      note.Tags,
+			CreatedAt: note.CreatedAt,
+			UpdatedAt: note.UpdatedAt,
+		}
+	}
+
+	report, err := s.service.ImportNotes(r.Context(), notes, policy)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewImportReportResponse(report))
 }
 
 func (s *Server) handleDeleteNote(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	if err := s.service.DeleteNote(r.Context(), id); err != nil {
+	version, err := s.expectedVersion(r.Context(), r, id)
+	if err != nil {
+		s.writeWriteError(w, r, id, err)
+		return
+	}
+
+	if err = s.service.DeleteNoteIfVersion(r.Context(), id, version); err != nil {
+		s.writeWriteError(w, r, id, err)
+		return
+	}
+
+	writeJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *Server) handleRestoreNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	note, err := s.service.RestoreNote(r.Context(), id)
+	if err != nil {
 		status, code, msg := mapError(err)
 		writeError(w, status, code, msg)
 		return
 	}
 
-	writeJSON(w, http.StatusNoContent, nil)
+	writeNote(w, http.StatusOK, note)
+}
+
+func (s *Server) handleArchiveNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	note, err := s.service.ArchiveNote(r.Context(), id)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeNote(w, http.StatusOK, note)
+}
+
+func (s *Server) handleUnarchiveNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	note, err := s.service.UnarchiveNote(r.Context(), id)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeNote(w, http.StatusOK, note)
+}
+
+func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.service.ListTags(r.Context())
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewTagListResponse(tags))
+}
+
+// handleRenameTag renames a tag on every note, merging it into the new name
+// when that tag already exists
+func (s *Server) handleRenameTag(w http.ResponseWriter, r *http.Request) {
+	from := model.NormalizeTag(mux.Vars(r)["name"])
+
+	req, ok := GetRequest[RenameTagRequest](r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
+		return
+	}
+
+	renamed, err := s.service.RenameTag(r.Context(), from, req.Name)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RenameTagResponse{
+		From:  from,
+		To:    model.NormalizeTag(req.Name),
+		Notes: renamed,
+	})
 }
 
+func (s *Server) handleListTrash(w http.ResponseWriter, r *http.Request) {
+	notes, err := s.service.ListTrash(r.Context())
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	modelNotes := make([]model.Note, len(notes))
+	for i, note := range notes {
+		modelNotes[i] = *note
+	}
+
+	writeJSON(w, http.StatusOK, NewNoteListResponse(modelNotes))
+}
+
+func (s *Server) handleListRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	revisions, err := s.service.ListRevisions(r.Context(), id)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewRevisionListResponse(id, revisions))
+}
+
+func (s *Server) handleRestoreRevision(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	rev, err := strconv.Atoi(vars["rev"])
+	if err != nil || rev < 1 {
+		writeError(w, http.StatusBadRequest, "invalid_request", "rev must be a positive integer")
+		return
+	}
+
+	note, err := s.service.RestoreRevision(r.Context(), id, rev)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeNote(w, http.StatusOK, note)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := query.Get("q")
+	if strings.TrimSpace(q) == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "q is required")
+		return
+	}
+
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	hits, err := s.service.SearchNotes(r.Context(), q, limit)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewSearchResponse(q, hits))
+}
+
+func (s *Server) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		NoteID: query.Get("note_id"),
+	}
+
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "since must be an RFC 3339 timestamp")
+			return
+		}
+		filter.Since = &t
+	}
+
+	events, err := s.service.ListAuditEvents(r.Context(), filter)
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewAuditListResponse(events))
+}
+
+// handleHealth reports whether the service can take writes. It answers 503
+// while the storage backend is read-only after detecting corruption.
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	response := map[string]string{
-		"status": "healthy",
-		"time":   time.Now().Format(time.RFC3339),
+	response := HealthResponse{
+		Status: "healthy",
+		Time:   time.Now().Format(time.RFC3339),
 	}
-	writeJSON(w, http.StatusOK, response)
+
+	health, err := s.service.StorageHealth(r.Context())
+	switch {
+	case errors.Is(err, domainstorage.ErrNotSupported):
+	case err != nil:
+		s.log.Error("Failed to read storage health", "error", err)
+		response.Status = "unhealthy"
+	default:
+		response.Storage = &StorageHealthSummary{
+			Status:    string(health.Status),
+			ReadOnly:  health.ReadOnly,
+			Check:     health.Check,
+			CheckedAt: health.CheckedAt,
+		}
+		if !health.Healthy() {
+			response.Status = "degraded"
+		}
+	}
+
+	// Queued writes are reported but do not make the service unhealthy: they
+	// are kept locally and sent once the storage server is back
+	pending, err := s.service.QueuedWrites(r.Context())
+	switch {
+	case errors.Is(err, domainstorage.ErrNotSupported):
+	case err != nil:
+		s.log.Warn("Failed to read write queue", "error", err)
+	default:
+		response.Outbox = &OutboxSummary{Pending: pending}
+	}
+
+	status := http.StatusOK
+	if response.Status != "healthy" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, response)
+}
+
+func (s *Server) handleCheckIntegrity(w http.ResponseWriter, r *http.Request) {
+	health, err := s.service.CheckIntegrity(r.Context())
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewIntegrityCheckResponse(*health))
 }
 
 // Start starts the HTTP server
@@ -264,3 +773,25 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	}
 	return nil
 }
+
+func (s *Server) handleCreateBackup(w http.ResponseWriter, r *http.Request) {
+	backup, err := s.service.CreateBackup(r.Context())
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, NewBackupResponse(*backup))
+}
+
+func (s *Server) handleListBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := s.service.ListBackups(r.Context())
+	if err != nil {
+		status, code, msg := mapError(err)
+		writeError(w, status, code, msg)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, NewBackupListResponse(backups))
+}
@@ -1,7 +1,11 @@
 // Package gui defines interfaces for the graphical user interface components
 package gui
 
-import "fyne.io/fyne/v2"
+import (
+	"fyne.io/fyne/v2"
+
+	"github.com/jonesrussell/godo/internal/infrastructure/storage"
+)
 
 // QuickNote defines the interface for quick note functionality
 type QuickNote interface {
@@ -18,4 +22,6 @@ type MainWindow interface {
 	CenterOnScreen()
 	GetWindow() fyne.Window
 	Refresh()
+	ShowBackups()
+	SetStore(store storage.NoteStore)
 }
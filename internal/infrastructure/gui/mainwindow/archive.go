@@ -0,0 +1,92 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// archiveSource is implemented by note stores that can archive notes
+type archiveSource interface {
+	QueryNotes(ctx context.Context, filter model.NoteFilter) ([]model.Note, error)
+	ArchiveNote(ctx context.Context, id string) error
+	UnarchiveNote(ctx context.Context, id string) error
+}
+
+// Labels of the archive scope select, in the order shown
+const (
+	scopeActive   = "Active"
+	scopeArchived = "Archived"
+	scopeAll      = "All"
+)
+
+// archiveScopes maps the scope select's labels to filter scopes
+var archiveScopes = map[string]model.ArchiveScope{
+	scopeActive:   model.ArchiveExclude,
+	scopeArchived: model.ArchiveOnly,
+	scopeAll:      model.ArchiveInclude,
+}
+
+// createScopeSelect creates the select that switches the list between
+// active, archived and all notes
+func (w *Window) createScopeSelect() {
+	w.scopeSelect = widget.NewSelect([]string{scopeActive, scopeArchived, scopeAll}, func(label string) {
+		w.archiveScope = archiveScopes[label]
+		w.loadNotes()
+	})
+	// Set directly so the list is not loaded before the window is built
+	w.scopeSelect.Selected = scopeActive
+}
+
+// listNotes returns the notes in the selected archive scope carrying the
+// selected tag, or every such note when the store cannot archive
+func (w *Window) listNotes(ctx context.Context) ([]model.Note, error) {
+	if source, ok := w.store.(archiveSource); ok {
+		filter := model.NoteFilter{Archived: w.archiveScope}
+		if w.tagFilter != "" {
+			filter.Tags = []string{w.tagFilter}
+		}
+		return source.QueryNotes(ctx, filter)
+	}
+	notes, err := w.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return w.tagged(notes), nil
+}
+
+// toggleArchived archives a note, or unarchives it when it is archived
+func (w *Window) toggleArchived(id widget.ListItemID) {
+	if id >= len(w.notes) {
+		return
+	}
+	note := w.notes[id]
+
+	source, ok := w.store.(archiveSource)
+	if !ok {
+		w.showStatus("Archiving is not supported by this storage", true)
+		return
+	}
+
+	ctx := guiContext()
+	archive, done := source.ArchiveNote, "Note archived"
+	if note.ArchivedAt != nil {
+		archive, done = source.UnarchiveNote, "Note unarchived"
+	}
+	if err := archive(ctx, note.ID); err != nil {
+		if errors.Is(err, domainstorage.ErrNotSupported) {
+			w.showStatus("Archiving is not supported by this storage", true)
+			return
+		}
+		w.log.Error("Failed to archive note", "note_id", note.ID, "error", err)
+		w.showStatus("Failed to archive note", true)
+		return
+	}
+
+	w.loadNotes()
+	w.showStatus(done, false)
+}
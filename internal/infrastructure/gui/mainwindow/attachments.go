@@ -0,0 +1,223 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// attachmentSource is implemented by note stores that keep files attached to notes
+type attachmentSource interface {
+	AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error)
+	ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error)
+	OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, id string) error
+}
+
+// errNoAttachments is shown when the store cannot keep attachments
+const errNoAttachments = "Attachments are not supported by this storage"
+
+// handleDrop attaches files dropped on the window to the selected note
+func (w *Window) handleDrop(_ fyne.Position, uris []fyne.URI) {
+	note, ok := w.selectedNote()
+	if !ok {
+		w.showStatus("Select a note to attach files to", true)
+		return
+	}
+	source, ok := w.store.(attachmentSource)
+	if !ok {
+		w.showStatus(errNoAttachments, true)
+		return
+	}
+
+	attached := 0
+	for _, uri := range uris {
+		if uri.Scheme() != "file" {
+			continue
+		}
+		if err := w.attachFile(source, note.ID, uri.Path()); err != nil {
+			w.reportAttachError(uri.Name(), err)
+			return
+		}
+		attached++
+	}
+	if attached == 0 {
+		w.showStatus("Only local files can be attached", true)
+		return
+	}
+	w.showStatus(fmt.Sprintf("Attached %d file(s) to the note", attached), false)
+}
+
+// attachFile stores a local file as an attachment of a note
+func (w *Window) attachFile(source attachmentSource, noteID, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a folder", info.Name())
+	}
+
+	name := model.AttachmentName(info.Name())
+	contentType, content := model.DetectContentType(name, file)
+	_, err = source.AddAttachment(guiContext(), noteID, name, contentType, content)
+	return err
+}
+
+// reportAttachError explains why a file could not be attached
+func (w *Window) reportAttachError(name string, err error) {
+	switch {
+	case errors.Is(err, domainstorage.ErrNotSupported):
+		w.showStatus(errNoAttachments, true)
+	case errors.Is(err, model.ErrAttachmentTooLarge):
+		w.showStatus(fmt.Sprintf("%s is larger than the attachment size limit", name), true)
+	default:
+		w.log.Error("Failed to attach file", "name", name, "error", err)
+		w.showStatus(fmt.Sprintf("Failed to attach %s", name), true)
+	}
+}
+
+// selectedNote returns the note selected in the list, if any
+func (w *Window) selectedNote() (model.Note, bool) {
+	for _, note := range w.notes {
+		if note.ID == w.selectedID {
+			return note, true
+		}
+	}
+	return model.Note{}, false
+}
+
+// showAttachments opens a dialog listing a note's attachments, with options
+// to save or remove each and to attach another file
+func (w *Window) showAttachments(id widget.ListItemID) {
+	if id >= len(w.notes) {
+		return
+	}
+	note := w.notes[id]
+
+	source, ok := w.store.(attachmentSource)
+	if !ok {
+		w.showStatus(errNoAttachments, true)
+		return
+	}
+
+	attachments, err := source.ListAttachments(guiContext(), note.ID)
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		w.showStatus(errNoAttachments, true)
+		return
+	}
+	if err != nil {
+		w.log.Error("Failed to list attachments", "note_id", note.ID, "error", err)
+		w.showStatus("Failed to list attachments", true)
+		return
+	}
+
+	var list dialog.Dialog
+	reopen := func() {
+		list.Hide()
+		w.showAttachments(id)
+	}
+
+	items := container.NewVBox()
+	if len(attachments) == 0 {
+		items.Add(widget.NewLabel("No files attached. Drop files on the window to attach them to the selected note."))
+	}
+	for _, attachment := range attachments {
+		name := widget.NewLabel(attachment.Name)
+		name.Truncation = fyne.TextTruncateEllipsis
+		size := widget.NewLabel(formatSize(attachment.Size))
+
+		save := widget.NewButtonWithIcon("Save", theme.DownloadIcon(), func() {
+			w.saveAttachment(source, attachment)
+		})
+		remove := widget.NewButtonWithIcon("Remove", theme.DeleteIcon(), func() {
+			w.confirmRemoveAttachment(source, attachment, reopen)
+		})
+
+		items.Add(container.NewBorder(nil, nil, nil,
+			container.NewHBox(size, layout.NewSpacer(), save, remove),
+			name,
+		))
+	}
+
+	attach := widget.NewButtonWithIcon("Attach File", theme.ContentAddIcon(), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, openErr error) {
+			if openErr != nil || reader == nil {
+				return
+			}
+			path, name := reader.URI().Path(), reader.URI().Name()
+			reader.Close()
+			if attachErr := w.attachFile(source, note.ID, path); attachErr != nil {
+				w.reportAttachError(name, attachErr)
+				return
+			}
+			w.showStatus(fmt.Sprintf("Attached %s", name), false)
+			reopen()
+		}, w.window)
+	})
+
+	list = dialog.NewCustom("Attachments", "Close",
+		container.NewBorder(nil, attach, nil, nil, container.NewVScroll(items)),
+		w.window,
+	)
+	list.Resize(fyne.NewSize(500, 400))
+	list.Show()
+}
+
+// saveAttachment asks where to save an attachment and writes its content there
+func (w *Window) saveAttachment(source attachmentSource, attachment model.Attachment) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, saveErr error) {
+		if saveErr != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		_, content, err := source.OpenAttachment(guiContext(), attachment.ID)
+		if err == nil {
+			_, err = io.Copy(writer, content)
+			content.Close()
+		}
+		if err != nil {
+			w.log.Error("Failed to save attachment", "attachment_id", attachment.ID, "error", err)
+			w.showStatus(fmt.Sprintf("Failed to save %s", attachment.Name), true)
+			return
+		}
+		w.showStatus(fmt.Sprintf("Saved %s", attachment.Name), false)
+	}, w.window)
+	save.SetFileName(attachment.Name)
+	save.Show()
+}
+
+// confirmRemoveAttachment asks before removing an attachment, then calls done
+func (w *Window) confirmRemoveAttachment(source attachmentSource, attachment model.Attachment, done func()) {
+	dialog.ShowConfirm("Remove Attachment", fmt.Sprintf("Remove %s from the note?", attachment.Name), func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := source.DeleteAttachment(guiContext(), attachment.ID); err != nil {
+			w.log.Error("Failed to remove attachment", "attachment_id", attachment.ID, "error", err)
+			w.showStatus(fmt.Sprintf("Failed to remove %s", attachment.Name), true)
+			return
+		}
+		w.showStatus(fmt.Sprintf("Removed %s", attachment.Name), false)
+		done()
+	}, w.window)
+}
@@ -0,0 +1,133 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// backupSource is implemented by note stores that can snapshot their data
+type backupSource interface {
+	Backup(ctx context.Context) (*model.Backup, error)
+	ListBackups(ctx context.Context) ([]model.Backup, error)
+	RestoreBackup(ctx context.Context, name string) error
+}
+
+// ShowBackups opens the backups dialog
+func (w *Window) ShowBackups() {
+	fyne.Do(w.showBackups)
+}
+
+// showBackups opens a dialog listing snapshots, newest first, with an option
+// to restore each and to take a new one
+func (w *Window) showBackups() {
+	source, ok := w.store.(backupSource)
+	if !ok {
+		w.showStatus("Backups are not supported by this storage", true)
+		return
+	}
+
+	backups, err := source.ListBackups(guiContext())
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		w.showStatus("Backups are not supported by this storage", true)
+		return
+	}
+	if err != nil {
+		w.log.Error("Failed to list backups", "error", err)
+		w.showStatus("Failed to list backups", true)
+		return
+	}
+
+	var list dialog.Dialog
+	items := container.NewVBox()
+	if len(backups) == 0 {
+		items.Add(widget.NewLabel("No backups yet."))
+	}
+	for _, backup := range backups {
+		label := widget.NewLabel(backup.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+		size := widget.NewLabel(formatSize(backup.Size))
+
+		restore := widget.NewButtonWithIcon("Restore", theme.ContentUndoIcon(), func() {
+			list.Hide()
+			w.confirmRestoreBackup(source, backup)
+		})
+
+		items.Add(container.NewBorder(nil, nil, nil,
+			container.NewHBox(size, layout.NewSpacer(), restore),
+			label,
+		))
+	}
+
+	backupNow := widget.NewButtonWithIcon("Back Up Now", theme.DocumentSaveIcon(), func() {
+		list.Hide()
+		w.backupNow(source)
+	})
+
+	list = dialog.NewCustom("Backups", "Close",
+		container.NewBorder(nil, backupNow, nil, nil, container.NewVScroll(items)),
+		w.window,
+	)
+	list.Resize(fyne.NewSize(500, 400))
+	list.Show()
+}
+
+// backupNow takes a snapshot and reopens the backups dialog
+func (w *Window) backupNow(source backupSource) {
+	backup, err := source.Backup(guiContext())
+	if err != nil {
+		w.log.Error("Failed to back up", "error", err)
+		w.showStatus("Failed to back up", true)
+		return
+	}
+
+	w.showStatus(fmt.Sprintf("Saved backup %s", backup.Name), false)
+	w.showBackups()
+}
+
+// confirmRestoreBackup asks before replacing every note with a snapshot's contents
+func (w *Window) confirmRestoreBackup(source backupSource, backup model.Backup) {
+	message := fmt.Sprintf("Replace all notes with the backup from %s?\n\nYour current notes are backed up first.",
+		backup.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	dialog.ShowConfirm("Restore Backup", message, func(confirmed bool) {
+		if confirmed {
+			w.restoreBackup(source, backup)
+		}
+	}, w.window)
+}
+
+// restoreBackup swaps in a snapshot and reloads the list
+func (w *Window) restoreBackup(source backupSource, backup model.Backup) {
+	if err := source.RestoreBackup(guiContext(), backup.Name); err != nil {
+		w.log.Error("Failed to restore backup", "backup", backup.Name, "error", err)
+		dialog.ShowError(fmt.Errorf("backup could not be restored: %w", err), w.window)
+		return
+	}
+
+	w.checkHealth()
+	w.loadNotes()
+	w.showStatus(fmt.Sprintf("Restored backup %s", backup.Name), false)
+}
+
+// formatSize renders a byte count for display
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,19 @@
+package mainwindow
+
+import "testing"
+
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+	}
+	for size, want := range tests {
+		if got := formatSize(size); got != want {
+			t.Errorf("formatSize(%d)=%q want %q", size, got, want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// healthSource is implemented by note stores that check their own integrity
+type healthSource interface {
+	Health(ctx context.Context) (*model.StorageHealth, error)
+}
+
+// createHealthBanner creates the banner shown while the database is damaged
+func (w *Window) createHealthBanner() {
+	message := widget.NewLabelWithStyle(
+		"The note database is damaged and has been opened read-only.",
+		fyne.TextAlignLeading, fyne.TextStyle{Bold: true},
+	)
+	message.Wrapping = fyne.TextWrapWord
+	restore := widget.NewButtonWithIcon("Restore Latest Backup", theme.WarningIcon(), w.confirmRestoreLatest)
+
+	w.healthBanner = container.NewBorder(nil, widget.NewSeparator(), nil, restore, message)
+	w.healthBanner.Hide()
+}
+
+// checkHealth shows the banner when the store reports corruption and hides it otherwise
+func (w *Window) checkHealth() {
+	source, ok := w.store.(healthSource)
+	if !ok {
+		return
+	}
+	health, err := source.Health(guiContext())
+	if err != nil {
+		// Backends that cannot check themselves never show the banner
+		return
+	}
+
+	fyne.Do(func() {
+		if health.Healthy() {
+			w.healthBanner.Hide()
+		} else {
+			w.healthBanner.Show()
+		}
+	})
+}
+
+// confirmRestoreLatest asks before restoring the newest snapshot that passes its integrity check
+func (w *Window) confirmRestoreLatest() {
+	source, ok := w.store.(backupSource)
+	if !ok {
+		dialog.ShowInformation("Restore", "This storage has no backups to restore.", w.window)
+		return
+	}
+	dialog.ShowConfirm("Restore Latest Backup",
+		"Replace the damaged notes with the newest backup that passes an integrity check?\n\n"+
+			"A copy of the damaged database is kept alongside the backups.",
+		func(confirmed bool) {
+			if confirmed {
+				w.restoreLatest(source)
+			}
+		}, w.window)
+}
+
+// restoreLatest restores the newest good snapshot, skipping damaged ones
+func (w *Window) restoreLatest(source backupSource) {
+	ctx := guiContext()
+	backups, err := source.ListBackups(ctx)
+	if err != nil {
+		w.log.Error("Failed to list backups", "error", err)
+		dialog.ShowError(fmt.Errorf("failed to list backups: %w", err), w.window)
+		return
+	}
+
+	for _, backup := range backups {
+		err = source.RestoreBackup(ctx, backup.Name)
+		if errors.Is(err, model.ErrBackupCorrupt) {
+			w.log.Warn("Skipping damaged backup", "backup", backup.Name, "error", err)
+			continue
+		}
+		if err != nil {
+			w.log.Error("Failed to restore backup", "backup", backup.Name, "error", err)
+			dialog.ShowError(fmt.Errorf("backup could not be restored: %w", err), w.window)
+			return
+		}
+
+		w.checkHealth()
+		w.loadNotes()
+		w.showStatus(fmt.Sprintf("Restored backup %s", backup.Name), false)
+		return
+	}
+
+	dialog.ShowInformation("Restore", "No usable backup was found.", w.window)
+}
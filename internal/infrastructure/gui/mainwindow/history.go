@@ -0,0 +1,202 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// revisionSource is implemented by note stores that keep revision history
+type revisionSource interface {
+	ListRevisions(ctx context.Context, noteID string) ([]model.NoteRevision, error)
+}
+
+// showHistory opens a dialog listing the revisions of a note, each shown as a
+// diff against the state that replaced it
+func (w *Window) showHistory(id int) {
+	if id >= len(w.notes) {
+		return
+	}
+	note := w.notes[id]
+
+	source, ok := w.store.(revisionSource)
+	if !ok {
+		w.showStatus("History is not supported by this storage", true)
+		return
+	}
+
+	revisions, err := source.ListRevisions(guiContext(), note.ID)
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		w.showStatus("History is not supported by this storage", true)
+		return
+	}
+	if err != nil {
+		w.log.Error("Failed to load note history", "note_id", note.ID, "error", err)
+		w.showStatus("Failed to load note history", true)
+		return
+	}
+
+	if len(revisions) == 0 {
+		dialog.ShowInformation("History", "This note has no earlier revisions.", w.window)
+		return
+	}
+
+	var history dialog.Dialog
+	items := container.NewVBox()
+	next := note.Content
+	nextDone := note.Done
+	for _, rev := range revisions {
+		items.Add(w.revisionItem(note, rev, next, nextDone, func() { history.Hide() }))
+		items.Add(widget.NewSeparator())
+		next, nextDone = rev.Content, rev.Done
+	}
+
+	history = dialog.NewCustom("History", "Close", container.NewVScroll(items), w.window)
+	history.Resize(fyne.NewSize(500, 400))
+	history.Show()
+}
+
+// revisionItem renders one revision with a diff towards the state that
+// followed it and a button to restore it
+func (w *Window) revisionItem(note model.Note, rev model.NoteRevision, next string, nextDone bool, closeDialog func()) fyne.CanvasObject {
+	header := fmt.Sprintf("Revision %d · %s · %s", rev.Revision, rev.RecordedAt.Local().Format("2006-01-02 15:04"), rev.Actor)
+	if rev.Done != nextDone {
+		header += fmt.Sprintf(" · done: %t → %t", rev.Done, nextDone)
+	}
+
+	diff := widget.NewRichText(diffSegments(diffWords(rev.Content, next))...)
+	diff.Wrapping = fyne.TextWrapWord
+
+	restore := widget.NewButtonWithIcon("Restore", theme.HistoryIcon(), func() {
+		closeDialog()
+		w.restoreRevision(note, rev)
+	})
+
+	return container.NewBorder(
+		container.NewHBox(widget.NewLabelWithStyle(header, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
+		nil, nil, restore,
+		diff,
+	)
+}
+
+// restoreRevision writes a revision's content and done state back to the note
+func (w *Window) restoreRevision(note model.Note, rev model.NoteRevision) {
+	note.Content = rev.Content
+	note.Done = rev.Done
+	note.UpdatedAt = time.Now()
+
+	if err := w.store.Update(guiContext(), &note); err != nil {
+		w.log.Error("Failed to restore revision", "note_id", note.ID, "revision", rev.Revision, "error", err)
+		w.showStatus("Failed to restore revision", true)
+		return
+	}
+
+	w.loadNotes()
+	w.showStatus(fmt.Sprintf("Restored revision %d", rev.Revision), false)
+}
+
+// diffKind classifies a run of words in a diff
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffRemoved
+	diffAdded
+)
+
+// diffChunk is a run of words sharing the same diffKind
+type diffChunk struct {
+	kind diffKind
+	text string
+}
+
+// diffWords computes a word-level diff from old to updated using the longest
+// common subsequence. Consecutive words of the same kind are merged.
+func diffWords(old, updated string) []diffChunk {
+	a := strings.Fields(old)
+	b := strings.Fields(updated)
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var chunks []diffChunk
+	emit := func(kind diffKind, word string) {
+		if n := len(chunks); n > 0 && chunks[n-1].kind == kind {
+			chunks[n-1].text += " " + word
+			return
+		}
+		chunks = append(chunks, diffChunk{kind: kind, text: word})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			emit(diffEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			emit(diffRemoved, a[i])
+			i++
+		default:
+			emit(diffAdded, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		emit(diffRemoved, a[i])
+	}
+	for ; j < len(b); j++ {
+		emit(diffAdded, b[j])
+	}
+	return chunks
+}
+
+// diffSegments renders diff chunks as rich text, colouring removed words as
+// errors and added words as successes
+func diffSegments(chunks []diffChunk) []widget.RichTextSegment {
+	segments := make([]widget.RichTextSegment, 0, len(chunks))
+	for i, chunk := range chunks {
+		text := chunk.text
+		if i < len(chunks)-1 {
+			text += " "
+		}
+		style := widget.RichTextStyleInline
+		switch chunk.kind {
+		case diffRemoved:
+			style.ColorName = theme.ColorNameError
+			style.TextStyle = fyne.TextStyle{Italic: true}
+		case diffAdded:
+			style.ColorName = theme.ColorNameSuccess
+			style.TextStyle = fyne.TextStyle{Bold: true}
+		}
+		segments = append(segments, &widget.TextSegment{Text: text, Style: style})
+	}
+	if len(segments) == 0 {
+		segments = append(segments, &widget.TextSegment{Text: "(empty)", Style: widget.RichTextStyleInline})
+	}
+	return segments
+}
@@ -0,0 +1,54 @@
+package mainwindow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		old     string
+		updated string
+		want    []diffChunk
+	}{
+		{
+			name:    "unchanged",
+			old:     "buy milk",
+			updated: "buy milk",
+			want:    []diffChunk{{diffEqual, "buy milk"}},
+		},
+		{
+			name:    "replaced word",
+			old:     "buy milk today",
+			updated: "buy bread today",
+			want: []diffChunk{
+				{diffEqual, "buy"},
+				{diffRemoved, "milk"},
+				{diffAdded, "bread"},
+				{diffEqual, "today"},
+			},
+		},
+		{
+			name:    "appended",
+			old:     "call",
+			updated: "call mum tonight",
+			want:    []diffChunk{{diffEqual, "call"}, {diffAdded, "mum tonight"}},
+		},
+		{
+			name:    "from empty",
+			old:     "",
+			updated: "new",
+			want:    []diffChunk{{diffAdded, "new"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := diffWords(tt.old, tt.updated); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("diffWords(%q, %q) = %+v, want %+v", tt.old, tt.updated, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package mainwindow
+
+import (
+	"context"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// liveRefreshDelay gathers a burst of changes, such as a sync pass, into
+// one reload
+const liveRefreshDelay = 200 * time.Millisecond
+
+// changeSource is implemented by note stores that report changes as they
+// are committed
+type changeSource interface {
+	Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent
+}
+
+// watchChanges reloads the notes whenever the store reports a change, so
+// writes from the quick note, the API or a sync pass show up without
+// pressing Refresh. It replaces the subscription to the previous store.
+func (w *Window) watchChanges() {
+	if w.unwatch != nil {
+		w.unwatch()
+		w.unwatch = nil
+	}
+	source, ok := w.store.(changeSource)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.unwatch = cancel
+	// Dropping events is harmless here: any event means reload everything
+	events := source.Subscribe(ctx, model.NoteEventFilter{SlowConsumer: model.SlowConsumerDropOldest})
+	go func() {
+		for range events {
+			timer := time.NewTimer(liveRefreshDelay)
+		gather:
+			for {
+				select {
+				case _, open := <-events:
+					if !open {
+						break gather
+					}
+				case <-timer.C:
+					break gather
+				}
+			}
+			timer.Stop()
+			if ctx.Err() != nil {
+				return
+			}
+			fyne.Do(w.loadNotes)
+		}
+	}()
+}
@@ -3,7 +3,10 @@ package mainwindow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -15,7 +18,9 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
 	"github.com/jonesrussell/godo/internal/infrastructure/storage"
 )
@@ -31,6 +36,8 @@ type Interface interface {
 	CenterOnScreen()
 	GetWindow() fyne.Window
 	Refresh()
+	ShowBackups()
+	SetStore(store storage.NoteStore)
 }
 
 // Window represents the main application window
@@ -43,12 +50,40 @@ type Window struct {
 	cfg    config.WindowConfig
 
 	// UI components
-	noteList    *widget.List
-	addButton   *widget.Button
-	refreshBtn  *widget.Button
-	searchEntry *widget.Entry
-	toolbar     *fyne.Container
-	statusBar   *widget.Label
+	noteList     *widget.List
+	addButton    *widget.Button
+	refreshBtn   *widget.Button
+	trashBtn     *widget.Button
+	backupsBtn   *widget.Button
+	scopeSelect  *widget.Select
+	searchEntry  *widget.Entry
+	toolbar      *fyne.Container
+	healthBanner *fyne.Container
+	statusBar    *widget.Label
+
+	// problemsBanner lists note files the store could not read cleanly
+	problemsBanner *fyne.Container
+	problemsLabel  *widget.Label
+	problems       []model.StorageProblem
+
+	// outboxBanner counts writes waiting for an unreachable server
+	outboxBanner *fyne.Container
+	outboxLabel  *widget.Label
+
+	// archiveScope is the archive scope picked in scopeSelect
+	archiveScope model.ArchiveScope
+
+	// tagSidebar lists the tags in use; tagFilter is the one picked there
+	tagSidebar *fyne.Container
+	tagList    *widget.List
+	tags       []model.TagCount
+	tagFilter  string
+
+	// selectedID is the note selected in the list; dropped files attach to it
+	selectedID string
+
+	// unwatch ends the subscription to the store's changes
+	unwatch context.CancelFunc
 }
 
 // New creates a new main window
@@ -64,6 +99,8 @@ func New(app fyne.App, store storage.NoteStore, log logger.Logger, cfg config.Wi
 
 	w.setupUI()
 	w.loadNotes()
+	w.checkHealth()
+	w.watchChanges()
 	return w
 }
 
@@ -96,7 +133,11 @@ func (w *Window) SetContent(content fyne.CanvasObject) {
 // setupUI initializes the user interface
 func (w *Window) setupUI() {
 	w.createNoteList()
+	w.createTagSidebar()
 	w.createToolbar()
+	w.createHealthBanner()
+	w.createProblemsBanner()
+	w.createOutboxBanner()
 	w.createStatusBar()
 	w.createMainLayout()
 }
@@ -109,8 +150,12 @@ func (w *Window) createNoteList() {
 			return container.NewHBox(
 				widget.NewCheck("", nil),
 				widget.NewLabel("Note content"),
+				container.NewHBox(),
 				layout.NewSpacer(),
 				widget.NewButton("Edit", nil),
+				widget.NewButton("History", nil),
+				widget.NewButton("Files", nil),
+				widget.NewButton("Archive", nil),
 				widget.NewButton("Delete", nil),
 			)
 		},
@@ -118,6 +163,14 @@ func (w *Window) createNoteList() {
 			w.updateNoteListItem(id, obj)
 		},
 	)
+	w.noteList.OnSelected = func(id widget.ListItemID) {
+		if id < len(w.notes) {
+			w.selectedID = w.notes[id].ID
+		}
+	}
+	w.noteList.OnUnselected = func(widget.ListItemID) {
+		w.selectedID = ""
+	}
 }
 
 // updateNoteListItem updates a note list item
@@ -146,15 +199,46 @@ func (w *Window) updateNoteListItem(id widget.ListItemID, obj fyne.CanvasObject)
 		label.SetText(note.Content)
 	}
 
+	// Update tag chips
+	if chips, okChips := box.Objects[2].(*fyne.Container); okChips {
+		w.updateTagChips(chips, note.Tags)
+	}
+
 	// Update edit button
-	if editBtn, okEdit := box.Objects[3].(*widget.Button); okEdit {
+	if editBtn, okEdit := box.Objects[4].(*widget.Button); okEdit {
 		editBtn.OnTapped = func() {
 			w.editNote(id)
 		}
 	}
 
+	// Update history button
+	if historyBtn, okHistory := box.Objects[5].(*widget.Button); okHistory {
+		historyBtn.OnTapped = func() {
+			w.showHistory(id)
+		}
+	}
+
+	// Update files button
+	if filesBtn, okFiles := box.Objects[6].(*widget.Button); okFiles {
+		filesBtn.OnTapped = func() {
+			w.showAttachments(id)
+		}
+	}
+
+	// Update archive button
+	if archiveBtn, okArchive := box.Objects[7].(*widget.Button); okArchive {
+		if note.ArchivedAt != nil {
+			archiveBtn.SetText("Unarchive")
+		} else {
+			archiveBtn.SetText("Archive")
+		}
+		archiveBtn.OnTapped = func() {
+			w.toggleArchived(id)
+		}
+	}
+
 	// Update delete button
-	if deleteBtn, okDelete := box.Objects[4].(*widget.Button); okDelete {
+	if deleteBtn, okDelete := box.Objects[8].(*widget.Button); okDelete {
 		deleteBtn.OnTapped = func() {
 			w.deleteNote(id)
 		}
@@ -169,6 +253,15 @@ func (w *Window) createToolbar() {
 	// Create refresh button
 	w.refreshBtn = widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), w.loadNotes)
 
+	// Create trash button
+	w.trashBtn = widget.NewButtonWithIcon("Trash", theme.DeleteIcon(), w.showTrash)
+
+	// Create backups button
+	w.backupsBtn = widget.NewButtonWithIcon("Backups", theme.DocumentSaveIcon(), w.showBackups)
+
+	// Create archive scope select
+	w.createScopeSelect()
+
 	// Create search entry
 	w.searchEntry = widget.NewEntry()
 	w.searchEntry.SetPlaceHolder("Search notes...")
@@ -178,7 +271,10 @@ func (w *Window) createToolbar() {
 	w.toolbar = container.NewHBox(
 		w.addButton,
 		w.refreshBtn,
+		w.trashBtn,
+		w.backupsBtn,
 		layout.NewSpacer(),
+		w.scopeSelect,
 		w.searchEntry,
 	)
 }
@@ -194,22 +290,30 @@ func (w *Window) createMainLayout() {
 	fyne.Do(func() {
 		// Create main container
 		content := container.NewBorder(
-			w.toolbar,
+			container.NewVBox(w.healthBanner, w.problemsBanner, w.outboxBanner, w.toolbar),
 			w.statusBar,
-			nil,
+			w.tagSidebar,
 			nil,
 			w.noteList,
 		)
 
 		w.window.SetContent(content)
-		w.window.Resize(fyne.NewSize(600, 400))
+		w.window.SetOnDropped(w.handleDrop)
+		w.window.Resize(fyne.NewSize(760, 400))
 	})
 }
 
-// loadNotes loads all notes from storage
+// loadNotes loads the notes in the selected archive scope and tag from
+// storage, keeping any active search applied
 func (w *Window) loadNotes() {
-	ctx := context.Background()
-	notes, err := w.store.List(ctx)
+	w.loadTags()
+	if w.searchEntry != nil && strings.TrimSpace(w.searchEntry.Text) != "" {
+		w.filterNotes(w.searchEntry.Text)
+		return
+	}
+
+	ctx := guiContext()
+	notes, err := w.listNotes(ctx)
 	if err != nil {
 		w.log.Error("Failed to load notes", "error", err)
 		w.showStatus("Failed to load notes", true)
@@ -218,6 +322,8 @@ func (w *Window) loadNotes() {
 
 	w.notes = notes
 	w.noteList.Refresh()
+	w.checkProblems()
+	w.checkOutbox()
 	w.showStatus(fmt.Sprintf("Loaded %d notes", len(notes)), false)
 	w.log.Info("Notes loaded", "count", len(notes))
 }
@@ -226,6 +332,10 @@ func (w *Window) loadNotes() {
 func (w *Window) addNote() {
 	content := widget.NewEntry()
 	content.SetPlaceHolder("Enter note content...")
+	tags := newTagsEntry(nil)
+	if w.tagFilter != "" {
+		tags.SetText(w.tagFilter)
+	}
 
 	form := dialog.NewForm(
 		"Add Note",
@@ -233,22 +343,33 @@ func (w *Window) addNote() {
 		"Cancel",
 		[]*widget.FormItem{
 			widget.NewFormItem("Note", content),
+			widget.NewFormItem("Tags", tags),
 		},
 		func(confirm bool) {
 			if !confirm || content.Text == "" {
 				return
 			}
+			noteTags, err := parseTagList(tags.Text)
+			if err != nil {
+				w.showStatus(err.Error(), true)
+				return
+			}
 
 			note := model.Note{
 				ID:        uuid.New().String(),
 				Content:   content.Text,
 				Done:      false,
+				Tags:      noteTags,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
 
-			ctx := context.Background()
-			if err := w.store.Add(ctx, &note); err != nil {
+			ctx := guiContext()
+			if err = w.store.Add(ctx, &note); err != nil {
+				if errors.Is(err, domainstorage.ErrNotSupported) {
+					w.showStatus(errNoTags, true)
+					return
+				}
 				w.log.Error("Failed to add note", "error", err)
 				w.showStatus("Failed to add note", true)
 				return
@@ -274,8 +395,13 @@ func (w *Window) toggleNote(id widget.ListItemID, done bool) {
 	note.Done = done
 	note.UpdatedAt = time.Now()
 
-	ctx := context.Background()
+	ctx := guiContext()
 	if err := w.store.Update(ctx, &note); err != nil {
+		if errors.Is(err, model.ErrVersionConflict) {
+			w.loadNotes()
+			w.showStatus("Note was changed elsewhere, list reloaded", true)
+			return
+		}
 		w.log.Error("Failed to update note", "note_id", note.ID, "error", err)
 		w.showStatus("Failed to update note", true)
 		return
@@ -295,6 +421,7 @@ func (w *Window) editNote(id widget.ListItemID) {
 	note := w.notes[id]
 	content := widget.NewEntry()
 	content.SetText(note.Content)
+	tags := newTagsEntry(note.Tags)
 
 	form := dialog.NewForm(
 		"Edit Note",
@@ -302,22 +429,47 @@ func (w *Window) editNote(id widget.ListItemID) {
 		"Cancel",
 		[]*widget.FormItem{
 			widget.NewFormItem("Note", content),
+			widget.NewFormItem("Tags", tags),
 		},
 		func(confirm bool) {
 			if !confirm || content.Text == "" {
 				return
 			}
+			noteTags, err := parseTagList(tags.Text)
+			if err != nil {
+				w.showStatus(err.Error(), true)
+				return
+			}
 
 			note.Content = content.Text
 			note.UpdatedAt = time.Now()
 
-			ctx := context.Background()
-			if err := w.store.Update(ctx, &note); err != nil {
+			ctx := guiContext()
+			if err = w.store.Update(ctx, &note); err != nil {
+				if errors.Is(err, model.ErrVersionConflict) {
+					w.resolveEditConflict(note.ID, content.Text)
+					return
+				}
 				w.log.Error("Failed to update note", "note_id", note.ID, "error", err)
 				w.showStatus("Failed to update note", true)
 				return
 			}
+			if err = w.saveTags(note, noteTags); err != nil {
+				if errors.Is(err, domainstorage.ErrNotSupported) {
+					w.showStatus(errNoTags, true)
+				} else {
+					w.log.Error("Failed to update note tags", "note_id", note.ID, "error", err)
+					w.showStatus("Failed to update note tags", true)
+				}
+				w.loadNotes()
+				return
+			}
 
+			if !slices.Equal(note.Tags, noteTags) {
+				w.loadNotes()
+				w.showStatus("Note updated", false)
+				return
+			}
 			w.notes[id] = note
 			w.noteList.Refresh()
 			w.showStatus("Note updated", false)
@@ -329,6 +481,47 @@ func (w *Window) editNote(id widget.ListItemID) {
 	form.Show()
 }
 
+// resolveEditConflict warns that a note changed while it was being edited and
+// offers to overwrite the newer version with the edited content
+func (w *Window) resolveEditConflict(id, content string) {
+	ctx := guiContext()
+	current, err := w.store.GetByID(ctx, id)
+	if err != nil {
+		w.log.Error("Failed to reload changed note", "note_id", id, "error", err)
+		w.loadNotes()
+		w.showStatus("Note was changed or deleted elsewhere", true)
+		return
+	}
+
+	dialog.ShowConfirm(
+		"Note Changed",
+		fmt.Sprintf("This note was changed elsewhere while you were editing it. It now reads:\n\n%s\n\nOverwrite it with your version?", current.Content),
+		func(overwrite bool) {
+			if !overwrite {
+				w.loadNotes()
+				w.showStatus("Edit discarded", false)
+				return
+			}
+
+			current.Content = content
+			current.UpdatedAt = time.Now()
+			if updateErr := w.store.Update(ctx, &current); updateErr != nil {
+				if errors.Is(updateErr, model.ErrVersionConflict) {
+					w.resolveEditConflict(id, content)
+					return
+				}
+				w.log.Error("Failed to update note", "note_id", id, "error", updateErr)
+				w.showStatus("Failed to update note", true)
+				return
+			}
+
+			w.loadNotes()
+			w.showStatus("Note updated", false)
+		},
+		w.window,
+	)
+}
+
 // deleteNote deletes a note with confirmation
 func (w *Window) deleteNote(id widget.ListItemID) {
 	if id >= len(w.notes) {
@@ -339,13 +532,13 @@ func (w *Window) deleteNote(id widget.ListItemID) {
 
 	confirm := dialog.NewConfirm(
 		"Delete Note",
-		fmt.Sprintf("Are you sure you want to delete '%s'?", note.Content),
+		fmt.Sprintf("Move '%s' to the trash?", note.Content),
 		func(confirm bool) {
 			if !confirm {
 				return
 			}
 
-			ctx := context.Background()
+			ctx := guiContext()
 			if err := w.store.Delete(ctx, note.ID); err != nil {
 				w.log.Error("Failed to delete note", "note_id", note.ID, "error", err)
 				w.showStatus("Failed to delete note", true)
@@ -353,7 +546,7 @@ func (w *Window) deleteNote(id widget.ListItemID) {
 			}
 
 			w.loadNotes()
-			w.showStatus("Note deleted", false)
+			w.showStatus("Note moved to trash", false)
 		},
 		w.window,
 	)
@@ -361,13 +554,6 @@ func (w *Window) deleteNote(id widget.ListItemID) {
 	confirm.Show()
 }
 
-// filterNotes filters the note list based on search text
-func (w *Window) filterNotes(searchText string) {
-	// For now, just reload all notes
-	// In a real implementation, you might want to implement client-side filtering
-	w.loadNotes()
-}
-
 // showStatus shows a status message
 func (w *Window) showStatus(message string, isError bool) {
 	fyne.Do(func() {
@@ -390,6 +576,11 @@ func (w *Window) showStatus(message string, isError bool) {
 	}()
 }
 
+// guiContext returns a context that attributes mutations to the main window
+func guiContext() context.Context {
+	return audit.WithActor(context.Background(), audit.ActorGUI)
+}
+
 // CenterOnScreen centers the main window on the screen
 func (w *Window) CenterOnScreen() {
 	fyne.Do(func() {
@@ -397,6 +588,16 @@ func (w *Window) CenterOnScreen() {
 	})
 }
 
+// SetStore shows the notes of another store, as when the profile changes
+func (w *Window) SetStore(store storage.NoteStore) {
+	fyne.Do(func() {
+		w.store = store
+		w.loadNotes()
+		w.checkHealth()
+		w.watchChanges()
+	})
+}
+
 // Refresh refreshes the main window content
 func (w *Window) Refresh() {
 	fyne.Do(func() {
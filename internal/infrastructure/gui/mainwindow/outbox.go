@@ -0,0 +1,51 @@
+package mainwindow
+
+import (
+	"context"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// queueSource is implemented by note stores that queue writes while their
+// server is unreachable
+type queueSource interface {
+	QueueDepth(ctx context.Context) (int, error)
+}
+
+// createOutboxBanner creates the banner shown while writes wait for the server
+func (w *Window) createOutboxBanner() {
+	w.outboxLabel = widget.NewLabel("")
+	w.outboxLabel.Wrapping = fyne.TextWrapWord
+	refresh := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), w.loadNotes)
+
+	w.outboxBanner = container.NewBorder(nil, widget.NewSeparator(), nil, refresh, w.outboxLabel)
+	w.outboxBanner.Hide()
+}
+
+// checkOutbox shows the banner while the store holds unsent writes
+func (w *Window) checkOutbox() {
+	source, ok := w.store.(queueSource)
+	if !ok {
+		return
+	}
+	depth, err := source.QueueDepth(guiContext())
+	if err != nil {
+		// Backends that never queue writes never show the banner
+		return
+	}
+
+	fyne.Do(func() {
+		if depth == 0 {
+			w.outboxBanner.Hide()
+			return
+		}
+		w.outboxLabel.SetText(fmt.Sprintf(
+			"The server is unreachable. %d change(s) are saved on this computer and will be sent when it is back.",
+			depth))
+		w.outboxBanner.Show()
+	})
+}
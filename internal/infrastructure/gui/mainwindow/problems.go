@@ -0,0 +1,71 @@
+package mainwindow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// problemSource is implemented by note stores that can be edited outside Godo
+// and report entries they could not read cleanly
+type problemSource interface {
+	Problems(ctx context.Context) ([]model.StorageProblem, error)
+}
+
+// createProblemsBanner creates the banner shown while some notes could not be
+// read cleanly
+func (w *Window) createProblemsBanner() {
+	w.problemsLabel = widget.NewLabel("")
+	w.problemsLabel.Wrapping = fyne.TextWrapWord
+	details := widget.NewButtonWithIcon("Details", theme.WarningIcon(), w.showProblems)
+
+	w.problemsBanner = container.NewBorder(nil, widget.NewSeparator(), nil, details, w.problemsLabel)
+	w.problemsBanner.Hide()
+}
+
+// checkProblems shows the banner while the store reports unreadable entries
+func (w *Window) checkProblems() {
+	source, ok := w.store.(problemSource)
+	if !ok {
+		return
+	}
+	problems, err := source.Problems(guiContext())
+	if err != nil {
+		// Backends that only Godo writes to never show the banner
+		return
+	}
+
+	w.problems = problems
+	fyne.Do(func() {
+		if len(problems) == 0 {
+			w.problemsBanner.Hide()
+			return
+		}
+		w.problemsLabel.SetText(fmt.Sprintf(
+			"%d note file(s) could not be read cleanly and are shown as plain text. Saving a note repairs its file.",
+			len(problems)))
+		w.problemsBanner.Show()
+	})
+}
+
+// showProblems lists each entry the store could not read and why
+func (w *Window) showProblems() {
+	lines := make([]string, 0, len(w.problems))
+	for _, problem := range w.problems {
+		lines = append(lines, fmt.Sprintf("%s: %s", problem.Source, problem.Message))
+	}
+	text := widget.NewLabel(strings.Join(lines, "\n"))
+	text.Wrapping = fyne.TextWrapWord
+
+	scroll := container.NewVScroll(text)
+	scroll.SetMinSize(fyne.NewSize(480, 200))
+	dialog.ShowCustom("Unreadable Notes", "Close", scroll, w.window)
+}
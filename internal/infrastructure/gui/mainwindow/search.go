@@ -0,0 +1,73 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// searchLimit caps the number of notes shown for a search
+const searchLimit = 200
+
+// noteSearcher is implemented by note stores with a full-text index
+type noteSearcher interface {
+	Search(ctx context.Context, query string, limit int) ([]model.SearchHit, error)
+}
+
+// filterNotes narrows the note list to notes matching the search text and
+// carrying the selected tag, best matches first. Stores without a full-text
+// index fall back to a case-insensitive substring match.
+func (w *Window) filterNotes(searchText string) {
+	query := strings.TrimSpace(searchText)
+	if query == "" {
+		w.loadNotes()
+		return
+	}
+
+	notes, err := w.searchNotes(query)
+	if err != nil {
+		w.log.Error("Failed to search notes", "error", err)
+		w.showStatus("Failed to search notes", true)
+		return
+	}
+
+	w.notes = w.tagged(notes)
+	w.noteList.Refresh()
+	w.showStatus(fmt.Sprintf("Found %d notes", len(w.notes)), false)
+}
+
+// searchNotes runs query against the store's index, or filters in memory
+// when the store has none
+func (w *Window) searchNotes(query string) ([]model.Note, error) {
+	ctx := guiContext()
+	if searcher, ok := w.store.(noteSearcher); ok {
+		hits, err := searcher.Search(ctx, query, searchLimit)
+		if err == nil {
+			notes := make([]model.Note, len(hits))
+			for i, hit := range hits {
+				notes[i] = hit.Note
+			}
+			return notes, nil
+		}
+		if !errors.Is(err, domainstorage.ErrNotSupported) {
+			return nil, err
+		}
+	}
+
+	all, err := w.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	needle := strings.ToLower(query)
+	notes := make([]model.Note, 0, len(all))
+	for _, note := range all {
+		if strings.Contains(strings.ToLower(note.Content), needle) {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
@@ -0,0 +1,223 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// tagSource is implemented by note stores that keep tags apart from content
+type tagSource interface {
+	SetNoteTags(ctx context.Context, id string, tags []string) error
+	ListTags(ctx context.Context) ([]model.TagCount, error)
+	RenameTag(ctx context.Context, from, to string) (int, error)
+}
+
+// errNoTags is shown when the store cannot keep tags
+const errNoTags = "Tags are not supported by this storage"
+
+// createTagSidebar creates the sidebar listing every tag with its note
+// count. Picking a tag narrows the note list to notes carrying it; the first
+// entry shows every note again.
+func (w *Window) createTagSidebar() {
+	w.tagList = widget.NewList(
+		func() int { return len(w.tags) + 1 },
+		func() fyne.CanvasObject { return widget.NewLabel("Tag name (0)") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			label, ok := obj.(*widget.Label)
+			if !ok {
+				return
+			}
+			if id == 0 {
+				label.SetText("All notes")
+				return
+			}
+			if id <= len(w.tags) {
+				tag := w.tags[id-1]
+				label.SetText(fmt.Sprintf("#%s (%d)", tag.Name, tag.Count))
+			}
+		},
+	)
+	w.tagList.OnSelected = func(id widget.ListItemID) {
+		tag := ""
+		if id > 0 && id <= len(w.tags) {
+			tag = w.tags[id-1].Name
+		}
+		if tag != w.tagFilter {
+			w.tagFilter = tag
+			w.loadNotes()
+		}
+	}
+
+	rename := widget.NewButtonWithIcon("Rename", theme.DocumentCreateIcon(), w.renameTag)
+	w.tagSidebar = container.NewBorder(widget.NewLabel("Tags"), rename, nil, nil, w.tagList)
+	w.tagSidebar.Hide()
+}
+
+// loadTags refreshes the tag sidebar, hiding it when the store has no tags
+func (w *Window) loadTags() {
+	source, ok := w.store.(tagSource)
+	if !ok {
+		w.tags, w.tagFilter = nil, ""
+		w.tagSidebar.Hide()
+		return
+	}
+	tags, err := source.ListTags(guiContext())
+	if err != nil {
+		if !errors.Is(err, domainstorage.ErrNotSupported) {
+			w.log.Error("Failed to load tags", "error", err)
+		}
+		w.tags, w.tagFilter = nil, ""
+		w.tagSidebar.Hide()
+		return
+	}
+
+	// Keep the picked tag highlighted as tags come and go, and drop it once
+	// no note carries it
+	w.tags = tags
+	selected := slices.IndexFunc(tags, func(tag model.TagCount) bool { return tag.Name == w.tagFilter })
+	if selected < 0 {
+		w.tagFilter = ""
+	}
+	w.tagList.Refresh()
+	w.tagList.Select(selected + 1)
+	w.tagSidebar.Show()
+}
+
+// selectTag narrows the note list to notes carrying tag
+func (w *Window) selectTag(tag string) {
+	for i, t := range w.tags {
+		if t.Name == tag {
+			w.tagList.Select(i + 1)
+			return
+		}
+	}
+}
+
+// renameTag asks for a new name for the tag picked in the sidebar and renames
+// it on every note, merging it into an existing tag of that name
+func (w *Window) renameTag() {
+	from := w.tagFilter
+	source, ok := w.store.(tagSource)
+	if from == "" || !ok {
+		w.showStatus("Pick a tag to rename", true)
+		return
+	}
+
+	name := widget.NewEntry()
+	name.SetText(from)
+	dialog.ShowForm(
+		"Rename Tag",
+		"Rename",
+		"Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("New name", name),
+		},
+		func(confirm bool) {
+			to := model.NormalizeTag(name.Text)
+			if !confirm || to == from {
+				return
+			}
+			if err := model.CheckTag(to); err != nil {
+				w.showStatus(err.Error(), true)
+				return
+			}
+			if !slices.ContainsFunc(w.tags, func(tag model.TagCount) bool { return tag.Name == to }) {
+				w.applyRename(source, from, to)
+				return
+			}
+			dialog.ShowConfirm("Merge Tags",
+				fmt.Sprintf("#%s already exists. Merge #%s into it?", to, from),
+				func(merge bool) {
+					if merge {
+						w.applyRename(source, from, to)
+					}
+				},
+				w.window,
+			)
+		},
+		w.window,
+	)
+}
+
+// applyRename renames a tag and keeps the list filtered by its new name
+func (w *Window) applyRename(source tagSource, from, to string) {
+	renamed, err := source.RenameTag(guiContext(), from, to)
+	if err != nil {
+		w.log.Error("Failed to rename tag", "from", from, "to", to, "error", err)
+		w.showStatus("Failed to rename tag", true)
+		return
+	}
+
+	w.tagFilter = to
+	w.loadNotes()
+	w.showStatus(fmt.Sprintf("Moved %d notes to #%s", renamed, to), false)
+}
+
+// updateTagChips shows a note's tags as buttons that filter the list by tag
+func (w *Window) updateTagChips(chips *fyne.Container, tags []string) {
+	objects := make([]fyne.CanvasObject, len(tags))
+	for i, tag := range tags {
+		chip := widget.NewButton("#"+tag, func() {
+			w.selectTag(tag)
+		})
+		chip.Importance = widget.LowImportance
+		objects[i] = chip
+	}
+	chips.Objects = objects
+	chips.Refresh()
+}
+
+// newTagsEntry creates the entry for a note's tags in the add and edit forms
+func newTagsEntry(tags []string) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("work, errands")
+	entry.SetText(strings.Join(tags, ", "))
+	return entry
+}
+
+// parseTagList reads the tags typed into a tags entry, separated by commas
+// or spaces, with or without a leading "#"
+func parseTagList(text string) ([]string, error) {
+	tags := model.NormalizeTags(strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}))
+	if err := model.CheckTags(tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// saveTags writes a note's tags after its other fields were saved, when they
+// changed
+func (w *Window) saveTags(note model.Note, tags []string) error {
+	if slices.Equal(note.Tags, tags) {
+		return nil
+	}
+	source, ok := w.store.(tagSource)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	return source.SetNoteTags(guiContext(), note.ID, tags)
+}
+
+// tagged keeps the notes carrying the tag picked in the sidebar
+func (w *Window) tagged(notes []model.Note) []model.Note {
+	if w.tagFilter == "" {
+		return notes
+	}
+	return slices.DeleteFunc(notes, func(note model.Note) bool {
+		return !slices.Contains(note.Tags, w.tagFilter)
+	})
+}
@@ -0,0 +1,32 @@
+package mainwindow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTagList(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", text: "  ", want: nil},
+		{name: "commas and spaces", text: "work, #Errands  home,work", want: []string{"errands", "home", "work"}},
+		{name: "invalid name", text: "work, a/b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseTagList(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTagList(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseTagList(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package mainwindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// trashSource is implemented by note stores that soft-delete notes
+type trashSource interface {
+	ListTrash(ctx context.Context) ([]model.Note, error)
+	RestoreNote(ctx context.Context, id string) error
+}
+
+// showTrash opens a dialog listing deleted notes with an option to restore each
+func (w *Window) showTrash() {
+	source, ok := w.store.(trashSource)
+	if !ok {
+		w.showStatus("Trash is not supported by this storage", true)
+		return
+	}
+
+	notes, err := source.ListTrash(guiContext())
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		w.showStatus("Trash is not supported by this storage", true)
+		return
+	}
+	if err != nil {
+		w.log.Error("Failed to load trash", "error", err)
+		w.showStatus("Failed to load trash", true)
+		return
+	}
+
+	if len(notes) == 0 {
+		dialog.ShowInformation("Trash", "The trash is empty.", w.window)
+		return
+	}
+
+	var trash dialog.Dialog
+	items := container.NewVBox()
+	for _, note := range notes {
+		label := widget.NewLabel(note.Content)
+		label.Truncation = fyne.TextTruncateEllipsis
+
+		deleted := ""
+		if note.DeletedAt != nil {
+			deleted = note.DeletedAt.Local().Format("2006-01-02 15:04")
+		}
+
+		restore := widget.NewButtonWithIcon("Restore", theme.ContentUndoIcon(), func() {
+			trash.Hide()
+			w.restoreNote(source, note)
+		})
+
+		items.Add(container.NewBorder(nil, nil, nil,
+			container.NewHBox(widget.NewLabel(deleted), layout.NewSpacer(), restore),
+			label,
+		))
+	}
+
+	trash = dialog.NewCustom("Trash", "Close", container.NewVScroll(items), w.window)
+	trash.Resize(fyne.NewSize(500, 400))
+	trash.Show()
+}
+
+// restoreNote moves a note out of the trash and reloads the list
+func (w *Window) restoreNote(source trashSource, note model.Note) {
+	if err := source.RestoreNote(guiContext(), note.ID); err != nil {
+		w.log.Error("Failed to restore note", "note_id", note.ID, "error", err)
+		w.showStatus("Failed to restore note", true)
+		return
+	}
+
+	w.loadNotes()
+	w.showStatus(fmt.Sprintf("Restored '%s'", note.Content), false)
+}
@@ -3,6 +3,7 @@ package quicknote
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -13,7 +14,9 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/jonesrussell/godo/internal/config"
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
 	"github.com/jonesrussell/godo/internal/infrastructure/storage"
 )
@@ -28,6 +31,8 @@ type Interface interface {
 	Show()
 	// Hide hides the quick note window
 	Hide()
+	// SetStore saves further notes to another store
+	SetStore(store storage.NoteStore)
 }
 
 // Window represents a quick note window for rapid note entry
@@ -100,6 +105,13 @@ func (w *Window) Hide() {
 	})
 }
 
+// SetStore saves further notes to store, as when the profile changes
+func (w *Window) SetStore(store storage.NoteStore) {
+	fyne.Do(func() {
+		w.store = store
+	})
+}
+
 // setupUI initializes the user interface
 func (w *Window) setupUI() {
 	w.log.Debug("Setting up quick note UI")
@@ -150,27 +162,39 @@ func (w *Window) setupKeyboardShortcuts() {
 	w.entry.SetOnEscape(w.Hide)
 }
 
-// addNote adds a new note from the entry field
+// addNote adds a new note from the entry field. "#tag" words become the
+// note's tags; a note of nothing but tags keeps them as its text, and so do
+// notes saved to storage that cannot keep tags.
 func (w *Window) addNote() {
-	content := w.entry.Text
-	if content == "" {
+	text := w.entry.Text
+	if text == "" {
 		return
 	}
 
 	// Create new note
+	content, tags := model.ParseTags(text)
+	if content == "" {
+		content, tags = text, nil
+	}
 	note := model.Note{
 		ID:        uuid.New().String(),
 		Content:   content,
 		Done:      false,
+		Tags:      tags,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	// Save note to store
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(audit.WithActor(context.Background(), audit.ActorHotkey), 5*time.Second)
 	defer cancel()
 
-	if err := w.store.Add(ctx, &note); err != nil {
+	err := w.store.Add(ctx, &note)
+	if errors.Is(err, domainstorage.ErrNotSupported) && len(tags) > 0 {
+		note.Content, note.Tags = text, nil
+		err = w.store.Add(ctx, &note)
+	}
+	if err != nil {
 		w.log.Error("Failed to create note", "error", err)
 		w.showStatus("Failed to create note", true)
 		return
@@ -179,7 +203,7 @@ func (w *Window) addNote() {
 	// Clear entry and show success message
 	w.entry.SetText("")
 	w.showStatus("Note added successfully", false)
-	w.log.Debug("Note added successfully", "content", content)
+	w.log.Debug("Note added successfully", "content", note.Content, "tags", note.Tags)
 }
 
 // clearEntry clears the entry field
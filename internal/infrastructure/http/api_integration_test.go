@@ -1,22 +1,29 @@
 package http_test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 
+	"github.com/jonesrussell/godo/internal/domain/model"
 	"github.com/jonesrussell/godo/internal/domain/repository"
 	"github.com/jonesrussell/godo/internal/domain/service"
 	"github.com/jonesrussell/godo/internal/domain/testfixtures"
 	"github.com/jonesrussell/godo/internal/infrastructure/api"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/changefeed"
 	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
 )
 
@@ -150,3 +157,893 @@ func TestAPI_CreateNote_InvalidJSON(t *testing.T) {
 		t.Fatalf("expected 400 got %d", resp.StatusCode)
 	}
 }
+
+func TestAPI_AuditTrail_AttributesJWTUser(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/notes", strings.NewReader(`{"content":"audited"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var created api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	req2, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/audit?note_id="+created.ID, http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req2.Header.Set("Authorization", "Bearer "+token)
+	resp2, err := ts.Client().Do(req2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp2.Body.Close()
+	if resp2.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp2.Body)
+		t.Fatalf("audit status=%d body=%s", resp2.StatusCode, b)
+	}
+	var list api.AuditListResponse
+	if err := json.NewDecoder(resp2.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(list.Events))
+	}
+	ev := list.Events[0]
+	if ev.Actor != "test-user" || ev.Operation != "create" || ev.After == nil || ev.After.Content != "audited" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	req3, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/audit?since=yesterday", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req3.Header.Set("Authorization", "Bearer "+token)
+	resp3, err := ts.Client().Do(req3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp3.Body.Close()
+	if resp3.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad since, got %d", resp3.StatusCode)
+	}
+}
+
+func apiRequest(t *testing.T, ts *httptest.Server, token, method, path, body string) *http.Response {
+	t.Helper()
+	var reader io.Reader = http.NoBody
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestAPI_RevisionHistoryAndRestore(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"draft"}`)
+	var created api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPatch, "/api/v1/notes/"+created.ID, `{"content":"final"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("patch status=%d", resp.StatusCode)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes/"+created.ID+"/revisions", "")
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("revisions status=%d body=%s", resp.StatusCode, b)
+	}
+	var history api.RevisionListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Revisions) != 1 || history.Revisions[0].Content != "draft" || history.Revisions[0].Actor != "test-user" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/"+created.ID+"/revisions/1/restore", "")
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("restore status=%d body=%s", resp.StatusCode, b)
+	}
+	var restored api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Content != "draft" {
+		t.Fatalf("restored content=%q", restored.Content)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/"+created.ID+"/revisions/42/restore", "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown revision, got %d", resp.StatusCode)
+	}
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/"+created.ID+"/revisions/abc/restore", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad revision, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPI_TrashAndRestore(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"oops"}`)
+	var created api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodDelete, "/api/v1/notes/"+created.ID, "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status=%d", resp.StatusCode)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/trash", "")
+	var trash api.NoteListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&trash); err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Notes) != 1 || trash.Notes[0].ID != created.ID || trash.Notes[0].DeletedAt == nil {
+		t.Fatalf("unexpected trash: %+v", trash)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/"+created.ID+"/restore", "")
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("restore status=%d body=%s", resp.StatusCode, b)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes", "")
+	var list api.NoteListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Notes) != 1 || list.Notes[0].DeletedAt != nil {
+		t.Fatalf("expected restored note in list, got %+v", list)
+	}
+}
+
+func TestAPI_Search(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	for _, content := range []string{"water the plants", "plan the trip", "groceries"} {
+		resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"`+content+`"}`)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create status=%d", resp.StatusCode)
+		}
+	}
+
+	resp := apiRequest(t, ts, token, http.MethodGet, "/api/v1/search?q=plan", "")
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("search status=%d body=%s", resp.StatusCode, b)
+	}
+	var result api.SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) != 2 || result.Query != "plan" {
+		t.Fatalf("unexpected search result: %+v", result)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/search", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without q, got %d", resp.StatusCode)
+	}
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/search?q=plan&limit=zero", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad limit, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPI_ListNotes_QueryParameters(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	for _, content := range []string{"alpha", "beta", "gamma"} {
+		resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"`+content+`"}`)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create status=%d", resp.StatusCode)
+		}
+	}
+
+	resp := apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes?sort=created_at&limit=2&offset=1", "")
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("list status=%d body=%s", resp.StatusCode, b)
+	}
+	var list api.NoteListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Notes) != 2 || list.Notes[0].Content != "beta" || list.Notes[1].Content != "gamma" {
+		t.Fatalf("unexpected page: %+v", list.Notes)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes?content=AMM&done=false", "")
+	list = api.NoteListResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Notes) != 1 || list.Notes[0].Content != "gamma" {
+		t.Fatalf("unexpected filter result: %+v", list.Notes)
+	}
+
+	for _, query := range []string{"sort=size", "done=maybe", "limit=-1", "created_after=today"} {
+		resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes?"+query, "")
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestAPI_ListNotes_CursorPagination(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	for i := range 5 {
+		resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", fmt.Sprintf(`{"content":"note %d"}`, i))
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create status=%d", resp.StatusCode)
+		}
+	}
+
+	seen := map[string]bool{}
+	path := "/api/v1/notes?limit=2"
+	for pages := 1; ; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		resp := apiRequest(t, ts, token, http.MethodGet, path, "")
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Fatalf("list status=%d body=%s", resp.StatusCode, b)
+		}
+		var page api.NoteListResponse
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range page.Notes {
+			if seen[n.ID] {
+				t.Fatalf("note %s returned twice", n.ID)
+			}
+			seen[n.ID] = true
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Fatalf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		path = "/api/v1/notes?limit=2&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 notes, saw %d", len(seen))
+	}
+
+	for _, query := range []string{"cursor=garbage", "cursor=eyJrIjoiYSIsImkiOiJiIn0&sort=updated_at"} {
+		resp := apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes?"+query, "")
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestAPI_IfMatchPreconditions(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"shared"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status=%d", resp.StatusCode)
+	}
+	var created api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("create ETag=%q", etag)
+	}
+
+	conditional := func(method, path, body, ifMatch string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	path := "/api/v1/notes/" + created.ID
+	resp = conditional(http.MethodPatch, path, `{"content":"first"}`, etag)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("matching PATCH status=%d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("ETag"); got != `"2"` {
+		t.Fatalf("PATCH ETag=%q", got)
+	}
+
+	// The second writer still holds the original ETag
+	resp = conditional(http.MethodPut, path, `{"content":"second","done":false}`, etag)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale PUT status=%d", resp.StatusCode)
+	}
+	var current api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&current); err != nil {
+		t.Fatal(err)
+	}
+	if current.Content != "first" || current.Version != 2 || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("412 should carry the current note, got %+v etag=%q", current, resp.Header.Get("ETag"))
+	}
+
+	resp = conditional(http.MethodDelete, path, "", etag)
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale DELETE status=%d", resp.StatusCode)
+	}
+	resp = conditional(http.MethodDelete, path, "", `"7", "2"`)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE with matching tag in list status=%d", resp.StatusCode)
+	}
+}
+
+func TestAPI_BulkUpdateIsAllOrNothing(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	var ids []string
+	for _, content := range []string{"a", "b"} {
+		resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"`+content+`"}`)
+		var note api.NoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&note); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, note.ID)
+	}
+
+	// One unknown ID aborts the whole batch
+	body := fmt.Sprintf(`{"ids":[%q,%q],"done":true}`, ids[0], "00000000-0000-0000-0000-000000000000")
+	resp := apiRequest(t, ts, token, http.MethodPatch, "/api/v1/notes", body)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("bulk update with unknown ID status=%d", resp.StatusCode)
+	}
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes/"+ids[0], "")
+	var first api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Done {
+		t.Fatal("failed bulk update must not change any note")
+	}
+
+	body = fmt.Sprintf(`{"ids":[%q,%q],"done":true}`, ids[0], ids[1])
+	resp = apiRequest(t, ts, token, http.MethodPatch, "/api/v1/notes", body)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("bulk update status=%d body=%s", resp.StatusCode, b)
+	}
+	var updated api.NoteListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Notes) != 2 || !updated.Notes[0].Done || !updated.Notes[1].Done {
+		t.Fatalf("unexpected bulk update result: %+v", updated.Notes)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPatch, "/api/v1/notes", `{"ids":["not-a-uuid"],"done":true}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bulk update with invalid ID status=%d", resp.StatusCode)
+	}
+}
+
+func TestAPI_ImportNotesKeepsIDsAndReportsEachNote(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	const id = "3d2c1b0a-9f8e-4d7c-8b6a-5f4e3d2c1b0a"
+	body := `{"policy":"newer-wins","notes":[
+		{"id":"` + id + `","content":"migrated","done":true,
+		 "created_at":"2024-01-02T03:04:05Z","updated_at":"2024-01-03T00:00:00Z"},
+		{"id":"not-a-uuid","content":"bad"}]}`
+	resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/import", body)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("import status=%d body=%s", resp.StatusCode, b)
+	}
+	var report api.ImportReportResponse
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 1 || report.Failed != 1 || len(report.Results) != 2 ||
+		report.Results[0].Outcome != "created" || report.Results[1].Error == "" {
+		t.Fatalf("unexpected import report: %+v", report)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes/"+id, "")
+	var note api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&note); err != nil {
+		t.Fatal(err)
+	}
+	if !note.Done || note.CreatedAt.Format(time.RFC3339) != "2024-01-02T03:04:05Z" {
+		t.Fatalf("imported note lost its state: %+v", note)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/import", `{"policy":"merge","notes":[{"id":"`+id+`"}]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown policy status=%d", resp.StatusCode)
+	}
+}
+
+func TestAPI_RetentionArchivesAndDeletesOldNotes(t *testing.T) {
+	t.Parallel()
+	log := logger.NewNoopLogger()
+	svc := service.NewNoteService(repository.NewNoteRepository(sqlite.NewUnifiedAdapter(testfixtures.NewTempSQLiteStore(t))), log)
+	const secret = "test-secret-for-ci"
+	ts := httptest.NewServer(api.NewServer(svc, log, secret))
+	t.Cleanup(ts.Close)
+	token := mintTestJWT(secret)
+	ctx := context.Background()
+
+	const oldID = "0b1c2d3e-4f5a-4b6c-8d7e-9f0a1b2c3d4e"
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	if _, err := svc.ImportNotes(ctx, []model.Note{
+		{ID: oldID, Content: "finished long ago", Done: true, CreatedAt: old, UpdatedAt: old},
+	}, model.ConflictSkip); err != nil {
+		t.Fatal(err)
+	}
+	recent, err := svc.CreateNote(ctx, "finished today")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := true
+	if _, err = svc.UpdateNote(ctx, recent.ID, service.NoteUpdateRequest{Done: &done}); err != nil {
+		t.Fatal(err)
+	}
+
+	listIDs := func(query string) []string {
+		t.Helper()
+		resp := apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes"+query, "")
+		var list api.NoteListResponse
+		if decodeErr := json.NewDecoder(resp.Body).Decode(&list); decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		ids := make([]string, len(list.Notes))
+		for i, note := range list.Notes {
+			ids[i] = note.ID
+		}
+		return ids
+	}
+
+	archive := []model.RetentionRule{{Action: model.RetentionArchive, After: 14 * 24 * time.Hour}}
+	report, err := svc.ApplyRetention(ctx, archive, true)
+	if err != nil || len(report.Archived) != 1 || report.Archived[0] != oldID {
+		t.Fatalf("dry run should report the old note only, got %+v, %v", report, err)
+	}
+	if ids := listIDs(""); len(ids) != 2 {
+		t.Fatalf("a dry run should change nothing, listed %v", ids)
+	}
+
+	if _, err = svc.ApplyRetention(ctx, archive, false); err != nil {
+		t.Fatal(err)
+	}
+	if ids := listIDs(""); len(ids) != 1 || ids[0] != recent.ID {
+		t.Fatalf("archived note should leave the default list, got %v", ids)
+	}
+	if ids := listIDs("?archived=true"); len(ids) != 1 || ids[0] != oldID {
+		t.Fatalf("archived=true should list the archived note, got %v", ids)
+	}
+	if ids := listIDs("?archived=all"); len(ids) != 2 {
+		t.Fatalf("archived=all should list both notes, got %v", ids)
+	}
+	if resp := apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes?archived=maybe", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("archived=maybe: expected 400, got %d", resp.StatusCode)
+	}
+
+	resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/"+recent.ID+"/archive", "")
+	var archived api.NoteResponse
+	if err = json.NewDecoder(resp.Body).Decode(&archived); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || archived.ArchivedAt == nil {
+		t.Fatalf("archive status=%d note=%+v", resp.StatusCode, archived)
+	}
+	if resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes/"+recent.ID+"/unarchive", ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("unarchive status=%d", resp.StatusCode)
+	}
+
+	report, err = svc.ApplyRetention(ctx, []model.RetentionRule{{Action: model.RetentionDelete, After: time.Nanosecond}}, false)
+	if err != nil || len(report.Deleted) != 1 || report.Deleted[0] != oldID {
+		t.Fatalf("delete rule should remove the archived note only, got %+v, %v", report, err)
+	}
+	trash, err := svc.ListTrash(ctx)
+	if err != nil || len(trash) != 0 {
+		t.Fatalf("deleted note should be purged, not left in the trash, got %v, %v", trash, err)
+	}
+}
+
+func TestAPI_TagsFilterCountAndRename(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	ids := make(map[string]string)
+	for content, tags := range map[string]string{
+		"quarterly report": `["Work","urgent"]`,
+		"code review":      `["#work"]`,
+		"buy milk":         `["home"]`,
+	} {
+		resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"`+content+`","tags":`+tags+`}`)
+		if resp.StatusCode != http.StatusCreated {
+			b, _ := io.ReadAll(resp.Body)
+			t.Fatalf("create status=%d body=%s", resp.StatusCode, b)
+		}
+		var note api.NoteResponse
+		if err := json.NewDecoder(resp.Body).Decode(&note); err != nil {
+			t.Fatal(err)
+		}
+		ids[content] = note.ID
+	}
+
+	resp := apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes?tag=work&exclude_tag=urgent", "")
+	var list api.NoteListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Notes) != 1 || list.Notes[0].Content != "code review" || len(list.Notes[0].Tags) != 1 {
+		t.Fatalf("unexpected tag filter result: %+v", list.Notes)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPatch, "/api/v1/notes/"+ids["buy milk"], `{"tags":["home","errands"]}`)
+	var patched api.NoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&patched); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || patched.Content != "buy milk" || strings.Join(patched.Tags, ",") != "errands,home" {
+		t.Fatalf("patch tags status=%d note=%+v", resp.StatusCode, patched)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/tags/urgent/rename", `{"name":"work"}`)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("rename status=%d body=%s", resp.StatusCode, b)
+	}
+	var renamed api.RenameTagResponse
+	if err := json.NewDecoder(resp.Body).Decode(&renamed); err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Notes != 1 || renamed.To != "work" {
+		t.Fatalf("unexpected rename result: %+v", renamed)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/tags", "")
+	var tags api.TagListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		t.Fatal(err)
+	}
+	want := []api.TagResponse{{Name: "errands", Count: 1}, {Name: "home", Count: 1}, {Name: "work", Count: 2}}
+	if fmt.Sprint(tags.Tags) != fmt.Sprint(want) {
+		t.Fatalf("tags after merge = %+v, want %+v", tags.Tags, want)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/tags/urgent/rename", `{"name":"later"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("renaming a tag no note carries: status=%d", resp.StatusCode)
+	}
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"x","tags":["a/b"]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid tag name: status=%d", resp.StatusCode)
+	}
+}
+
+// uploadAttachment posts content as the "file" part of a multipart form
+func uploadAttachment(t *testing.T, ts *httptest.Server, token, noteID, name, content string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = part.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err = form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/notes/"+noteID+"/attachments", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestAPI_AttachmentsUploadAndDownload(t *testing.T) {
+	t.Parallel()
+	log := logger.NewNoopLogger()
+	st := testfixtures.NewTempSQLiteStore(t)
+	st.SetAttachmentPolicy(sqlite.AttachmentPolicy{Dir: t.TempDir(), MaxSize: 64})
+	svc := service.NewNoteService(repository.NewNoteRepository(sqlite.NewUnifiedAdapter(st)), log)
+	const secret = "test-secret-for-ci"
+	ts := httptest.NewServer(api.NewServer(svc, log, secret))
+	t.Cleanup(ts.Close)
+	token := mintTestJWT(secret)
+
+	note, err := svc.CreateNote(context.Background(), "crash on startup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const logText = "panic: runtime error\n"
+	resp := uploadAttachment(t, ts, token, note.ID, "../crash.log", logText)
+	if resp.StatusCode != http.StatusCreated {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("upload status=%d body=%s", resp.StatusCode, b)
+	}
+	var uploaded api.AttachmentResponse
+	if err = json.NewDecoder(resp.Body).Decode(&uploaded); err != nil {
+		t.Fatal(err)
+	}
+	if uploaded.Name != "crash.log" || uploaded.NoteID != note.ID || uploaded.Size != int64(len(logText)) {
+		t.Fatalf("unexpected attachment: %+v", uploaded)
+	}
+	// multipart.CreateFormFile sends octet-stream, so the type comes from the name or content
+	if !strings.HasPrefix(uploaded.ContentType, "text/") {
+		t.Fatalf("content type should be worked out for an untyped upload, got %q", uploaded.ContentType)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/attachments/"+uploaded.ID, "")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != logText {
+		t.Fatalf("download status=%d body=%q", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("Content-Type"); got != uploaded.ContentType {
+		t.Fatalf("download Content-Type=%q, want %q", got, uploaded.ContentType)
+	}
+	if got := resp.Header.Get("Content-Disposition"); !strings.Contains(got, `filename=crash.log`) {
+		t.Fatalf("download Content-Disposition=%q", got)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/notes/"+note.ID+"/attachments", "")
+	var list api.AttachmentListResponse
+	if err = json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Attachments) != 1 || list.Attachments[0].ID != uploaded.ID {
+		t.Fatalf("unexpected attachment list: %+v", list)
+	}
+
+	if resp = uploadAttachment(t, ts, token, note.ID, "huge.bin", strings.Repeat("x", 65)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: expected 413, got %d", resp.StatusCode)
+	}
+	if resp = uploadAttachment(t, ts, token, "2f1e0d9c-8b7a-4c6d-9e5f-4a3b2c1d0e9f", "a.txt", "x"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("upload to a missing note: expected 404, got %d", resp.StatusCode)
+	}
+
+	if resp = apiRequest(t, ts, token, http.MethodDelete, "/api/v1/attachments/"+uploaded.ID, ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete status=%d", resp.StatusCode)
+	}
+	if resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/attachments/"+uploaded.ID, ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("deleted attachment: expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPI_AdminBackup(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"keep me safe"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status=%d", resp.StatusCode)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/admin/backup", "")
+	if resp.StatusCode != http.StatusCreated {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("backup status=%d body=%s", resp.StatusCode, b)
+	}
+	var backup api.BackupResponse
+	if err := json.NewDecoder(resp.Body).Decode(&backup); err != nil {
+		t.Fatal(err)
+	}
+	if backup.Name == "" || backup.Size == 0 {
+		t.Fatalf("unexpected backup: %+v", backup)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodGet, "/api/v1/admin/backups", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list status=%d", resp.StatusCode)
+	}
+	var list api.BackupListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Backups) != 1 || list.Backups[0].Name != backup.Name {
+		t.Fatalf("unexpected backup list: %+v", list)
+	}
+}
+
+func TestAPI_HealthReportsStorage(t *testing.T) {
+	t.Parallel()
+	srv, token := newTestAPIServer(t)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	resp, err := ts.Client().Get(ts.URL + "/api/v1/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("health status=%d", resp.StatusCode)
+	}
+	var health api.HealthResponse
+	if err = json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if health.Status != "healthy" || health.Storage == nil || health.Storage.Status != "ok" || health.Storage.ReadOnly {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+	if health.Outbox != nil {
+		t.Fatalf("SQLite never queues writes, got outbox %+v", health.Outbox)
+	}
+
+	resp = apiRequest(t, ts, token, http.MethodPost, "/api/v1/admin/integrity-check", "")
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("integrity check status=%d body=%s", resp.StatusCode, b)
+	}
+	var check api.IntegrityCheckResponse
+	if err = json.NewDecoder(resp.Body).Decode(&check); err != nil {
+		t.Fatal(err)
+	}
+	if check.Status != "ok" || check.Check != "integrity_check" || len(check.Problems) != 0 {
+		t.Fatalf("unexpected integrity check: %+v", check)
+	}
+}
+
+// readEvent reads one server-sent event, skipping heartbeats
+func readEvent(t *testing.T, r *bufio.Reader) (id, event string, data api.NoteEventResponse) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && id != "":
+			return id, event, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestAPI_EventStreamResumesFromLastEventID(t *testing.T) {
+	t.Parallel()
+	log := logger.NewNoopLogger()
+	store := changefeed.New(sqlite.NewUnifiedAdapter(testfixtures.NewTempSQLiteStore(t)), changefeed.NewFeed(8, 100))
+	svc := service.NewNoteService(repository.NewNoteRepository(store), log)
+	const secret = "test-secret-for-ci"
+	ts := httptest.NewServer(api.NewServer(svc, log, secret))
+	t.Cleanup(ts.Close)
+	token := mintTestJWT(secret)
+
+	subscribe := func(lastEventID string) (*bufio.Reader, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/v1/events?type=created", http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("events status=%d content-type=%q", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		return bufio.NewReader(resp.Body), cancel
+	}
+
+	stream, cancel := subscribe("")
+	if resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"first"}`); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status=%d", resp.StatusCode)
+	}
+	id, event, data := readEvent(t, stream)
+	if event != "created" || data.After == nil || data.After.Content != "first" || data.Actor != "test-user" {
+		t.Fatalf("unexpected event %s: %+v", event, data)
+	}
+	cancel()
+
+	if resp := apiRequest(t, ts, token, http.MethodPost, "/api/v1/notes", `{"content":"second"}`); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status=%d", resp.StatusCode)
+	}
+	stream, cancel = subscribe(id)
+	defer cancel()
+	_, event, data = readEvent(t, stream)
+	if event != "created" || data.After == nil || data.After.Content != "second" {
+		t.Fatalf("resumed with %s: %+v", event, data)
+	}
+
+	resp := apiRequest(t, ts, token, http.MethodGet, "/api/v1/events?type=renamed", "")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown type status=%d", resp.StatusCode)
+	}
+}
@@ -3,6 +3,8 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"github.com/jonesrussell/godo/internal/domain/model"
 	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
@@ -20,16 +22,28 @@ func NewNoteStoreAdapter(unifiedStore domainstorage.UnifiedNoteStorage) *NoteSto
 	}
 }
 
-// Add creates a new note
+// Add creates a new note. A note with tags fails with ErrNotSupported,
+// before anything is written, when the underlying storage cannot keep tags.
 func (a *NoteStoreAdapter) Add(ctx context.Context, note *model.Note) error {
+	if len(note.Tags) > 0 && !domainstorage.Supports[domainstorage.Tagger](a.store) {
+		return domainstorage.ErrNotSupported
+	}
 	createdNote, err := a.store.CreateNote(ctx, note.Content)
 	if err != nil {
 		return err
 	}
+	if len(note.Tags) > 0 {
+		tagger, _ := domainstorage.As[domainstorage.Tagger](a.store)
+		if createdNote, err = tagger.SetNoteTags(ctx, createdNote.ID, note.Tags); err != nil {
+			return err
+		}
+	}
 	// Copy the generated ID and timestamps back to the original note
 	note.ID = createdNote.ID
+	note.Tags = createdNote.Tags
 	note.CreatedAt = createdNote.CreatedAt
 	note.UpdatedAt = createdNote.UpdatedAt
+	note.Version = createdNote.Version
 	return nil
 }
 
@@ -42,14 +56,24 @@ func (a *NoteStoreAdapter) GetByID(ctx context.Context, id string) (model.Note,
 	return *note, nil
 }
 
-// Update modifies an existing note
+// Update modifies an existing note. A versioned note is only written over the
+// version it was read at; a stale one fails with model.ErrVersionConflict.
 func (a *NoteStoreAdapter) Update(ctx context.Context, note *model.Note) error {
-	updatedNote, err := a.store.UpdateNote(ctx, note.ID, note.Content, note.Done)
+	var updatedNote *model.Note
+	err := domainstorage.ErrNotSupported
+	if writer, ok := domainstorage.As[domainstorage.ConditionalWriter](a.store); ok && note.Version != 0 {
+		updatedNote, err = writer.UpdateNoteIfVersion(ctx, note.ID, note.Content, note.Done, note.Version)
+	}
+	// Also reached when a decorator offers conditional writes the backend lacks
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		updatedNote, err = a.store.UpdateNote(ctx, note.ID, note.Content, note.Done)
+	}
 	if err != nil {
 		return err
 	}
-	// Copy the updated timestamp back to the original note
+	// Copy the updated timestamp and version back to the original note
 	note.UpdatedAt = updatedNote.UpdatedAt
+	note.Version = updatedNote.Version
 	return nil
 }
 
@@ -73,6 +97,240 @@ func (a *NoteStoreAdapter) List(ctx context.Context) ([]model.Note, error) {
 	return result, nil
 }
 
+// QueryNotes returns the notes matching filter, filtering in memory when the
+// underlying storage cannot filter itself
+func (a *NoteStoreAdapter) QueryNotes(ctx context.Context, filter model.NoteFilter) ([]model.Note, error) {
+	var notes []*model.Note
+	var err error
+	if querier, ok := domainstorage.As[domainstorage.NoteQuerier](a.store); ok {
+		notes, err = querier.QueryNotes(ctx, filter)
+	} else if notes, err = a.store.GetAllNotes(ctx); err == nil {
+		notes = domainstorage.ApplyFilter(notes, filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Note, len(notes))
+	for i, note := range notes {
+		result[i] = *note
+	}
+
+	return result, nil
+}
+
+// ArchiveNote archives a note when the underlying storage supports archiving
+func (a *NoteStoreAdapter) ArchiveNote(ctx context.Context, id string) error {
+	archiver, ok := domainstorage.As[domainstorage.Archiver](a.store)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	_, err := archiver.ArchiveNote(ctx, id)
+	return err
+}
+
+// UnarchiveNote takes a note out of the archive
+func (a *NoteStoreAdapter) UnarchiveNote(ctx context.Context, id string) error {
+	archiver, ok := domainstorage.As[domainstorage.Archiver](a.store)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	_, err := archiver.UnarchiveNote(ctx, id)
+	return err
+}
+
+// SetNoteTags replaces the tags of a note when the underlying storage keeps tags
+func (a *NoteStoreAdapter) SetNoteTags(ctx context.Context, id string, tags []string) error {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](a.store)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	_, err := tagger.SetNoteTags(ctx, id, tags)
+	return err
+}
+
+// ListTags returns every tag in use with its note count, ordered by name
+func (a *NoteStoreAdapter) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return tagger.ListTags(ctx)
+}
+
+// RenameTag moves every note from one tag to another, merging the two when
+// the new name is already in use
+func (a *NoteStoreAdapter) RenameTag(ctx context.Context, from, to string) (int, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](a.store)
+	if !ok {
+		return 0, domainstorage.ErrNotSupported
+	}
+	return tagger.RenameTag(ctx, from, to)
+}
+
+// AddAttachment attaches content to a note when the underlying storage keeps attachments
+func (a *NoteStoreAdapter) AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error) {
+	attacher, ok := domainstorage.As[domainstorage.Attacher](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return attacher.AddAttachment(ctx, noteID, name, contentType, content)
+}
+
+// ListAttachments returns the attachments of a note, oldest first
+func (a *NoteStoreAdapter) ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error) {
+	attacher, ok := domainstorage.As[domainstorage.Attacher](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return attacher.ListAttachments(ctx, noteID)
+}
+
+// OpenAttachment returns an attachment and a reader over its content
+func (a *NoteStoreAdapter) OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error) {
+	attacher, ok := domainstorage.As[domainstorage.Attacher](a.store)
+	if !ok {
+		return nil, nil, domainstorage.ErrNotSupported
+	}
+	return attacher.OpenAttachment(ctx, id)
+}
+
+// DeleteAttachment removes an attachment
+func (a *NoteStoreAdapter) DeleteAttachment(ctx context.Context, id string) error {
+	attacher, ok := domainstorage.As[domainstorage.Attacher](a.store)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	return attacher.DeleteAttachment(ctx, id)
+}
+
+// ListRevisions returns the revision history of a note when the underlying
+// storage keeps one
+func (a *NoteStoreAdapter) ListRevisions(ctx context.Context, noteID string) ([]model.NoteRevision, error) {
+	revisions, ok := domainstorage.As[domainstorage.RevisionStore](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return revisions.ListRevisions(ctx, noteID)
+}
+
+// GetRevision returns a single revision of a note
+func (a *NoteStoreAdapter) GetRevision(ctx context.Context, noteID string, revision int) (*model.NoteRevision, error) {
+	revisions, ok := domainstorage.As[domainstorage.RevisionStore](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return revisions.GetRevision(ctx, noteID, revision)
+}
+
+// ListTrash returns the notes in the trash when the underlying storage
+// supports soft deletion
+func (a *NoteStoreAdapter) ListTrash(ctx context.Context) ([]model.Note, error) {
+	trash, ok := domainstorage.As[domainstorage.Trash](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	notes, err := trash.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Note, len(notes))
+	for i, note := range notes {
+		result[i] = *note
+	}
+
+	return result, nil
+}
+
+// RestoreNote moves a note out of the trash
+func (a *NoteStoreAdapter) RestoreNote(ctx context.Context, id string) error {
+	trash, ok := domainstorage.As[domainstorage.Trash](a.store)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	_, err := trash.RestoreNote(ctx, id)
+	return err
+}
+
+// Search runs a full-text search when the underlying storage has an index
+func (a *NoteStoreAdapter) Search(ctx context.Context, query string, limit int) ([]model.SearchHit, error) {
+	searcher, ok := domainstorage.As[domainstorage.Searcher](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return searcher.Search(ctx, query, limit)
+}
+
+// Backup writes a snapshot when the underlying storage supports backups
+func (a *NoteStoreAdapter) Backup(ctx context.Context) (*model.Backup, error) {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return backupper.Backup(ctx)
+}
+
+// ListBackups returns the available snapshots, newest first
+func (a *NoteStoreAdapter) ListBackups(ctx context.Context) ([]model.Backup, error) {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return backupper.ListBackups(ctx)
+}
+
+// RestoreBackup replaces the stored data with the named snapshot
+func (a *NoteStoreAdapter) RestoreBackup(ctx context.Context, name string) error {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](a.store)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	return backupper.RestoreBackup(ctx, name)
+}
+
+// Health returns the last integrity check result when the underlying storage
+// checks itself
+func (a *NoteStoreAdapter) Health(ctx context.Context) (*model.StorageHealth, error) {
+	checker, ok := domainstorage.As[domainstorage.HealthChecker](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return checker.Health(ctx)
+}
+
+// Problems lists entries the backend could not read cleanly. Backends whose
+// data is only written by Godo return ErrNotSupported.
+func (a *NoteStoreAdapter) Problems(ctx context.Context) ([]model.StorageProblem, error) {
+	reporter, ok := domainstorage.As[domainstorage.ProblemReporter](a.store)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return reporter.Problems(ctx)
+}
+
+// QueueDepth returns the number of writes waiting for an unreachable server.
+// Backends that never queue writes return ErrNotSupported.
+func (a *NoteStoreAdapter) QueueDepth(ctx context.Context) (int, error) {
+	queue, ok := domainstorage.As[domainstorage.WriteQueue](a.store)
+	if !ok {
+		return 0, domainstorage.ErrNotSupported
+	}
+	return queue.QueueDepth(ctx)
+}
+
+// Subscribe returns the storage's change events. Storage without a change
+// feed returns a closed channel.
+func (a *NoteStoreAdapter) Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent {
+	feed, ok := domainstorage.As[domainstorage.ChangeFeed](a.store)
+	if !ok {
+		ch := make(chan model.NoteEvent)
+		close(ch)
+		return ch
+	}
+	return feed.Subscribe(ctx, filter)
+}
+
 // Close closes the storage
 func (a *NoteStoreAdapter) Close() error {
 	return a.store.Close()
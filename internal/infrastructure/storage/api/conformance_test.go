@@ -0,0 +1,132 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/api"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/conformance"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/memory"
+)
+
+// newFakeServer serves the notes API the store speaks, keeping the notes in
+// a memory store
+func newFakeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	notes := memory.New()
+	mux := http.NewServeMux()
+
+	writeNote := func(w http.ResponseWriter, status int, note *model.Note, err error) {
+		switch {
+		case errors.Is(err, model.ErrNoteNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, model.ErrVersionConflict):
+			w.WriteHeader(http.StatusPreconditionFailed)
+		case err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(api.APIResponse{Data: toAPINote(note)})
+		}
+	}
+	ifMatch := func(r *http.Request) int64 {
+		version, _ := strconv.ParseInt(strings.Trim(r.Header.Get("If-Match"), `"`), 10, 64)
+		return version
+	}
+
+	mux.HandleFunc("GET /notes", func(w http.ResponseWriter, r *http.Request) {
+		all, err := notes.GetAllNotes(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp := api.APIListResponse{Data: make([]api.APINote, len(all))}
+		for i, note := range all {
+			resp.Data[i] = toAPINote(note)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("POST /notes", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Content string `json:"content"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		note, err := notes.CreateNote(r.Context(), body.Content)
+		writeNote(w, http.StatusCreated, note, err)
+	})
+	mux.HandleFunc("GET /notes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		note, err := notes.GetNote(r.Context(), r.PathValue("id"))
+		writeNote(w, http.StatusOK, note, err)
+	})
+	mux.HandleFunc("PATCH /notes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Content string `json:"content"`
+			Done    bool   `json:"done"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		note, err := notes.UpdateNoteIfVersion(r.Context(), r.PathValue("id"), body.Content, body.Done, ifMatch(r))
+		writeNote(w, http.StatusOK, note, err)
+	})
+	mux.HandleFunc("DELETE /notes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		err := notes.DeleteNoteIfVersion(r.Context(), r.PathValue("id"), ifMatch(r))
+		if err != nil {
+			writeNote(w, 0, nil, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	for tail, change := range map[string]func(context.Context, string) (*model.Note, error){
+		"toggle":      notes.ToggleDone,
+		"mark-done":   notes.MarkDone,
+		"mark-undone": notes.MarkUndone,
+	} {
+		mux.HandleFunc("PATCH /notes/{id}/"+tail, func(w http.ResponseWriter, r *http.Request) {
+			note, err := change(r.Context(), r.PathValue("id"))
+			writeNote(w, http.StatusOK, note, err)
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func toAPINote(note *model.Note) api.APINote {
+	return api.APINote{
+		ID:        note.ID,
+		Content:   note.Content,
+		Done:      note.Done,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+		Version:   note.Version,
+	}
+}
+
+func TestStore_Conformance(t *testing.T) {
+	t.Parallel()
+	conformance.Run(t, func(t *testing.T) domainstorage.UnifiedNoteStorage {
+		srv := newFakeServer(t)
+		store, err := api.New(domainstorage.APIConfig{BaseURL: srv.URL, Timeout: 5, RetryDelay: 1}, logger.NewNoopLogger())
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return store
+	})
+}
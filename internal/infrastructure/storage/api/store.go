@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/jonesrussell/godo/internal/domain/model"
@@ -23,6 +26,8 @@ type Store struct {
 	logger     logger.Logger
 	retryCount int
 	retryDelay time.Duration
+	// closed is set by Close; later calls fail with storageerrors.ErrStoreClosed
+	closed atomic.Bool
 }
 
 // New creates a new API store
@@ -134,9 +139,69 @@ func (s *Store) GetNote(ctx context.Context, id string) (*model.Note, error) {
 	return s.mapAPINoteToModel(&apiResp.Data), nil
 }
 
-// GetAllNotes retrieves all notes via API
+// GetAllNotes retrieves all notes via API. When the backend paginates its
+// response, the next_cursor of each page is followed until the last page.
 func (s *Store) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/notes", http.NoBody)
+	return s.listAllNotes(ctx, url.Values{"archived": {"all"}}, 0)
+}
+
+// NotesUpdatedSince retrieves the notes updated at or after since, oldest
+// update first, for incremental sync. Servers that ignore the updated_since
+// parameter return every note, which sync handles the same way, only slower.
+func (s *Store) NotesUpdatedSince(ctx context.Context, since time.Time) ([]*model.Note, error) {
+	params := url.Values{"sort": {string(model.SortUpdatedAsc)}, "archived": {"all"}}
+	if !since.IsZero() {
+		params.Set("updated_since", since.UTC().Format(time.RFC3339Nano))
+	}
+	return s.listAllNotes(ctx, params, 0)
+}
+
+// listAllNotes retrieves every note matching params, following next_cursor
+// until the last page or, when limit is positive, until limit notes are read
+func (s *Store) listAllNotes(ctx context.Context, params url.Values, limit int) ([]*model.Note, error) {
+	notes := make([]*model.Note, 0)
+	seen := map[string]bool{}
+	cursor := ""
+
+	for {
+		page, err := s.getNotesPage(ctx, params, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, apiNote := range page.Data {
+			notes = append(notes, s.mapAPINoteToModel(&apiNote))
+		}
+
+		if limit > 0 && len(notes) >= limit {
+			return notes[:limit], nil
+		}
+		if page.NextCursor == "" {
+			return notes, nil
+		}
+		if seen[page.NextCursor] {
+			return nil, fmt.Errorf("API returned a repeated page cursor")
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+}
+
+// getNotesPage fetches one page of the note listing matching params,
+// starting at cursor when set
+func (s *Store) getNotesPage(ctx context.Context, params url.Values, cursor string) (*APIListResponse, error) {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	endpoint := s.baseURL + "/notes"
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -157,17 +222,67 @@ func (s *Store) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
 	if decErr := json.NewDecoder(resp.Body).Decode(&apiResp); decErr != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", decErr)
 	}
+	return &apiResp, nil
+}
 
-	notes := make([]*model.Note, len(apiResp.Data))
-	for i, apiNote := range apiResp.Data {
-		notes[i] = s.mapAPINoteToModel(&apiNote)
+// QueryNotes retrieves the notes matching filter, passing the filter to the
+// API as query-string parameters. Servers that paginate are followed page by
+// page until the filter's limit, or the last page, is reached.
+func (s *Store) QueryNotes(ctx context.Context, filter model.NoteFilter) ([]*model.Note, error) {
+	limit := 0
+	if filter.Limit != nil {
+		limit = *filter.Limit
 	}
+	return s.listAllNotes(ctx, filterQuery(filter), limit)
+}
 
-	return notes, nil
+// filterQuery encodes a note filter as query-string parameters
+func filterQuery(filter model.NoteFilter) url.Values {
+	params := url.Values{}
+	if filter.Done != nil {
+		params.Set("done", strconv.FormatBool(*filter.Done))
+	}
+	if filter.Content != nil && *filter.Content != "" {
+		params.Set("content", *filter.Content)
+	}
+	if filter.CreatedAfter != nil {
+		params.Set("created_after", filter.CreatedAfter.Format(time.RFC3339Nano))
+	}
+	if filter.CreatedBefore != nil {
+		params.Set("created_before", filter.CreatedBefore.Format(time.RFC3339Nano))
+	}
+	if filter.Limit != nil && *filter.Limit > 0 {
+		params.Set("limit", strconv.Itoa(*filter.Limit))
+	}
+	if filter.Offset != nil && *filter.Offset > 0 {
+		params.Set("offset", strconv.Itoa(*filter.Offset))
+	}
+	if filter.Sort != "" {
+		params.Set("sort", string(filter.Sort))
+	}
+	switch filter.Archived {
+	case model.ArchiveOnly:
+		params.Set("archived", "true")
+	case model.ArchiveInclude:
+		params.Set("archived", "all")
+	}
+	for _, tag := range filter.Tags {
+		params.Add("tag", tag)
+	}
+	for _, tag := range filter.ExcludeTags {
+		params.Add("exclude_tag", tag)
+	}
+	return params
 }
 
 // UpdateNote updates a note via API
 func (s *Store) UpdateNote(ctx context.Context, id string, content string, done bool) (*model.Note, error) {
+	return s.UpdateNoteIfVersion(ctx, id, content, done, 0)
+}
+
+// UpdateNoteIfVersion updates a note via API, sending If-Match so the server
+// rejects the write if the note has moved past version. A zero version sends no precondition.
+func (s *Store) UpdateNoteIfVersion(ctx context.Context, id string, content string, done bool, version int64) (*model.Note, error) {
 	requestBody := map[string]any{
 		"content": content,
 		"done":    done,
@@ -185,6 +300,7 @@ func (s *Store) UpdateNote(ctx context.Context, id string, content string, done
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	setIfMatch(req, version)
 
 	resp, err := s.executeWithRetry(req)
 	if err != nil {
@@ -196,6 +312,10 @@ func (s *Store) UpdateNote(ctx context.Context, id string, content string, done
 		return nil, &storageerrors.NotFoundError{ID: id}
 	}
 
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil, fmt.Errorf("note %s: %w", id, model.ErrVersionConflict)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, s.handleAPIError(resp)
 	}
@@ -210,12 +330,19 @@ func (s *Store) UpdateNote(ctx context.Context, id string, content string, done
 
 // DeleteNote deletes a note via API
 func (s *Store) DeleteNote(ctx context.Context, id string) error {
+	return s.DeleteNoteIfVersion(ctx, id, 0)
+}
+
+// DeleteNoteIfVersion deletes a note via API, sending If-Match so the server
+// rejects the delete if the note has moved past version. A zero version sends no precondition.
+func (s *Store) DeleteNoteIfVersion(ctx context.Context, id string, version int64) error {
 	req, err := http.NewRequestWithContext(ctx, "DELETE", s.baseURL+"/notes/"+id, http.NoBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json")
+	setIfMatch(req, version)
 
 	resp, err := s.executeWithRetry(req)
 	if err != nil {
@@ -227,6 +354,10 @@ func (s *Store) DeleteNote(ctx context.Context, id string) error {
 		return &storageerrors.NotFoundError{ID: id}
 	}
 
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("note %s: %w", id, model.ErrVersionConflict)
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		return s.handleAPIError(resp)
 	}
@@ -234,6 +365,13 @@ func (s *Store) DeleteNote(ctx context.Context, id string) error {
 	return nil
 }
 
+// setIfMatch makes req conditional on the note still being at version
+func setIfMatch(req *http.Request, version int64) {
+	if version != 0 {
+		req.Header.Set("If-Match", `"`+strconv.FormatInt(version, 10)+`"`)
+	}
+}
+
 // patchNoteJSONNoBody issues PATCH {baseURL}/notes/{id}[/{tail}] with no body and decodes a single-note JSON envelope.
 func (s *Store) patchNoteJSONNoBody(ctx context.Context, id, tail string) (*model.Note, error) {
 	path := "/notes/" + id
@@ -281,14 +419,21 @@ func (s *Store) MarkUndone(ctx context.Context, id string) (*model.Note, error)
 	return s.patchNoteJSONNoBody(ctx, id, "mark-undone")
 }
 
-// Close closes the API store (no-op for HTTP client)
+// Close releases idle connections; later calls fail with
+// storageerrors.ErrStoreClosed and closing again does nothing
 func (s *Store) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
 	s.client.CloseIdleConnections()
 	return nil
 }
 
 // executeWithRetry executes HTTP request with retry logic
 func (s *Store) executeWithRetry(req *http.Request) (*http.Response, error) {
+	if s.closed.Load() {
+		return nil, storageerrors.ErrStoreClosed
+	}
 	var lastErr error
 
 	for attempt := 0; attempt <= s.retryCount; attempt++ {
@@ -346,11 +491,14 @@ func (s *Store) handleAPIError(resp *http.Response) error {
 // mapAPINoteToModel converts API note format to domain model
 func (s *Store) mapAPINoteToModel(apiNote *APINote) *model.Note {
 	return &model.Note{
-		ID:        apiNote.ID,
-		Content:   apiNote.Content,
-		Done:      apiNote.Done,
-		CreatedAt: apiNote.CreatedAt,
-		UpdatedAt: apiNote.UpdatedAt,
+		ID:         apiNote.ID,
+		Content:    apiNote.Content,
+		Done:       apiNote.Done,
+		CreatedAt:  apiNote.CreatedAt,
+		UpdatedAt:  apiNote.UpdatedAt,
+		ArchivedAt: apiNote.ArchivedAt,
+		Tags:       model.NormalizeTags(apiNote.Tags),
+		Version:    apiNote.Version,
 	}
 }
 
@@ -366,6 +514,8 @@ type APIResponse struct {
 type APIListResponse struct {
 	Data    []APINote `json:"data"`
 	Message string    `json:"message"`
+	// NextCursor is set by paginated backends when more notes follow
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // APINote represents a note in API format
@@ -375,6 +525,12 @@ type APINote struct {
 	Done      bool      `json:"done"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// ArchivedAt is set on archived notes
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// Tags is empty when the server does not tag notes
+	Tags []string `json:"tags,omitempty"`
+	// Version is zero when the server does not version notes
+	Version int64 `json:"version,omitempty"`
 }
 
 // APIErrorResponse represents an API error response
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+func newTestStore(t *testing.T, handler http.HandlerFunc) *Store {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	st, err := New(domainstorage.APIConfig{BaseURL: ts.URL, Timeout: 5, RetryDelay: 1}, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func TestGetAllNotes_FollowsCursors(t *testing.T) {
+	t.Parallel()
+	pages := map[string]APIListResponse{
+		"":   {Data: []APINote{{ID: "1"}, {ID: "2"}}, NextCursor: "c1"},
+		"c1": {Data: []APINote{{ID: "3"}}, NextCursor: "c2"},
+		"c2": {Data: []APINote{{ID: "4"}}},
+	}
+	st := newTestStore(t, func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			http.Error(w, "unknown cursor", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	})
+
+	notes, err := st.GetAllNotes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 4 || notes[0].ID != "1" || notes[3].ID != "4" {
+		t.Fatalf("notes=%+v", notes)
+	}
+}
+
+func TestGetAllNotes_RejectsCursorLoop(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(APIListResponse{Data: []APINote{{ID: "x"}}, NextCursor: "same"})
+	})
+
+	if _, err := st.GetAllNotes(context.Background()); err == nil {
+		t.Fatal("expected error for repeated cursor")
+	}
+}
+
+func TestNotesUpdatedSince_KeepsFilterAcrossPages(t *testing.T) {
+	t.Parallel()
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	st := newTestStore(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("updated_since") != since.Format(time.RFC3339Nano) || q.Get("sort") != "updated_at" {
+			http.Error(w, "missing filter: "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		page := APIListResponse{Data: []APINote{{ID: "1"}}, NextCursor: "c1"}
+		if q.Get("cursor") == "c1" {
+			page = APIListResponse{Data: []APINote{{ID: "2"}}}
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	})
+
+	notes, err := st.NotesUpdatedSince(context.Background(), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || notes[1].ID != "2" {
+		t.Fatalf("notes=%+v", notes)
+	}
+}
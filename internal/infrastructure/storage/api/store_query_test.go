@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestQueryNotes_EncodesFilter(t *testing.T) {
+	t.Parallel()
+	var got url.Values
+	st := newTestStore(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		_ = json.NewEncoder(w).Encode(APIListResponse{
+			Data: []APINote{{ID: "n1", Content: "milk"}},
+		})
+	})
+
+	done := true
+	content := "milk & honey"
+	after := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	limit, offset := 10, 20
+	notes, err := st.QueryNotes(context.Background(), model.NoteFilter{
+		Done:         &done,
+		Content:      &content,
+		CreatedAfter: &after,
+		Limit:        &limit,
+		Offset:       &offset,
+		Sort:         model.SortUpdatedAsc,
+		Tags:         []string{"work", "urgent"},
+		ExcludeTags:  []string{"someday"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].ID != "n1" {
+		t.Fatalf("notes=%+v", notes)
+	}
+
+	want := map[string]string{
+		"done":          "true",
+		"content":       "milk & honey",
+		"created_after": "2024-01-02T03:04:05Z",
+		"limit":         "10",
+		"offset":        "20",
+		"sort":          "updated_at",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Fatalf("param %s=%q want %q (all: %v)", k, got.Get(k), v, got)
+		}
+	}
+	if got.Has("created_before") {
+		t.Fatalf("unset bound should be omitted: %v", got)
+	}
+	if tags := got["tag"]; len(tags) != 2 || tags[0] != "work" || tags[1] != "urgent" || got.Get("exclude_tag") != "someday" {
+		t.Fatalf("tag params = %v", got)
+	}
+}
+
+func TestQueryNotes_FollowsCursorsUpToLimit(t *testing.T) {
+	t.Parallel()
+	pages := map[string]APIListResponse{
+		"":   {Data: []APINote{{ID: "1"}, {ID: "2"}}, NextCursor: "c1"},
+		"c1": {Data: []APINote{{ID: "3"}, {ID: "4"}}, NextCursor: "c2"},
+		"c2": {Data: []APINote{{ID: "5"}}},
+	}
+	st := newTestStore(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("done") != "true" {
+			http.Error(w, "filter lost: "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(pages[r.URL.Query().Get("cursor")])
+	})
+
+	done := true
+	notes, err := st.QueryNotes(context.Background(), model.NoteFilter{Done: &done})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 5 || notes[4].ID != "5" {
+		t.Fatalf("expected every page, got %+v", notes)
+	}
+
+	limit := 3
+	notes, err = st.QueryNotes(context.Background(), model.NoteFilter{Done: &done, Limit: &limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 3 || notes[2].ID != "3" {
+		t.Fatalf("expected the first 3 notes, got %+v", notes)
+	}
+}
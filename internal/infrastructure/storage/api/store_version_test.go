@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestUpdateNoteIfVersion_SendsIfMatch(t *testing.T) {
+	t.Parallel()
+
+	var ifMatch string
+	st := newTestStore(t, func(w http.ResponseWriter, r *http.Request) {
+		ifMatch = r.Header.Get("If-Match")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"id":"n1","content":"new","version":4}}`))
+	})
+
+	note, err := st.UpdateNoteIfVersion(context.Background(), "n1", "new", false, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ifMatch != `"3"` {
+		t.Fatalf("If-Match=%q", ifMatch)
+	}
+	if note.Version != 4 {
+		t.Fatalf("version=%d want 4", note.Version)
+	}
+
+	if _, err = st.UpdateNote(context.Background(), "n1", "new", false); err != nil {
+		t.Fatal(err)
+	}
+	if ifMatch != "" {
+		t.Fatalf("unconditional update sent If-Match=%q", ifMatch)
+	}
+}
+
+func TestDeleteNoteIfVersion_PreconditionFailed(t *testing.T) {
+	t.Parallel()
+
+	st := newTestStore(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+	})
+
+	err := st.DeleteNoteIfVersion(context.Background(), "n1", 2)
+	if !errors.Is(err, model.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+// Package changefeed publishes an event after every committed change to a
+// note, so the main window, API clients and other consumers can react to
+// writes made anywhere in the process.
+package changefeed
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// Default sizes used when the configuration leaves them at zero
+const (
+	DefaultBufferSize  = 64
+	DefaultHistorySize = 1000
+)
+
+// Feed fans note events out to subscribers. Each subscriber has a bounded
+// buffer; what happens when it fills is the subscriber's SlowConsumerPolicy.
+// The most recent events are kept so a subscriber can resume from a Seq.
+type Feed struct {
+	bufferSize  int
+	historySize int
+	now         func() time.Time
+
+	mu      sync.Mutex
+	seq     uint64
+	history []model.NoteEvent // oldest first
+	subs    map[*subscriber]struct{}
+	closed  bool
+}
+
+type subscriber struct {
+	ch     chan model.NoteEvent
+	filter model.NoteEventFilter
+}
+
+// NewFeed creates a feed whose subscribers buffer bufferSize events and
+// which keeps the last historySize events for resuming. Sequence numbers
+// start from the current time in microseconds, so they keep increasing
+// across restarts and a Seq from an earlier run is recognised as lost.
+func NewFeed(bufferSize, historySize int) *Feed {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Feed{
+		bufferSize:  bufferSize,
+		historySize: historySize,
+		now:         time.Now,
+		seq:         uint64(time.Now().UnixMicro()),
+		subs:        make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish numbers the events and delivers them to matching subscribers.
+// Events must not be modified afterwards; subscribers share them.
+func (f *Feed) Publish(events ...model.NoteEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+
+	for _, e := range events {
+		f.seq++
+		e.Seq = f.seq
+		if e.At.IsZero() {
+			e.At = f.now().UTC()
+		}
+		f.remember(e)
+		for sub := range f.subs {
+			if sub.filter.Matches(e) {
+				f.deliver(sub, e)
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel of the events matching filter, starting with
+// the retained events after filter.Since. When some of those are no longer
+// retained the channel starts with a NoteEventsLost event instead. The
+// channel is closed when ctx is done or the feed is closed.
+func (f *Feed) Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	replay := f.replay(filter)
+	sub := &subscriber{
+		ch:     make(chan model.NoteEvent, f.bufferSize+len(replay)),
+		filter: filter,
+	}
+	for _, e := range replay {
+		sub.ch <- e
+	}
+	if f.closed || ctx.Err() != nil {
+		close(sub.ch)
+		return sub.ch
+	}
+
+	f.subs[sub] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.drop(sub)
+	}()
+	return sub.ch
+}
+
+// Close closes every subscriber's channel. Later events are discarded.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for sub := range f.subs {
+		f.drop(sub)
+	}
+}
+
+// replay returns the retained events after filter.Since that match it. The
+// caller holds mu.
+func (f *Feed) replay(filter model.NoteEventFilter) []model.NoteEvent {
+	if filter.Since == 0 || filter.Since == f.seq {
+		return nil
+	}
+	// Events after Since have been forgotten, or Since is not ours
+	if filter.Since > f.seq || len(f.history) == 0 || f.history[0].Seq > filter.Since+1 {
+		return []model.NoteEvent{{Seq: f.seq, Type: model.NoteEventsLost, At: f.now().UTC()}}
+	}
+
+	var events []model.NoteEvent
+	for _, e := range f.history {
+		if e.Seq > filter.Since && filter.Matches(e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// remember adds e to the history, forgetting the oldest events beyond
+// historySize. The caller holds mu.
+func (f *Feed) remember(e model.NoteEvent) {
+	f.history = append(f.history, e)
+	if len(f.history) >= 2*f.historySize {
+		f.history = append(f.history[:0], f.history[len(f.history)-f.historySize:]...)
+	}
+}
+
+// deliver hands e to sub without blocking, applying its slow consumer
+// policy when the buffer is full. The caller holds mu.
+func (f *Feed) deliver(sub *subscriber, e model.NoteEvent) {
+	select {
+	case sub.ch <- e:
+		return
+	default:
+	}
+
+	if sub.filter.SlowConsumer != model.SlowConsumerDropOldest {
+		f.drop(sub)
+		return
+	}
+	select {
+	case <-sub.ch:
+	default:
+	}
+	select {
+	case sub.ch <- e:
+	default:
+	}
+}
+
+// drop unsubscribes sub and closes its channel. The caller holds mu.
+func (f *Feed) drop(sub *subscriber) {
+	if _, ok := f.subs[sub]; !ok {
+		return
+	}
+	delete(f.subs, sub)
+	close(sub.ch)
+}
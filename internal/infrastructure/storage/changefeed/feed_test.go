@@ -0,0 +1,280 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/conformance"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/memory"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
+)
+
+// receive reads n events from ch or fails the test
+func receive(t *testing.T, ch <-chan model.NoteEvent, n int) []model.NoteEvent {
+	t.Helper()
+	events := make([]model.NoteEvent, 0, n)
+	for range n {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed after %d of %d events", len(events), n)
+			}
+			events = append(events, e)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d of %d events", len(events), n)
+		}
+	}
+	return events
+}
+
+func TestStorage_PublishesWritesWithBeforeAndAfter(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(audit.WithActor(context.Background(), "tester"))
+	defer cancel()
+	store := New(memory.New(), NewFeed(8, 8))
+	events := store.Subscribe(ctx, model.NoteEventFilter{})
+
+	note, err := store.CreateNote(ctx, "draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.UpdateNote(ctx, note.ID, "final", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.ToggleDone(ctx, "missing"); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err = store.DeleteNote(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got := receive(t, events, 3)
+	if got[0].Type != model.NoteCreated || got[0].After.Content != "draft" || got[0].Before != nil {
+		t.Fatalf("created event = %+v", got[0])
+	}
+	if got[1].Type != model.NoteUpdated || got[1].Before.Content != "draft" || got[1].After.Content != "final" {
+		t.Fatalf("updated event = %+v", got[1])
+	}
+	if got[2].Type != model.NoteDeleted || got[2].NoteID != note.ID || got[2].After != nil {
+		t.Fatalf("deleted event = %+v", got[2])
+	}
+	for i, e := range got {
+		if e.Actor != "tester" {
+			t.Fatalf("event %d actor = %q", i, e.Actor)
+		}
+		if i > 0 && e.Seq != got[i-1].Seq+1 {
+			t.Fatalf("sequence not contiguous: %d after %d", e.Seq, got[i-1].Seq)
+		}
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event for a failed write: %+v", e)
+	default:
+	}
+}
+
+func TestStorage_EventsDoNotShareNotesWithTheCaller(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(memory.New(), NewFeed(8, 8))
+	events := store.Subscribe(ctx, model.NoteEventFilter{})
+
+	note, err := store.CreateNote(ctx, "kept")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived, err := store.ArchiveNote(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archivedAt := *archived.ArchivedAt
+	*archived.ArchivedAt = time.Time{}
+	tagged, err := store.SetNoteTags(ctx, note.ID, []string{"work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagged.Tags[0] = "changed"
+
+	got := receive(t, events, 3)
+	if after := got[1].After; after.ArchivedAt == nil || !after.ArchivedAt.Equal(archivedAt) {
+		t.Fatalf("event changed through the returned note: %+v", after)
+	}
+	if after := got[2].After; !slices.Equal(after.Tags, []string{"work"}) {
+		t.Fatalf("event tags changed through the returned note: %v", after.Tags)
+	}
+}
+
+// listCounter counts full listings of the notes
+type listCounter struct {
+	*memory.Store
+	lists atomic.Int32
+}
+
+func (s *listCounter) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	s.lists.Add(1)
+	return s.Store.GetAllNotes(ctx)
+}
+
+func TestStorage_PublishesImportsWithoutListingNotes(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := &listCounter{Store: memory.New()}
+	store := New(backend, NewFeed(8, 8))
+
+	kept, err := store.CreateNote(ctx, "stored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := store.Subscribe(ctx, model.NoteEventFilter{})
+
+	replaced := *kept
+	replaced.Content = "imported over"
+	added := *model.NewNote("imported")
+	report, err := store.ImportNotes(ctx, []model.Note{replaced, added}, model.ConflictOverwrite)
+	if err != nil || report.Updated != 1 || report.Created != 1 {
+		t.Fatalf("ImportNotes = %+v, %v", report, err)
+	}
+
+	got := receive(t, events, 2)
+	if got[0].Type != model.NoteUpdated || got[0].Before.Content != "stored" || got[0].After.Content != "imported over" {
+		t.Fatalf("updated event = %+v", got[0])
+	}
+	if got[1].Type != model.NoteCreated || got[1].Before != nil || got[1].NoteID != added.ID {
+		t.Fatalf("created event = %+v", got[1])
+	}
+	if lists := backend.lists.Load(); lists != 0 {
+		t.Fatalf("import listed every note %d times", lists)
+	}
+}
+
+func TestFeed_ResumesFromSeqOrReportsLostEvents(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed := NewFeed(8, 2)
+
+	for _, id := range []string{"a", "b", "c"} {
+		feed.Publish(model.NoteEvent{Type: model.NoteCreated, NoteID: id})
+	}
+	first := receive(t, feed.Subscribe(ctx, model.NoteEventFilter{Since: 1}), 1)[0]
+	if first.Type != model.NoteEventsLost {
+		// Seq 1 is not from this feed, which numbers from the clock
+		t.Fatalf("expected a reset for an unknown seq, got %+v", first)
+	}
+
+	for _, id := range []string{"d", "e"} {
+		feed.Publish(model.NoteEvent{Type: model.NoteCreated, NoteID: id})
+	}
+	feed.mu.Lock()
+	last := feed.seq
+	feed.mu.Unlock()
+
+	resumed := receive(t, feed.Subscribe(ctx, model.NoteEventFilter{Since: last - 1}), 1)
+	if resumed[0].NoteID != "e" {
+		t.Fatalf("resumed with %+v, want note e", resumed[0])
+	}
+	lost := receive(t, feed.Subscribe(ctx, model.NoteEventFilter{Since: last - 4}), 1)
+	if lost[0].Type != model.NoteEventsLost {
+		t.Fatalf("expected a reset once the history is trimmed, got %+v", lost[0])
+	}
+
+	filtered := feed.Subscribe(ctx, model.NoteEventFilter{Since: last, NoteID: "f"})
+	feed.Publish(model.NoteEvent{Type: model.NoteCreated, NoteID: "g"})
+	feed.Publish(model.NoteEvent{Type: model.NoteUpdated, NoteID: "f"})
+	if got := receive(t, filtered, 1)[0]; got.NoteID != "f" {
+		t.Fatalf("filter let through %+v", got)
+	}
+}
+
+func TestFeed_SlowConsumerPolicies(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed := NewFeed(2, 10)
+
+	disconnected := feed.Subscribe(ctx, model.NoteEventFilter{})
+	dropping := feed.Subscribe(ctx, model.NoteEventFilter{SlowConsumer: model.SlowConsumerDropOldest})
+	for _, id := range []string{"a", "b", "c"} {
+		feed.Publish(model.NoteEvent{Type: model.NoteCreated, NoteID: id})
+	}
+
+	receive(t, disconnected, 2)
+	if _, ok := <-disconnected; ok {
+		t.Fatal("expected the slow subscriber to be disconnected")
+	}
+
+	got := receive(t, dropping, 2)
+	if got[0].NoteID != "b" || got[1].NoteID != "c" {
+		t.Fatalf("drop-oldest kept %s and %s", got[0].NoteID, got[1].NoteID)
+	}
+	cancel()
+	for range dropping {
+	}
+}
+
+func TestStorage_PublishesTransactionAfterCommit(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	local, err := sqlite.New(filepath.Join(t.TempDir(), "notes.db"), logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := New(sqlite.NewUnifiedAdapter(local), NewFeed(8, 8))
+	defer store.Close()
+	events := store.Subscribe(ctx, model.NoteEventFilter{})
+
+	rollback := errors.New("rollback")
+	err = store.WithinTx(ctx, func(tx domainstorage.UnifiedNoteStorage) error {
+		if _, createErr := tx.CreateNote(ctx, "discarded"); createErr != nil {
+			return createErr
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("expected the rollback error, got %v", err)
+	}
+
+	err = store.WithinTx(ctx, func(tx domainstorage.UnifiedNoteStorage) error {
+		if _, createErr := tx.CreateNote(ctx, "kept"); createErr != nil {
+			return createErr
+		}
+		select {
+		case e := <-events:
+			t.Errorf("event published before commit: %+v", e)
+		default:
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := receive(t, events, 1)[0]
+	if got.Type != model.NoteCreated || got.After.Content != "kept" {
+		t.Fatalf("committed event = %+v", got)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("rolled back write was published: %+v", e)
+	default:
+	}
+}
+
+func TestStorage_Conformance(t *testing.T) {
+	t.Parallel()
+	conformance.Run(t, func(*testing.T) domainstorage.UnifiedNoteStorage {
+		return New(memory.New(), NewFeed(0, 0))
+	})
+}
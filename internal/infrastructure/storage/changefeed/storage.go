@@ -0,0 +1,460 @@
+package changefeed
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// Storage publishes an event to its feed after each successful write to the
+// storage it wraps. Updates and deletes read the note first to fill in
+// Before. Writes inside a transaction are published once it commits;
+// restoring a backup, syncing and importing publish the difference they made.
+type Storage struct {
+	next    domainstorage.UnifiedNoteStorage
+	feed    *Feed
+	publish func(events ...model.NoteEvent)
+}
+
+var (
+	_ domainstorage.ChangeFeed        = (*Storage)(nil)
+	_ domainstorage.ConditionalWriter = (*Storage)(nil)
+	_ domainstorage.Transactor        = (*Storage)(nil)
+	_ domainstorage.Trash             = (*Storage)(nil)
+	_ domainstorage.Backupper         = (*Storage)(nil)
+	_ domainstorage.Syncer            = (*Storage)(nil)
+	_ domainstorage.Archiver          = (*Storage)(nil)
+	_ domainstorage.Tagger            = (*Storage)(nil)
+	_ domainstorage.Importer          = (*Storage)(nil)
+)
+
+// New publishes the writes made through next to feed
+func New(next domainstorage.UnifiedNoteStorage, feed *Feed) *Storage {
+	return &Storage{next: next, feed: feed, publish: feed.Publish}
+}
+
+// Unwrap returns the wrapped storage
+func (s *Storage) Unwrap() domainstorage.UnifiedNoteStorage {
+	return s.next
+}
+
+// Subscribe returns the events matching filter; see Feed.Subscribe
+func (s *Storage) Subscribe(ctx context.Context, filter model.NoteEventFilter) <-chan model.NoteEvent {
+	return s.feed.Subscribe(ctx, filter)
+}
+
+// CreateNote creates a note and publishes it
+func (s *Storage) CreateNote(ctx context.Context, content string) (*model.Note, error) {
+	note, err := s.next.CreateNote(ctx, content)
+	if err == nil {
+		s.publish(event(ctx, model.NoteCreated, nil, note))
+	}
+	return note, err
+}
+
+// GetNote reads a note
+func (s *Storage) GetNote(ctx context.Context, id string) (*model.Note, error) {
+	return s.next.GetNote(ctx, id)
+}
+
+// GetAllNotes lists the notes
+func (s *Storage) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	return s.next.GetAllNotes(ctx)
+}
+
+// UpdateNote updates a note and publishes the change
+func (s *Storage) UpdateNote(ctx context.Context, id, content string, done bool) (*model.Note, error) {
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return s.next.UpdateNote(ctx, id, content, done)
+	})
+}
+
+// DeleteNote deletes a note and publishes its removal
+func (s *Storage) DeleteNote(ctx context.Context, id string) error {
+	return s.deleted(ctx, id, func() error {
+		return s.next.DeleteNote(ctx, id)
+	})
+}
+
+// ToggleDone flips a note's done flag and publishes the change
+func (s *Storage) ToggleDone(ctx context.Context, id string) (*model.Note, error) {
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return s.next.ToggleDone(ctx, id)
+	})
+}
+
+// MarkDone marks a note done and publishes the change
+func (s *Storage) MarkDone(ctx context.Context, id string) (*model.Note, error) {
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return s.next.MarkDone(ctx, id)
+	})
+}
+
+// MarkUndone marks a note not done and publishes the change
+func (s *Storage) MarkUndone(ctx context.Context, id string) (*model.Note, error) {
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return s.next.MarkUndone(ctx, id)
+	})
+}
+
+// Close closes the subscribers' channels and the wrapped storage
+func (s *Storage) Close() error {
+	s.feed.Close()
+	return s.next.Close()
+}
+
+// UpdateNoteIfVersion updates a note at version and publishes the change
+func (s *Storage) UpdateNoteIfVersion(
+	ctx context.Context, id, content string, done bool, version int64,
+) (*model.Note, error) {
+	writer, ok := domainstorage.As[domainstorage.ConditionalWriter](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return writer.UpdateNoteIfVersion(ctx, id, content, done, version)
+	})
+}
+
+// DeleteNoteIfVersion deletes a note at version and publishes its removal
+func (s *Storage) DeleteNoteIfVersion(ctx context.Context, id string, version int64) error {
+	writer, ok := domainstorage.As[domainstorage.ConditionalWriter](s.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	return s.deleted(ctx, id, func() error {
+		return writer.DeleteNoteIfVersion(ctx, id, version)
+	})
+}
+
+// WithinTx runs fn in a transaction and publishes its writes once it commits
+func (s *Storage) WithinTx(ctx context.Context, fn func(tx domainstorage.UnifiedNoteStorage) error) error {
+	transactor, ok := domainstorage.As[domainstorage.Transactor](s.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+
+	var pending []model.NoteEvent
+	err := transactor.WithinTx(ctx, func(tx domainstorage.UnifiedNoteStorage) error {
+		return fn(&Storage{next: tx, feed: s.feed, publish: func(events ...model.NoteEvent) {
+			pending = append(pending, events...)
+		}})
+	})
+	if err == nil {
+		s.publish(pending...)
+	}
+	return err
+}
+
+// ListTrash lists the trashed notes
+func (s *Storage) ListTrash(ctx context.Context) ([]*model.Note, error) {
+	trash, ok := domainstorage.As[domainstorage.Trash](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return trash.ListTrash(ctx)
+}
+
+// RestoreNote restores a note from the trash and publishes it as created
+func (s *Storage) RestoreNote(ctx context.Context, id string) (*model.Note, error) {
+	trash, ok := domainstorage.As[domainstorage.Trash](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	note, err := trash.RestoreNote(ctx, id)
+	if err == nil {
+		s.publish(event(ctx, model.NoteCreated, nil, note))
+	}
+	return note, err
+}
+
+// ArchiveNote archives a note and publishes the change
+func (s *Storage) ArchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	archiver, ok := domainstorage.As[domainstorage.Archiver](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return archiver.ArchiveNote(ctx, id)
+	})
+}
+
+// UnarchiveNote takes a note out of the archive and publishes the change
+func (s *Storage) UnarchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	archiver, ok := domainstorage.As[domainstorage.Archiver](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return archiver.UnarchiveNote(ctx, id)
+	})
+}
+
+// SetNoteTags replaces a note's tags and publishes the change
+func (s *Storage) SetNoteTags(ctx context.Context, id string, tags []string) (*model.Note, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return s.updated(ctx, id, func() (*model.Note, error) {
+		return tagger.SetNoteTags(ctx, id, tags)
+	})
+}
+
+// ListTags lists the tags in use
+func (s *Storage) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return tagger.ListTags(ctx)
+}
+
+// RenameTag renames a tag and publishes the notes it changed
+func (s *Storage) RenameTag(ctx context.Context, from, to string) (int, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](s.next)
+	if !ok {
+		return 0, domainstorage.ErrNotSupported
+	}
+	var renamed int
+	err := s.diffed(ctx, func() (err error) {
+		renamed, err = tagger.RenameTag(ctx, from, to)
+		return err
+	})
+	return renamed, err
+}
+
+// PurgeTrash removes old trashed notes. Their deletion was already published.
+func (s *Storage) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error) {
+	trash, ok := domainstorage.As[domainstorage.Trash](s.next)
+	if !ok {
+		return 0, domainstorage.ErrNotSupported
+	}
+	return trash.PurgeTrash(ctx, deletedBefore)
+}
+
+// PurgeNote removes one trashed note. Its deletion was already published.
+func (s *Storage) PurgeNote(ctx context.Context, id string) error {
+	trash, ok := domainstorage.As[domainstorage.Trash](s.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	return trash.PurgeNote(ctx, id)
+}
+
+// Backup snapshots the storage
+func (s *Storage) Backup(ctx context.Context) (*model.Backup, error) {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return backupper.Backup(ctx)
+}
+
+// ListBackups lists the snapshots
+func (s *Storage) ListBackups(ctx context.Context) ([]model.Backup, error) {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return backupper.ListBackups(ctx)
+}
+
+// RestoreBackup restores a snapshot and publishes what it changed
+func (s *Storage) RestoreBackup(ctx context.Context, name string) error {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](s.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	return s.diffed(ctx, func() error {
+		return backupper.RestoreBackup(ctx, name)
+	})
+}
+
+// ImportNotes imports notes and publishes the ones it created or replaced,
+// reading only those notes rather than diffing every note
+func (s *Storage) ImportNotes(
+	ctx context.Context, notes []model.Note, policy model.ConflictPolicy,
+) (*model.ImportReport, error) {
+	importer, ok := domainstorage.As[domainstorage.Importer](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	// A skipping import never replaces a note, so there is nothing to read first
+	before := make(map[string]*model.Note)
+	if policy != model.ConflictSkip {
+		for i := range notes {
+			if prev := s.current(ctx, notes[i].ID); prev != nil {
+				before[prev.ID] = prev
+			}
+		}
+	}
+
+	report, err := importer.ImportNotes(ctx, notes, policy)
+	if err != nil {
+		return report, err
+	}
+	s.publish(s.imported(ctx, report, before)...)
+	return report, nil
+}
+
+// imported describes the notes an import created or replaced as events
+func (s *Storage) imported(
+	ctx context.Context, report *model.ImportReport, before map[string]*model.Note,
+) []model.NoteEvent {
+	var events []model.NoteEvent
+	for _, result := range report.Results {
+		if result.Outcome != model.ImportCreated && result.Outcome != model.ImportUpdated {
+			continue
+		}
+		after := s.current(ctx, result.ID)
+		if after == nil {
+			// The change is committed but cannot be described
+			return []model.NoteEvent{{Type: model.NoteEventsLost}}
+		}
+		kind := model.NoteUpdated
+		if before[result.ID] == nil {
+			kind = model.NoteCreated
+		}
+		events = append(events, event(ctx, kind, before[result.ID], after))
+	}
+	return events
+}
+
+// Sync runs a sync pass and publishes the changes it pulled
+func (s *Storage) Sync(ctx context.Context) (*model.SyncStatus, error) {
+	syncer, ok := domainstorage.As[domainstorage.Syncer](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	var status *model.SyncStatus
+	err := s.diffed(ctx, func() (err error) {
+		status, err = syncer.Sync(ctx)
+		return err
+	})
+	return status, err
+}
+
+// SyncStatus returns the last sync result
+func (s *Storage) SyncStatus(ctx context.Context) (*model.SyncStatus, error) {
+	syncer, ok := domainstorage.As[domainstorage.Syncer](s.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return syncer.SyncStatus(ctx)
+}
+
+// updated runs a write that returns the note and publishes the change
+func (s *Storage) updated(ctx context.Context, id string, write func() (*model.Note, error)) (*model.Note, error) {
+	before := s.current(ctx, id)
+	note, err := write()
+	if err == nil {
+		s.publish(event(ctx, model.NoteUpdated, before, note))
+	}
+	return note, err
+}
+
+// deleted runs a delete and publishes the removal
+func (s *Storage) deleted(ctx context.Context, id string, write func() error) error {
+	before := s.current(ctx, id)
+	if err := write(); err != nil {
+		return err
+	}
+	e := event(ctx, model.NoteDeleted, before, nil)
+	e.NoteID = id
+	s.publish(e)
+	return nil
+}
+
+// diffed runs a bulk write and publishes the difference between the notes
+// before and after it
+func (s *Storage) diffed(ctx context.Context, write func() error) error {
+	before, beforeErr := s.next.GetAllNotes(ctx)
+	if err := write(); err != nil {
+		return err
+	}
+	after, err := s.next.GetAllNotes(ctx)
+	if beforeErr != nil || err != nil {
+		// The change is committed but cannot be described
+		s.publish(model.NoteEvent{Type: model.NoteEventsLost})
+		return nil
+	}
+	s.publish(diff(ctx, before, after)...)
+	return nil
+}
+
+// current reads the note before a write, or returns nil if it cannot
+func (s *Storage) current(ctx context.Context, id string) *model.Note {
+	note, err := s.next.GetNote(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return note
+}
+
+// diff describes the change from before to after as events
+func diff(ctx context.Context, before, after []*model.Note) []model.NoteEvent {
+	old := make(map[string]*model.Note, len(before))
+	for _, note := range before {
+		old[note.ID] = note
+	}
+
+	var events []model.NoteEvent
+	for _, note := range after {
+		prev, ok := old[note.ID]
+		delete(old, note.ID)
+		switch {
+		case !ok:
+			events = append(events, event(ctx, model.NoteCreated, nil, note))
+		case prev.Version != note.Version || prev.Content != note.Content ||
+			prev.Done != note.Done || !prev.UpdatedAt.Equal(note.UpdatedAt) ||
+			!slices.Equal(prev.Tags, note.Tags):
+			events = append(events, event(ctx, model.NoteUpdated, prev, note))
+		}
+	}
+	for _, note := range before {
+		if _, gone := old[note.ID]; gone {
+			events = append(events, event(ctx, model.NoteDeleted, note, nil))
+		}
+	}
+	return events
+}
+
+// event describes a change, copying the notes so later changes by the
+// caller do not reach subscribers
+func event(ctx context.Context, kind model.NoteEventType, before, after *model.Note) model.NoteEvent {
+	e := model.NoteEvent{
+		Type:   kind,
+		Before: cloneNote(before),
+		After:  cloneNote(after),
+		Actor:  audit.ActorFromContext(ctx),
+	}
+	switch {
+	case after != nil:
+		e.NoteID = after.ID
+	case before != nil:
+		e.NoteID = before.ID
+	}
+	return e
+}
+
+func cloneNote(note *model.Note) *model.Note {
+	if note == nil {
+		return nil
+	}
+	clone := *note
+	if note.DeletedAt != nil {
+		deletedAt := *note.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	if note.ArchivedAt != nil {
+		archivedAt := *note.ArchivedAt
+		clone.ArchivedAt = &archivedAt
+	}
+	clone.Tags = slices.Clone(note.Tags)
+	return &clone
+}
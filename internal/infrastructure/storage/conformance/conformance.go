@@ -0,0 +1,670 @@
+// Package conformance is the behavior every UnifiedNoteStorage backend must
+// share. A backend passes by calling Run from its own tests with a function
+// that opens a fresh, empty store:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, func(t *testing.T) domainstorage.UnifiedNoteStorage {
+//			return newEmptyStore(t)
+//		})
+//	}
+//
+// The suite owns the store it is given and closes it when the test ends.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	storageerrors "github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
+)
+
+// Factory opens a new, empty store for one test
+type Factory func(t *testing.T) domainstorage.UnifiedNoteStorage
+
+// Concurrency of the concurrent writer tests
+const (
+	writers          = 8
+	notesPerWriter   = 10
+	togglesPerWriter = 4
+)
+
+// Run runs the conformance suite against the stores opened by newStore
+func Run(t *testing.T, newStore Factory) {
+	t.Helper()
+	open := func(t *testing.T) domainstorage.UnifiedNoteStorage {
+		t.Helper()
+		store := newStore(t)
+		t.Cleanup(func() { _ = store.Close() })
+		return store
+	}
+
+	t.Run("CRUD", func(t *testing.T) {
+		t.Parallel()
+		testCRUD(t, open(t))
+	})
+	t.Run("ListNewestFirst", func(t *testing.T) {
+		t.Parallel()
+		testListNewestFirst(t, open(t))
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+		testNotFound(t, open(t))
+	})
+	t.Run("Toggles", func(t *testing.T) {
+		t.Parallel()
+		testToggles(t, open(t))
+	})
+	t.Run("Timestamps", func(t *testing.T) {
+		t.Parallel()
+		testTimestamps(t, open(t))
+	})
+	t.Run("ConcurrentWriters", func(t *testing.T) {
+		t.Parallel()
+		testConcurrentWriters(t, open(t))
+	})
+	t.Run("Import", func(t *testing.T) {
+		t.Parallel()
+		testImport(t, open(t))
+	})
+	t.Run("Archive", func(t *testing.T) {
+		t.Parallel()
+		testArchive(t, open(t))
+	})
+	t.Run("Tags", func(t *testing.T) {
+		t.Parallel()
+		testTags(t, open(t))
+	})
+	t.Run("Close", func(t *testing.T) {
+		t.Parallel()
+		testClose(t, newStore(t))
+	})
+}
+
+func testCRUD(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	ctx := context.Background()
+
+	notes, err := store.GetAllNotes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllNotes on an empty store: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Fatalf("new store holds %d notes", len(notes))
+	}
+
+	created, err := store.CreateNote(ctx, "first")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+	if created.ID == "" || created.Content != "first" || created.Done {
+		t.Fatalf("CreateNote returned %+v", created)
+	}
+	if created.CreatedAt.IsZero() || created.UpdatedAt.IsZero() {
+		t.Fatalf("CreateNote left timestamps unset: %+v", created)
+	}
+
+	got, err := store.GetNote(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+	assertSameNote(t, "GetNote", got, created)
+
+	updated, err := store.UpdateNote(ctx, created.ID, "second", true)
+	if err != nil {
+		t.Fatalf("UpdateNote: %v", err)
+	}
+	if updated.ID != created.ID || updated.Content != "second" || !updated.Done {
+		t.Fatalf("UpdateNote returned %+v", updated)
+	}
+	got, err = store.GetNote(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetNote after update: %v", err)
+	}
+	assertSameNote(t, "GetNote after update", got, updated)
+
+	other, err := store.CreateNote(ctx, "other")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+	if other.ID == created.ID {
+		t.Fatalf("two notes share the ID %s", other.ID)
+	}
+	notes, err = store.GetAllNotes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllNotes: %v", err)
+	}
+	if ids := noteIDs(notes); len(ids) != 2 || !ids[created.ID] || !ids[other.ID] {
+		t.Fatalf("GetAllNotes returned %v, want both notes", ids)
+	}
+
+	if err = store.DeleteNote(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteNote: %v", err)
+	}
+	if _, err = store.GetNote(ctx, created.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("GetNote after delete: want model.ErrNoteNotFound, got %v", err)
+	}
+	notes, err = store.GetAllNotes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllNotes after delete: %v", err)
+	}
+	if ids := noteIDs(notes); len(ids) != 1 || !ids[other.ID] {
+		t.Fatalf("GetAllNotes after delete returned %v", ids)
+	}
+}
+
+func testListNewestFirst(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	ctx := context.Background()
+	var ids []string
+	for i := range 3 {
+		note, err := store.CreateNote(ctx, fmt.Sprintf("note %d", i))
+		if err != nil {
+			t.Fatalf("CreateNote: %v", err)
+		}
+		ids = append(ids, note.ID)
+		// Keep creation times distinct on clocks with coarse resolution
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	notes, err := store.GetAllNotes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllNotes: %v", err)
+	}
+	if len(notes) != len(ids) {
+		t.Fatalf("GetAllNotes returned %d notes, want %d", len(notes), len(ids))
+	}
+	for i, note := range notes {
+		if want := ids[len(ids)-1-i]; note.ID != want {
+			t.Fatalf("note %d is %q, want %q: notes must be listed newest first", i, note.Content, want)
+		}
+	}
+}
+
+func testNotFound(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	ctx := context.Background()
+	const id = "00000000-0000-4000-8000-000000000000"
+
+	calls := map[string]func() error{
+		"GetNote": func() error {
+			_, err := store.GetNote(ctx, id)
+			return err
+		},
+		"UpdateNote": func() error {
+			_, err := store.UpdateNote(ctx, id, "content", false)
+			return err
+		},
+		"DeleteNote": func() error {
+			return store.DeleteNote(ctx, id)
+		},
+		"ToggleDone": func() error {
+			_, err := store.ToggleDone(ctx, id)
+			return err
+		},
+		"MarkDone": func() error {
+			_, err := store.MarkDone(ctx, id)
+			return err
+		},
+		"MarkUndone": func() error {
+			_, err := store.MarkUndone(ctx, id)
+			return err
+		},
+	}
+	for name, call := range calls {
+		err := call()
+		if !errors.Is(err, model.ErrNoteNotFound) {
+			t.Errorf("%s on a missing note: want model.ErrNoteNotFound, got %v", name, err)
+		}
+		var notFound *storageerrors.NotFoundError
+		if errors.As(err, &notFound) && notFound.ID != id {
+			t.Errorf("%s reported the missing note as %q", name, notFound.ID)
+		}
+	}
+}
+
+func testToggles(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	ctx := context.Background()
+	note, err := store.CreateNote(ctx, "toggle me")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+
+	steps := []struct {
+		name string
+		call func(context.Context, string) (*model.Note, error)
+		want bool
+	}{
+		{"ToggleDone", store.ToggleDone, true},
+		{"ToggleDone", store.ToggleDone, false},
+		{"MarkDone", store.MarkDone, true},
+		{"MarkDone again", store.MarkDone, true},
+		{"MarkUndone", store.MarkUndone, false},
+		{"MarkUndone again", store.MarkUndone, false},
+	}
+	for _, step := range steps {
+		got, stepErr := step.call(ctx, note.ID)
+		if stepErr != nil {
+			t.Fatalf("%s: %v", step.name, stepErr)
+		}
+		if got.Done != step.want || got.Content != "toggle me" {
+			t.Fatalf("%s returned %+v, want done=%v", step.name, got, step.want)
+		}
+		stored, getErr := store.GetNote(ctx, note.ID)
+		if getErr != nil {
+			t.Fatalf("GetNote after %s: %v", step.name, getErr)
+		}
+		if stored.Done != step.want {
+			t.Fatalf("after %s the stored note has done=%v", step.name, stored.Done)
+		}
+	}
+}
+
+func testTimestamps(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	ctx := context.Background()
+	note, err := store.CreateNote(ctx, "v1")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+	if note.UpdatedAt.Before(note.CreatedAt) {
+		t.Fatalf("created note was updated at %v, before its creation at %v", note.UpdatedAt, note.CreatedAt)
+	}
+
+	prev := note
+	writes := []struct {
+		name  string
+		write func() (*model.Note, error)
+	}{
+		{"UpdateNote", func() (*model.Note, error) { return store.UpdateNote(ctx, note.ID, "v2", false) }},
+		{"ToggleDone", func() (*model.Note, error) { return store.ToggleDone(ctx, note.ID) }},
+		{"MarkUndone", func() (*model.Note, error) { return store.MarkUndone(ctx, note.ID) }},
+		{"MarkDone", func() (*model.Note, error) { return store.MarkDone(ctx, note.ID) }},
+	}
+	for _, w := range writes {
+		time.Sleep(2 * time.Millisecond)
+		got, writeErr := w.write()
+		if writeErr != nil {
+			t.Fatalf("%s: %v", w.name, writeErr)
+		}
+		if !got.CreatedAt.Equal(note.CreatedAt) {
+			t.Fatalf("%s moved CreatedAt from %v to %v", w.name, note.CreatedAt, got.CreatedAt)
+		}
+		if !got.UpdatedAt.After(prev.UpdatedAt) {
+			t.Fatalf("%s set UpdatedAt to %v, not after the previous %v", w.name, got.UpdatedAt, prev.UpdatedAt)
+		}
+		if prev.Version != 0 && got.Version <= prev.Version {
+			t.Fatalf("%s left the version at %d after %d", w.name, got.Version, prev.Version)
+		}
+		stored, getErr := store.GetNote(ctx, note.ID)
+		if getErr != nil {
+			t.Fatalf("GetNote after %s: %v", w.name, getErr)
+		}
+		assertSameNote(t, "GetNote after "+w.name, stored, got)
+		prev = got
+	}
+}
+
+func testConcurrentWriters(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	ctx := context.Background()
+	shared, err := store.CreateNote(ctx, "shared")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created = map[string]bool{}
+		toggled int
+		errs    []error
+	)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range notesPerWriter {
+				note, createErr := store.CreateNote(ctx, fmt.Sprintf("writer %d note %d", w, i))
+				mu.Lock()
+				if createErr != nil {
+					errs = append(errs, createErr)
+				} else {
+					created[note.ID] = true
+				}
+				mu.Unlock()
+			}
+			for range togglesPerWriter {
+				_, toggleErr := store.ToggleDone(ctx, shared.ID)
+				mu.Lock()
+				if toggleErr != nil {
+					errs = append(errs, toggleErr)
+				} else {
+					toggled++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		t.Fatalf("%d concurrent writes failed, first: %v", len(errs), errs[0])
+	}
+	if len(created) != writers*notesPerWriter {
+		t.Fatalf("concurrent creates produced %d distinct IDs, want %d", len(created), writers*notesPerWriter)
+	}
+
+	notes, err := store.GetAllNotes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllNotes: %v", err)
+	}
+	ids := noteIDs(notes)
+	for id := range created {
+		if !ids[id] {
+			t.Fatalf("created note %s is missing from GetAllNotes", id)
+		}
+	}
+
+	got, err := store.GetNote(ctx, shared.ID)
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+	if want := toggled%2 == 1; got.Done != want {
+		t.Fatalf("after %d toggles done=%v: a concurrent toggle was lost", toggled, got.Done)
+	}
+}
+
+// testImport runs only against stores that implement domainstorage.Importer
+func testImport(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	importer, ok := domainstorage.As[domainstorage.Importer](store)
+	if !ok {
+		t.Skip("store does not implement domainstorage.Importer")
+	}
+	ctx := context.Background()
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	original := model.Note{
+		ID: "6f1c2d9e-0a4b-4c3d-8e2f-1a2b3c4d5e6f", Content: "imported", Done: true,
+		CreatedAt: created, UpdatedAt: created.Add(time.Hour),
+	}
+
+	importOne := func(note model.Note, policy model.ConflictPolicy, want model.ImportOutcome) {
+		t.Helper()
+		report, err := importer.ImportNotes(ctx, []model.Note{note}, policy)
+		if errors.Is(err, domainstorage.ErrNotSupported) {
+			t.Skip("store does not support imports")
+		}
+		if err != nil {
+			t.Fatalf("ImportNotes(%s): %v", policy, err)
+		}
+		if len(report.Results) != 1 || report.Results[0].Outcome != want {
+			t.Fatalf("ImportNotes(%s) reported %+v, want %s", policy, report.Results, want)
+		}
+	}
+	assertStored := func(op string, want model.Note) {
+		t.Helper()
+		got, err := store.GetNote(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetNote after %s: %v", op, err)
+		}
+		if got.Content != want.Content || got.Done != want.Done ||
+			!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+			t.Fatalf("after %s the store holds %+v, want %+v", op, got, want)
+		}
+	}
+
+	importOne(original, model.ConflictSkip, model.ImportCreated)
+	assertStored("import", original)
+
+	older := original
+	older.Content = "older"
+	older.UpdatedAt = created
+	importOne(older, model.ConflictSkip, model.ImportSkipped)
+	importOne(older, model.ConflictNewerWins, model.ImportSkipped)
+	assertStored("skipped imports", original)
+
+	newer := original
+	newer.Content = "newer"
+	newer.Done = false
+	newer.UpdatedAt = created.Add(2 * time.Hour)
+	importOne(newer, model.ConflictNewerWins, model.ImportUpdated)
+	assertStored("newer-wins import", newer)
+
+	importOne(older, model.ConflictOverwrite, model.ImportUpdated)
+	assertStored("overwrite import", older)
+
+	report, err := importer.ImportNotes(ctx, []model.Note{
+		{Content: "no ID", CreatedAt: created, UpdatedAt: created},
+		{ID: "9a8b7c6d-5e4f-4a3b-9c2d-1e0f1a2b3c4d", Content: "second", CreatedAt: created, UpdatedAt: created},
+	}, model.ConflictSkip)
+	if err != nil {
+		t.Fatalf("ImportNotes with an invalid note: %v", err)
+	}
+	if report.Failed != 1 || report.Created != 1 || report.Results[0].Outcome != model.ImportFailed {
+		t.Fatalf("an invalid note should fail alone, got %+v", report)
+	}
+	if _, err = store.GetNote(ctx, "9a8b7c6d-5e4f-4a3b-9c2d-1e0f1a2b3c4d"); err != nil {
+		t.Fatalf("note imported after an invalid one: %v", err)
+	}
+}
+
+// testArchive runs only against stores that implement domainstorage.Archiver
+func testArchive(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	archiver, ok := domainstorage.As[domainstorage.Archiver](store)
+	if !ok {
+		t.Skip("store does not implement domainstorage.Archiver")
+	}
+	ctx := context.Background()
+	kept, err := store.CreateNote(ctx, "kept")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+	note, err := store.CreateNote(ctx, "archived")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+
+	archived, err := archiver.ArchiveNote(ctx, note.ID)
+	if errors.Is(err, domainstorage.ErrNotSupported) {
+		t.Skip("store does not support archiving")
+	}
+	if err != nil {
+		t.Fatalf("ArchiveNote: %v", err)
+	}
+	if archived.ArchivedAt == nil || archived.Content != note.Content {
+		t.Fatalf("ArchiveNote returned %+v", archived)
+	}
+	again, err := archiver.ArchiveNote(ctx, note.ID)
+	if err != nil || again.ArchivedAt == nil || !again.ArchivedAt.Equal(*archived.ArchivedAt) {
+		t.Fatalf("archiving twice should leave the note as it was, got %+v, %v", again, err)
+	}
+	if got, getErr := store.GetNote(ctx, note.ID); getErr != nil || got.ArchivedAt == nil {
+		t.Fatalf("GetNote should still find the archived note, got %+v, %v", got, getErr)
+	}
+
+	scope := func(archive model.ArchiveScope) []string {
+		t.Helper()
+		var notes []*model.Note
+		if querier, isQuerier := domainstorage.As[domainstorage.NoteQuerier](store); isQuerier {
+			notes, err = querier.QueryNotes(ctx, model.NoteFilter{Archived: archive})
+		} else if notes, err = store.GetAllNotes(ctx); err == nil {
+			notes = domainstorage.ApplyFilter(notes, model.NoteFilter{Archived: archive})
+		}
+		if err != nil {
+			t.Fatalf("listing scope %q: %v", archive, err)
+		}
+		ids := make([]string, len(notes))
+		for i, n := range notes {
+			ids[i] = n.ID
+		}
+		return ids
+	}
+	if got := scope(model.ArchiveExclude); len(got) != 1 || got[0] != kept.ID {
+		t.Fatalf("default scope should leave the archived note out, got %v", got)
+	}
+	if got := scope(model.ArchiveOnly); len(got) != 1 || got[0] != note.ID {
+		t.Fatalf("archived scope should list only the archived note, got %v", got)
+	}
+	if got := scope(model.ArchiveInclude); len(got) != 2 {
+		t.Fatalf("include scope should list both notes, got %v", got)
+	}
+
+	unarchived, err := archiver.UnarchiveNote(ctx, note.ID)
+	if err != nil || unarchived.ArchivedAt != nil {
+		t.Fatalf("UnarchiveNote returned %+v, %v", unarchived, err)
+	}
+	if got := scope(model.ArchiveExclude); len(got) != 2 {
+		t.Fatalf("unarchived note should be listed again, got %v", got)
+	}
+	if _, err = archiver.ArchiveNote(ctx, "missing"); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("ArchiveNote of a missing note: want model.ErrNoteNotFound, got %v", err)
+	}
+}
+
+func testTags(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](store)
+	if !ok {
+		t.Skip("store does not implement domainstorage.Tagger")
+	}
+	ctx := context.Background()
+	var notes [3]*model.Note
+	for i, content := range []string{"report", "review", "groceries"} {
+		note, err := store.CreateNote(ctx, content)
+		if err != nil {
+			t.Fatalf("CreateNote: %v", err)
+		}
+		notes[i] = note
+	}
+	report, review, groceries := notes[0], notes[1], notes[2]
+
+	tagged, err := tagger.SetNoteTags(ctx, report.ID, []string{"Work", "#urgent", "work"})
+	if err != nil {
+		t.Fatalf("SetNoteTags: %v", err)
+	}
+	if !slices.Equal(tagged.Tags, []string{"urgent", "work"}) || tagged.Content != report.Content {
+		t.Fatalf("SetNoteTags should store normalized tags, got %+v", tagged)
+	}
+	if _, err = tagger.SetNoteTags(ctx, review.ID, []string{"work"}); err != nil {
+		t.Fatalf("SetNoteTags: %v", err)
+	}
+	if _, err = tagger.SetNoteTags(ctx, groceries.ID, []string{"home"}); err != nil {
+		t.Fatalf("SetNoteTags: %v", err)
+	}
+
+	// Editing a note leaves its tags alone
+	if _, err = store.UpdateNote(ctx, report.ID, "quarterly report", false); err != nil {
+		t.Fatalf("UpdateNote: %v", err)
+	}
+	if got, getErr := store.GetNote(ctx, report.ID); getErr != nil || !slices.Equal(got.Tags, []string{"urgent", "work"}) {
+		t.Fatalf("GetNote after UpdateNote returned %+v, %v", got, getErr)
+	}
+
+	counts := func() []model.TagCount {
+		t.Helper()
+		tags, listErr := tagger.ListTags(ctx)
+		if listErr != nil {
+			t.Fatalf("ListTags: %v", listErr)
+		}
+		return tags
+	}
+	want := []model.TagCount{{Name: "home", Count: 1}, {Name: "urgent", Count: 1}, {Name: "work", Count: 2}}
+	if got := counts(); !slices.Equal(got, want) {
+		t.Fatalf("ListTags = %v, want %v", got, want)
+	}
+
+	filtered := func(filter model.NoteFilter) map[string]bool {
+		t.Helper()
+		var found []*model.Note
+		if querier, isQuerier := domainstorage.As[domainstorage.NoteQuerier](store); isQuerier {
+			found, err = querier.QueryNotes(ctx, filter)
+		} else if found, err = store.GetAllNotes(ctx); err == nil {
+			found = domainstorage.ApplyFilter(found, filter)
+		}
+		if err != nil {
+			t.Fatalf("listing %+v: %v", filter, err)
+		}
+		return noteIDs(found)
+	}
+	if got := filtered(model.NoteFilter{Tags: []string{"work"}}); len(got) != 2 || !got[report.ID] || !got[review.ID] {
+		t.Fatalf("tag filter should keep the work notes, got %v", got)
+	}
+	if got := filtered(model.NoteFilter{Tags: []string{"work", "urgent"}}); len(got) != 1 || !got[report.ID] {
+		t.Fatalf("tag filter should keep notes carrying every tag, got %v", got)
+	}
+	if got := filtered(model.NoteFilter{ExcludeTags: []string{"urgent"}}); len(got) != 2 || got[report.ID] {
+		t.Fatalf("excluded tag should drop the urgent note, got %v", got)
+	}
+
+	// Renaming onto a tag in use merges the two
+	renamed, err := tagger.RenameTag(ctx, "work", "home")
+	if err != nil || renamed != 2 {
+		t.Fatalf("RenameTag = %d, %v, want 2 notes", renamed, err)
+	}
+	want = []model.TagCount{{Name: "home", Count: 3}, {Name: "urgent", Count: 1}}
+	if got := counts(); !slices.Equal(got, want) {
+		t.Fatalf("ListTags after merge = %v, want %v", got, want)
+	}
+	if got, getErr := store.GetNote(ctx, report.ID); getErr != nil || !slices.Equal(got.Tags, []string{"home", "urgent"}) {
+		t.Fatalf("GetNote after RenameTag returned %+v, %v", got, getErr)
+	}
+	if _, err = tagger.RenameTag(ctx, "work", "office"); !errors.Is(err, model.ErrTagNotFound) {
+		t.Fatalf("RenameTag of an unused tag: want model.ErrTagNotFound, got %v", err)
+	}
+
+	cleared, err := tagger.SetNoteTags(ctx, groceries.ID, nil)
+	if err != nil || len(cleared.Tags) != 0 {
+		t.Fatalf("SetNoteTags with no tags returned %+v, %v", cleared, err)
+	}
+	if _, err = tagger.SetNoteTags(ctx, "missing", []string{"x"}); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("SetNoteTags of a missing note: want model.ErrNoteNotFound, got %v", err)
+	}
+}
+
+func testClose(t *testing.T, store domainstorage.UnifiedNoteStorage) {
+	ctx := context.Background()
+	note, err := store.CreateNote(ctx, "before close")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+
+	if err = store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err = store.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+
+	if _, err = store.GetNote(ctx, note.ID); !errors.Is(err, storageerrors.ErrStoreClosed) {
+		t.Errorf("GetNote after Close: want errors.ErrStoreClosed, got %v", err)
+	}
+	if _, err = store.GetAllNotes(ctx); !errors.Is(err, storageerrors.ErrStoreClosed) {
+		t.Errorf("GetAllNotes after Close: want errors.ErrStoreClosed, got %v", err)
+	}
+	if _, err = store.CreateNote(ctx, "after close"); !errors.Is(err, storageerrors.ErrStoreClosed) {
+		t.Errorf("CreateNote after Close: want errors.ErrStoreClosed, got %v", err)
+	}
+	if err = store.DeleteNote(ctx, note.ID); !errors.Is(err, storageerrors.ErrStoreClosed) {
+		t.Errorf("DeleteNote after Close: want errors.ErrStoreClosed, got %v", err)
+	}
+}
+
+// assertSameNote fails unless got and want describe the same stored note
+func assertSameNote(t *testing.T, op string, got, want *model.Note) {
+	t.Helper()
+	if got.ID != want.ID || got.Content != want.Content || got.Done != want.Done || got.Version != want.Version {
+		t.Fatalf("%s returned %+v, want %+v", op, got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("%s returned timestamps %v/%v, want %v/%v",
+			op, got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+}
+
+func noteIDs(notes []*model.Note) map[string]bool {
+	ids := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		ids[note.ID] = true
+	}
+	return ids
+}
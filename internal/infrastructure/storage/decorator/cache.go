@@ -0,0 +1,426 @@
+package decorator
+
+import (
+	"container/list"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// Cache keeps recently read notes, and the full note list, in memory. Writes
+// through the cache update or invalidate what it holds. Writes made through
+// the backend's optional capabilities, such as restoring from the trash or a
+// backup, clear it, so the cache implements those capabilities and reports
+// domainstorage.ErrNotSupported when the backend lacks them. Changes made
+// outside Godo are seen once an entry is older than the TTL.
+type Cache struct {
+	next domainstorage.UnifiedNoteStorage
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	all     []*model.Note
+	allAt   time.Time
+	hasAll  bool
+	// gen changes with every invalidation, so a read that raced with a write
+	// does not store what it read
+	gen uint64
+}
+
+type cacheEntry struct {
+	note     *model.Note
+	storedAt time.Time
+}
+
+var (
+	_ domainstorage.ConditionalWriter = (*Cache)(nil)
+	_ domainstorage.Transactor        = (*Cache)(nil)
+	_ domainstorage.Trash             = (*Cache)(nil)
+	_ domainstorage.Backupper         = (*Cache)(nil)
+	_ domainstorage.Syncer            = (*Cache)(nil)
+	_ domainstorage.Archiver          = (*Cache)(nil)
+	_ domainstorage.Tagger            = (*Cache)(nil)
+	_ domainstorage.Importer          = (*Cache)(nil)
+)
+
+// NewCache caches up to size notes read from next for at most ttl; a zero
+// ttl keeps them until they are evicted or written
+func NewCache(next domainstorage.UnifiedNoteStorage, size int, ttl time.Duration) *Cache {
+	return &Cache{
+		next:    next,
+		size:    max(size, 1),
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Unwrap returns the decorated storage
+func (c *Cache) Unwrap() domainstorage.UnifiedNoteStorage {
+	return c.next
+}
+
+// GetNote returns a cached copy of the note, reading it on a miss
+func (c *Cache) GetNote(ctx context.Context, id string) (*model.Note, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if c.fresh(entry.storedAt) {
+			c.order.MoveToFront(elem)
+			note := cloneNote(entry.note)
+			c.mu.Unlock()
+			return note, nil
+		}
+		c.remove(elem)
+	}
+	gen := c.gen
+	c.mu.Unlock()
+
+	note, err := c.next.GetNote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if gen == c.gen {
+		c.put(note)
+	}
+	c.mu.Unlock()
+	return note, nil
+}
+
+// GetAllNotes returns a cached copy of the note list, reading it on a miss
+func (c *Cache) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	c.mu.Lock()
+	if c.hasAll && c.fresh(c.allAt) {
+		notes := cloneNotes(c.all)
+		c.mu.Unlock()
+		return notes, nil
+	}
+	gen := c.gen
+	c.mu.Unlock()
+
+	notes, err := c.next.GetAllNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if gen == c.gen {
+		c.all = cloneNotes(notes)
+		c.allAt = c.now()
+		c.hasAll = true
+	}
+	c.mu.Unlock()
+	return notes, nil
+}
+
+// CreateNote creates a note and caches it
+func (c *Cache) CreateNote(ctx context.Context, content string) (*model.Note, error) {
+	note, err := c.next.CreateNote(ctx, content)
+	c.written("", note, err)
+	return note, err
+}
+
+// UpdateNote updates a note and caches the result
+func (c *Cache) UpdateNote(ctx context.Context, id, content string, done bool) (*model.Note, error) {
+	note, err := c.next.UpdateNote(ctx, id, content, done)
+	c.written(id, note, err)
+	return note, err
+}
+
+// DeleteNote deletes a note and drops it from the cache
+func (c *Cache) DeleteNote(ctx context.Context, id string) error {
+	err := c.next.DeleteNote(ctx, id)
+	c.written(id, nil, err)
+	return err
+}
+
+// ToggleDone flips a note's done flag and caches the result
+func (c *Cache) ToggleDone(ctx context.Context, id string) (*model.Note, error) {
+	note, err := c.next.ToggleDone(ctx, id)
+	c.written(id, note, err)
+	return note, err
+}
+
+// MarkDone marks a note done and caches the result
+func (c *Cache) MarkDone(ctx context.Context, id string) (*model.Note, error) {
+	note, err := c.next.MarkDone(ctx, id)
+	c.written(id, note, err)
+	return note, err
+}
+
+// MarkUndone marks a note not done and caches the result
+func (c *Cache) MarkUndone(ctx context.Context, id string) (*model.Note, error) {
+	note, err := c.next.MarkUndone(ctx, id)
+	c.written(id, note, err)
+	return note, err
+}
+
+// Close empties the cache and closes the decorated storage
+func (c *Cache) Close() error {
+	c.Purge()
+	return c.next.Close()
+}
+
+// UpdateNoteIfVersion updates a note at version and caches the result
+func (c *Cache) UpdateNoteIfVersion(
+	ctx context.Context, id, content string, done bool, version int64,
+) (*model.Note, error) {
+	writer, ok := domainstorage.As[domainstorage.ConditionalWriter](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	note, err := writer.UpdateNoteIfVersion(ctx, id, content, done, version)
+	c.written(id, note, err)
+	return note, err
+}
+
+// DeleteNoteIfVersion deletes a note at version and drops it from the cache
+func (c *Cache) DeleteNoteIfVersion(ctx context.Context, id string, version int64) error {
+	writer, ok := domainstorage.As[domainstorage.ConditionalWriter](c.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	err := writer.DeleteNoteIfVersion(ctx, id, version)
+	c.written(id, nil, err)
+	return err
+}
+
+// WithinTx runs fn in a backend transaction and clears the cache afterwards
+func (c *Cache) WithinTx(ctx context.Context, fn func(tx domainstorage.UnifiedNoteStorage) error) error {
+	transactor, ok := domainstorage.As[domainstorage.Transactor](c.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	defer c.Purge()
+	return transactor.WithinTx(ctx, fn)
+}
+
+// ImportNotes imports notes into the backend and clears the cache
+func (c *Cache) ImportNotes(
+	ctx context.Context, notes []model.Note, policy model.ConflictPolicy,
+) (*model.ImportReport, error) {
+	importer, ok := domainstorage.As[domainstorage.Importer](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	defer c.Purge()
+	return importer.ImportNotes(ctx, notes, policy)
+}
+
+// ListTrash lists the trash of the backend
+func (c *Cache) ListTrash(ctx context.Context) ([]*model.Note, error) {
+	trash, ok := domainstorage.As[domainstorage.Trash](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return trash.ListTrash(ctx)
+}
+
+// RestoreNote restores a note from the trash and clears the cache
+func (c *Cache) RestoreNote(ctx context.Context, id string) (*model.Note, error) {
+	trash, ok := domainstorage.As[domainstorage.Trash](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	defer c.Purge()
+	return trash.RestoreNote(ctx, id)
+}
+
+// ArchiveNote archives a note and caches the result
+func (c *Cache) ArchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	archiver, ok := domainstorage.As[domainstorage.Archiver](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	note, err := archiver.ArchiveNote(ctx, id)
+	c.written(id, note, err)
+	return note, err
+}
+
+// UnarchiveNote takes a note out of the archive and caches the result
+func (c *Cache) UnarchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	archiver, ok := domainstorage.As[domainstorage.Archiver](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	note, err := archiver.UnarchiveNote(ctx, id)
+	c.written(id, note, err)
+	return note, err
+}
+
+// SetNoteTags replaces a note's tags and caches the result
+func (c *Cache) SetNoteTags(ctx context.Context, id string, tags []string) (*model.Note, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	note, err := tagger.SetNoteTags(ctx, id, tags)
+	c.written(id, note, err)
+	return note, err
+}
+
+// ListTags lists the backend's tags
+func (c *Cache) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return tagger.ListTags(ctx)
+}
+
+// RenameTag renames a tag across the backend and clears the cache
+func (c *Cache) RenameTag(ctx context.Context, from, to string) (int, error) {
+	tagger, ok := domainstorage.As[domainstorage.Tagger](c.next)
+	if !ok {
+		return 0, domainstorage.ErrNotSupported
+	}
+	defer c.Purge()
+	return tagger.RenameTag(ctx, from, to)
+}
+
+// PurgeTrash empties the backend's trash
+func (c *Cache) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error) {
+	trash, ok := domainstorage.As[domainstorage.Trash](c.next)
+	if !ok {
+		return 0, domainstorage.ErrNotSupported
+	}
+	return trash.PurgeTrash(ctx, deletedBefore)
+}
+
+// PurgeNote removes one note from the backend's trash
+func (c *Cache) PurgeNote(ctx context.Context, id string) error {
+	trash, ok := domainstorage.As[domainstorage.Trash](c.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	return trash.PurgeNote(ctx, id)
+}
+
+// Backup snapshots the backend
+func (c *Cache) Backup(ctx context.Context) (*model.Backup, error) {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return backupper.Backup(ctx)
+}
+
+// ListBackups lists the backend's snapshots
+func (c *Cache) ListBackups(ctx context.Context) ([]model.Backup, error) {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return backupper.ListBackups(ctx)
+}
+
+// RestoreBackup restores a snapshot and clears the cache
+func (c *Cache) RestoreBackup(ctx context.Context, name string) error {
+	backupper, ok := domainstorage.As[domainstorage.Backupper](c.next)
+	if !ok {
+		return domainstorage.ErrNotSupported
+	}
+	defer c.Purge()
+	return backupper.RestoreBackup(ctx, name)
+}
+
+// Sync runs a sync pass and clears the cache, since it may pull changes
+func (c *Cache) Sync(ctx context.Context) (*model.SyncStatus, error) {
+	syncer, ok := domainstorage.As[domainstorage.Syncer](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	defer c.Purge()
+	return syncer.Sync(ctx)
+}
+
+// SyncStatus returns the backend's sync status
+func (c *Cache) SyncStatus(ctx context.Context) (*model.SyncStatus, error) {
+	syncer, ok := domainstorage.As[domainstorage.Syncer](c.next)
+	if !ok {
+		return nil, domainstorage.ErrNotSupported
+	}
+	return syncer.SyncStatus(ctx)
+}
+
+// Purge empties the cache
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.all, c.hasAll = nil, false
+}
+
+// written records the outcome of a write to id. The note list is always
+// dropped; the note is cached when the write returned it and dropped
+// otherwise, since a failed write may still have been applied.
+func (c *Cache) written(id string, note *model.Note, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.all, c.hasAll = nil, false
+	if elem, ok := c.entries[id]; ok {
+		c.remove(elem)
+	}
+	if err == nil && note != nil {
+		c.put(note)
+	}
+}
+
+// put caches a copy of note, evicting the least recently used note when
+// full. The caller holds mu.
+func (c *Cache) put(note *model.Note) {
+	if elem, ok := c.entries[note.ID]; ok {
+		c.remove(elem)
+	}
+	c.entries[note.ID] = c.order.PushFront(&cacheEntry{note: cloneNote(note), storedAt: c.now()})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove drops a cached note. The caller holds mu.
+func (c *Cache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheEntry).note.ID)
+	c.order.Remove(elem)
+}
+
+// fresh reports whether something stored at storedAt is within the TTL.
+// The caller holds mu.
+func (c *Cache) fresh(storedAt time.Time) bool {
+	return c.ttl <= 0 || c.now().Sub(storedAt) < c.ttl
+}
+
+func cloneNote(note *model.Note) *model.Note {
+	clone := *note
+	if note.DeletedAt != nil {
+		deletedAt := *note.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	if note.ArchivedAt != nil {
+		archivedAt := *note.ArchivedAt
+		clone.ArchivedAt = &archivedAt
+	}
+	clone.Tags = slices.Clone(note.Tags)
+	return &clone
+}
+
+func cloneNotes(notes []*model.Note) []*model.Note {
+	clones := make([]*model.Note, len(notes))
+	for i, note := range notes {
+		clones[i] = cloneNote(note)
+	}
+	return clones
+}
@@ -0,0 +1,125 @@
+// Package decorator provides wrappers that add cross-cutting behavior to any
+// UnifiedNoteStorage backend: metrics, logging, a read cache, retries and
+// timeouts. Each wrapper implements UnifiedNoteStorage and Unwrap, so they
+// stack in any order and the backend's optional capabilities stay reachable
+// through domainstorage.As.
+package decorator
+
+import (
+	"context"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// Operation names, as they appear in logs and metrics
+const (
+	OpCreateNote  = "create_note"
+	OpGetNote     = "get_note"
+	OpGetAllNotes = "get_all_notes"
+	OpUpdateNote  = "update_note"
+	OpDeleteNote  = "delete_note"
+	OpToggleDone  = "toggle_done"
+	OpMarkDone    = "mark_done"
+	OpMarkUndone  = "mark_undone"
+)
+
+// call is one storage operation, run with the context it is given
+type call func(ctx context.Context) error
+
+// aroundFunc runs a storage operation on behalf of a decorator. id is the
+// note the operation targets, or empty.
+type aroundFunc func(ctx context.Context, op, id string, fn call) error
+
+// wrapped implements UnifiedNoteStorage by running every operation on next
+// through around. Close is passed straight through.
+type wrapped struct {
+	next   domainstorage.UnifiedNoteStorage
+	around aroundFunc
+}
+
+// Unwrap returns the decorated storage
+func (w *wrapped) Unwrap() domainstorage.UnifiedNoteStorage {
+	return w.next
+}
+
+// CreateNote creates a note through the decorator
+func (w *wrapped) CreateNote(ctx context.Context, content string) (*model.Note, error) {
+	var note *model.Note
+	err := w.around(ctx, OpCreateNote, "", func(ctx context.Context) (err error) {
+		note, err = w.next.CreateNote(ctx, content)
+		return err
+	})
+	return note, err
+}
+
+// GetNote reads a note through the decorator
+func (w *wrapped) GetNote(ctx context.Context, id string) (*model.Note, error) {
+	var note *model.Note
+	err := w.around(ctx, OpGetNote, id, func(ctx context.Context) (err error) {
+		note, err = w.next.GetNote(ctx, id)
+		return err
+	})
+	return note, err
+}
+
+// GetAllNotes lists the notes through the decorator
+func (w *wrapped) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	var notes []*model.Note
+	err := w.around(ctx, OpGetAllNotes, "", func(ctx context.Context) (err error) {
+		notes, err = w.next.GetAllNotes(ctx)
+		return err
+	})
+	return notes, err
+}
+
+// UpdateNote updates a note through the decorator
+func (w *wrapped) UpdateNote(ctx context.Context, id, content string, done bool) (*model.Note, error) {
+	var note *model.Note
+	err := w.around(ctx, OpUpdateNote, id, func(ctx context.Context) (err error) {
+		note, err = w.next.UpdateNote(ctx, id, content, done)
+		return err
+	})
+	return note, err
+}
+
+// DeleteNote deletes a note through the decorator
+func (w *wrapped) DeleteNote(ctx context.Context, id string) error {
+	return w.around(ctx, OpDeleteNote, id, func(ctx context.Context) error {
+		return w.next.DeleteNote(ctx, id)
+	})
+}
+
+// ToggleDone flips a note's done flag through the decorator
+func (w *wrapped) ToggleDone(ctx context.Context, id string) (*model.Note, error) {
+	return w.noteOp(ctx, OpToggleDone, id, w.next.ToggleDone)
+}
+
+// MarkDone marks a note done through the decorator
+func (w *wrapped) MarkDone(ctx context.Context, id string) (*model.Note, error) {
+	return w.noteOp(ctx, OpMarkDone, id, w.next.MarkDone)
+}
+
+// MarkUndone marks a note not done through the decorator
+func (w *wrapped) MarkUndone(ctx context.Context, id string) (*model.Note, error) {
+	return w.noteOp(ctx, OpMarkUndone, id, w.next.MarkUndone)
+}
+
+// Close closes the decorated storage
+func (w *wrapped) Close() error {
+	return w.next.Close()
+}
+
+// noteOp runs an operation that takes and returns a single note
+func (w *wrapped) noteOp(
+	ctx context.Context,
+	op, id string,
+	fn func(ctx context.Context, id string) (*model.Note, error),
+) (*model.Note, error) {
+	var note *model.Note
+	err := w.around(ctx, op, id, func(ctx context.Context) (err error) {
+		note, err = fn(ctx, id)
+		return err
+	})
+	return note, err
+}
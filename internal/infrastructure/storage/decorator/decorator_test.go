@@ -0,0 +1,289 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/conformance"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/memory"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
+)
+
+// countingStore counts reads and fails the next failures calls with a
+// network error
+type countingStore struct {
+	*memory.Store
+	reads    atomic.Int32
+	calls    atomic.Int32
+	failures atomic.Int32
+}
+
+func (s *countingStore) fail() error {
+	s.calls.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		return &url.Error{Op: "Get", URL: "http://remote.test", Err: errors.New("connection reset")}
+	}
+	return nil
+}
+
+func (s *countingStore) GetNote(ctx context.Context, id string) (*model.Note, error) {
+	s.reads.Add(1)
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.Store.GetNote(ctx, id)
+}
+
+func (s *countingStore) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	s.reads.Add(1)
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.Store.GetAllNotes(ctx)
+}
+
+func (s *countingStore) CreateNote(ctx context.Context, content string) (*model.Note, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	return s.Store.CreateNote(ctx, content)
+}
+
+func newCountingStore() *countingStore {
+	return &countingStore{Store: memory.New()}
+}
+
+func TestCache_ServesReadsAndInvalidatesOnWrite(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	backend := newCountingStore()
+	cache := NewCache(backend, 10, 0)
+
+	note, err := cache.CreateNote(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		got, getErr := cache.GetNote(ctx, note.ID)
+		if getErr != nil || got.Content != "first" {
+			t.Fatalf("GetNote = %+v, %v", got, getErr)
+		}
+		got.Content = "changed by the caller"
+		if _, getErr = cache.GetAllNotes(ctx); getErr != nil {
+			t.Fatal(getErr)
+		}
+	}
+	if got := backend.reads.Load(); got != 1 {
+		t.Fatalf("backend reads = %d, want only the first list", got)
+	}
+
+	if _, err = cache.UpdateNote(ctx, note.ID, "second", true); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.GetNote(ctx, note.ID)
+	if err != nil || got.Content != "second" || !got.Done {
+		t.Fatalf("stale note after update: %+v, %v", got, err)
+	}
+	notes, err := cache.GetAllNotes(ctx)
+	if err != nil || len(notes) != 1 || notes[0].Content != "second" {
+		t.Fatalf("stale list after update: %+v, %v", notes, err)
+	}
+
+	if err = cache.DeleteNote(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cache.GetNote(ctx, note.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("expected not found after delete, got %v", err)
+	}
+}
+
+func TestCache_ReturnsCopiesCallersCannotChange(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cache := NewCache(memory.New(), 10, 0)
+
+	note, err := cache.CreateNote(ctx, "kept")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived, err := cache.ArchiveNote(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archivedAt := *archived.ArchivedAt
+	if _, err = cache.SetNoteTags(ctx, note.ID, []string{"work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cache.GetNote(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	*got.ArchivedAt = time.Time{}
+	got.Tags[0] = "changed"
+
+	got, err = cache.GetNote(ctx, note.ID)
+	if err != nil || got.ArchivedAt == nil || !got.ArchivedAt.Equal(archivedAt) ||
+		!slices.Equal(got.Tags, []string{"work"}) {
+		t.Fatalf("cached note changed through a returned copy: %+v, %v", got, err)
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	backend := newCountingStore()
+	cache := NewCache(backend, 2, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	var ids []string
+	for _, content := range []string{"a", "b", "c"} {
+		note, err := backend.Store.CreateNote(ctx, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, note.ID)
+		if _, err = cache.GetNote(ctx, note.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backend.reads.Store(0)
+	if _, err := cache.GetNote(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetNote(ctx, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if got := backend.reads.Load(); got != 1 {
+		t.Fatalf("backend reads = %d, want one for the evicted note", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.GetNote(ctx, ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if got := backend.reads.Load(); got != 2 {
+		t.Fatalf("backend reads = %d, want the expired note read again", got)
+	}
+}
+
+func TestCache_CapabilityWritesClearIt(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	local, err := sqlite.New(filepath.Join(t.TempDir(), "notes.db"), logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var store domainstorage.UnifiedNoteStorage = NewCache(sqlite.NewUnifiedAdapter(local), 10, 0)
+	store = NewMetrics(store, new(expvar.Map))
+	defer store.Close()
+
+	note, err := store.CreateNote(ctx, "kept")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.GetAllNotes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.DeleteNote(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	trash, ok := domainstorage.As[domainstorage.Trash](store)
+	if !ok {
+		t.Fatal("trash not reachable through the decorators")
+	}
+	if _, err = trash.RestoreNote(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	notes, err := store.GetAllNotes(ctx)
+	if err != nil || len(notes) != 1 {
+		t.Fatalf("restored note missing from the cached list: %+v, %v", notes, err)
+	}
+
+	if _, ok = domainstorage.As[domainstorage.Searcher](store); !ok {
+		t.Fatal("search not reachable through the decorators")
+	}
+}
+
+func TestRetry_RepeatsTransientFailures(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	backend := newCountingStore()
+	store := NewRetry(backend, 3, time.Millisecond)
+
+	backend.failures.Store(2)
+	if _, err := store.GetAllNotes(ctx); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if got := backend.calls.Load(); got != 3 {
+		t.Fatalf("calls = %d, want 3", got)
+	}
+
+	backend.calls.Store(0)
+	backend.failures.Store(1)
+	if _, err := store.CreateNote(ctx, "once"); err == nil {
+		t.Fatal("expected the create to fail without a retry")
+	}
+	if got := backend.calls.Load(); got != 1 {
+		t.Fatalf("create was attempted %d times", got)
+	}
+
+	backend.calls.Store(0)
+	if _, err := store.GetNote(ctx, "missing"); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if got := backend.calls.Load(); got != 1 {
+		t.Fatalf("a missing note was read %d times", got)
+	}
+}
+
+// slowStore blocks reads until their context is done
+type slowStore struct {
+	*memory.Store
+}
+
+func (s slowStore) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeoutInsideRetry_GivesEachAttemptADeadline(t *testing.T) {
+	t.Parallel()
+	stats := new(expvar.Map)
+	store := NewMetrics(NewRetry(NewTimeout(slowStore{memory.New()}, 5*time.Millisecond), 2, time.Millisecond), stats)
+
+	_, err := store.GetAllNotes(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if calls := stats.Get(OpGetAllNotes + ".calls"); calls == nil || calls.String() != "1" {
+		t.Fatalf("calls metric = %v", calls)
+	}
+	if errs := stats.Get(OpGetAllNotes + ".errors"); errs == nil || errs.String() != "1" {
+		t.Fatalf("errors metric = %v", errs)
+	}
+}
+
+func TestDecorators_Conformance(t *testing.T) {
+	t.Parallel()
+	conformance.Run(t, func(*testing.T) domainstorage.UnifiedNoteStorage {
+		var store domainstorage.UnifiedNoteStorage = memory.New()
+		store = NewTimeout(store, time.Second)
+		store = NewRetry(store, 2, time.Millisecond)
+		store = NewCache(store, 10, 0)
+		return NewMetrics(store, new(expvar.Map))
+	})
+}
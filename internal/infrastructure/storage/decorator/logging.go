@@ -0,0 +1,48 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// Logging logs every storage operation with its duration. Failures, other
+// than notes that do not exist, and operations slower than the threshold are
+// logged as warnings; the rest at debug level.
+type Logging struct {
+	wrapped
+	log  logger.Logger
+	slow time.Duration
+}
+
+// NewLogging logs the operations on next. A zero slow disables the slow
+// operation warning.
+func NewLogging(next domainstorage.UnifiedNoteStorage, log logger.Logger, slow time.Duration) *Logging {
+	l := &Logging{log: log, slow: slow}
+	l.wrapped = wrapped{next: next, around: l.record}
+	return l
+}
+
+func (l *Logging) record(ctx context.Context, op, id string, fn call) error {
+	start := time.Now()
+	err := fn(ctx)
+	elapsed := time.Since(start)
+
+	fields := []any{"op", op, "duration", elapsed}
+	if id != "" {
+		fields = append(fields, "note_id", id)
+	}
+	switch {
+	case err != nil && !errors.Is(err, model.ErrNoteNotFound):
+		l.log.Warn("Storage operation failed", append(fields, "error", err)...)
+	case l.slow > 0 && elapsed >= l.slow:
+		l.log.Warn("Slow storage operation", fields...)
+	default:
+		l.log.Debug("Storage operation", fields...)
+	}
+	return err
+}
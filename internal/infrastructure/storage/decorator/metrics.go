@@ -0,0 +1,38 @@
+package decorator
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// DefaultStats holds the counters of every Metrics decorator created by the
+// factory. It is published through expvar as "godo_storage".
+var DefaultStats = expvar.NewMap("godo_storage")
+
+// Metrics counts calls, errors and time spent per storage operation. For
+// each operation op, stats holds "op.calls", "op.errors" and "op.total_us".
+type Metrics struct {
+	wrapped
+	stats *expvar.Map
+}
+
+// NewMetrics records the operations on next in stats
+func NewMetrics(next domainstorage.UnifiedNoteStorage, stats *expvar.Map) *Metrics {
+	m := &Metrics{stats: stats}
+	m.wrapped = wrapped{next: next, around: m.observe}
+	return m
+}
+
+func (m *Metrics) observe(ctx context.Context, op, _ string, fn call) error {
+	start := time.Now()
+	err := fn(ctx)
+	m.stats.Add(op+".calls", 1)
+	m.stats.Add(op+".total_us", time.Since(start).Microseconds())
+	if err != nil {
+		m.stats.Add(op+".errors", 1)
+	}
+	return err
+}
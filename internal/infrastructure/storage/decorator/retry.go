@@ -0,0 +1,83 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// Retry repeats operations that failed with a transient error, waiting delay
+// before the first retry and twice as long before each next one. Creating a
+// note and toggling its done flag are not repeated, since a failed attempt
+// may still have been applied. Place a Timeout inside the Retry to give each
+// attempt its own deadline.
+type Retry struct {
+	wrapped
+	attempts int
+	delay    time.Duration
+}
+
+// NewRetry makes up to attempts tries of each operation on next
+func NewRetry(next domainstorage.UnifiedNoteStorage, attempts int, delay time.Duration) *Retry {
+	r := &Retry{attempts: max(attempts, 1), delay: delay}
+	r.wrapped = wrapped{next: next, around: r.retry}
+	return r
+}
+
+func (r *Retry) retry(ctx context.Context, op, _ string, fn call) error {
+	err := fn(ctx)
+	if op == OpCreateNote || op == OpToggleDone {
+		return err
+	}
+
+	delay := r.delay
+	for attempt := 1; attempt < r.attempts && retryable(ctx, err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+
+		err = fn(ctx)
+		// The attempt that failed may have deleted the note after all
+		if op == OpDeleteNote && errors.Is(err, model.ErrNoteNotFound) {
+			return nil
+		}
+	}
+	return err
+}
+
+// retryable reports whether a failed attempt should be repeated. A deadline
+// that passed while ctx is still live belongs to the attempt, as when a
+// Timeout decorator sits inside the Retry, so it is retried too.
+func retryable(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return true
+	}
+	return IsTransient(err)
+}
+
+// IsTransient reports whether err is worth retrying: a network failure or
+// timeout, or SQLite reporting the database busy. Cancellation is not.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "SQLITE_BUSY")
+}
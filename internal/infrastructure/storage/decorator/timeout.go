@@ -0,0 +1,36 @@
+package decorator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// Timeout gives every operation a deadline. Backends stop when their
+// context is done, so a stuck database lock or an unresponsive server fails
+// the operation instead of hanging the caller.
+type Timeout struct {
+	wrapped
+	timeout time.Duration
+}
+
+// NewTimeout limits each operation on next to timeout
+func NewTimeout(next domainstorage.UnifiedNoteStorage, timeout time.Duration) *Timeout {
+	t := &Timeout{timeout: timeout}
+	t.wrapped = wrapped{next: next, around: t.limit}
+	return t
+}
+
+func (t *Timeout) limit(ctx context.Context, op, _ string, fn call) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("storage %s timed out after %s: %w: %w", op, t.timeout, context.DeadlineExceeded, err)
+	}
+	return err
+}
@@ -4,6 +4,8 @@ package errors
 import (
 	"errors"
 	"fmt"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
 )
 
 // Common errors
@@ -18,18 +20,19 @@ var (
 	ErrEmptyID = errors.New("task ID cannot be empty")
 )
 
-// NotFoundError is returned when a task cannot be found
+// NotFoundError is returned when a note cannot be found
 type NotFoundError struct {
 	ID string
 }
 
 func (e *NotFoundError) Error() string {
-	return "task not found: " + e.ID
+	return "note not found: " + e.ID
 }
 
-// Is implements errors.Is interface to match against ErrNoteNotFound
+// Is implements errors.Is interface to match against ErrNoteNotFound and
+// the domain's model.ErrNoteNotFound
 func (e *NotFoundError) Is(target error) bool {
-	return target == ErrNoteNotFound
+	return target == ErrNoteNotFound || target == model.ErrNoteNotFound
 }
 
 // ValidationError is returned when validation fails
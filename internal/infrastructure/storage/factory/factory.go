@@ -3,14 +3,25 @@ package factory
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/jonesrussell/godo/internal/domain/model"
 	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
 	"github.com/jonesrussell/godo/internal/infrastructure/storage/api"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/changefeed"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/markdown"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/memory"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/outbox"
 	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/syncer"
 )
 
-// NewUnifiedStorage creates a new storage implementation based on configuration
+// NewUnifiedStorage creates a new storage implementation based on configuration,
+// wrapped in the decorators listed in config.Middleware and, outermost, the
+// change feed
 func NewUnifiedStorage(config *domainstorage.StorageConfig, log logger.Logger) (domainstorage.UnifiedNoteStorage, error) {
 	if config == nil {
 		return nil, fmt.Errorf("storage configuration is required")
@@ -20,11 +31,34 @@ func NewUnifiedStorage(config *domainstorage.StorageConfig, log logger.Logger) (
 		return nil, fmt.Errorf("logger is required")
 	}
 
+	store, err := newBackend(config, log)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := applyMiddleware(store, config.Middleware, log)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	feed := changefeed.NewFeed(config.Events.BufferSize, config.Events.HistorySize)
+	return changefeed.New(wrapped, feed), nil
+}
+
+// newBackend creates the storage backend selected by config.Type
+func newBackend(config *domainstorage.StorageConfig, log logger.Logger) (domainstorage.UnifiedNoteStorage, error) {
 	switch config.Type {
 	case domainstorage.StorageTypeSQLite:
+		if config.Sync.Enabled {
+			return NewSyncedStorage(config, log)
+		}
 		return NewSQLiteStorage(&config.SQLite, log)
 	case domainstorage.StorageTypeAPI:
 		return NewAPIStorage(&config.API, log)
+	case domainstorage.StorageTypeMemory:
+		return NewMemoryStorage(&config.Memory, log)
+	case domainstorage.StorageTypeMarkdown:
+		return NewMarkdownStorage(&config.Markdown, log)
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s", config.Type)
 	}
@@ -32,6 +66,43 @@ func NewUnifiedStorage(config *domainstorage.StorageConfig, log logger.Logger) (
 
 // NewSQLiteStorage creates a new SQLite storage implementation
 func NewSQLiteStorage(config *domainstorage.SQLiteConfig, log logger.Logger) (domainstorage.UnifiedNoteStorage, error) {
+	store, err := openSQLite(config, log)
+	if err != nil {
+		return nil, err
+	}
+	return sqlite.NewUnifiedAdapter(store), nil
+}
+
+// NewSyncedStorage creates a SQLite storage that syncs with the API backend.
+// Notes are read and written locally; the remote is only contacted by Sync.
+func NewSyncedStorage(config *domainstorage.StorageConfig, log logger.Logger) (domainstorage.UnifiedNoteStorage, error) {
+	if err := domainstorage.ValidateAPIBaseURL(config.API.BaseURL); err != nil {
+		return nil, err
+	}
+	remote, err := api.New(config.API, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sync remote: %w", err)
+	}
+
+	store, err := openSQLite(&config.SQLite, log)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := syncer.NewEngine(store, remote, model.SyncConflictPolicy(config.Sync.ConflictPolicy), log)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+
+	log.Info("Sync enabled", "base_url", config.API.BaseURL, "conflict_policy", config.Sync.ConflictPolicy)
+
+	return syncer.NewStorage(sqlite.NewUnifiedAdapter(store), engine, remote), nil
+}
+
+// openSQLite opens the SQLite database with its connection, encryption and
+// backup settings
+func openSQLite(config *domainstorage.SQLiteConfig, log logger.Logger) (*sqlite.Store, error) {
 	if config == nil {
 		return nil, fmt.Errorf("SQLite configuration is required")
 	}
@@ -42,15 +113,100 @@ func NewSQLiteStorage(config *domainstorage.SQLiteConfig, log logger.Logger) (do
 
 	log.Debug("Creating SQLite storage", "file_path", config.FilePath)
 
-	store, err := sqlite.New(config.FilePath, log)
+	opts := sqliteOptions(config)
+	key, err := encryptionKey(&config.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	opts.Key = key
+
+	store, err := sqlite.NewWithOptions(config.FilePath, opts, log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SQLite store: %w", err)
 	}
 
-	adapter := sqlite.NewUnifiedAdapter(store)
+	store.SetBackupPolicy(backupPolicy(config))
+	store.SetAttachmentPolicy(attachmentPolicy(config))
+
 	log.Info("SQLite storage created successfully", "file_path", config.FilePath)
 
-	return adapter, nil
+	return store, nil
+}
+
+// sqliteOptions overlays the configured connection settings on the defaults
+func sqliteOptions(config *domainstorage.SQLiteConfig) sqlite.Options {
+	opts := sqlite.DefaultOptions()
+	if config.JournalMode != "" {
+		opts.JournalMode = config.JournalMode
+	}
+	if config.BusyTimeoutMs != 0 {
+		opts.BusyTimeout = time.Duration(config.BusyTimeoutMs) * time.Millisecond
+	}
+	if config.Synchronous != "" {
+		opts.Synchronous = config.Synchronous
+	}
+	if config.ForeignKeys != nil {
+		opts.ForeignKeys = *config.ForeignKeys
+	}
+	if config.CacheSize != 0 {
+		opts.CacheSize = config.CacheSize
+	}
+	if config.MaxOpenConns != 0 {
+		opts.MaxOpenConns = config.MaxOpenConns
+	}
+	if config.MaxIdleConns != 0 {
+		opts.MaxIdleConns = config.MaxIdleConns
+	}
+	return opts
+}
+
+// encryptionKey loads the content encryption key from the key file or the
+// passphrase environment variable. Disabled encryption yields the zero key.
+func encryptionKey(config *domainstorage.EncryptionConfig) (sqlite.KeySource, error) {
+	if !config.Enabled {
+		return sqlite.KeySource{}, nil
+	}
+	if config.KeyFile != "" {
+		return sqlite.KeyFromFile(config.KeyFile)
+	}
+	passphrase := os.Getenv(config.PassphraseEnv)
+	if passphrase == "" {
+		return sqlite.KeySource{}, fmt.Errorf("encryption is enabled but $%s is not set", config.PassphraseEnv)
+	}
+	return sqlite.PassphraseKey(passphrase), nil
+}
+
+// backupPolicy resolves the snapshot settings for a SQLite database. A relative
+// or empty directory is taken relative to the database file.
+func backupPolicy(config *domainstorage.SQLiteConfig) sqlite.BackupPolicy {
+	dir := config.Backup.Dir
+	if dir == "" {
+		dir = "backups"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(config.FilePath), dir)
+	}
+	return sqlite.BackupPolicy{
+		Dir:       dir,
+		KeepCount: config.Backup.KeepCount,
+		MaxAge:    time.Duration(config.Backup.KeepDays) * 24 * time.Hour,
+	}
+}
+
+// attachmentPolicy resolves the attachment settings for a SQLite database. A
+// relative or empty directory is taken relative to the database file.
+func attachmentPolicy(config *domainstorage.SQLiteConfig) sqlite.AttachmentPolicy {
+	dir := config.Attachments.Dir
+	if dir == "" {
+		dir = "attachments"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(config.FilePath), dir)
+	}
+	return sqlite.AttachmentPolicy{
+		Dir:     dir,
+		MaxSize: int64(config.Attachments.MaxSizeMB) << 20,
+	}
 }
 
 // NewAPIStorage creates a new API storage implementation
@@ -76,5 +232,58 @@ func NewAPIStorage(config *domainstorage.APIConfig, log logger.Logger) (domainst
 
 	log.Info("API storage created successfully", "base_url", config.BaseURL)
 
+	if config.OutboxPath == "" {
+		return store, nil
+	}
+	queue, err := outbox.OpenQueue(config.OutboxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+	log.Info("Writes are queued while the API is unreachable", "outbox_path", config.OutboxPath)
+
+	return outbox.New(store, queue, log), nil
+}
+
+// NewMemoryStorage creates an in-memory storage, seeded from a JSON snapshot
+// when one is configured
+func NewMemoryStorage(config *domainstorage.MemoryConfig, log logger.Logger) (domainstorage.UnifiedNoteStorage, error) {
+	if config == nil {
+		return nil, fmt.Errorf("memory configuration is required")
+	}
+
+	if config.SnapshotPath == "" {
+		log.Info("Memory storage created; notes are discarded on exit")
+		return memory.New(), nil
+	}
+
+	store, err := memory.NewFromSnapshot(config.SnapshotPath, config.SaveOnClose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memory store: %w", err)
+	}
+
+	log.Info("Memory storage created from snapshot", "snapshot_path", config.SnapshotPath, "save_on_close", config.SaveOnClose)
+
+	return store, nil
+}
+
+// NewMarkdownStorage creates a storage that keeps one Markdown file per note
+func NewMarkdownStorage(config *domainstorage.MarkdownConfig, log logger.Logger) (domainstorage.UnifiedNoteStorage, error) {
+	if config == nil {
+		return nil, fmt.Errorf("markdown configuration is required")
+	}
+
+	if config.Dir == "" {
+		return nil, fmt.Errorf("markdown directory is required")
+	}
+
+	log.Debug("Creating Markdown storage", "dir", config.Dir)
+
+	store, err := markdown.New(config.Dir, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Markdown store: %w", err)
+	}
+
+	log.Info("Markdown storage created successfully", "dir", config.Dir)
+
 	return store, nil
 }
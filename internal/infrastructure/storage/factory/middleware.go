@@ -0,0 +1,84 @@
+package factory
+
+import (
+	"fmt"
+	"time"
+
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/decorator"
+)
+
+// Defaults for middleware settings left at zero
+const (
+	defaultCacheSize     = 1000
+	defaultRetryAttempts = 3
+	defaultRetryDelay    = 100 * time.Millisecond
+	defaultTimeout       = 10 * time.Second
+)
+
+// applyMiddleware wraps store in the configured decorators. The first entry
+// is the outermost, so it sees every call first.
+func applyMiddleware(
+	store domainstorage.UnifiedNoteStorage,
+	middleware []domainstorage.MiddlewareConfig,
+	log logger.Logger,
+) (domainstorage.UnifiedNoteStorage, error) {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		wrapped, err := newMiddleware(store, middleware[i], log)
+		if err != nil {
+			return nil, fmt.Errorf("storage.middleware[%d]: %w", i, err)
+		}
+		store = wrapped
+	}
+	if len(middleware) > 0 {
+		log.Info("Storage middleware enabled", "count", len(middleware))
+	}
+	return store, nil
+}
+
+// newMiddleware wraps store in one decorator
+func newMiddleware(
+	store domainstorage.UnifiedNoteStorage,
+	config domainstorage.MiddlewareConfig,
+	log logger.Logger,
+) (domainstorage.UnifiedNoteStorage, error) {
+	switch config.Type {
+	case domainstorage.MiddlewareMetrics:
+		return decorator.NewMetrics(store, decorator.DefaultStats), nil
+	case domainstorage.MiddlewareLogging:
+		return decorator.NewLogging(store, log, time.Duration(config.SlowMs)*time.Millisecond), nil
+	case domainstorage.MiddlewareCache:
+		size := config.Size
+		if size == 0 {
+			size = defaultCacheSize
+		}
+		if size < 0 || config.TTLSeconds < 0 {
+			return nil, fmt.Errorf("cache size and ttl_seconds must not be negative")
+		}
+		return decorator.NewCache(store, size, time.Duration(config.TTLSeconds)*time.Second), nil
+	case domainstorage.MiddlewareRetry:
+		attempts, delay := config.Attempts, time.Duration(config.DelayMs)*time.Millisecond
+		if attempts == 0 {
+			attempts = defaultRetryAttempts
+		}
+		if delay == 0 {
+			delay = defaultRetryDelay
+		}
+		if attempts < 0 || delay < 0 {
+			return nil, fmt.Errorf("retry attempts and delay_ms must not be negative")
+		}
+		return decorator.NewRetry(store, attempts, delay), nil
+	case domainstorage.MiddlewareTimeout:
+		timeout := time.Duration(config.TimeoutMs) * time.Millisecond
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+		if timeout < 0 {
+			return nil, fmt.Errorf("timeout_ms must not be negative")
+		}
+		return decorator.NewTimeout(store, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown middleware type %q", config.Type)
+	}
+}
@@ -0,0 +1,20 @@
+package markdown
+
+import (
+	"testing"
+
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/conformance"
+)
+
+func TestStore_Conformance(t *testing.T) {
+	t.Parallel()
+	conformance.Run(t, func(t *testing.T) domainstorage.UnifiedNoteStorage {
+		store, err := New(t.TempDir(), logger.NewNoopLogger())
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return store
+	})
+}
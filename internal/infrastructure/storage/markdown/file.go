@@ -0,0 +1,184 @@
+// Package markdown stores notes as Markdown files with YAML front matter
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+const (
+	// noteExt is the extension of note files; other files are ignored
+	noteExt = ".md"
+	// delimiter opens and closes the front matter block
+	delimiter = "---"
+)
+
+// frontMatter is the YAML header of a note file. Keys Godo does not know are
+// kept in Extra and written back unchanged.
+type frontMatter struct {
+	ID         string         `yaml:"id"`
+	Done       bool           `yaml:"done"`
+	CreatedAt  time.Time      `yaml:"created_at"`
+	UpdatedAt  time.Time      `yaml:"updated_at"`
+	ArchivedAt *time.Time     `yaml:"archived_at,omitempty"`
+	Tags       []string       `yaml:"tags,omitempty,flow"`
+	Extra      map[string]any `yaml:",inline"`
+}
+
+// noteFile is a note together with the file it lives in
+type noteFile struct {
+	note  model.Note
+	name  string
+	extra map[string]any
+}
+
+// isNoteFile reports whether name is a note file rather than a hidden,
+// temporary or unrelated file
+func isNoteFile(name string) bool {
+	base := filepath.Base(name)
+	return filepath.Ext(base) == noteExt && !strings.HasPrefix(base, ".")
+}
+
+// parseNoteFile decodes a note file. When the front matter is missing or
+// broken it returns an error together with a stand-in note, listed under
+// the file name with the whole file as its content, so the note stays
+// visible and saving it from Godo repairs the file.
+func parseNoteFile(name string, data []byte, modTime time.Time) (noteFile, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	fallback := noteFile{
+		name: name,
+		note: model.Note{
+			ID:        strings.TrimSuffix(name, noteExt),
+			Content:   strings.TrimSuffix(text, "\n"),
+			CreatedAt: modTime,
+			UpdatedAt: modTime,
+		},
+	}
+
+	header, body, err := splitFrontMatter(text)
+	if err != nil {
+		return fallback, err
+	}
+	var fm frontMatter
+	if err = yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return fallback, fmt.Errorf("invalid front matter: %w", err)
+	}
+	if strings.TrimSpace(fm.ID) == "" {
+		return fallback, errors.New("front matter has no id")
+	}
+
+	// Hand-written files may leave out the timestamps
+	if fm.CreatedAt.IsZero() {
+		fm.CreatedAt = modTime
+	}
+	if fm.UpdatedAt.IsZero() {
+		fm.UpdatedAt = modTime
+	}
+	return noteFile{
+		name: name,
+		note: model.Note{
+			ID:         fm.ID,
+			Content:    strings.TrimSuffix(body, "\n"),
+			Done:       fm.Done,
+			CreatedAt:  fm.CreatedAt,
+			UpdatedAt:  fm.UpdatedAt,
+			ArchivedAt: fm.ArchivedAt,
+			Tags:       fileTags(fm.Tags),
+		},
+		extra: fm.Extra,
+	}, nil
+}
+
+// fileTags normalizes the tags of a hand-written file, dropping names Godo
+// would refuse so the note can still be saved
+func fileTags(tags []string) []string {
+	return slices.DeleteFunc(model.NormalizeTags(tags), func(tag string) bool {
+		return model.CheckTag(tag) != nil
+	})
+}
+
+// splitFrontMatter separates the YAML block between the opening and closing
+// delimiter lines from the body that follows it
+func splitFrontMatter(text string) (string, string, error) {
+	rest, ok := strings.CutPrefix(text, delimiter+"\n")
+	if !ok {
+		return "", "", errors.New("file does not start with front matter")
+	}
+	if body, found := strings.CutPrefix(rest, delimiter+"\n"); found {
+		return "", body, nil
+	}
+	if header, body, found := strings.Cut(rest, "\n"+delimiter+"\n"); found {
+		return header, body, nil
+	}
+	if header, found := strings.CutSuffix(rest, "\n"+delimiter); found {
+		return header, "", nil
+	}
+	return "", "", errors.New("front matter is not closed")
+}
+
+// formatNoteFile encodes a note as front matter followed by its content
+func formatNoteFile(nf noteFile) ([]byte, error) {
+	header, err := yaml.Marshal(frontMatter{
+		ID:         nf.note.ID,
+		Done:       nf.note.Done,
+		CreatedAt:  nf.note.CreatedAt.UTC(),
+		UpdatedAt:  nf.note.UpdatedAt.UTC(),
+		ArchivedAt: utcTime(nf.note.ArchivedAt),
+		Tags:       nf.note.Tags,
+		Extra:      nf.extra,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode front matter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(delimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(delimiter + "\n")
+	buf.WriteString(nf.note.Content)
+	if !strings.HasSuffix(nf.note.Content, "\n") {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// utcTime returns t in UTC, or nil when t is nil
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// writeFileAtomic writes data to a hidden temporary file in dir and renames
+// it over name, so readers and sync tools never see a half-written note
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
@@ -0,0 +1,461 @@
+package markdown
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
+)
+
+// Store keeps each note in its own Markdown file in one directory. It
+// implements the domain's UnifiedNoteStorage, Archiver, Tagger, Importer and
+// ProblemReporter. Files changed by other programs are picked up by a watcher; files whose front
+// matter cannot be read are still listed and reported through Problems.
+// Files carry no version, so notes are always at version 0.
+type Store struct {
+	dir string
+	log logger.Logger
+
+	mu     sync.RWMutex
+	notes  map[string]*noteFile
+	byName map[string]string
+	// problems is keyed by file name
+	problems map[string]model.StorageProblem
+	closed   bool
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// New opens the note directory at dir, creating it if needed, loads every
+// note file in it and starts watching it for changes. A watcher that cannot
+// be started is logged and the store works without it.
+func New(dir string, log logger.Logger) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create note directory: %w", err)
+	}
+
+	s := &Store{
+		dir:      dir,
+		log:      log,
+		notes:    make(map[string]*noteFile),
+		byName:   make(map[string]string),
+		problems: make(map[string]model.StorageProblem),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && isNoteFile(entry.Name()) {
+			s.load(entry.Name())
+		}
+	}
+
+	if err = s.watch(); err != nil {
+		log.Warn("Failed to watch note directory, external edits need a restart", "dir", dir, "error", err)
+	}
+	return s, nil
+}
+
+// CreateNote creates a new note in <id>.md
+func (s *Store) CreateNote(_ context.Context, content string) (*model.Note, error) {
+	note := model.NewNote(content)
+	if err := note.IsValid(); err != nil {
+		return nil, err
+	}
+	note.Version = 0
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	name := note.ID + noteExt
+	if _, exists := s.notes[note.ID]; exists {
+		return nil, model.ErrDuplicateID
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, name)); err == nil {
+		return nil, model.ErrDuplicateID
+	}
+	if err := s.save(&noteFile{note: *note, name: name}); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// GetNote retrieves a note by ID
+func (s *Store) GetNote(_ context.Context, id string) (*model.Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	nf, exists := s.notes[id]
+	if !exists {
+		return nil, &errors.NotFoundError{ID: id}
+	}
+	note := nf.note
+	return &note, nil
+}
+
+// GetAllNotes returns every note, newest first
+func (s *Store) GetAllNotes(_ context.Context) ([]*model.Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	notes := make([]*model.Note, 0, len(s.notes))
+	for _, nf := range s.notes {
+		note := nf.note
+		notes = append(notes, &note)
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].CreatedAt.Equal(notes[j].CreatedAt) {
+			return notes[i].CreatedAt.After(notes[j].CreatedAt)
+		}
+		return notes[i].ID > notes[j].ID
+	})
+	return notes, nil
+}
+
+// UpdateNote replaces a note's content and done state
+func (s *Store) UpdateNote(_ context.Context, id string, content string, done bool) (*model.Note, error) {
+	return s.modify(id, func(note *model.Note) {
+		note.UpdateContent(content)
+		note.Done = done
+	})
+}
+
+// DeleteNote removes a note's file
+func (s *Store) DeleteNote(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.ErrStoreClosed
+	}
+
+	nf, exists := s.notes[id]
+	if !exists {
+		return &errors.NotFoundError{ID: id}
+	}
+	if err := os.Remove(filepath.Join(s.dir, nf.name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete note file: %w", err)
+	}
+	s.forget(nf.name)
+	return nil
+}
+
+// ToggleDone flips the done status of a note
+func (s *Store) ToggleDone(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, (*model.Note).ToggleDone)
+}
+
+// MarkDone marks a note as done
+func (s *Store) MarkDone(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, (*model.Note).MarkDone)
+}
+
+// MarkUndone marks a note as not done
+func (s *Store) MarkUndone(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, (*model.Note).MarkUndone)
+}
+
+// ArchiveNote archives a note, recording archived_at in its front matter
+func (s *Store) ArchiveNote(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, func(note *model.Note) {
+		if note.ArchivedAt == nil {
+			now := time.Now().UTC()
+			note.ArchivedAt = &now
+		}
+	})
+}
+
+// UnarchiveNote takes a note out of the archive
+func (s *Store) UnarchiveNote(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, func(note *model.Note) {
+		note.ArchivedAt = nil
+	})
+}
+
+// SetNoteTags replaces the tags of a note, listing them in its front matter
+func (s *Store) SetNoteTags(_ context.Context, id string, tags []string) (*model.Note, error) {
+	return s.modify(id, func(note *model.Note) {
+		note.Tags = model.NormalizeTags(tags)
+		note.UpdatedAt = time.Now()
+	})
+}
+
+// ListTags returns every tag in use with the number of notes carrying it,
+// ordered by name
+func (s *Store) ListTags(_ context.Context) ([]model.TagCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	counts := make(map[string]int)
+	for _, nf := range s.notes {
+		for _, tag := range nf.note.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]model.TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, model.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// RenameTag moves every note from one tag to another, merging the two when
+// to is already in use. Files are rewritten one at a time under the lock, so
+// a write error leaves the notes before it renamed.
+func (s *Store) RenameTag(_ context.Context, from, to string) (int, error) {
+	from, to = model.NormalizeTag(from), model.NormalizeTag(to)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errors.ErrStoreClosed
+	}
+
+	var carrying []*noteFile
+	for _, nf := range s.notes {
+		if slices.Contains(nf.note.Tags, from) {
+			carrying = append(carrying, nf)
+		}
+	}
+	if len(carrying) == 0 {
+		return 0, fmt.Errorf("%w: %s", model.ErrTagNotFound, from)
+	}
+	if from == to {
+		return 0, nil
+	}
+
+	now := time.Now()
+	for i, stored := range carrying {
+		nf := *stored
+		tags := slices.DeleteFunc(slices.Clone(nf.note.Tags), func(tag string) bool { return tag == from })
+		nf.note.Tags = model.NormalizeTags(append(tags, to))
+		nf.note.UpdatedAt = now
+		if err := s.save(&nf); err != nil {
+			return i, err
+		}
+	}
+	return len(carrying), nil
+}
+
+// ImportNotes writes notes with their own IDs, done state and timestamps. A
+// new note goes to <id>.md, so IDs that are not plain file names are
+// rejected; a stored note is kept or rewritten in its own file according to
+// policy. Files are written one at a time, so a write error leaves the notes
+// before it imported.
+func (s *Store) ImportNotes(_ context.Context, notes []model.Note, policy model.ConflictPolicy) (*model.ImportReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	report := &model.ImportReport{}
+	for _, note := range notes {
+		if err := model.CheckImport(&note); err != nil {
+			report.Add(note.ID, model.ImportFailed, err)
+			continue
+		}
+		note.Version = 0
+		note.DeletedAt = nil
+		note.Tags = model.NormalizeTags(note.Tags)
+
+		outcome := model.ImportUpdated
+		nf, exists := s.notes[note.ID]
+		switch {
+		case !exists:
+			name := note.ID + noteExt
+			if filepath.Base(name) != name || !isNoteFile(name) {
+				report.Add(note.ID, model.ImportFailed, fmt.Errorf("note ID %q cannot be used as a file name", note.ID))
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(s.dir, name)); err == nil {
+				report.Add(note.ID, model.ImportFailed, model.ErrDuplicateID)
+				continue
+			}
+			nf = &noteFile{name: name}
+			outcome = model.ImportCreated
+		case !policy.Replaces(&nf.note, &note):
+			report.Add(note.ID, model.ImportSkipped, nil)
+			continue
+		}
+
+		// Front matter keys Godo does not know are kept, as modify keeps them
+		updated := *nf
+		updated.note = note
+		if err := s.save(&updated); err != nil {
+			return nil, err
+		}
+		report.Add(note.ID, outcome, nil)
+	}
+	return report, nil
+}
+
+// Problems lists the note files that could not be read cleanly, by file name
+func (s *Store) Problems(_ context.Context) ([]model.StorageProblem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	problems := make([]model.StorageProblem, 0, len(s.problems))
+	for _, problem := range s.problems {
+		problems = append(problems, problem)
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Source < problems[j].Source })
+	return problems, nil
+}
+
+// Close stops the watcher and makes every later call fail with
+// errors.ErrStoreClosed. Closing an already closed store does nothing.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.notes = nil
+	s.byName = nil
+	s.problems = nil
+	s.mu.Unlock()
+
+	if s.watcher == nil {
+		return nil
+	}
+	err := s.watcher.Close()
+	<-s.done
+	return err
+}
+
+// modify applies change to a note and writes its file, all under one lock so
+// that read-modify-write helpers such as ToggleDone are atomic. Saving a note
+// whose file was broken rewrites it with valid front matter.
+func (s *Store) modify(id string, change func(note *model.Note)) (*model.Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	stored, exists := s.notes[id]
+	if !exists {
+		return nil, &errors.NotFoundError{ID: id}
+	}
+	nf := *stored
+	change(&nf.note)
+	if err := nf.note.IsValid(); err != nil {
+		return nil, err
+	}
+	nf.note.ID = id
+	nf.note.Version = 0
+	if err := s.save(&nf); err != nil {
+		return nil, err
+	}
+	note := nf.note
+	return &note, nil
+}
+
+// save writes a note file and indexes it. Callers hold the write lock.
+func (s *Store) save(nf *noteFile) error {
+	data, err := formatNoteFile(*nf)
+	if err != nil {
+		return err
+	}
+	if err = writeFileAtomic(s.dir, nf.name, data); err != nil {
+		return fmt.Errorf("failed to write note file: %w", err)
+	}
+	s.notes[nf.note.ID] = nf
+	s.byName[nf.name] = nf.note.ID
+	delete(s.problems, nf.name)
+	return nil
+}
+
+// load reads one note file into the index, replacing whatever the file held
+// before. A file that is gone is dropped from the index; a file that cannot
+// be parsed is indexed under its stand-in note and recorded as a problem.
+// Callers hold the write lock or have not shared the store yet.
+func (s *Store) load(name string) {
+	s.forget(name)
+
+	path := filepath.Join(s.dir, name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		s.retryShadowed()
+		return
+	}
+	var data []byte
+	if err == nil {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		s.report(name, "", fmt.Sprintf("cannot read file: %v", err))
+		return
+	}
+
+	nf, parseErr := parseNoteFile(name, data, info.ModTime().UTC())
+	if other, taken := s.notes[nf.note.ID]; taken {
+		s.report(name, "", fmt.Sprintf("note %s is already stored in %s", nf.note.ID, other.name))
+		return
+	}
+	s.notes[nf.note.ID] = &nf
+	s.byName[name] = nf.note.ID
+	if parseErr != nil {
+		s.report(name, nf.note.ID, parseErr.Error())
+	}
+}
+
+// retryShadowed reloads files that were left out of the index, such as a
+// second file claiming an ID, since the file they clashed with may be gone.
+// Callers hold the write lock.
+func (s *Store) retryShadowed() {
+	var names []string
+	for name, problem := range s.problems {
+		if problem.NoteID == "" {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		s.load(name)
+	}
+}
+
+// forget drops a file's note and problem from the index. Callers hold the
+// write lock.
+func (s *Store) forget(name string) {
+	if id, indexed := s.byName[name]; indexed {
+		delete(s.notes, id)
+		delete(s.byName, name)
+	}
+	delete(s.problems, name)
+}
+
+// report records and logs a problem with a note file. Callers hold the write
+// lock.
+func (s *Store) report(name, noteID, message string) {
+	s.log.Warn("Note file could not be read cleanly", "file", name, "error", message)
+	s.problems[name] = model.StorageProblem{Source: name, NoteID: noteID, Message: message}
+}
@@ -0,0 +1,223 @@
+package markdown
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	storageerrors "github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
+)
+
+func openStore(t *testing.T, dir string) *Store {
+	t.Helper()
+	st, err := New(dir, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	return st
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// eventually polls cond until it holds, since watcher events arrive
+// asynchronously
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStore_UnifiedOperations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	var st domainstorage.UnifiedNoteStorage = openStore(t, dir)
+
+	first, err := st.CreateNote(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := st.CreateNote(ctx, "second\n\nwith a paragraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.CreateNote(ctx, ""); err == nil {
+		t.Fatal("empty content should fail validation")
+	}
+	if done, err := st.MarkDone(ctx, first.ID); err != nil || !done.Done {
+		t.Fatalf("MarkDone = %+v, %v", done, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, first.ID+".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"---\nid: " + first.ID + "\n", "done: true\n", "created_at: ", "updated_at: ", "---\nfirst\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("note file lacks %q:\n%s", want, data)
+		}
+	}
+
+	// Everything written can be read back by a fresh store
+	reopened := openStore(t, dir)
+	notes, err := reopened.GetAllNotes(ctx)
+	if err != nil || len(notes) != 2 {
+		t.Fatalf("GetAllNotes = %d notes, %v", len(notes), err)
+	}
+	got, err := reopened.GetNote(ctx, second.ID)
+	if err != nil || got.Content != second.Content || !got.CreatedAt.Equal(second.CreatedAt) {
+		t.Fatalf("GetNote = %+v, %v", got, err)
+	}
+
+	if err = st.DeleteNote(ctx, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, second.ID+".md")); !os.IsNotExist(err) {
+		t.Fatalf("deleted note file still exists: %v", err)
+	}
+	if err = st.DeleteNote(ctx, second.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("second delete: err = %v, want ErrNoteNotFound", err)
+	}
+
+	// Temporary files never linger after a write
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("directory holds %d entries, %v", len(entries), err)
+	}
+}
+
+func TestStore_ReportsBrokenFiles(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, dir, "plain.md", "just some text\n")
+	writeFile(t, dir, "bad-yaml.md", "---\nid: [unclosed\n---\nbody\n")
+	writeFile(t, dir, "no-id.md", "---\ndone: true\n---\nbody\n")
+	writeFile(t, dir, "good.md", "---\r\nid: abc\r\ndone: true\r\ntags: [kept]\r\n---\r\nhand written\r\n")
+	writeFile(t, dir, "z-copy.md", "---\nid: abc\n---\ncopied\n")
+	writeFile(t, dir, "readme.txt", "not a note")
+	st := openStore(t, dir)
+
+	problems, err := st.Problems(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sources []string
+	for _, problem := range problems {
+		sources = append(sources, problem.Source)
+	}
+	if strings.Join(sources, ",") != "bad-yaml.md,no-id.md,plain.md,z-copy.md" {
+		t.Fatalf("problems = %+v", problems)
+	}
+
+	// Broken files stay visible under their file name
+	notes, err := st.GetAllNotes(ctx)
+	if err != nil || len(notes) != 4 {
+		t.Fatalf("GetAllNotes = %d notes, %v", len(notes), err)
+	}
+	good, err := st.GetNote(ctx, "abc")
+	if err != nil || good.Content != "hand written" || !good.Done {
+		t.Fatalf("hand-written note = %+v, %v", good, err)
+	}
+
+	// Saving a broken note from Godo repairs its file
+	plain, err := st.UpdateNote(ctx, "plain", "just some text, fixed", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, _ = st.Problems(ctx); len(problems) != 3 {
+		t.Fatalf("problems after repair = %+v", problems)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "plain.md"))
+	if err != nil || !strings.HasPrefix(string(data), "---\nid: plain\n") {
+		t.Fatalf("repaired file = %q, %v", data, err)
+	}
+	if plain.ID != "plain" {
+		t.Fatalf("repaired note ID = %q", plain.ID)
+	}
+
+	// Unknown front matter keys survive a rewrite
+	if _, err = st.ToggleDone(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "good.md"))
+	if err != nil || !strings.Contains(string(data), "tags:") {
+		t.Fatalf("rewritten file lost unknown keys: %q, %v", data, err)
+	}
+}
+
+func TestStore_WatchesExternalEdits(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	st := openStore(t, dir)
+	if st.watcher == nil {
+		t.Skip("file watching is not available")
+	}
+
+	writeFile(t, dir, "outside.md", "---\nid: outside\n---\nwritten elsewhere\n")
+	eventually(t, "the new file", func() bool {
+		note, err := st.GetNote(ctx, "outside")
+		return err == nil && note.Content == "written elsewhere"
+	})
+
+	writeFile(t, dir, "outside.md", "---\nid: outside\ndone: true\n---\nedited elsewhere\n")
+	eventually(t, "the edit", func() bool {
+		note, err := st.GetNote(ctx, "outside")
+		return err == nil && note.Done && note.Content == "edited elsewhere"
+	})
+
+	writeFile(t, dir, "outside.md", "no front matter any more")
+	eventually(t, "the problem report", func() bool {
+		problems, err := st.Problems(ctx)
+		return err == nil && len(problems) == 1 && problems[0].Source == "outside.md"
+	})
+
+	if err := os.Remove(filepath.Join(dir, "outside.md")); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the removal", func() bool {
+		_, err := st.GetNote(ctx, "outside")
+		problems, _ := st.Problems(ctx)
+		return errors.Is(err, model.ErrNoteNotFound) && len(problems) == 0
+	})
+}
+
+func TestStore_ClosedStoreFails(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	st := openStore(t, t.TempDir())
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if _, err := st.CreateNote(ctx, "x"); !errors.Is(err, storageerrors.ErrStoreClosed) {
+		t.Fatalf("CreateNote after Close: err = %v", err)
+	}
+	if _, err := st.GetAllNotes(ctx); !errors.Is(err, storageerrors.ErrStoreClosed) {
+		t.Fatalf("GetAllNotes after Close: err = %v", err)
+	}
+	if _, err := st.Problems(ctx); !errors.Is(err, storageerrors.ErrStoreClosed) {
+		t.Fatalf("Problems after Close: err = %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package markdown
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watch starts a watcher that reloads note files when other programs create,
+// change, rename or remove them. Godo's own writes are seen too; reloading
+// them is harmless because the file already matches the index.
+func (s *Store) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err = watcher.Add(s.dir); err != nil {
+		watcher.Close()
+		return err
+	}
+	s.watcher = watcher
+	s.done = make(chan struct{})
+	go s.watchLoop()
+	return nil
+}
+
+// watchLoop applies file events to the index until the watcher is closed
+func (s *Store) watchLoop() {
+	defer close(s.done)
+	for {
+		select {
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Base(event.Name)
+			if event.Op == fsnotify.Chmod || !isNoteFile(name) {
+				continue
+			}
+			s.mu.Lock()
+			if !s.closed {
+				s.load(name)
+			}
+			s.mu.Unlock()
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			s.log.Warn("Note directory watcher failed", "dir", s.dir, "error", err)
+		}
+	}
+}
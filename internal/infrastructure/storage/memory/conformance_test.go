@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/conformance"
+)
+
+func TestStore_Conformance(t *testing.T) {
+	t.Parallel()
+	conformance.Run(t, func(*testing.T) domainstorage.UnifiedNoteStorage {
+		return New()
+	})
+}
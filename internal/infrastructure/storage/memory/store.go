@@ -1,97 +1,393 @@
-// Package memory provides an in-memory implementation of the storage.NoteStore interface
+// Package memory provides an in-memory implementation of the storage interfaces
 package memory
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/jonesrussell/godo/internal/domain/model"
-	"github.com/jonesrussell/godo/internal/infrastructure/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
 )
 
-// Store implements storage.NoteStore interface using in-memory storage
+// Store keeps notes in memory. It implements the domain's UnifiedNoteStorage,
+// ConditionalWriter, Archiver, Tagger and Importer as well as the older
+// storage.NoteStore interface. Every method fails with errors.ErrStoreClosed once the store is closed.
 type Store struct {
-	notes map[string]model.Note
-	mu    sync.RWMutex
+	mu     sync.RWMutex
+	notes  map[string]model.Note
+	closed bool
+	// snapshot is written by Close when set; see NewFromSnapshot
+	snapshot string
 }
 
-// New creates a new memory store
+// New creates a new, empty memory store
 func New() *Store {
 	return &Store{
 		notes: make(map[string]model.Note),
 	}
 }
 
+// CreateNote creates a new note
+func (s *Store) CreateNote(_ context.Context, content string) (*model.Note, error) {
+	note := model.NewNote(content)
+	if err := note.IsValid(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+	s.notes[note.ID] = *note
+	return note, nil
+}
+
+// GetNote retrieves a note by ID
+func (s *Store) GetNote(ctx context.Context, id string) (*model.Note, error) {
+	note, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// GetAllNotes returns every note, newest first
+func (s *Store) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	notes, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*model.Note, len(notes))
+	for i := range notes {
+		result[i] = &notes[i]
+	}
+	return result, nil
+}
+
+// UpdateNote replaces a note's content and done state
+func (s *Store) UpdateNote(ctx context.Context, id string, content string, done bool) (*model.Note, error) {
+	return s.UpdateNoteIfVersion(ctx, id, content, done, 0)
+}
+
+// UpdateNoteIfVersion updates a note only if its stored version is version.
+// A zero version updates whatever version is current.
+func (s *Store) UpdateNoteIfVersion(_ context.Context, id string, content string, done bool, version int64) (*model.Note, error) {
+	return s.modify(id, version, func(note *model.Note) {
+		note.UpdateContent(content)
+		note.Done = done
+	})
+}
+
+// DeleteNote removes a note
+func (s *Store) DeleteNote(ctx context.Context, id string) error {
+	return s.DeleteNoteIfVersion(ctx, id, 0)
+}
+
+// DeleteNoteIfVersion removes a note only if its stored version is version.
+// A zero version deletes unconditionally.
+func (s *Store) DeleteNoteIfVersion(_ context.Context, id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.ErrStoreClosed
+	}
+
+	note, exists := s.notes[id]
+	if !exists {
+		return &errors.NotFoundError{ID: id}
+	}
+	if version != 0 && version != note.Version {
+		return fmt.Errorf("note %s is at version %d, not %d: %w", id, note.Version, version, model.ErrVersionConflict)
+	}
+	delete(s.notes, id)
+	return nil
+}
+
+// ToggleDone flips the done status of a note
+func (s *Store) ToggleDone(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, 0, (*model.Note).ToggleDone)
+}
+
+// MarkDone marks a note as done
+func (s *Store) MarkDone(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, 0, (*model.Note).MarkDone)
+}
+
+// MarkUndone marks a note as not done
+func (s *Store) MarkUndone(_ context.Context, id string) (*model.Note, error) {
+	return s.modify(id, 0, (*model.Note).MarkUndone)
+}
+
 // Add adds a new note to the store
 func (s *Store) Add(_ context.Context, note *model.Note) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.closed {
+		return errors.ErrStoreClosed
+	}
 
 	if _, exists := s.notes[note.ID]; exists {
-		return storage.ErrDuplicateID
+		return model.ErrDuplicateID
 	}
-
+	if note.Version == 0 {
+		note.Version = 1
+	}
+	note.Tags = model.NormalizeTags(note.Tags)
 	s.notes[note.ID] = *note
 	return nil
 }
 
+// ArchiveNote archives a note
+func (s *Store) ArchiveNote(_ context.Context, id string) (*model.Note, error) {
+	return s.setArchived(id, true)
+}
+
+// UnarchiveNote takes a note out of the archive
+func (s *Store) UnarchiveNote(_ context.Context, id string) (*model.Note, error) {
+	return s.setArchived(id, false)
+}
+
+// SetNoteTags replaces the tags of a note, bumping its version
+func (s *Store) SetNoteTags(_ context.Context, id string, tags []string) (*model.Note, error) {
+	return s.modify(id, 0, func(note *model.Note) {
+		note.Tags = model.NormalizeTags(tags)
+		note.UpdatedAt = time.Now()
+	})
+}
+
+// ListTags returns every tag in use with the number of notes carrying it,
+// ordered by name
+func (s *Store) ListTags(_ context.Context) ([]model.TagCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	counts := make(map[string]int)
+	for _, note := range s.notes {
+		for _, tag := range note.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]model.TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, model.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// RenameTag moves every note from one tag to another, merging the two when
+// to is already in use. The whole rename runs under one lock.
+func (s *Store) RenameTag(_ context.Context, from, to string) (int, error) {
+	from, to = model.NormalizeTag(from), model.NormalizeTag(to)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errors.ErrStoreClosed
+	}
+
+	var carrying []string
+	for id, note := range s.notes {
+		if slices.Contains(note.Tags, from) {
+			carrying = append(carrying, id)
+		}
+	}
+	if len(carrying) == 0 {
+		return 0, fmt.Errorf("%w: %s", model.ErrTagNotFound, from)
+	}
+	if from == to {
+		return 0, nil
+	}
+
+	now := time.Now()
+	for _, id := range carrying {
+		note := s.notes[id]
+		tags := slices.DeleteFunc(slices.Clone(note.Tags), func(tag string) bool { return tag == from })
+		note.Tags = model.NormalizeTags(append(tags, to))
+		note.UpdatedAt = now
+		note.Version++
+		s.notes[id] = note
+	}
+	return len(carrying), nil
+}
+
+// ImportNotes stores notes with their own IDs, done state and timestamps,
+// keeping or replacing a stored note with the same ID according to policy.
+// The whole import runs under one lock.
+func (s *Store) ImportNotes(_ context.Context, notes []model.Note, policy model.ConflictPolicy) (*model.ImportReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+
+	report := &model.ImportReport{}
+	for _, note := range notes {
+		if err := model.CheckImport(&note); err != nil {
+			report.Add(note.ID, model.ImportFailed, err)
+			continue
+		}
+		note.DeletedAt = nil
+		note.Tags = model.NormalizeTags(note.Tags)
+		stored, exists := s.notes[note.ID]
+		switch {
+		case !exists:
+			note.Version = 1
+			report.Add(note.ID, model.ImportCreated, nil)
+		case policy.Replaces(&stored, &note):
+			note.Version = stored.Version + 1
+			report.Add(note.ID, model.ImportUpdated, nil)
+		default:
+			report.Add(note.ID, model.ImportSkipped, nil)
+			continue
+		}
+		s.notes[note.ID] = note
+	}
+	return report, nil
+}
+
 // GetByID retrieves a note by its ID
 func (s *Store) GetByID(_ context.Context, id string) (model.Note, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if s.closed {
+		return model.Note{}, errors.ErrStoreClosed
+	}
 
 	note, exists := s.notes[id]
 	if !exists {
-		return model.Note{}, storage.ErrNoteNotFound
+		return model.Note{}, &errors.NotFoundError{ID: id}
 	}
-
+	note.Tags = slices.Clone(note.Tags)
 	return note, nil
 }
 
-// List returns all notes in the store
+// List returns all notes in the store, newest first
 func (s *Store) List(_ context.Context) ([]model.Note, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
+	return s.sorted(), nil
+}
 
-	notes := make([]model.Note, 0, len(s.notes))
-	for _, note := range s.notes {
-		notes = append(notes, note)
+// Update replaces an existing note. When note.Version is set the write only
+// applies if it is still the stored version; on success note.Version holds the new one.
+func (s *Store) Update(_ context.Context, note *model.Note) error {
+	updated, err := s.modify(note.ID, note.Version, func(stored *model.Note) {
+		version := stored.Version
+		*stored = *note
+		stored.Version = version
+		stored.Tags = model.NormalizeTags(note.Tags)
+	})
+	if err != nil {
+		return err
 	}
+	note.Version = updated.Version
+	return nil
+}
 
-	return notes, nil
+// Delete removes a note from the store
+func (s *Store) Delete(ctx context.Context, id string) error {
+	return s.DeleteNoteIfVersion(ctx, id, 0)
 }
 
-// Update updates an existing note
-func (s *Store) Update(_ context.Context, note *model.Note) error {
+// Close releases the notes and makes every later call fail with
+// errors.ErrStoreClosed. A store created from a snapshot writes it back
+// first. Closing an already closed store does nothing.
+func (s *Store) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	if _, exists := s.notes[note.ID]; !exists {
-		return storage.ErrNoteNotFound
+	if s.closed {
+		return nil
 	}
 
-	s.notes[note.ID] = *note
-	return nil
+	var err error
+	if s.snapshot != "" {
+		err = writeSnapshot(s.snapshot, s.sorted())
+	}
+	s.closed = true
+	s.notes = nil
+	return err
 }
 
-// Delete removes a note from the store
-func (s *Store) Delete(_ context.Context, id string) error {
+// modify applies change to a stored note and bumps its version, all under
+// one lock so that read-modify-write helpers such as ToggleDone are atomic.
+// A non-zero version must match the stored version.
+func (s *Store) modify(id string, version int64, change func(note *model.Note)) (*model.Note, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
 
-	if _, exists := s.notes[id]; !exists {
-		return storage.ErrNoteNotFound
+	note, exists := s.notes[id]
+	if !exists {
+		return nil, &errors.NotFoundError{ID: id}
+	}
+	if version != 0 && version != note.Version {
+		return nil, fmt.Errorf("note %s is at version %d, not %d: %w", id, note.Version, version, model.ErrVersionConflict)
 	}
 
-	delete(s.notes, id)
-	return nil
+	note.Tags = slices.Clone(note.Tags)
+	change(&note)
+	if err := note.IsValid(); err != nil {
+		return nil, err
+	}
+	note.ID = id
+	note.Version++
+	s.notes[id] = note
+	note.Tags = slices.Clone(note.Tags)
+	return &note, nil
 }
 
-// Close implements storage.NoteStore interface
-func (s *Store) Close() error {
+// setArchived archives or unarchives a note, bumping its version only when
+// that changes anything
+func (s *Store) setArchived(id string, archived bool) (*model.Note, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.ErrStoreClosed
+	}
 
-	s.notes = make(map[string]model.Note)
-	return nil
+	note, exists := s.notes[id]
+	if !exists {
+		return nil, &errors.NotFoundError{ID: id}
+	}
+	if (note.ArchivedAt != nil) == archived {
+		return &note, nil
+	}
+	note.ArchivedAt = nil
+	if archived {
+		now := time.Now().UTC()
+		note.ArchivedAt = &now
+	}
+	note.Version++
+	s.notes[id] = note
+	return &note, nil
+}
+
+// sorted returns a copy of the notes ordered like the SQLite store lists
+// them: newest first, ties broken by ID. Callers hold the lock.
+func (s *Store) sorted() []model.Note {
+	notes := make([]model.Note, 0, len(s.notes))
+	for _, note := range s.notes {
+		note.Tags = slices.Clone(note.Tags)
+		notes = append(notes, note)
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].CreatedAt.Equal(notes[j].CreatedAt) {
+			return notes[i].CreatedAt.After(notes[j].CreatedAt)
+		}
+		return notes[i].ID > notes[j].ID
+	})
+	return notes
 }
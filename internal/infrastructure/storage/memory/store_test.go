@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	storageerrors "github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
+)
+
+func TestStore_UnifiedOperations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var st domainstorage.UnifiedNoteStorage = New()
+
+	first, err := st.CreateNote(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := st.CreateNote(ctx, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.CreateNote(ctx, ""); err == nil {
+		t.Fatal("empty content should fail validation")
+	}
+
+	notes, err := st.GetAllNotes(ctx)
+	if err != nil || len(notes) != 2 {
+		t.Fatalf("GetAllNotes = %d notes, %v", len(notes), err)
+	}
+
+	toggled, err := st.ToggleDone(ctx, first.ID)
+	if err != nil || !toggled.Done || toggled.Version != 2 {
+		t.Fatalf("ToggleDone = %+v, %v", toggled, err)
+	}
+	if undone, err := st.MarkUndone(ctx, first.ID); err != nil || undone.Done {
+		t.Fatalf("MarkUndone = %+v, %v", undone, err)
+	}
+	if done, err := st.MarkDone(ctx, first.ID); err != nil || !done.Done {
+		t.Fatalf("MarkDone = %+v, %v", done, err)
+	}
+
+	updated, err := st.UpdateNote(ctx, second.ID, "second, edited", true)
+	if err != nil || updated.Content != "second, edited" || !updated.Done {
+		t.Fatalf("UpdateNote = %+v, %v", updated, err)
+	}
+
+	writer := st.(domainstorage.ConditionalWriter)
+	if _, err = writer.UpdateNoteIfVersion(ctx, second.ID, "stale", false, 1); !errors.Is(err, model.ErrVersionConflict) {
+		t.Fatalf("stale update: err = %v, want ErrVersionConflict", err)
+	}
+	if err = writer.DeleteNoteIfVersion(ctx, second.ID, updated.Version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.GetNote(ctx, second.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("deleted note: err = %v, want ErrNoteNotFound", err)
+	}
+	if err = st.DeleteNote(ctx, second.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("second delete: err = %v, want ErrNoteNotFound", err)
+	}
+}
+
+func TestStore_ToggleIsAtomic(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	st := New()
+	note, err := st.CreateNote(ctx, "flip me")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const toggles = 100
+	var wg sync.WaitGroup
+	for range toggles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, toggleErr := st.ToggleDone(ctx, note.ID); toggleErr != nil {
+				t.Error(toggleErr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := st.GetNote(ctx, note.ID)
+	if err != nil || got.Done || got.Version != toggles+1 {
+		t.Fatalf("after %d toggles: %+v, %v", toggles, got, err)
+	}
+}
+
+func TestStore_ClosedStoreFails(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	st := New()
+	note, err := st.CreateNote(ctx, "before close")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+
+	calls := map[string]func() error{
+		"CreateNote":  func() error { _, e := st.CreateNote(ctx, "x"); return e },
+		"GetNote":     func() error { _, e := st.GetNote(ctx, note.ID); return e },
+		"GetAllNotes": func() error { _, e := st.GetAllNotes(ctx); return e },
+		"UpdateNote":  func() error { _, e := st.UpdateNote(ctx, note.ID, "x", false); return e },
+		"DeleteNote":  func() error { return st.DeleteNote(ctx, note.ID) },
+		"ToggleDone":  func() error { _, e := st.ToggleDone(ctx, note.ID); return e },
+		"Add":         func() error { return st.Add(ctx, model.NewNote("x")) },
+		"SaveSnapshot": func() error {
+			return st.SaveSnapshot(filepath.Join(t.TempDir(), "notes.json"))
+		},
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, storageerrors.ErrStoreClosed) {
+			t.Errorf("%s after Close: err = %v, want ErrStoreClosed", name, err)
+		}
+	}
+}
+
+func TestStore_Snapshot(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "demo", "notes.json")
+
+	// A missing snapshot starts empty
+	st, err := NewFromSnapshot(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	note, err := st.CreateNote(ctx, "kept")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.MarkDone(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Reloading keeps IDs, state and versions; without saveOnClose the
+	// changes of this run are thrown away
+	demo, err := NewFromSnapshot(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := demo.GetNote(ctx, note.ID)
+	if err != nil || got.Content != "kept" || !got.Done || got.Version != 2 || !got.CreatedAt.Equal(note.CreatedAt) {
+		t.Fatalf("reloaded note = %+v, %v", got, err)
+	}
+	if _, err = demo.CreateNote(ctx, "scratch"); err != nil {
+		t.Fatal(err)
+	}
+	if err = demo.Close(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewFromSnapshot(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes, _ := again.GetAllNotes(ctx); len(notes) != 1 {
+		t.Fatalf("demo changes were saved: %d notes", len(notes))
+	}
+
+	// A broken snapshot is reported rather than silently replaced
+	if err = os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewFromSnapshot(path, true); err == nil {
+		t.Fatal("expected an error for a corrupt snapshot")
+	}
+}
@@ -0,0 +1,162 @@
+// Package outbox keeps API-backed storage usable while its server is
+// unreachable. Writes that cannot be sent are recorded in a small SQLite file
+// and answered with the result they will have once sent; they are replayed
+// in order when the server is back.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // SQLite driver
+)
+
+// Kind is the type of a queued write
+type Kind string
+
+// Queued write kinds
+const (
+	KindCreate Kind = "create"
+	KindUpdate Kind = "update"
+	KindDone   Kind = "done"
+	KindDelete Kind = "delete"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS operations (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	note_id TEXT NOT NULL,
+	content TEXT NOT NULL DEFAULT '',
+	done BOOLEAN NOT NULL DEFAULT 0,
+	queued_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS id_map (
+	client_id TEXT PRIMARY KEY,
+	server_id TEXT NOT NULL
+);`
+
+// Op is a write waiting to be sent. NoteID is the ID the caller used, which
+// for a note created offline is the client-generated one.
+type Op struct {
+	Seq      int64
+	Kind     Kind
+	NoteID   string
+	Content  string
+	Done     bool
+	QueuedAt time.Time
+}
+
+// Queue is the durable list of unsent writes, oldest first, along with the
+// server IDs given to notes that were created offline
+type Queue struct {
+	db *sql.DB
+}
+
+// OpenQueue opens or creates the queue file at path
+func OpenQueue(path string) (*Queue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	query := url.Values{"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)", "synchronous(FULL)"}}
+	db, err := sql.Open("sqlite", path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+	// One connection keeps the appends strictly ordered
+	db.SetMaxOpenConns(1)
+
+	if _, err = db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create outbox schema: %w", err)
+	}
+	return &Queue{db: db}, nil
+}
+
+// Append adds op to the end of the queue and sets its Seq
+func (q *Queue) Append(ctx context.Context, op *Op) error {
+	result, err := q.db.ExecContext(ctx,
+		"INSERT INTO operations (kind, note_id, content, done, queued_at) VALUES (?, ?, ?, ?, ?)",
+		op.Kind, op.NoteID, op.Content, op.Done, op.QueuedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to queue %s of note %s: %w", op.Kind, op.NoteID, err)
+	}
+	op.Seq, err = result.LastInsertId()
+	return err
+}
+
+// Pending returns the queued writes, oldest first
+func (q *Queue) Pending(ctx context.Context) ([]Op, error) {
+	rows, err := q.db.QueryContext(ctx,
+		"SELECT seq, kind, note_id, content, done, queued_at FROM operations ORDER BY seq ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ops := make([]Op, 0)
+	for rows.Next() {
+		var op Op
+		if err = rows.Scan(&op.Seq, &op.Kind, &op.NoteID, &op.Content, &op.Done, &op.QueuedAt); err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// Depth returns the number of queued writes
+func (q *Queue) Depth(ctx context.Context) (int, error) {
+	var depth int
+	err := q.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM operations").Scan(&depth)
+	return depth, err
+}
+
+// Remove drops a write once it has been sent or rejected
+func (q *Queue) Remove(ctx context.Context, seq int64) error {
+	_, err := q.db.ExecContext(ctx, "DELETE FROM operations WHERE seq = ?", seq)
+	return err
+}
+
+// Created records the server ID of a note created offline and drops the
+// create from the queue, in one transaction
+func (q *Queue) Created(ctx context.Context, seq int64, clientID, serverID string) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO id_map (client_id, server_id) VALUES (?, ?)",
+		clientID, serverID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM operations WHERE seq = ?", seq); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ServerID returns the server ID of a note created offline, or id itself
+// when it was never remapped
+func (q *Queue) ServerID(ctx context.Context, id string) (string, error) {
+	var serverID string
+	err := q.db.QueryRowContext(ctx, "SELECT server_id FROM id_map WHERE client_id = ?", id).Scan(&serverID)
+	if err == sql.ErrNoRows {
+		return id, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return serverID, nil
+}
+
+// Close closes the queue file
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
@@ -0,0 +1,563 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// Replay backoff after the server is found unreachable
+const (
+	minBackoff = 2 * time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// ErrWritesQueued is returned by conditional writes while earlier writes are
+// still waiting to be sent, since the version they name cannot be checked
+var ErrWritesQueued = errors.New("earlier writes are still queued for the server")
+
+// Store wraps API-backed storage with an outbox. Writes go straight to the
+// server while it is reachable and nothing is queued; otherwise they are
+// queued and answered optimistically. Reads show the queued writes on top of
+// the server's notes, or of the notes last seen while the server is down.
+//
+// Queued writes are sent at least once: a write that reached the server just
+// before the process stopped is sent again on the next start.
+type Store struct {
+	remote domainstorage.UnifiedNoteStorage
+	queue  *Queue
+	log    logger.Logger
+
+	// writeMu orders a direct send against the queue: while anything is
+	// queued, new writes join the queue instead of overtaking it
+	writeMu sync.Mutex
+	// flushMu lets one replay run at a time
+	flushMu sync.Mutex
+
+	knownMu sync.RWMutex
+	// known holds the notes last seen from the server, by server ID
+	known map[string]model.Note
+
+	minBackoff, maxBackoff time.Duration
+
+	kick   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New wraps remote with the outbox in queue and starts replaying any writes
+// queued by an earlier run
+func New(remote domainstorage.UnifiedNoteStorage, queue *Queue, log logger.Logger) *Store {
+	return newStore(remote, queue, log, minBackoff, maxBackoff)
+}
+
+// newStore creates a store with the given replay backoff bounds
+func newStore(remote domainstorage.UnifiedNoteStorage, queue *Queue, log logger.Logger, minDelay, maxDelay time.Duration) *Store {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Store{
+		remote:     remote,
+		queue:      queue,
+		log:        log,
+		known:      make(map[string]model.Note),
+		minBackoff: minDelay,
+		maxBackoff: maxDelay,
+		kick:       make(chan struct{}, 1),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s
+}
+
+// CreateNote creates a note. While the server is unreachable the note gets a
+// client-generated ID, which keeps working after the server assigns its own.
+func (s *Store) CreateNote(ctx context.Context, content string) (*model.Note, error) {
+	note := model.NewNote(content)
+	if err := note.IsValid(); err != nil {
+		return nil, err
+	}
+	op := &Op{Kind: KindCreate, NoteID: note.ID, Content: content, QueuedAt: note.CreatedAt}
+	return s.write(ctx, op, func(ctx context.Context, _ string) (*model.Note, error) {
+		return s.remote.CreateNote(ctx, content)
+	})
+}
+
+// GetNote retrieves a note, with any queued writes applied
+func (s *Store) GetNote(ctx context.Context, id string) (*model.Note, error) {
+	serverID, err := s.queue.ServerID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var base []*model.Note
+	note, err := s.remote.GetNote(ctx, serverID)
+	switch {
+	case err == nil:
+		s.remember(note)
+		base = []*model.Note{note}
+	case isOffline(err):
+		if cached, ok := s.cached(serverID); ok {
+			base = []*model.Note{cached}
+		}
+	case !errors.Is(err, model.ErrNoteNotFound):
+		return nil, err
+	}
+
+	notes, viewErr := s.overlay(ctx, base)
+	if viewErr != nil {
+		return nil, viewErr
+	}
+	for _, n := range notes {
+		if n.ID == serverID {
+			return n, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("note %s: %w", id, model.ErrNoteNotFound)
+}
+
+// GetAllNotes retrieves every note, with any queued writes applied. While
+// the server is unreachable the notes last seen from it are used.
+func (s *Store) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	notes, err := s.remote.GetAllNotes(ctx)
+	switch {
+	case err == nil:
+		s.rememberAll(notes)
+	case isOffline(err):
+		notes = s.cachedAll()
+	default:
+		return nil, err
+	}
+	return s.overlay(ctx, notes)
+}
+
+// UpdateNote replaces a note's content and done state
+func (s *Store) UpdateNote(ctx context.Context, id string, content string, done bool) (*model.Note, error) {
+	probe := model.Note{Content: content}
+	if err := probe.IsValid(); err != nil {
+		return nil, err
+	}
+	op := &Op{Kind: KindUpdate, NoteID: id, Content: content, Done: done, QueuedAt: time.Now()}
+	return s.write(ctx, op, func(ctx context.Context, serverID string) (*model.Note, error) {
+		return s.remote.UpdateNote(ctx, serverID, content, done)
+	})
+}
+
+// UpdateNoteIfVersion updates a note only while it is at version. The check
+// needs the server, so it fails while the server is unreachable or earlier
+// writes are queued. A zero version updates unconditionally.
+func (s *Store) UpdateNoteIfVersion(ctx context.Context, id string, content string, done bool, version int64) (*model.Note, error) {
+	writer, ok := domainstorage.As[domainstorage.ConditionalWriter](s.remote)
+	if version == 0 || !ok {
+		return s.UpdateNote(ctx, id, content, done)
+	}
+	var note *model.Note
+	err := s.direct(ctx, id, func(serverID string) (err error) {
+		note, err = writer.UpdateNoteIfVersion(ctx, serverID, content, done, version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.remember(note)
+	return note, nil
+}
+
+// DeleteNote deletes a note
+func (s *Store) DeleteNote(ctx context.Context, id string) error {
+	op := &Op{Kind: KindDelete, NoteID: id, QueuedAt: time.Now()}
+	_, err := s.write(ctx, op, func(ctx context.Context, serverID string) (*model.Note, error) {
+		if err := s.remote.DeleteNote(ctx, serverID); err != nil {
+			return nil, err
+		}
+		s.forget(serverID)
+		return nil, nil
+	})
+	return err
+}
+
+// DeleteNoteIfVersion deletes a note only while it is at version, under the
+// same conditions as UpdateNoteIfVersion
+func (s *Store) DeleteNoteIfVersion(ctx context.Context, id string, version int64) error {
+	writer, ok := domainstorage.As[domainstorage.ConditionalWriter](s.remote)
+	if version == 0 || !ok {
+		return s.DeleteNote(ctx, id)
+	}
+	return s.direct(ctx, id, func(serverID string) error {
+		if err := writer.DeleteNoteIfVersion(ctx, serverID, version); err != nil {
+			return err
+		}
+		s.forget(serverID)
+		return nil
+	})
+}
+
+// ToggleDone flips the done status of a note. It is sent as an explicit
+// done or undone, so replaying it cannot flip the note twice.
+func (s *Store) ToggleDone(ctx context.Context, id string) (*model.Note, error) {
+	note, err := s.GetNote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.setDone(ctx, id, !note.Done)
+}
+
+// MarkDone marks a note as done
+func (s *Store) MarkDone(ctx context.Context, id string) (*model.Note, error) {
+	return s.setDone(ctx, id, true)
+}
+
+// MarkUndone marks a note as not done
+func (s *Store) MarkUndone(ctx context.Context, id string) (*model.Note, error) {
+	return s.setDone(ctx, id, false)
+}
+
+// setDone sets the done status of a note
+func (s *Store) setDone(ctx context.Context, id string, done bool) (*model.Note, error) {
+	op := &Op{Kind: KindDone, NoteID: id, Done: done, QueuedAt: time.Now()}
+	return s.write(ctx, op, func(ctx context.Context, serverID string) (*model.Note, error) {
+		if done {
+			return s.remote.MarkDone(ctx, serverID)
+		}
+		return s.remote.MarkUndone(ctx, serverID)
+	})
+}
+
+// QueueDepth returns the number of writes waiting for the server
+func (s *Store) QueueDepth(ctx context.Context) (int, error) {
+	return s.queue.Depth(ctx)
+}
+
+// Flush sends the queued writes in order. It stops at the first write the
+// server cannot be reached for; writes the server rejects are logged and
+// dropped so that they do not hold up the rest.
+func (s *Store) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	ops, err := s.queue.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox: %w", err)
+	}
+	for _, op := range ops {
+		err = s.replay(ctx, op)
+		if err == nil {
+			continue
+		}
+		if isOffline(err) || ctx.Err() != nil {
+			return err
+		}
+		s.log.Warn("Server rejected a queued write, dropping it",
+			"kind", op.Kind, "note_id", op.NoteID, "queued_at", op.QueuedAt, "error", err)
+		if err = s.queue.Remove(ctx, op.Seq); err != nil {
+			return err
+		}
+	}
+	if len(ops) > 0 {
+		s.log.Info("Queued writes sent to the server", "count", len(ops))
+	}
+	return nil
+}
+
+// Close stops replaying and closes the outbox and the remote
+func (s *Store) Close() error {
+	s.cancel()
+	<-s.done
+	return errors.Join(s.queue.Close(), s.remote.Close())
+}
+
+// write sends op with send while nothing is queued, and queues it when the
+// queue is not empty or the server turns out to be unreachable
+func (s *Store) write(ctx context.Context, op *Op, send func(ctx context.Context, serverID string) (*model.Note, error)) (*model.Note, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	depth, err := s.queue.Depth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	if depth == 0 {
+		serverID, idErr := s.queue.ServerID(ctx, op.NoteID)
+		if idErr != nil {
+			return nil, idErr
+		}
+		note, sendErr := send(ctx, serverID)
+		if sendErr == nil {
+			s.remember(note)
+			return note, nil
+		}
+		if !isOffline(sendErr) {
+			return nil, sendErr
+		}
+		s.log.Info("Server unreachable, queueing write", "kind", op.Kind, "note_id", op.NoteID, "error", sendErr)
+	}
+
+	if err = s.queue.Append(ctx, op); err != nil {
+		return nil, err
+	}
+	s.wake()
+	return s.optimistic(ctx, op)
+}
+
+// direct runs a write that cannot be queued, failing with ErrWritesQueued
+// while other writes wait
+func (s *Store) direct(ctx context.Context, id string, send func(serverID string) error) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	depth, err := s.queue.Depth(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox: %w", err)
+	}
+	if depth > 0 {
+		return fmt.Errorf("conditional write of note %s: %w", id, ErrWritesQueued)
+	}
+	serverID, err := s.queue.ServerID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return send(serverID)
+}
+
+// optimistic returns the note as it will be once op is sent
+func (s *Store) optimistic(ctx context.Context, op *Op) (*model.Note, error) {
+	if op.Kind == KindDelete {
+		return nil, nil
+	}
+	serverID, err := s.queue.ServerID(ctx, op.NoteID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.overlay(ctx, s.cachedAll())
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		if note.ID == serverID {
+			return note, nil
+		}
+	}
+	// A note never seen from the server, for instance after a restart while offline
+	return &model.Note{ID: op.NoteID, Content: op.Content, Done: op.Done, UpdatedAt: op.QueuedAt}, nil
+}
+
+// replay sends one queued write and removes it from the queue
+func (s *Store) replay(ctx context.Context, op Op) error {
+	serverID, err := s.queue.ServerID(ctx, op.NoteID)
+	if err != nil {
+		return err
+	}
+
+	var note *model.Note
+	switch op.Kind {
+	case KindCreate:
+		if note, err = s.remote.CreateNote(ctx, op.Content); err != nil {
+			return err
+		}
+		if err = s.queue.Created(ctx, op.Seq, op.NoteID, note.ID); err != nil {
+			return err
+		}
+		s.remember(note)
+		return nil
+	case KindUpdate:
+		note, err = s.remote.UpdateNote(ctx, serverID, op.Content, op.Done)
+	case KindDone:
+		if op.Done {
+			note, err = s.remote.MarkDone(ctx, serverID)
+		} else {
+			note, err = s.remote.MarkUndone(ctx, serverID)
+		}
+	case KindDelete:
+		err = s.remote.DeleteNote(ctx, serverID)
+		if errors.Is(err, model.ErrNoteNotFound) {
+			err = nil
+		}
+		s.forget(serverID)
+	default:
+		err = fmt.Errorf("unknown queued write %q", op.Kind)
+	}
+	if err != nil {
+		return err
+	}
+	if note != nil {
+		s.remember(note)
+	}
+	return s.queue.Remove(ctx, op.Seq)
+}
+
+// run replays queued writes when woken and, while the server stays
+// unreachable, with exponential backoff
+func (s *Store) run(ctx context.Context) {
+	defer close(s.done)
+
+	delay := s.minBackoff
+	backingOff := false
+	// Start with an attempt, for writes queued before a restart
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.kick:
+			if backingOff {
+				// The timer retries soon enough; new writes only join the queue
+				continue
+			}
+		case <-timer.C:
+		}
+
+		err := s.Flush(ctx)
+		switch {
+		case err == nil:
+			delay = s.minBackoff
+			backingOff = false
+		case ctx.Err() != nil:
+			return
+		default:
+			s.log.Debug("Outbox replay paused", "retry_in", delay, "error", err)
+			backingOff = true
+			timer.Reset(delay)
+			delay = min(delay*2, s.maxBackoff)
+		}
+	}
+}
+
+// wake asks the replay loop for an attempt
+func (s *Store) wake() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// overlay applies the queued writes to notes, returning copies
+func (s *Store) overlay(ctx context.Context, notes []*model.Note) ([]*model.Note, error) {
+	ops, err := s.queue.Pending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	result := make([]*model.Note, 0, len(notes))
+	byID := make(map[string]*model.Note, len(notes))
+	for _, note := range notes {
+		c := *note
+		result = append(result, &c)
+		byID[c.ID] = &c
+	}
+
+	var created []*model.Note
+	deleted := map[string]bool{}
+	for _, op := range ops {
+		id, idErr := s.queue.ServerID(ctx, op.NoteID)
+		if idErr != nil {
+			return nil, idErr
+		}
+		if op.Kind == KindCreate {
+			note := &model.Note{ID: id, Content: op.Content, CreatedAt: op.QueuedAt, UpdatedAt: op.QueuedAt}
+			created = append(created, note)
+			byID[id] = note
+			continue
+		}
+		note, ok := byID[id]
+		if !ok {
+			continue
+		}
+		switch op.Kind {
+		case KindUpdate:
+			note.Content = op.Content
+			note.Done = op.Done
+		case KindDone:
+			note.Done = op.Done
+		case KindDelete:
+			deleted[id] = true
+		}
+		note.UpdatedAt = op.QueuedAt
+	}
+
+	// Notes created offline are the newest
+	view := make([]*model.Note, 0, len(created)+len(result))
+	for i := len(created) - 1; i >= 0; i-- {
+		if !deleted[created[i].ID] {
+			view = append(view, created[i])
+		}
+	}
+	for _, note := range result {
+		if !deleted[note.ID] {
+			view = append(view, note)
+		}
+	}
+	return view, nil
+}
+
+// remember caches a note read from or written to the server
+func (s *Store) remember(note *model.Note) {
+	if note == nil {
+		return
+	}
+	s.knownMu.Lock()
+	defer s.knownMu.Unlock()
+	s.known[note.ID] = *note
+}
+
+// rememberAll replaces the cache with the server's full list of notes
+func (s *Store) rememberAll(notes []*model.Note) {
+	known := make(map[string]model.Note, len(notes))
+	for _, note := range notes {
+		known[note.ID] = *note
+	}
+	s.knownMu.Lock()
+	defer s.knownMu.Unlock()
+	s.known = known
+}
+
+// forget drops a deleted note from the cache
+func (s *Store) forget(id string) {
+	s.knownMu.Lock()
+	defer s.knownMu.Unlock()
+	delete(s.known, id)
+}
+
+// cached returns the last copy of a note seen from the server
+func (s *Store) cached(id string) (*model.Note, bool) {
+	s.knownMu.RLock()
+	defer s.knownMu.RUnlock()
+	note, ok := s.known[id]
+	return &note, ok
+}
+
+// cachedAll returns the notes last seen from the server, newest first
+func (s *Store) cachedAll() []*model.Note {
+	s.knownMu.RLock()
+	notes := make([]*model.Note, 0, len(s.known))
+	for _, note := range s.known {
+		n := note
+		notes = append(notes, &n)
+	}
+	s.knownMu.RUnlock()
+
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].CreatedAt.Equal(notes[j].CreatedAt) {
+			return notes[i].CreatedAt.After(notes[j].CreatedAt)
+		}
+		return notes[i].ID < notes[j].ID
+	})
+	return notes
+}
+
+// isOffline reports whether err means the server could not be reached at all
+func isOffline(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
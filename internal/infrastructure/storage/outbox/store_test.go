@@ -0,0 +1,253 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/memory"
+)
+
+// flakyRemote is an in-memory server that can be taken offline
+type flakyRemote struct {
+	*memory.Store
+	offline atomic.Bool
+}
+
+func (r *flakyRemote) check() error {
+	if r.offline.Load() {
+		return &url.Error{Op: "Get", URL: "http://remote.test/notes", Err: errors.New("connection refused")}
+	}
+	return nil
+}
+
+func (r *flakyRemote) CreateNote(ctx context.Context, content string) (*model.Note, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r.Store.CreateNote(ctx, content)
+}
+
+func (r *flakyRemote) GetNote(ctx context.Context, id string) (*model.Note, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r.Store.GetNote(ctx, id)
+}
+
+func (r *flakyRemote) GetAllNotes(ctx context.Context) ([]*model.Note, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r.Store.GetAllNotes(ctx)
+}
+
+func (r *flakyRemote) UpdateNote(ctx context.Context, id, content string, done bool) (*model.Note, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r.Store.UpdateNote(ctx, id, content, done)
+}
+
+func (r *flakyRemote) DeleteNote(ctx context.Context, id string) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	return r.Store.DeleteNote(ctx, id)
+}
+
+func (r *flakyRemote) MarkDone(ctx context.Context, id string) (*model.Note, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r.Store.MarkDone(ctx, id)
+}
+
+func (r *flakyRemote) MarkUndone(ctx context.Context, id string) (*model.Note, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	return r.Store.MarkUndone(ctx, id)
+}
+
+func (r *flakyRemote) Close() error { return nil }
+
+// openTestStore opens a store over remote whose replay loop retries quickly
+func openTestStore(t *testing.T, path string, remote *flakyRemote) *Store {
+	t.Helper()
+	queue, err := OpenQueue(path)
+	if err != nil {
+		t.Fatalf("OpenQueue: %v", err)
+	}
+	return newStore(remote, queue, logger.NewNoopLogger(), 10*time.Millisecond, 50*time.Millisecond)
+}
+
+func depth(t *testing.T, st *Store) int {
+	t.Helper()
+	n, err := st.QueueDepth(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestStore_OfflineWritesSurviveRestart(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	remote := &flakyRemote{Store: memory.New()}
+	remote.offline.Store(true)
+
+	st := openTestStore(t, path, remote)
+	note, err := st.CreateNote(ctx, "typed while offline")
+	if err != nil {
+		t.Fatalf("CreateNote offline: %v", err)
+	}
+	if note.ID == "" || note.Content != "typed while offline" {
+		t.Fatalf("unexpected optimistic note: %+v", note)
+	}
+	if _, err = st.MarkDone(ctx, note.ID); err != nil {
+		t.Fatalf("MarkDone offline: %v", err)
+	}
+
+	notes, err := st.GetAllNotes(ctx)
+	if err != nil {
+		t.Fatalf("GetAllNotes offline: %v", err)
+	}
+	if len(notes) != 1 || notes[0].ID != note.ID || !notes[0].Done {
+		t.Fatalf("queued writes not shown: %+v", notes)
+	}
+	if got := depth(t, st); got != 2 {
+		t.Fatalf("QueueDepth = %d, want 2", got)
+	}
+	if err = st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The queue outlives the process and is sent once the server is back
+	remote.offline.Store(false)
+	st = openTestStore(t, path, remote)
+	defer st.Close()
+	waitForEmptyQueue(t, st)
+
+	sent, err := remote.Store.GetAllNotes(ctx)
+	if err != nil || len(sent) != 1 || sent[0].Content != "typed while offline" || !sent[0].Done {
+		t.Fatalf("queued writes not replayed: %+v, %v", sent, err)
+	}
+
+	// The client ID keeps working after the server assigned its own
+	updated, err := st.UpdateNote(ctx, note.ID, "edited online", false)
+	if err != nil {
+		t.Fatalf("UpdateNote by client ID: %v", err)
+	}
+	if updated.ID != sent[0].ID || updated.Content != "edited online" {
+		t.Fatalf("unexpected update result: %+v", updated)
+	}
+}
+
+func TestStore_QueuedWritesKeepOrder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	remote := &flakyRemote{Store: memory.New()}
+	existing, err := remote.Store.CreateNote(ctx, "on the server")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := openTestStore(t, filepath.Join(t.TempDir(), "outbox.db"), remote)
+	defer st.Close()
+	if _, err = st.GetAllNotes(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	remote.offline.Store(true)
+	note, err := st.CreateNote(ctx, "first draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.UpdateNote(ctx, note.ID, "second draft", false); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.DeleteNote(ctx, existing.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := st.GetAllNotes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].Content != "second draft" {
+		t.Fatalf("expected only the edited draft while offline, got %+v", notes)
+	}
+
+	remote.offline.Store(false)
+	waitForEmptyQueue(t, st)
+
+	sent, err := remote.Store.GetAllNotes(ctx)
+	if err != nil || len(sent) != 1 || sent[0].Content != "second draft" {
+		t.Fatalf("server state after replay: %+v, %v", sent, err)
+	}
+}
+
+func TestStore_DropsRejectedWrites(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	remote := &flakyRemote{Store: memory.New()}
+	remote.offline.Store(true)
+
+	st := openTestStore(t, filepath.Join(t.TempDir(), "outbox.db"), remote)
+	defer st.Close()
+	if _, err := st.UpdateNote(ctx, "missing", "edit of a note the server never had", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.CreateNote(ctx, "still sent"); err != nil {
+		t.Fatal(err)
+	}
+
+	remote.offline.Store(false)
+	waitForEmptyQueue(t, st)
+
+	sent, err := remote.Store.GetAllNotes(ctx)
+	if err != nil || len(sent) != 1 || sent[0].Content != "still sent" {
+		t.Fatalf("expected the rejected write to be dropped and the rest sent, got %+v, %v", sent, err)
+	}
+}
+
+func TestStore_ConditionalWritesNeedAnEmptyQueue(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	remote := &flakyRemote{Store: memory.New()}
+	note, err := remote.Store.CreateNote(ctx, "versioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := openTestStore(t, filepath.Join(t.TempDir(), "outbox.db"), remote)
+	defer st.Close()
+
+	remote.offline.Store(true)
+	if _, err = st.CreateNote(ctx, "queued"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.UpdateNoteIfVersion(ctx, note.ID, "changed", false, note.Version); !errors.Is(err, ErrWritesQueued) {
+		t.Fatalf("expected ErrWritesQueued, got %v", err)
+	}
+}
+
+// waitForEmptyQueue waits for the replay loop to send everything
+func waitForEmptyQueue(t *testing.T, st *Store) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for depth(t, st) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue still holds %d writes", depth(t, st))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
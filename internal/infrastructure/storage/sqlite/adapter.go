@@ -3,11 +3,20 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
 )
 
+// maxModifyAttempts bounds how often a read-modify-write is retried after
+// losing a race with another writer
+const maxModifyAttempts = 16
+
 // UnifiedAdapter adapts the existing SQLite Store to the UnifiedNoteStorage interface
 type UnifiedAdapter struct {
 	store *Store
@@ -59,30 +68,66 @@ func (a *UnifiedAdapter) GetAllNotes(ctx context.Context) ([]*model.Note, error)
 	return result, nil
 }
 
-// UpdateNote updates a note
-func (a *UnifiedAdapter) UpdateNote(ctx context.Context, id string, content string, done bool) (*model.Note, error) {
-	// First get the existing note
-	note, err := a.store.GetByID(ctx, id)
+// QueryNotes retrieves the notes matching filter
+func (a *UnifiedAdapter) QueryNotes(ctx context.Context, filter model.NoteFilter) ([]*model.Note, error) {
+	notes, err := a.store.Query(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update the note fields
-	note.Content = content
-	note.Done = done
-	note.UpdateContent(content) // This also updates UpdatedAt
+	result := make([]*model.Note, len(notes))
+	for i := range notes {
+		result[i] = &notes[i]
+	}
+
+	return result, nil
+}
 
-	// Save the updated note
-	if upErr := a.store.Update(ctx, &note); upErr != nil {
-		return nil, fmt.Errorf("failed to update note: %w", upErr)
+// PageNotes retrieves one page of the notes matching filter
+func (a *UnifiedAdapter) PageNotes(ctx context.Context, filter model.NoteFilter) (*model.NotePage, error) {
+	notes, next, err := a.store.Page(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
 
-	return &note, nil
+	page := &model.NotePage{
+		Notes:      make([]*model.Note, len(notes)),
+		NextCursor: next,
+	}
+	for i := range notes {
+		page.Notes[i] = &notes[i]
+	}
+
+	return page, nil
+}
+
+// UpdateNote updates a note
+func (a *UnifiedAdapter) UpdateNote(ctx context.Context, id string, content string, done bool) (*model.Note, error) {
+	return a.UpdateNoteIfVersion(ctx, id, content, done, 0)
+}
+
+// UpdateNoteIfVersion updates a note only if its stored version is version.
+// A zero version updates whatever version is current.
+func (a *UnifiedAdapter) UpdateNoteIfVersion(ctx context.Context, id string, content string, done bool, version int64) (*model.Note, error) {
+	note, err := a.modify(ctx, id, version, func(note *model.Note) {
+		note.Done = done
+		note.UpdateContent(content) // This also updates UpdatedAt
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	return note, nil
 }
 
 // DeleteNote deletes a note
 func (a *UnifiedAdapter) DeleteNote(ctx context.Context, id string) error {
-	if err := a.store.Delete(ctx, id); err != nil {
+	return a.DeleteNoteIfVersion(ctx, id, 0)
+}
+
+// DeleteNoteIfVersion deletes a note only if its stored version is version.
+// A zero version deletes unconditionally.
+func (a *UnifiedAdapter) DeleteNoteIfVersion(ctx context.Context, id string, version int64) error {
+	if err := a.store.DeleteIfVersion(ctx, id, version); err != nil {
 		return fmt.Errorf("failed to delete note: %w", err)
 	}
 	return nil
@@ -90,50 +135,225 @@ func (a *UnifiedAdapter) DeleteNote(ctx context.Context, id string) error {
 
 // ToggleDone toggles the done status of a note
 func (a *UnifiedAdapter) ToggleDone(ctx context.Context, id string) (*model.Note, error) {
-	note, err := a.store.GetByID(ctx, id)
+	note, err := a.modify(ctx, id, 0, (*model.Note).ToggleDone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to toggle note status: %w", err)
+	}
+	return note, nil
+}
+
+// MarkDone marks a note as done
+func (a *UnifiedAdapter) MarkDone(ctx context.Context, id string) (*model.Note, error) {
+	note, err := a.modify(ctx, id, 0, (*model.Note).MarkDone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark note as done: %w", err)
+	}
+	return note, nil
+}
+
+// MarkUndone marks a note as undone
+func (a *UnifiedAdapter) MarkUndone(ctx context.Context, id string) (*model.Note, error) {
+	note, err := a.modify(ctx, id, 0, (*model.Note).MarkUndone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark note as undone: %w", err)
+	}
+	return note, nil
+}
+
+// modify reads a note, applies change and writes it back. A non-zero version
+// must match the stored one. Otherwise the write is still made conditional on
+// the version read, and is retried when another writer changed the note in
+// between, so concurrent changes are applied one after the other, not lost.
+func (a *UnifiedAdapter) modify(ctx context.Context, id string, version int64, change func(note *model.Note)) (*model.Note, error) {
+	for attempt := 1; ; attempt++ {
+		note, err := a.store.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		change(&note)
+		if version != 0 {
+			note.Version = version
+		}
+
+		err = a.store.Update(ctx, &note)
+		if err == nil {
+			return &note, nil
+		}
+		if version != 0 || attempt == maxModifyAttempts || !errors.Is(err, model.ErrVersionConflict) {
+			return nil, err
+		}
+	}
+}
+
+// ListAuditEvents returns the audit trail recorded by the underlying store
+func (a *UnifiedAdapter) ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+	return a.store.ListAuditEvents(ctx, filter)
+}
+
+// ListRevisions returns the revision history of a note
+func (a *UnifiedAdapter) ListRevisions(ctx context.Context, noteID string) ([]model.NoteRevision, error) {
+	return a.store.ListRevisions(ctx, noteID)
+}
+
+// GetRevision returns a single revision of a note
+func (a *UnifiedAdapter) GetRevision(ctx context.Context, noteID string, revision int) (*model.NoteRevision, error) {
+	return a.store.GetRevision(ctx, noteID, revision)
+}
+
+// ListTrash returns the notes in the trash
+func (a *UnifiedAdapter) ListTrash(ctx context.Context) ([]*model.Note, error) {
+	notes, err := a.store.ListDeleted(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	note.ToggleDone()
+	result := make([]*model.Note, len(notes))
+	for i := range notes {
+		result[i] = &notes[i]
+	}
 
-	if upErr := a.store.Update(ctx, &note); upErr != nil {
-		return nil, fmt.Errorf("failed to toggle note status: %w", upErr)
+	return result, nil
+}
+
+// RestoreNote moves a note out of the trash
+func (a *UnifiedAdapter) RestoreNote(ctx context.Context, id string) (*model.Note, error) {
+	if err := a.store.Restore(ctx, id); err != nil {
+		return nil, err
 	}
+	return a.GetNote(ctx, id)
+}
 
+// ArchiveNote archives a note
+func (a *UnifiedAdapter) ArchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	note, err := a.store.Archive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 	return &note, nil
 }
 
-// MarkDone marks a note as done
-func (a *UnifiedAdapter) MarkDone(ctx context.Context, id string) (*model.Note, error) {
-	note, err := a.store.GetByID(ctx, id)
+// UnarchiveNote takes a note out of the archive
+func (a *UnifiedAdapter) UnarchiveNote(ctx context.Context, id string) (*model.Note, error) {
+	note, err := a.store.Unarchive(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	return &note, nil
+}
 
-	note.MarkDone()
+// PurgeTrash permanently removes notes deleted before the given time
+func (a *UnifiedAdapter) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return a.store.Purge(ctx, deletedBefore)
+}
 
-	if upErr := a.store.Update(ctx, &note); upErr != nil {
-		return nil, fmt.Errorf("failed to mark note as done: %w", upErr)
-	}
+// PurgeNote permanently removes one note from the trash
+func (a *UnifiedAdapter) PurgeNote(ctx context.Context, id string) error {
+	return a.store.PurgeNote(ctx, id)
+}
 
+// SetNoteTags replaces the tags of a note
+func (a *UnifiedAdapter) SetNoteTags(ctx context.Context, id string, tags []string) (*model.Note, error) {
+	note, err := a.store.SetTags(ctx, id, tags)
+	if err != nil {
+		return nil, err
+	}
 	return &note, nil
 }
 
-// MarkUndone marks a note as undone
-func (a *UnifiedAdapter) MarkUndone(ctx context.Context, id string) (*model.Note, error) {
-	note, err := a.store.GetByID(ctx, id)
+// ListTags returns every tag in use with the number of notes carrying it
+func (a *UnifiedAdapter) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	return a.store.ListTags(ctx)
+}
+
+// RenameTag moves every note from one tag to another in one transaction
+func (a *UnifiedAdapter) RenameTag(ctx context.Context, from, to string) (int, error) {
+	return a.store.RenameTag(ctx, from, to)
+}
+
+// AddAttachment stores content as a new attachment of a note
+func (a *UnifiedAdapter) AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error) {
+	return a.store.AddAttachment(ctx, noteID, name, contentType, content)
+}
+
+// ListAttachments returns the attachments of a note, oldest first
+func (a *UnifiedAdapter) ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error) {
+	return a.store.ListAttachments(ctx, noteID)
+}
+
+// OpenAttachment returns an attachment and a reader over its content
+func (a *UnifiedAdapter) OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error) {
+	return a.store.OpenAttachment(ctx, id)
+}
+
+// DeleteAttachment removes an attachment
+func (a *UnifiedAdapter) DeleteAttachment(ctx context.Context, id string) error {
+	return a.store.DeleteAttachment(ctx, id)
+}
+
+// PruneAttachments removes attachment content nothing refers to
+func (a *UnifiedAdapter) PruneAttachments(ctx context.Context) (int, error) {
+	return a.store.PruneAttachments(ctx)
+}
+
+// Search runs a full-text search over note content
+func (a *UnifiedAdapter) Search(ctx context.Context, query string, limit int) ([]model.SearchHit, error) {
+	return a.store.Search(ctx, query, limit)
+}
+
+// ImportNotes stores notes with their own IDs and timestamps in one transaction
+func (a *UnifiedAdapter) ImportNotes(ctx context.Context, notes []model.Note, policy model.ConflictPolicy) (*model.ImportReport, error) {
+	return a.store.ImportNotes(ctx, notes, policy)
+}
+
+// Backup writes a snapshot of the database and rotates old ones
+func (a *UnifiedAdapter) Backup(ctx context.Context) (*model.Backup, error) {
+	backup, err := a.store.Backup(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return &backup, nil
+}
+
+// ListBackups returns the available snapshots, newest first
+func (a *UnifiedAdapter) ListBackups(ctx context.Context) ([]model.Backup, error) {
+	return a.store.ListBackups(ctx)
+}
 
-	note.MarkUndone()
+// RestoreBackup replaces the database with a verified snapshot
+func (a *UnifiedAdapter) RestoreBackup(ctx context.Context, name string) error {
+	return a.store.RestoreBackup(ctx, name)
+}
 
-	if upErr := a.store.Update(ctx, &note); upErr != nil {
-		return nil, fmt.Errorf("failed to mark note as undone: %w", upErr)
+// Health returns the result of the last integrity check
+func (a *UnifiedAdapter) Health(ctx context.Context) (*model.StorageHealth, error) {
+	health := a.store.Health(ctx)
+	return &health, nil
+}
+
+// CheckIntegrity runs a full integrity check
+func (a *UnifiedAdapter) CheckIntegrity(ctx context.Context) (*model.StorageHealth, error) {
+	health, err := a.store.CheckIntegrity(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return &health, nil
+}
 
-	return &note, nil
+// Optimize refreshes planner statistics and reclaims free pages
+func (a *UnifiedAdapter) Optimize(ctx context.Context) error {
+	return a.store.Optimize(ctx)
+}
+
+// Rekey re-encrypts all note content with a key from source
+func (a *UnifiedAdapter) Rekey(ctx context.Context, source KeySource) error {
+	return a.store.Rekey(ctx, source)
+}
+
+// WithinTx runs fn with an adapter bound to a single SQLite transaction
+func (a *UnifiedAdapter) WithinTx(ctx context.Context, fn func(tx domainstorage.UnifiedNoteStorage) error) error {
+	return a.store.WithinTx(ctx, func(tx *Store) error {
+		return fn(NewUnifiedAdapter(tx))
+	})
 }
 
 // Close closes the storage
@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestStore_ArchiveBumpsVersionAndIsAudited(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("old and done")
+	note.Done = true
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+
+	archived, err := st.Archive(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if archived.ArchivedAt == nil || archived.Version != note.Version+1 || !archived.UpdatedAt.Equal(note.UpdatedAt) {
+		t.Fatalf("archive should set archived_at and bump only the version, got %+v", archived)
+	}
+	if again, againErr := st.Archive(ctx, note.ID); againErr != nil || again.Version != archived.Version {
+		t.Fatalf("archiving again should change nothing, got %+v, %v", again, againErr)
+	}
+
+	stored, err := st.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ArchivedAt == nil || !stored.ArchivedAt.Equal(*archived.ArchivedAt) {
+		t.Fatalf("archived_at not stored: %+v", stored)
+	}
+
+	hidden, err := st.Query(ctx, model.NoteFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hidden) != 0 {
+		t.Fatalf("archived note should be left out of the default query, got %d notes", len(hidden))
+	}
+
+	if _, err = st.Unarchive(ctx, note.ID); err != nil {
+		t.Fatalf("Unarchive: %v", err)
+	}
+	events, err := st.ListAuditEvents(ctx, audit.Filter{NoteID: note.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[1].Operation != audit.OperationUpdate || events[2].Operation != audit.OperationUpdate {
+		t.Fatalf("expected create plus two audited updates, got %+v", events)
+	}
+}
@@ -0,0 +1,337 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// DefaultMaxAttachmentSize is the attachment size limit of a new store
+const DefaultMaxAttachmentSize = 25 << 20
+
+// attachmentColumns is the column list shared by every attachments SELECT
+const attachmentColumns = "id, note_id, name, content_type, size, sha256, created_at"
+
+// Upload files are written under a hidden name until their hash is known;
+// ones older than staleUploadAge were left by an interrupted upload
+const (
+	uploadPrefix   = ".upload-"
+	staleUploadAge = time.Hour
+)
+
+// AttachmentPolicy controls where attachment content is kept and how large
+// an attachment may be
+type AttachmentPolicy struct {
+	// Dir holds the blob files, one per distinct SHA-256
+	Dir string
+	// MaxSize is the largest attachment accepted, in bytes; 0 accepts any size
+	MaxSize int64
+}
+
+// blobStore guards the blob directory. Adding an attachment and pruning
+// blobs hold mu, so a blob is never removed between being written and its
+// attachment row being committed.
+type blobStore struct {
+	mu     sync.Mutex
+	policy AttachmentPolicy
+}
+
+// path returns the file holding the blob with the given hex SHA-256
+func (b *blobStore) path(sum string) string {
+	return filepath.Join(b.policy.Dir, sum[:2], sum)
+}
+
+// SetAttachmentPolicy replaces the blob directory and size limit
+func (s *Store) SetAttachmentPolicy(policy AttachmentPolicy) {
+	s.blobs.mu.Lock()
+	defer s.blobs.mu.Unlock()
+	s.blobs.policy = policy
+}
+
+// AddAttachment stores content as a new attachment of a note. The content
+// is hashed while it is written, and a blob with the same SHA-256 is reused.
+func (s *Store) AddAttachment(ctx context.Context, noteID, name, contentType string, content io.Reader) (*model.Attachment, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	if s.health.readOnly() {
+		return nil, s.readOnlyError()
+	}
+
+	s.blobs.mu.Lock()
+	defer s.blobs.mu.Unlock()
+
+	upload, sum, size, err := s.writeUpload(content)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(upload)
+
+	attachment := model.Attachment{
+		ID:          uuid.New().String(),
+		NoteID:      noteID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      sum,
+		CreatedAt:   time.Now().UTC(),
+	}
+	err = s.withTx(ctx, func(q queryer) error {
+		if _, getErr := getNote(ctx, q, noteID); getErr != nil {
+			return getErr
+		}
+		if _, execErr := q.ExecContext(ctx,
+			"INSERT INTO attachments ("+attachmentColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+			attachment.ID, attachment.NoteID, attachment.Name, attachment.ContentType,
+			attachment.Size, attachment.SHA256, attachment.CreatedAt,
+		); execErr != nil {
+			return execErr
+		}
+		// A blob left behind by a failed commit is removed by the next prune
+		return s.keepUpload(upload, sum)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// writeUpload copies content to a hidden file in the blob directory,
+// returning its path, hex SHA-256 and size. Content over the size limit is
+// discarded with model.ErrAttachmentTooLarge. Callers hold blobs.mu.
+func (s *Store) writeUpload(content io.Reader) (string, string, int64, error) {
+	policy := s.blobs.policy
+	if err := os.MkdirAll(policy.Dir, 0o755); err != nil {
+		return "", "", 0, fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(policy.Dir, uploadPrefix+"*")
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to create attachment file: %w", err)
+	}
+
+	hash := sha256.New()
+	src := content
+	if policy.MaxSize > 0 {
+		// One byte past the limit is enough to tell the content is too large
+		src = io.LimitReader(content, policy.MaxSize+1)
+	}
+	size, err := io.Copy(io.MultiWriter(tmp, hash), src)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && policy.MaxSize > 0 && size > policy.MaxSize {
+		err = fmt.Errorf("attachment exceeds %d bytes: %w", policy.MaxSize, model.ErrAttachmentTooLarge)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", "", 0, err
+	}
+	return tmp.Name(), hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// keepUpload moves an upload into place as the blob for sum, or leaves the
+// existing blob when the same content is already stored. Callers hold blobs.mu.
+func (s *Store) keepUpload(upload, sum string) error {
+	path := s.blobs.path(sum)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	if err := os.Rename(upload, path); err != nil {
+		return fmt.Errorf("failed to store attachment: %w", err)
+	}
+	return nil
+}
+
+// ListAttachments returns the attachments of a note, oldest first
+func (s *Store) ListAttachments(ctx context.Context, noteID string) ([]model.Attachment, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	q := s.conn()
+	if _, err := getNote(ctx, q, noteID); err != nil {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx,
+		"SELECT "+attachmentColumns+" FROM attachments WHERE note_id = ? ORDER BY created_at ASC, id ASC",
+		noteID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]model.Attachment, 0)
+	for rows.Next() {
+		attachment, scanErr := scanAttachment(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// OpenAttachment returns an attachment together with a reader over its
+// content. The caller closes the reader.
+func (s *Store) OpenAttachment(ctx context.Context, id string) (*model.Attachment, io.ReadCloser, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := getAttachment(ctx, s.conn(), id)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(s.blobs.path(attachment.SHA256))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment %s: %w", id, err)
+	}
+	return &attachment, file, nil
+}
+
+// DeleteAttachment removes an attachment, and its blob when no other
+// attachment shares it
+func (s *Store) DeleteAttachment(ctx context.Context, id string) error {
+	s.blobs.mu.Lock()
+	defer s.blobs.mu.Unlock()
+
+	var sum string
+	err := s.withTx(ctx, func(q queryer) error {
+		attachment, err := getAttachment(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		sum = attachment.SHA256
+		_, err = q.ExecContext(ctx, "DELETE FROM attachments WHERE id = ?", id)
+		return err
+	})
+	if err != nil || s.tx != nil {
+		// A bound store leaves the blob to the next prune, as its
+		// transaction may still roll back
+		return err
+	}
+
+	var shared int
+	if err = s.conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM attachments WHERE sha256 = ?", sum).Scan(&shared); err != nil {
+		return err
+	}
+	if shared == 0 {
+		if err = os.Remove(s.blobs.path(sum)); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("Failed to remove attachment blob", "sha256", sum, "error", err)
+		}
+	}
+	return nil
+}
+
+// PruneAttachments removes blobs that no attachment refers to, such as those
+// of purged notes, and uploads left behind by an interrupted write. It
+// returns the number of files removed.
+func (s *Store) PruneAttachments(ctx context.Context) (int, error) {
+	if err := s.checkOpen(); err != nil {
+		return 0, err
+	}
+	s.blobs.mu.Lock()
+	defer s.blobs.mu.Unlock()
+
+	referenced, err := referencedBlobs(ctx, s.conn())
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	staleBefore := time.Now().Add(-staleUploadAge)
+	err = filepath.WalkDir(s.blobs.policy.Dir, func(path string, entry os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			return walkErr
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		name := entry.Name()
+		if strings.HasPrefix(name, uploadPrefix) {
+			info, infoErr := entry.Info()
+			if infoErr != nil || info.ModTime().After(staleBefore) {
+				return nil
+			}
+		} else if !isBlobName(name) || referenced[name] {
+			return nil
+		}
+		if removeErr := os.Remove(path); removeErr != nil {
+			return removeErr
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to prune attachments: %w", err)
+	}
+	if removed > 0 {
+		s.logger.Info("Pruned attachment files", "count", removed)
+	}
+	return removed, nil
+}
+
+// referencedBlobs returns the set of blob hashes attachments refer to
+func referencedBlobs(ctx context.Context, q queryer) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, "SELECT DISTINCT sha256 FROM attachments")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referenced := make(map[string]bool)
+	for rows.Next() {
+		var sum string
+		if err = rows.Scan(&sum); err != nil {
+			return nil, err
+		}
+		referenced[sum] = true
+	}
+	return referenced, rows.Err()
+}
+
+// isBlobName reports whether name is a hex SHA-256, as blob files are named
+func isBlobName(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+// getAttachment loads one attachment row
+func getAttachment(ctx context.Context, q queryer, id string) (model.Attachment, error) {
+	attachment, err := scanAttachment(q.QueryRowContext(ctx,
+		"SELECT "+attachmentColumns+" FROM attachments WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return model.Attachment{}, fmt.Errorf("%w: %s", model.ErrAttachmentNotFound, id)
+	}
+	return attachment, err
+}
+
+// scanAttachment reads a row selected with attachmentColumns
+func scanAttachment(row rowScanner) (model.Attachment, error) {
+	var a model.Attachment
+	err := row.Scan(&a.ID, &a.NoteID, &a.Name, &a.ContentType, &a.Size, &a.SHA256, &a.CreatedAt)
+	return a, err
+}
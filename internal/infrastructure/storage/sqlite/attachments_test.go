@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// countBlobs returns how many blob files the store's attachment directory holds
+func countBlobs(t *testing.T, st *Store) int {
+	t.Helper()
+	count := 0
+	err := filepath.WalkDir(st.blobs.policy.Dir, func(_ string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && isBlobName(entry.Name()) {
+			count++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestStore_AttachmentsShareContentBySHA256(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	first, second := model.NewNote("screenshot"), model.NewNote("same screenshot")
+	for _, note := range []*model.Note{first, second} {
+		if err := st.Add(ctx, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := st.AddAttachment(ctx, first.ID, "shot.png", "image/png", strings.NewReader("png bytes"))
+	if err != nil {
+		t.Fatalf("AddAttachment: %v", err)
+	}
+	b, err := st.AddAttachment(ctx, second.ID, "copy.png", "image/png", strings.NewReader("png bytes"))
+	if err != nil {
+		t.Fatalf("AddAttachment: %v", err)
+	}
+	if a.SHA256 != b.SHA256 || a.Size != int64(len("png bytes")) {
+		t.Fatalf("identical content should hash alike: %+v %+v", a, b)
+	}
+	if n := countBlobs(t, st); n != 1 {
+		t.Fatalf("identical content should be stored once, found %d blobs", n)
+	}
+
+	got, content, err := st.OpenAttachment(ctx, b.ID)
+	if err != nil {
+		t.Fatalf("OpenAttachment: %v", err)
+	}
+	body, err := io.ReadAll(content)
+	content.Close()
+	if err != nil || string(body) != "png bytes" || got.Name != "copy.png" || got.ContentType != "image/png" {
+		t.Fatalf("unexpected attachment %+v with %q, %v", got, body, err)
+	}
+
+	// Removing one attachment keeps the blob the other still uses
+	if err = st.DeleteAttachment(ctx, a.ID); err != nil {
+		t.Fatalf("DeleteAttachment: %v", err)
+	}
+	if n := countBlobs(t, st); n != 1 {
+		t.Fatalf("shared blob removed with one of its attachments")
+	}
+	if err = st.DeleteAttachment(ctx, b.ID); err != nil {
+		t.Fatalf("DeleteAttachment: %v", err)
+	}
+	if n := countBlobs(t, st); n != 0 {
+		t.Fatalf("blob should go with its last attachment, found %d", n)
+	}
+	if _, _, err = st.OpenAttachment(ctx, b.ID); !errors.Is(err, model.ErrAttachmentNotFound) {
+		t.Fatalf("expected ErrAttachmentNotFound, got %v", err)
+	}
+}
+
+func TestStore_AttachmentSizeLimit(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+	st.SetAttachmentPolicy(AttachmentPolicy{Dir: st.blobs.policy.Dir, MaxSize: 8})
+
+	note := model.NewNote("log")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.AddAttachment(ctx, note.ID, "ok.log", "text/plain", strings.NewReader("12345678")); err != nil {
+		t.Fatalf("content at the limit should be accepted: %v", err)
+	}
+	_, err := st.AddAttachment(ctx, note.ID, "big.log", "text/plain", strings.NewReader("123456789"))
+	if !errors.Is(err, model.ErrAttachmentTooLarge) {
+		t.Fatalf("expected ErrAttachmentTooLarge, got %v", err)
+	}
+
+	attachments, err := st.ListAttachments(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 1 || attachments[0].Name != "ok.log" {
+		t.Fatalf("rejected attachment should not be listed, got %+v", attachments)
+	}
+	entries, err := os.ReadDir(st.blobs.policy.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), uploadPrefix) {
+			t.Fatalf("rejected upload left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestStore_AttachmentToMissingNote(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := st.AddAttachment(ctx, "no-such-note", "a.txt", "text/plain", strings.NewReader("x"))
+	if !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("expected ErrNoteNotFound, got %v", err)
+	}
+	if _, err = st.PruneAttachments(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBlobs(t, st); n != 0 {
+		t.Fatalf("content of a failed attachment should be pruned, found %d blobs", n)
+	}
+}
+
+func TestStore_PurgeRemovesAttachments(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("with a file")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := st.AddAttachment(ctx, note.ID, "trace.log", "text/plain", strings.NewReader("panic: oops"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Delete(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBlobs(t, st); n != 1 {
+		t.Fatalf("trashed note should keep its attachments, found %d blobs", n)
+	}
+
+	if _, err = st.Purge(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if _, _, err = st.OpenAttachment(ctx, attachment.ID); !errors.Is(err, model.ErrAttachmentNotFound) {
+		t.Fatalf("purged note's attachment should be gone, got %v", err)
+	}
+	if n := countBlobs(t, st); n != 0 {
+		t.Fatalf("purged note's content should be pruned, found %d blobs", n)
+	}
+}
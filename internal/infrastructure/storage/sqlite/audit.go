@@ -0,0 +1,133 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// insertAuditSQL appends one audit row; it runs on every write
+const insertAuditSQL = "INSERT INTO audit_events (actor, operation, note_id, before_json, after_json, created_at) " +
+	"VALUES (?, ?, ?, ?, ?, ?)"
+
+// insertAuditEvent appends an audit row using the actor carried by ctx.
+// Timestamps are stored in UTC so range filters compare consistently.
+// Snapshots hold note content, so they are sealed like the content column.
+func insertAuditEvent(ctx context.Context, q queryer, op audit.Operation, noteID string, before, after *model.Note) error {
+	keys := keysOf(q)
+	beforeJSON, err := marshalSnapshot(before, keys)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalSnapshot(after, keys)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, insertAuditSQL,
+		audit.ActorFromContext(ctx), string(op), noteID, beforeJSON, afterJSON, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+	// Every audited write is also a change for the sync engine to push
+	return recordSyncChange(ctx, q, op, noteID)
+}
+
+// marshalSnapshot encodes and seals a note snapshot, mapping nil to SQL NULL
+func marshalSnapshot(note *model.Note, keys *keyring) (sql.NullString, error) {
+	if note == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(note)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	sealed, err := keys.seal(string(data))
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: sealed, Valid: true}, nil
+}
+
+// unmarshalSnapshot opens and decodes a nullable note snapshot
+func unmarshalSnapshot(raw sql.NullString, keys *keyring) (*model.Note, error) {
+	if !raw.Valid {
+		return nil, nil
+	}
+	data, err := keys.open(raw.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt audit snapshot: %w", err)
+	}
+	var note model.Note
+	if err = json.Unmarshal([]byte(data), &note); err != nil {
+		return nil, fmt.Errorf("failed to decode audit snapshot: %w", err)
+	}
+	return &note, nil
+}
+
+// ListAuditEvents returns audit events in the order they were recorded
+func (s *Store) ListAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+	var conditions []string
+	var args []any
+
+	if filter.NoteID != "" {
+		conditions = append(conditions, "note_id = ?")
+		args = append(args, filter.NoteID)
+	}
+	if filter.Since != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+
+	query := "SELECT id, actor, operation, note_id, before_json, after_json, created_at FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id ASC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]audit.Event, 0)
+	for rows.Next() {
+		var event audit.Event
+		var op string
+		var beforeJSON, afterJSON sql.NullString
+		if scanErr := rows.Scan(
+			&event.ID,
+			&event.Actor,
+			&op,
+			&event.NoteID,
+			&beforeJSON,
+			&afterJSON,
+			&event.CreatedAt,
+		); scanErr != nil {
+			return nil, scanErr
+		}
+		event.Operation = audit.Operation(op)
+
+		if event.Before, err = unmarshalSnapshot(beforeJSON, s.keys); err != nil {
+			return nil, err
+		}
+		if event.After, err = unmarshalSnapshot(afterJSON, s.keys); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
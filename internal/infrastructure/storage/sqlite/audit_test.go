@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	st, err := New(filepath.Join(t.TempDir(), "notes.db"), logger.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	return st
+}
+
+func TestStore_AuditTrailRecordsMutations(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := audit.WithActor(context.Background(), "user-42")
+
+	note := model.NewNote("first")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	note.UpdateContent("second")
+	if err := st.Update(audit.WithActor(context.Background(), audit.ActorGUI), note); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := st.Delete(context.Background(), note.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	events, err := st.ListAuditEvents(context.Background(), audit.Filter{NoteID: note.ID})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+
+	want := []struct {
+		op    audit.Operation
+		actor string
+	}{
+		{audit.OperationCreate, "user-42"},
+		{audit.OperationUpdate, audit.ActorGUI},
+		{audit.OperationDelete, audit.ActorSystem},
+	}
+	for i, w := range want {
+		if events[i].Operation != w.op || events[i].Actor != w.actor {
+			t.Fatalf("event %d = %s by %s, want %s by %s", i, events[i].Operation, events[i].Actor, w.op, w.actor)
+		}
+	}
+
+	if events[0].Before != nil || events[0].After == nil || events[0].After.Content != "first" {
+		t.Fatalf("unexpected create snapshots: %+v", events[0])
+	}
+	if events[1].Before.Content != "first" || events[1].After.Content != "second" {
+		t.Fatalf("unexpected update snapshots: before=%+v after=%+v", events[1].Before, events[1].After)
+	}
+	if events[2].Before.Content != "second" || events[2].After != nil {
+		t.Fatalf("unexpected delete snapshots: %+v", events[2])
+	}
+}
+
+func TestStore_AuditTrailIsAppendOnly(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	if err := st.Add(ctx, model.NewNote("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.db.Exec("UPDATE audit_events SET actor = 'mallory'"); err == nil {
+		t.Fatal("expected update of audit_events to be rejected")
+	}
+	if _, err := st.db.Exec("DELETE FROM audit_events"); err == nil {
+		t.Fatal("expected delete from audit_events to be rejected")
+	}
+}
+
+func TestStore_AuditFailedMutationLeavesNoEvent(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	if err := st.Delete(ctx, "missing"); err == nil {
+		t.Fatal("expected not found")
+	}
+	events, err := st.ListAuditEvents(ctx, audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events, got %d", len(events))
+	}
+}
+
+func TestStore_AuditFilterSince(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	if err := st.Add(ctx, model.NewNote("old")); err != nil {
+		t.Fatal(err)
+	}
+	cutoff := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	if err := st.Add(ctx, model.NewNote("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := st.ListAuditEvents(ctx, audit.Filter{Since: &cutoff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].After.Content != "new" {
+		t.Fatalf("unexpected events since cutoff: %+v", events)
+	}
+}
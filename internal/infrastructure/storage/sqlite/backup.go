@@ -0,0 +1,330 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	moderncsqlite "modernc.org/sqlite"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// Snapshot file names are "godo-<UTC timestamp>.db" so they sort by age
+const (
+	backupPrefix     = "godo-"
+	backupExt        = ".db"
+	backupTimeFormat = "20060102T150405.000Z"
+)
+
+// BackupPolicy controls where snapshots are written and which ones rotation keeps
+type BackupPolicy struct {
+	// Dir holds the snapshots
+	Dir string
+	// KeepCount is how many snapshots rotation keeps; 0 keeps any number
+	KeepCount int
+	// MaxAge removes snapshots older than this; 0 keeps them regardless of age
+	MaxAge time.Duration
+}
+
+// restorer is implemented by modernc.org/sqlite driver connections
+type restorer interface {
+	NewRestore(srcURI string) (*moderncsqlite.Backup, error)
+}
+
+// SetBackupPolicy replaces the snapshot directory and rotation limits
+func (s *Store) SetBackupPolicy(policy BackupPolicy) {
+	s.backups = policy
+}
+
+// Backup writes a consistent snapshot of the live database with VACUUM INTO
+// and then rotates old snapshots according to the backup policy
+func (s *Store) Backup(ctx context.Context) (model.Backup, error) {
+	if err := s.checkOpen(); err != nil {
+		return model.Backup{}, err
+	}
+	backup, err := s.snapshot(ctx)
+	if err != nil {
+		return model.Backup{}, err
+	}
+	if rotateErr := s.rotateBackups(ctx); rotateErr != nil {
+		s.logger.Warn("Failed to rotate backups", "dir", s.backups.Dir, "error", rotateErr)
+	}
+	return backup, nil
+}
+
+// snapshot writes a consistent snapshot of the live database with VACUUM
+// INTO, leaving older snapshots alone
+func (s *Store) snapshot(ctx context.Context) (model.Backup, error) {
+	if err := os.MkdirAll(s.backups.Dir, 0o755); err != nil {
+		return model.Backup{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	createdAt := time.Now().UTC()
+	name := backupPrefix + createdAt.Format(backupTimeFormat) + backupExt
+	path := filepath.Join(s.backups.Dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return model.Backup{}, fmt.Errorf("backup file already exists: %s", path)
+	}
+
+	// VACUUM cannot run inside a transaction, so always use the database handle
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return model.Backup{}, fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return model.Backup{}, err
+	}
+	backup := model.Backup{Name: name, CreatedAt: createdAt, Size: info.Size()}
+	s.logger.Info("Database backed up", "backup", path, "size", backup.Size)
+	return backup, nil
+}
+
+// ListBackups returns the snapshots in the backup directory, newest first
+func (s *Store) ListBackups(_ context.Context) ([]model.Backup, error) {
+	entries, err := os.ReadDir(s.backups.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []model.Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	backups := make([]model.Backup, 0, len(entries))
+	for _, entry := range entries {
+		createdAt, ok := parseBackupName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return nil, infoErr
+		}
+		backups = append(backups, model.Backup{Name: entry.Name(), CreatedAt: createdAt, Size: info.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces the live database with a snapshot. The snapshot is
+// copied aside, integrity-checked and migrated to the current schema before
+// anything is touched, and the live data is snapshotted first so the restore
+// can itself be undone.
+func (s *Store) RestoreBackup(ctx context.Context, name string) error {
+	if _, ok := parseBackupName(name); !ok || filepath.Base(name) != name {
+		return fmt.Errorf("%w: %s", model.ErrBackupNotFound, name)
+	}
+	source := filepath.Join(s.backups.Dir, name)
+	if _, err := os.Stat(source); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", model.ErrBackupNotFound, name)
+	}
+
+	staged, err := stageSnapshot(source, s.backups.Dir)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(staged)
+
+	if prepErr := s.prepareSnapshot(ctx, staged); prepErr != nil {
+		return fmt.Errorf("backup %s cannot be restored: %w", name, prepErr)
+	}
+
+	previous, err := s.preserveCurrent(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot current data before restore: %w", err)
+	}
+
+	if restoreErr := s.restoreFrom(ctx, staged); restoreErr != nil {
+		return fmt.Errorf("failed to restore backup %s: %w", name, restoreErr)
+	}
+	s.logger.Info("Database restored from backup", "backup", name, "previous_data", previous)
+
+	// A good snapshot lifts read-only mode
+	if _, err = s.runHealthCheck(ctx, quickCheck); err != nil {
+		return err
+	}
+	// The snapshot carries its own salt, or is not encrypted yet
+	return s.unlock(ctx)
+}
+
+// preserveCurrent saves the live data before a restore overwrites it and
+// returns where it went. A healthy database gets a regular snapshot, without
+// rotation so the snapshot being restored is never removed to make room; a
+// damaged one is copied byte for byte, outside the snapshot naming scheme so
+// it is never offered for restore.
+func (s *Store) preserveCurrent(ctx context.Context) (string, error) {
+	if !s.health.readOnly() {
+		backup, err := s.snapshot(ctx)
+		return backup.Name, err
+	}
+
+	if err := os.MkdirAll(s.backups.Dir, 0o755); err != nil {
+		return "", err
+	}
+	staged, err := stageSnapshot(s.path, s.backups.Dir)
+	if err != nil {
+		return "", err
+	}
+	damaged := filepath.Join(s.backups.Dir, "damaged-"+time.Now().UTC().Format(backupTimeFormat)+backupExt)
+	if err = os.Rename(staged, damaged); err != nil {
+		os.Remove(staged)
+		return "", err
+	}
+	return filepath.Base(damaged), nil
+}
+
+// prepareSnapshot checks a staged snapshot's integrity, applies any
+// migrations it predates, so it matches the schema this binary expects, and
+// makes sure the store's key can read it
+func (s *Store) prepareSnapshot(ctx context.Context, path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if checkErr := verifySnapshot(ctx, db); checkErr != nil {
+		return checkErr
+	}
+
+	migrator, err := NewMigrator(db, "", s.logger)
+	if err != nil {
+		return err
+	}
+	if err = migrator.Migrate(ctx); err != nil {
+		return err
+	}
+	return s.checkSnapshotKey(ctx, db)
+}
+
+// restoreFrom copies every page of the snapshot at path over the live
+// database using the SQLite online backup API. The copy runs under an
+// exclusive lock, so other connections see either the old or the new data.
+// It uses a connection of its own without the pool's pragmas, which would
+// fail to open when the damage reaches the schema.
+func (s *Store) restoreFrom(ctx context.Context, path string) error {
+	db, err := sql.Open("sqlite", s.opts.rawDSN(s.path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		r, ok := driverConn.(restorer)
+		if !ok {
+			return fmt.Errorf("driver connection %T does not support restore", driverConn)
+		}
+		backup, err := r.NewRestore(path)
+		if err != nil {
+			return err
+		}
+		for more := true; more; {
+			if more, err = backup.Step(-1); err != nil {
+				_ = backup.Finish()
+				return err
+			}
+		}
+		return backup.Finish()
+	})
+}
+
+// rotateBackups removes snapshots beyond the policy's count and age limits.
+// The newest snapshot is always kept.
+func (s *Store) rotateBackups(ctx context.Context) error {
+	backups, err := s.ListBackups(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Time{}
+	if s.backups.MaxAge > 0 {
+		cutoff = time.Now().Add(-s.backups.MaxAge)
+	}
+	for i, backup := range backups {
+		if i == 0 {
+			continue
+		}
+		tooMany := s.backups.KeepCount > 0 && i >= s.backups.KeepCount
+		tooOld := !cutoff.IsZero() && backup.CreatedAt.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		if removeErr := os.Remove(filepath.Join(s.backups.Dir, backup.Name)); removeErr != nil {
+			return removeErr
+		}
+		s.logger.Info("Removed old backup", "backup", backup.Name)
+	}
+	return nil
+}
+
+// verifySnapshot runs a full integrity check on a snapshot
+func verifySnapshot(ctx context.Context, db *sql.DB) error {
+	problems, err := integrityProblems(ctx, db, fullCheck)
+	if err != nil {
+		return fmt.Errorf("%w: %w", model.ErrBackupCorrupt, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", model.ErrBackupCorrupt, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// stageSnapshot copies a snapshot to a temporary file in dir so verification
+// and migrations never modify the original
+func stageSnapshot(source, dir string) (string, error) {
+	in, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(dir, ".restore-*"+backupExt)
+	if err != nil {
+		return "", fmt.Errorf("failed to stage backup: %w", err)
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", fmt.Errorf("failed to stage backup: %w", err)
+	}
+	if err = out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("failed to stage backup: %w", err)
+	}
+	return out.Name(), nil
+}
+
+// parseBackupName returns the creation time encoded in a snapshot file name
+func parseBackupName(name string) (time.Time, bool) {
+	stamp, ok := strings.CutPrefix(name, backupPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, ok = strings.CutSuffix(stamp, backupExt)
+	if !ok {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
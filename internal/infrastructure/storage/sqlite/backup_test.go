@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	storeerrors "github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
+)
+
+func TestStore_BackupAndRestore(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("precious")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := st.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if backup.Size == 0 {
+		t.Fatalf("backup %s is empty", backup.Name)
+	}
+
+	if err = st.Delete(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.Purge(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = st.GetByID(ctx, note.ID); err == nil {
+		t.Fatal("note still present after purge")
+	}
+
+	// Keep the safety snapshot taken by the restore from colliding with ours
+	time.Sleep(2 * time.Millisecond)
+	if err = st.RestoreBackup(ctx, backup.Name); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+
+	restored, err := st.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("note missing after restore: %v", err)
+	}
+	if restored.Content != "precious" {
+		t.Fatalf("restored content=%q", restored.Content)
+	}
+
+	backups, err := st.ListBackups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || backups[1].Name != backup.Name {
+		t.Fatalf("expected the snapshot and a safety snapshot, got %+v", backups)
+	}
+}
+
+func TestStore_RestoreKeepsSourceWithKeepCountOne(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+	st.SetBackupPolicy(BackupPolicy{Dir: st.backups.Dir, KeepCount: 1})
+
+	note := model.NewNote("before")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := st.Backup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	note.UpdateContent("after")
+	if err = st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if err = st.RestoreBackup(ctx, backup.Name); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if restored, getErr := st.GetByID(ctx, note.ID); getErr != nil || restored.Content != "before" {
+		t.Fatalf("restore did not bring back the snapshot: %+v, %v", restored, getErr)
+	}
+	// The safety snapshot must not rotate away the snapshot just restored
+	if _, err = os.Stat(filepath.Join(st.backups.Dir, backup.Name)); err != nil {
+		t.Fatalf("restored snapshot was removed: %v", err)
+	}
+	backups, err := st.ListBackups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected the restored and the safety snapshot, got %+v", backups)
+	}
+}
+
+func TestStore_RestoreRejectsBadSnapshots(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	if err := st.RestoreBackup(ctx, "godo-20240101T000000.000Z.db"); !errors.Is(err, model.ErrBackupNotFound) {
+		t.Fatalf("missing snapshot: got %v", err)
+	}
+	if err := st.RestoreBackup(ctx, "../notes.db"); !errors.Is(err, model.ErrBackupNotFound) {
+		t.Fatalf("path outside backup dir: got %v", err)
+	}
+
+	if err := os.MkdirAll(st.backups.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := "godo-20240101T000000.000Z.db"
+	if err := os.WriteFile(filepath.Join(st.backups.Dir, corrupt), []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RestoreBackup(ctx, corrupt); !errors.Is(err, model.ErrBackupCorrupt) {
+		t.Fatalf("corrupt snapshot: got %v", err)
+	}
+}
+
+func TestStore_BackupRotation(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+	dir := st.backups.Dir
+	st.SetBackupPolicy(BackupPolicy{Dir: dir, KeepCount: 3, MaxAge: 30 * 24 * time.Hour})
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	old := []time.Time{
+		now.Add(-1 * time.Hour),
+		now.Add(-2 * time.Hour),
+		now.Add(-3 * time.Hour),
+		now.Add(-60 * 24 * time.Hour),
+	}
+	for _, createdAt := range old {
+		name := backupPrefix + createdAt.Format(backupTimeFormat) + backupExt
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "unrelated.txt"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := st.Backup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := st.ListBackups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 3 || backups[0].Name != latest.Name {
+		t.Fatalf("expected the 3 newest snapshots, got %+v", backups)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "unrelated.txt")); err != nil {
+		t.Fatalf("rotation touched an unrelated file: %v", err)
+	}
+}
+
+func TestStore_BackupAfterClose(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Backup(context.Background()); !errors.Is(err, storeerrors.ErrStoreClosed) {
+		t.Fatalf("expected ErrStoreClosed, got %v", err)
+	}
+}
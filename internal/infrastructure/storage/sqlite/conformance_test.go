@@ -0,0 +1,15 @@
+package sqlite
+
+import (
+	"testing"
+
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/conformance"
+)
+
+func TestUnifiedAdapter_Conformance(t *testing.T) {
+	t.Parallel()
+	conformance.Run(t, func(t *testing.T) domainstorage.UnifiedNoteStorage {
+		return NewUnifiedAdapter(newTestStore(t))
+	})
+}
@@ -0,0 +1,296 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Encryption errors
+var (
+	// ErrWrongKey is returned when the passphrase or key file does not open the database
+	ErrWrongKey = errors.New("wrong passphrase or encryption key")
+
+	// ErrKeyRequired is returned when an encrypted database is opened without a key
+	ErrKeyRequired = errors.New("database is encrypted; a passphrase or key file is required")
+)
+
+const (
+	// keySize selects AES-256
+	keySize = 32
+	// saltSize is the length of the random salt a passphrase is stretched with
+	saltSize = 16
+	// sealedPrefix marks content encrypted by this package, and its format version
+	sealedPrefix = "enc:v1:"
+	// keyCheckPlaintext is sealed into the encryption row to verify keys
+	keyCheckPlaintext = "godo key check"
+)
+
+// Key derivation functions recorded in the encryption table
+const (
+	kdfArgon2id = "argon2id"
+	kdfRaw      = "raw"
+)
+
+// kdfParams are the Argon2id costs a passphrase is stretched with
+type kdfParams struct {
+	Time      uint32
+	MemoryKiB uint32
+	Threads   uint8
+}
+
+// defaultKDF follows the second recommended option of RFC 9106. New keys use
+// it; existing databases keep the costs stored with their salt.
+var defaultKDF = kdfParams{Time: 3, MemoryKiB: 64 * 1024, Threads: 4}
+
+// KeySource supplies the key note content is encrypted with: a passphrase,
+// stretched with Argon2id and a salt stored in the database, or a 32-byte
+// key read from a file. The zero value means content is not encrypted.
+type KeySource struct {
+	passphrase []byte
+	key        []byte
+}
+
+// PassphraseKey returns a key source that derives the key from passphrase
+func PassphraseKey(passphrase string) KeySource {
+	return KeySource{passphrase: []byte(passphrase)}
+}
+
+// KeyFromFile reads a key file holding 32 bytes, either raw or encoded as
+// hex or standard base64
+func KeyFromFile(path string) (KeySource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return KeySource{}, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if len(data) == keySize {
+		return KeySource{key: data}, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, hexErr := hex.DecodeString(text); hexErr == nil && len(key) == keySize {
+		return KeySource{key: key}, nil
+	}
+	if key, b64Err := base64.StdEncoding.DecodeString(text); b64Err == nil && len(key) == keySize {
+		return KeySource{key: key}, nil
+	}
+	return KeySource{}, fmt.Errorf("key file %s must hold %d bytes, raw or hex or base64 encoded", path, keySize)
+}
+
+// IsZero reports whether the source holds no key
+func (k KeySource) IsZero() bool {
+	return len(k.passphrase) == 0 && len(k.key) == 0
+}
+
+// keyCheck is the content of the encryption row
+type keyCheck struct {
+	KDF    string
+	Salt   []byte
+	Params kdfParams
+	// Sealed is keyCheckPlaintext sealed with the derived key
+	Sealed string
+}
+
+// newKeyCheck derives a key from source, with a fresh salt for passphrases,
+// and returns the cipher together with the row that verifies it
+func newKeyCheck(source KeySource) (*contentCipher, keyCheck, error) {
+	check := keyCheck{KDF: kdfRaw}
+	if len(source.passphrase) > 0 {
+		check.KDF = kdfArgon2id
+		check.Params = defaultKDF
+		check.Salt = make([]byte, saltSize)
+		if _, err := rand.Read(check.Salt); err != nil {
+			return nil, keyCheck{}, err
+		}
+	}
+
+	c, err := check.derive(source)
+	if err != nil {
+		return nil, keyCheck{}, err
+	}
+	if check.Sealed, err = c.seal(keyCheckPlaintext); err != nil {
+		return nil, keyCheck{}, err
+	}
+	return c, check, nil
+}
+
+// derive builds the cipher this row describes from source
+func (k keyCheck) derive(source KeySource) (*contentCipher, error) {
+	var key []byte
+	switch k.KDF {
+	case kdfArgon2id:
+		if len(source.passphrase) == 0 {
+			return nil, fmt.Errorf("%w: the database was encrypted with a passphrase", ErrWrongKey)
+		}
+		key = argon2.IDKey(source.passphrase, k.Salt, k.Params.Time, k.Params.MemoryKiB, k.Params.Threads, keySize)
+	case kdfRaw:
+		if len(source.key) == 0 {
+			return nil, fmt.Errorf("%w: the database was encrypted with a key file", ErrWrongKey)
+		}
+		key = source.key
+	default:
+		return nil, fmt.Errorf("unknown key derivation %q", k.KDF)
+	}
+	return newContentCipher(key)
+}
+
+// open derives the cipher from source and proves it against the sealed check value
+func (k keyCheck) open(source KeySource) (*contentCipher, error) {
+	c, err := k.derive(source)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := c.open(k.Sealed)
+	if err != nil || plain != keyCheckPlaintext {
+		return nil, ErrWrongKey
+	}
+	return c, nil
+}
+
+// contentCipher seals strings with AES-256-GCM. Each value gets a random
+// nonce, stored in front of the ciphertext.
+type contentCipher struct {
+	aead cipher.AEAD
+}
+
+// newContentCipher creates a cipher for a 32-byte key
+func newContentCipher(key []byte) (*contentCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &contentCipher{aead: aead}, nil
+}
+
+// seal encrypts plain into text that can be stored in a TEXT column
+func (c *contentCipher) seal(plain string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plain)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts text produced by seal
+func (c *contentCipher) open(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, sealedPrefix)
+	if !ok {
+		return "", errors.New("stored content is not encrypted")
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return "", errors.New("stored content is not valid ciphertext")
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt content: %w", err)
+	}
+	return string(plain), nil
+}
+
+// keyring holds the cipher a store and its transaction-bound copies use. A
+// nil keyring, or one without a cipher, stores content in plain text.
+type keyring struct {
+	// rotating is held for writing while the key changes, and for reading by
+	// every write transaction, so no write is sealed with a key being retired
+	rotating sync.RWMutex
+
+	mu sync.RWMutex
+	// source is kept so backups can be checked against the key before a restore
+	source  KeySource
+	current *contentCipher
+	// previous still opens values read before a key change was committed
+	previous *contentCipher
+}
+
+// enabled reports whether content is encrypted
+func (k *keyring) enabled() bool {
+	if k == nil {
+		return false
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current != nil
+}
+
+// set makes c, derived from source, the current cipher, keeping the old one
+// for reads in flight
+func (k *keyring) set(c *contentCipher, source KeySource) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.previous, k.current = k.current, c
+	k.source = source
+}
+
+// keySource returns the source of the current key, or of the key the store
+// was opened with if content is not encrypted yet
+func (k *keyring) keySource() KeySource {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.source
+}
+
+// cipher returns the current cipher, or nil when content is not encrypted
+func (k *keyring) cipher() *contentCipher {
+	if k == nil {
+		return nil
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// seal encrypts content for storage, or returns it unchanged when encryption is off
+func (k *keyring) seal(plain string) (string, error) {
+	c := k.cipher()
+	if c == nil {
+		return plain, nil
+	}
+	return c.seal(plain)
+}
+
+// open decrypts stored content, or returns it unchanged when encryption is off
+func (k *keyring) open(stored string) (string, error) {
+	if k == nil {
+		return stored, nil
+	}
+	k.mu.RLock()
+	current, previous := k.current, k.previous
+	k.mu.RUnlock()
+	if current == nil {
+		return stored, nil
+	}
+
+	plain, err := current.open(stored)
+	if err != nil && previous != nil {
+		if old, oldErr := previous.open(stored); oldErr == nil {
+			return old, nil
+		}
+	}
+	return plain, err
+}
+
+// keysOf returns the keyring carried by a queryer a store handed out. Other
+// queryers, such as a bare *sql.DB, carry none and see content as stored.
+func keysOf(q queryer) *keyring {
+	if cq, ok := q.(cachedQueryer); ok {
+		return cq.keys
+	}
+	return nil
+}
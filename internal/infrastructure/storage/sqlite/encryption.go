@@ -0,0 +1,245 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/infrastructure/storage"
+)
+
+// sealedColumns are the columns that hold note content, as text or inside a
+// JSON snapshot. Every one of them is encrypted when encryption is on.
+var sealedColumns = []struct{ table, column string }{
+	{"notes", "content"},
+	{"note_revisions", "content"},
+	{"audit_events", "before_json"},
+	{"audit_events", "after_json"},
+}
+
+// auditNoUpdateTrigger keeps audit_events append-only. Changing the key has
+// to rewrite the snapshots, so the trigger is lifted for that transaction only.
+const auditNoUpdateTrigger = `CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit_events is append-only');
+END`
+
+// Encrypted reports whether note content is stored encrypted
+func (s *Store) Encrypted() bool {
+	return s.keys.enabled()
+}
+
+// Rekey encrypts every note, revision and audit snapshot with a key from
+// source, in one transaction. On a database that is not encrypted yet it
+// turns encryption on.
+func (s *Store) Rekey(ctx context.Context, source KeySource) error {
+	if source.IsZero() {
+		return errors.New("rekey needs a passphrase or key file")
+	}
+	if s.tx != nil {
+		return &storage.TransactionError{Operation: "rekey", Message: "cannot change the key inside a transaction"}
+	}
+	if s.health.readOnly() {
+		return s.readOnlyError()
+	}
+
+	// Hold off every write until the new key is in place
+	s.keys.rotating.Lock()
+	defer s.keys.rotating.Unlock()
+
+	wasEncrypted := s.keys.enabled()
+	next, check, err := newKeyCheck(source)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	var rewritten int
+	err = s.commitTx(ctx, func(tx *sql.Tx) error {
+		var recodeErr error
+		if rewritten, recodeErr = recodeContent(ctx, tx, s.keys, next); recodeErr != nil {
+			return recodeErr
+		}
+		return writeKeyCheck(ctx, tx, check)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt content: %w", err)
+	}
+	s.keys.set(next, source)
+
+	if wasEncrypted {
+		s.logger.Info("Note content re-encrypted with a new key", "values", rewritten, "kdf", check.KDF)
+		return nil
+	}
+	s.logger.Info("Note content encrypted", "values", rewritten, "kdf", check.KDF)
+	s.scrubPlaintext(ctx)
+	return nil
+}
+
+// unlock loads the key the database was encrypted with, proving the
+// configured key against the check row. A key configured for a database that
+// is not encrypted yet encrypts it.
+func (s *Store) unlock(ctx context.Context) error {
+	check, found, err := readKeyCheck(ctx, s.db)
+	if err != nil && s.health.readOnly() {
+		// The damage may reach the encryption table itself; the notes that
+		// can still be read stay available until a backup is restored
+		s.logger.Warn("Database is damaged, encryption settings could not be read", "error", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read encryption settings: %w", err)
+	}
+	source := s.keys.keySource()
+
+	switch {
+	case found && source.IsZero():
+		return ErrKeyRequired
+	case found:
+		c, openErr := check.open(source)
+		if openErr != nil {
+			return openErr
+		}
+		s.keys.set(c, source)
+		return nil
+	case source.IsZero():
+		return nil
+	case s.health.readOnly():
+		s.logger.Warn("Database is damaged, leaving its content unencrypted until it is restored")
+		return nil
+	default:
+		return s.Rekey(ctx, source)
+	}
+}
+
+// checkSnapshotKey makes sure a snapshot can be read with the store's key
+// before it replaces the live data
+func (s *Store) checkSnapshotKey(ctx context.Context, db queryer) error {
+	check, found, err := readKeyCheck(ctx, db)
+	if err != nil || !found {
+		return err
+	}
+	source := s.keys.keySource()
+	if source.IsZero() {
+		return ErrKeyRequired
+	}
+	if _, err = check.open(source); err != nil {
+		return fmt.Errorf("backup is encrypted with a different key: %w", err)
+	}
+	return nil
+}
+
+// scrubPlaintext compacts the full-text index and rewrites the database file
+// after content is first encrypted, so the plain text does not linger in
+// index segments, free pages or the write-ahead log. Earlier backups still
+// hold it.
+func (s *Store) scrubPlaintext(ctx context.Context) {
+	for _, stmt := range []string{
+		"INSERT INTO notes_fts (notes_fts) VALUES ('optimize')",
+		"VACUUM",
+		"PRAGMA wal_checkpoint(TRUNCATE)",
+	} {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			s.logger.Warn("Failed to scrub plain text after encrypting", "statement", stmt, "error", err)
+			return
+		}
+	}
+}
+
+// recodeContent rewrites every sealed column, opening each value with from
+// and sealing it with to, and returns the number of values rewritten
+func recodeContent(ctx context.Context, tx *sql.Tx, from *keyring, to *contentCipher) (int, error) {
+	if _, err := tx.ExecContext(ctx, "DROP TRIGGER audit_events_no_update"); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, col := range sealedColumns {
+		n, err := recodeColumn(ctx, tx, col.table, col.column, from, to)
+		if err != nil {
+			return 0, fmt.Errorf("%s.%s: %w", col.table, col.column, err)
+		}
+		total += n
+	}
+
+	if _, err := tx.ExecContext(ctx, auditNoUpdateTrigger); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// recodeColumn re-encrypts the non-null values of one column. Rows are read
+// in full before any is written so the update never races its own cursor.
+func recodeColumn(ctx context.Context, tx *sql.Tx, table, column string, from *keyring, to *contentCipher) (int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT rowid, "+column+" FROM "+table+" WHERE "+column+" IS NOT NULL")
+	if err != nil {
+		return 0, err
+	}
+	type storedValue struct {
+		rowid int64
+		value string
+	}
+	var values []storedValue
+	for rows.Next() {
+		var v storedValue
+		if err = rows.Scan(&v.rowid, &v.value); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		values = append(values, v)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, v := range values {
+		plain, openErr := from.open(v.value)
+		if openErr != nil {
+			return 0, openErr
+		}
+		sealed, sealErr := to.seal(plain)
+		if sealErr != nil {
+			return 0, sealErr
+		}
+		if _, err = tx.ExecContext(ctx, "UPDATE "+table+" SET "+column+" = ? WHERE rowid = ?", sealed, v.rowid); err != nil {
+			return 0, err
+		}
+	}
+	return len(values), nil
+}
+
+// readKeyCheck loads the encryption row, reporting false when the database
+// is not encrypted or predates encryption support
+func readKeyCheck(ctx context.Context, q queryer) (keyCheck, bool, error) {
+	var tables int
+	if err := q.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'encryption'",
+	).Scan(&tables); err != nil || tables == 0 {
+		return keyCheck{}, false, err
+	}
+
+	var check keyCheck
+	err := q.QueryRowContext(ctx,
+		"SELECT kdf, salt, time_cost, memory_kib, threads, key_check FROM encryption WHERE id = 1",
+	).Scan(&check.KDF, &check.Salt, &check.Params.Time, &check.Params.MemoryKiB, &check.Params.Threads, &check.Sealed)
+	if err == sql.ErrNoRows {
+		return keyCheck{}, false, nil
+	}
+	if err != nil {
+		return keyCheck{}, false, err
+	}
+	return check, true, nil
+}
+
+// writeKeyCheck stores the encryption row, replacing any previous key's
+func writeKeyCheck(ctx context.Context, tx *sql.Tx, check keyCheck) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO encryption (id, kdf, salt, time_cost, memory_kib, threads, key_check, created_at)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?)`,
+		check.KDF, check.Salt, check.Params.Time, check.Params.MemoryKiB, check.Params.Threads, check.Sealed, time.Now().UTC(),
+	)
+	return err
+}
@@ -0,0 +1,223 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+func openWithKey(t *testing.T, path string, key KeySource) (*Store, error) {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.Key = key
+	st, err := NewWithOptions(path, opts, logger.NewNoopLogger())
+	if err == nil {
+		t.Cleanup(func() { _ = st.Close() })
+	}
+	return st, err
+}
+
+func writeKeyFile(t *testing.T, encode func([]byte) string) string {
+	t.Helper()
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "godo.key")
+	if err := os.WriteFile(path, []byte(encode(key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// assertNoPlaintext fails if secret appears in any column that holds content
+func assertNoPlaintext(t *testing.T, st *Store, secret string) {
+	t.Helper()
+	for _, col := range sealedColumns {
+		var leaks int
+		err := st.db.QueryRow(
+			"SELECT COUNT(*) FROM "+col.table+" WHERE "+col.column+" LIKE ?", "%"+secret+"%",
+		).Scan(&leaks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leaks > 0 {
+			t.Fatalf("%s.%s holds plain text", col.table, col.column)
+		}
+	}
+}
+
+func TestStore_EncryptsContentAtRest(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	st, err := openWithKey(t, filepath.Join(t.TempDir(), "notes.db"), PassphraseKey("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Encrypted() {
+		t.Fatal("store with a key should encrypt")
+	}
+
+	note := model.NewNote("the vault code is swordfish")
+	other := model.NewNote("buy milk")
+	for _, n := range []*model.Note{note, other} {
+		if err = st.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	note.UpdateContent("the vault code is swordfish, changed weekly")
+	if err = st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	assertNoPlaintext(t, st, "swordfish")
+
+	got, err := st.GetByID(ctx, note.ID)
+	if err != nil || got.Content != note.Content {
+		t.Fatalf("GetByID = %q, %v", got.Content, err)
+	}
+	revisions, err := st.ListRevisions(ctx, note.ID)
+	if err != nil || len(revisions) != 1 || revisions[0].Content != "the vault code is swordfish" {
+		t.Fatalf("revisions = %+v, %v", revisions, err)
+	}
+
+	hits, err := st.Search(ctx, "vault sword", 10)
+	if err != nil || len(hits) != 1 || hits[0].Note.ID != note.ID {
+		t.Fatalf("Search = %+v, %v", hits, err)
+	}
+	if !strings.Contains(hits[0].Snippet, model.HighlightStart+"swordfish"+model.HighlightEnd) {
+		t.Fatalf("snippet not highlighted: %q", hits[0].Snippet)
+	}
+
+	content := "SWORDFISH"
+	limit := 1
+	notes, err := st.Query(ctx, model.NoteFilter{Content: &content, Limit: &limit})
+	if err != nil || len(notes) != 1 || notes[0].ID != note.ID {
+		t.Fatalf("Query = %+v, %v", notes, err)
+	}
+	page, cursor, err := st.Page(ctx, model.NoteFilter{Content: &content, Limit: &limit})
+	if err != nil || len(page) != 1 || cursor != "" {
+		t.Fatalf("Page = %+v %q, %v", page, cursor, err)
+	}
+}
+
+func TestStore_RejectsWrongKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "notes.db")
+	st, err := openWithKey(t, path, PassphraseKey("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := model.NewNote("secret")
+	if err = st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+
+	if _, err = openWithKey(t, path, PassphraseKey("battery staple")); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("wrong passphrase: err = %v, want ErrWrongKey", err)
+	}
+	if _, err = openWithKey(t, path, KeySource{}); !errors.Is(err, ErrKeyRequired) {
+		t.Fatalf("no key: err = %v, want ErrKeyRequired", err)
+	}
+	keyFile, err := KeyFromFile(writeKeyFile(t, hex.EncodeToString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = openWithKey(t, path, keyFile); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("key file for a passphrase database: err = %v, want ErrWrongKey", err)
+	}
+
+	reopened, err := openWithKey(t, path, PassphraseKey("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.GetByID(ctx, note.ID); err != nil || got.Content != "secret" {
+		t.Fatalf("GetByID = %q, %v", got.Content, err)
+	}
+}
+
+func TestStore_Rekey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "notes.db")
+
+	// Start from a plain database with history in every sealed column
+	st, err := New(path, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := model.NewNote("launch codes")
+	if err = st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	note.UpdateContent("launch codes v2")
+	if err = st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile, err := KeyFromFile(writeKeyFile(t, base64.StdEncoding.EncodeToString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Rekey(ctx, keyFile); err != nil {
+		t.Fatalf("Rekey to key file: %v", err)
+	}
+	assertNoPlaintext(t, st, "launch")
+	if err = st.Rekey(ctx, PassphraseKey("new passphrase")); err != nil {
+		t.Fatalf("Rekey to passphrase: %v", err)
+	}
+	assertNoPlaintext(t, st, "launch")
+
+	// The audit trail is still append-only after being rewritten
+	if _, err = st.db.Exec("UPDATE audit_events SET actor = 'x'"); err == nil {
+		t.Fatal("audit_events accepted an update after rekey")
+	}
+	events, err := st.ListAuditEvents(ctx, audit.Filter{NoteID: note.ID})
+	if err != nil || len(events) != 2 || events[1].After.Content != "launch codes v2" {
+		t.Fatalf("audit events = %+v, %v", events, err)
+	}
+	st.Close()
+
+	if _, err = openWithKey(t, path, keyFile); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("retired key: err = %v, want ErrWrongKey", err)
+	}
+	reopened, err := openWithKey(t, path, PassphraseKey("new passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := reopened.GetByID(ctx, note.ID); err != nil || got.Content != "launch codes v2" {
+		t.Fatalf("GetByID = %q, %v", got.Content, err)
+	}
+}
+
+func TestKeyFromFile(t *testing.T) {
+	t.Parallel()
+	for name, encode := range map[string]func([]byte) string{
+		"raw":    func(b []byte) string { return string(b) },
+		"hex":    func(b []byte) string { return hex.EncodeToString(b) + "\n" },
+		"base64": base64.StdEncoding.EncodeToString,
+	} {
+		if _, err := KeyFromFile(writeKeyFile(t, encode)); err != nil {
+			t.Errorf("%s key file: %v", name, err)
+		}
+	}
+
+	short := filepath.Join(t.TempDir(), "short.key")
+	if err := os.WriteFile(short, []byte("too short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := KeyFromFile(short); err == nil {
+		t.Error("expected an error for a key of the wrong size")
+	}
+}
@@ -0,0 +1,162 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// Integrity checks, named after the PRAGMA that runs them
+const (
+	// quickCheck skips index consistency checks and runs in roughly linear time
+	quickCheck = "quick_check"
+	// fullCheck also verifies every index against its table
+	fullCheck = "integrity_check"
+)
+
+// autoVacuumIncremental is the PRAGMA auto_vacuum value for incremental mode
+const autoVacuumIncremental = 2
+
+// healthState holds the result of the last integrity check. It is shared by
+// a store and every copy WithinTx binds to a transaction.
+type healthState struct {
+	mu     sync.RWMutex
+	report model.StorageHealth
+}
+
+// get returns a copy of the last check result
+func (h *healthState) get() model.StorageHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	report := h.report
+	report.Problems = append([]string(nil), h.report.Problems...)
+	return report
+}
+
+// set records a check result
+func (h *healthState) set(report model.StorageHealth) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.report = report
+}
+
+// readOnly reports whether writes are currently refused
+func (h *healthState) readOnly() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.report.ReadOnly
+}
+
+// Health returns the result of the most recent integrity check
+func (s *Store) Health(_ context.Context) model.StorageHealth {
+	return s.health.get()
+}
+
+// CheckIntegrity runs a full integrity check now. Finding corruption switches
+// the store to read-only mode; a clean result switches it back.
+func (s *Store) CheckIntegrity(ctx context.Context) (model.StorageHealth, error) {
+	return s.runHealthCheck(ctx, fullCheck)
+}
+
+// Optimize lets SQLite refresh its query planner statistics and returns free
+// pages to the file system. It does nothing while the store is read-only.
+func (s *Store) Optimize(ctx context.Context) error {
+	if s.health.readOnly() {
+		return s.readOnlyError()
+	}
+	if _, err := s.db.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+		return fmt.Errorf("failed to optimize database: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	s.logger.Debug("Database optimized")
+	return nil
+}
+
+// runHealthCheck runs check against the live database and records the result
+func (s *Store) runHealthCheck(ctx context.Context, check string) (model.StorageHealth, error) {
+	problems, err := integrityProblems(ctx, s.db, check)
+	if err != nil {
+		if ctx.Err() != nil {
+			return model.StorageHealth{}, err
+		}
+		// A check that cannot even run usually means the file is damaged
+		problems = []string{err.Error()}
+	}
+
+	report := model.StorageHealth{
+		Status:    model.HealthOK,
+		Check:     check,
+		Problems:  problems,
+		CheckedAt: time.Now(),
+	}
+	if len(problems) > 0 {
+		report.Status = model.HealthDegraded
+		report.ReadOnly = true
+		s.logger.Error("Database integrity check failed, switching to read-only mode",
+			"check", check, "problems", strings.Join(problems, "; "))
+	} else {
+		s.logger.Debug("Database integrity check passed", "check", check)
+	}
+	s.health.set(report)
+	return report, nil
+}
+
+// readOnlyError explains why a write was refused
+func (s *Store) readOnlyError() error {
+	report := s.health.get()
+	return fmt.Errorf("%w: %s found %d problem(s); restore a backup to continue",
+		model.ErrReadOnly, report.Check, len(report.Problems))
+}
+
+// enableIncrementalVacuum switches the database to incremental auto-vacuum so
+// Optimize can reclaim free pages. Existing files need one full VACUUM for the
+// change to take effect.
+func (s *Store) enableIncrementalVacuum(ctx context.Context) error {
+	var mode int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		return err
+	}
+	if mode == autoVacuumIncremental {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return err
+	}
+	s.logger.Info("Enabled incremental auto-vacuum")
+	return nil
+}
+
+// integrityProblems runs PRAGMA check and returns every problem it reports
+func integrityProblems(ctx context.Context, db queryer, check string) ([]string, error) {
+	if check != quickCheck && check != fullCheck {
+		return nil, errors.New("unknown integrity check: " + check)
+	}
+	rows, err := db.QueryContext(ctx, "PRAGMA "+check)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if scanErr := rows.Scan(&line); scanErr != nil {
+			return nil, scanErr
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+func TestStore_HealthyDatabase(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	if health := st.Health(ctx); !health.Healthy() || health.ReadOnly || health.Check != quickCheck {
+		t.Fatalf("startup health=%+v", health)
+	}
+
+	health, err := st.CheckIntegrity(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !health.Healthy() || health.Check != fullCheck {
+		t.Fatalf("full check=%+v", health)
+	}
+
+	var mode int
+	if err = st.db.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if mode != autoVacuumIncremental {
+		t.Fatalf("auto_vacuum=%d want incremental", mode)
+	}
+	if err = st.Optimize(ctx); err != nil {
+		t.Fatalf("Optimize: %v", err)
+	}
+}
+
+func TestStore_CorruptDatabaseOpensReadOnly(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "notes.db")
+	log := logger.NewNoopLogger()
+
+	st, err := New(path, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 200 {
+		if err = st.Add(ctx, model.NewNote(fmt.Sprintf("note %d with some padding to fill pages", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backup, err := st.Backup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	corruptPages(t, path)
+
+	st, err = New(path, log)
+	if err != nil {
+		t.Fatalf("damaged database should still open: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	health := st.Health(ctx)
+	if health.Healthy() || !health.ReadOnly || len(health.Problems) == 0 {
+		t.Fatalf("expected degraded health, got %+v", health)
+	}
+	if err = st.Add(ctx, model.NewNote("refused")); !errors.Is(err, model.ErrReadOnly) {
+		t.Fatalf("write on damaged database: got %v", err)
+	}
+	if err = st.Optimize(ctx); !errors.Is(err, model.ErrReadOnly) {
+		t.Fatalf("optimize on damaged database: got %v", err)
+	}
+
+	if err = st.RestoreBackup(ctx, backup.Name); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if health = st.Health(ctx); !health.Healthy() || health.ReadOnly {
+		t.Fatalf("health after restore=%+v", health)
+	}
+	notes, err := st.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 200 {
+		t.Fatalf("restored %d notes, want 200", len(notes))
+	}
+	if err = st.Add(ctx, model.NewNote("accepted")); err != nil {
+		t.Fatalf("write after restore: %v", err)
+	}
+
+	// The damaged file is kept but never offered as a backup
+	matches, err := filepath.Glob(filepath.Join(st.backups.Dir, "damaged-*.db"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("damaged copy: %v %v", matches, err)
+	}
+	backups, err := st.ListBackups(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("backups=%+v", backups)
+	}
+}
+
+// corruptPages overwrites the database past its first page, leaving the
+// header and schema readable but the note pages unusable
+func corruptPages(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const pageSize = 4096
+	garbage := make([]byte, pageSize)
+	for i := range garbage {
+		garbage[i] = 0xA5
+	}
+	for offset := int64(pageSize); offset < info.Size(); offset += 2 * pageSize {
+		if _, err = f.WriteAt(garbage, offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// ImportNotes writes notes with their own IDs, done state and timestamps in
+// one transaction. A note already stored, even in the trash, is kept or
+// replaced according to policy; a replaced note in the trash is restored.
+// Each write is audited and revisioned like any other. Notes that fail
+// model.CheckImport are reported as failed; a database error rolls back the
+// whole import.
+func (s *Store) ImportNotes(ctx context.Context, notes []model.Note, policy model.ConflictPolicy) (*model.ImportReport, error) {
+	var report *model.ImportReport
+	err := s.withTx(ctx, func(q queryer) error {
+		report = &model.ImportReport{}
+		for i := range notes {
+			outcome, err := importNote(ctx, q, notes[i], policy)
+			if err != nil {
+				return err
+			}
+			report.Add(notes[i].ID, outcome.outcome, outcome.err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importResult is what importNote did with a note, with the reason when the
+// note was rejected
+type importResult struct {
+	outcome model.ImportOutcome
+	err     error
+}
+
+// importNote adds or replaces one imported note. The returned error is a
+// database failure; a rejected note is reported in the result instead.
+func importNote(ctx context.Context, q queryer, note model.Note, policy model.ConflictPolicy) (importResult, error) {
+	if err := model.CheckImport(&note); err != nil {
+		return importResult{outcome: model.ImportFailed, err: err}, nil
+	}
+	note.Version = 0
+	note.DeletedAt = nil
+
+	stored, err := findNote(ctx, q, "SELECT "+noteColumns+" FROM notes WHERE id = ?", note.ID)
+	switch {
+	case errors.Is(err, model.ErrNoteNotFound):
+		if err = addNote(ctx, q, &note); err != nil {
+			return importResult{}, err
+		}
+		return importResult{outcome: model.ImportCreated}, nil
+	case err != nil:
+		return importResult{}, err
+	}
+
+	if !policy.Replaces(&stored, &note) {
+		return importResult{outcome: model.ImportSkipped}, nil
+	}
+	if stored.DeletedAt != nil {
+		if err = restoreNote(ctx, q, note.ID); err != nil {
+			return importResult{}, err
+		}
+	}
+	if err = updateNote(ctx, q, &note); err != nil {
+		return importResult{}, err
+	}
+	// updateNote leaves created_at and archived_at alone, as every other write should
+	if _, err = q.ExecContext(ctx, "UPDATE notes SET created_at = ?, archived_at = ? WHERE id = ?",
+		note.CreatedAt, note.ArchivedAt, note.ID,
+	); err != nil {
+		return importResult{}, err
+	}
+	return importResult{outcome: model.ImportUpdated}, nil
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestStore_ImportKeepsIDsAndTimestamps(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	created := time.Date(2023, 11, 5, 8, 0, 0, 0, time.UTC)
+	imported := model.Note{
+		ID: "note-from-elsewhere", Content: "migrated", Done: true,
+		CreatedAt: created, UpdatedAt: created.Add(30 * time.Minute),
+	}
+	report, err := st.ImportNotes(ctx, []model.Note{imported}, model.ConflictSkip)
+	if err != nil {
+		t.Fatalf("ImportNotes: %v", err)
+	}
+	if report.Created != 1 {
+		t.Fatalf("expected one created note, got %+v", report)
+	}
+
+	got, err := st.GetByID(ctx, imported.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if !got.Done || !got.CreatedAt.Equal(imported.CreatedAt) || !got.UpdatedAt.Equal(imported.UpdatedAt) || got.Version != 1 {
+		t.Fatalf("imported note not kept as given: %+v", got)
+	}
+
+	events, err := st.ListAuditEvents(ctx, audit.Filter{NoteID: imported.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Operation != audit.OperationCreate {
+		t.Fatalf("expected the import to be audited as a create, got %+v", events)
+	}
+}
+
+func TestStore_ImportedNotesSortWithLocalNotes(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	// A note written in a zone west of UTC, and an imported note an hour
+	// older that arrives in UTC
+	edt := time.FixedZone("EDT", -4*60*60)
+	now := time.Date(2026, 10, 16, 22, 58, 47, 0, edt)
+	local := model.NewNote("local")
+	local.CreatedAt, local.UpdatedAt = now, now
+	if err := st.Add(ctx, local); err != nil {
+		t.Fatal(err)
+	}
+	older := now.Add(-time.Hour).UTC()
+	imported := model.Note{ID: "imported", Content: "imported", CreatedAt: older, UpdatedAt: older}
+	if _, err := st.ImportNotes(ctx, []model.Note{imported}, model.ConflictSkip); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := st.Query(ctx, model.NoteFilter{Sort: model.SortCreatedDesc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || notes[0].ID != local.ID || notes[1].ID != imported.ID {
+		t.Fatalf("expected the local note first, got %+v", notes)
+	}
+
+	after := now.Add(-30 * time.Minute)
+	notes, err = st.Query(ctx, model.NoteFilter{CreatedAfter: &after})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].ID != local.ID {
+		t.Fatalf("expected only the local note after %v, got %+v", after, notes)
+	}
+}
+
+func TestStore_ImportOverwriteRestoresTrashedNote(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("local")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A trashed note still counts as stored, so skip leaves it in the trash
+	incoming := model.Note{
+		ID: note.ID, Content: "from backup",
+		CreatedAt: note.CreatedAt.Add(-24 * time.Hour), UpdatedAt: note.UpdatedAt.Add(-time.Hour),
+	}
+	report, err := st.ImportNotes(ctx, []model.Note{incoming}, model.ConflictSkip)
+	if err != nil || report.Skipped != 1 {
+		t.Fatalf("skip import: %+v %v", report, err)
+	}
+	if _, err = st.GetByID(ctx, note.ID); err == nil {
+		t.Fatal("a skipped import should leave the note in the trash")
+	}
+
+	report, err = st.ImportNotes(ctx, []model.Note{incoming}, model.ConflictOverwrite)
+	if err != nil || report.Updated != 1 {
+		t.Fatalf("overwrite import: %+v %v", report, err)
+	}
+	got, err := st.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("overwritten note should be out of the trash: %v", err)
+	}
+	if got.Content != incoming.Content || !got.CreatedAt.Equal(incoming.CreatedAt) || !got.UpdatedAt.Equal(incoming.UpdatedAt) {
+		t.Fatalf("overwrite did not keep the imported note: %+v", got)
+	}
+
+	revisions, err := st.ListRevisions(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || revisions[0].Content != "local" {
+		t.Fatalf("the replaced content should be kept as a revision, got %+v", revisions)
+	}
+}
@@ -2,21 +2,336 @@
 package sqlite
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// Migration errors
+var (
+	// ErrSchemaTooNew is returned when the database was migrated by a newer binary
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+	// ErrMigrationChecksum is returned when an applied migration no longer matches the embedded copy
+	ErrMigrationChecksum = errors.New("migration checksum mismatch")
 )
 
-// RunMigrations applies all database migrations
+// Migration is a single numbered schema step embedded in the binary
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+// MigrationError describes a failure while applying or verifying a migration
+type MigrationError struct {
+	Version int
+	Name    string
+	Err     error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %04d_%s: %v", e.Version, e.Name, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// LoadMigrations returns the embedded migrations ordered by version.
+// File names must follow the NNNN_description.sql convention.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	seen := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		version, name, parseErr := parseMigrationName(entry.Name())
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		if prev, dup := seen[version]; dup {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, prev, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		body, readErr := migrationFS.ReadFile("migrations/" + entry.Name())
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), readErr)
+		}
+
+		// Normalize line endings so checkouts with CRLF do not look like edited migrations
+		content := strings.ReplaceAll(string(body), "\r\n", "\n")
+		sum := sha256.Sum256([]byte(content))
+
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     name,
+			SQL:      content,
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseMigrationName splits "0001_create_notes.sql" into (1, "create_notes")
+func parseMigrationName(file string) (int, string, error) {
+	base := strings.TrimSuffix(file, ".sql")
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", fmt.Errorf("invalid migration file name %q: expected NNNN_description.sql", file)
+	}
+	version, err := strconv.Atoi(prefix)
+	if err != nil || version <= 0 {
+		return 0, "", fmt.Errorf("invalid migration version in %q", file)
+	}
+	return version, name, nil
+}
+
+// Migrator applies embedded migrations to a SQLite database
+type Migrator struct {
+	db         *sql.DB
+	dbPath     string
+	migrations []Migration
+	logger     logger.Logger
+}
+
+// NewMigrator creates a migrator for db. dbPath is the database file used for
+// pre-migration backups; pass an empty string to disable backups.
+func NewMigrator(db *sql.DB, dbPath string, log logger.Logger) (*Migrator, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		log = logger.NewNoopLogger()
+	}
+	return &Migrator{
+		db:         db,
+		dbPath:     dbPath,
+		migrations: migrations,
+		logger:     log,
+	}, nil
+}
+
+// LatestVersion returns the highest migration version embedded in the binary
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// CurrentVersion returns the highest migration version applied to the database
+func (m *Migrator) CurrentVersion(ctx context.Context) (int, error) {
+	if err := m.ensureMigrationsTable(ctx); err != nil {
+		return 0, err
+	}
+	var version int
+	err := m.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// Migrate verifies applied migrations and applies any pending ones in order.
+// Each migration runs in its own transaction together with its bookkeeping row.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	if err := m.ensureMigrationsTable(ctx); err != nil {
+		return err
+	}
+
+	applied, err := m.appliedChecksums(ctx)
+	if err != nil {
+		return err
+	}
+
+	if verifyErr := m.verifyApplied(applied); verifyErr != nil {
+		return verifyErr
+	}
+
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, done := applied[mig.Version]; !done {
+			pending = append(pending, mig)
+		}
+	}
+	if len(pending) == 0 {
+		m.logger.Debug("Database schema is up to date", "version", m.LatestVersion())
+		return nil
+	}
+
+	if backupErr := m.backupBeforeMigrate(ctx, len(applied) > 0, pending[len(pending)-1].Version); backupErr != nil {
+		return backupErr
+	}
+
+	for _, mig := range pending {
+		if applyErr := m.apply(ctx, mig); applyErr != nil {
+			return applyErr
+		}
+		m.logger.Info("Applied database migration", "version", mig.Version, "name", mig.Name)
+	}
+
+	return nil
+}
+
+// ensureMigrationsTable creates the bookkeeping table if it does not exist
+func (m *Migrator) ensureMigrationsTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// appliedChecksums returns version -> checksum for every applied migration
+func (m *Migrator) appliedChecksums(ctx context.Context) (map[int]string, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT version, checksum FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var checksum string
+		if scanErr := rows.Scan(&version, &checksum); scanErr != nil {
+			return nil, scanErr
+		}
+		applied[version] = checksum
+	}
+	return applied, rows.Err()
+}
+
+// verifyApplied refuses databases migrated by a newer binary or with edited migrations
+func (m *Migrator) verifyApplied(applied map[int]string) error {
+	latest := m.LatestVersion()
+	embedded := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		embedded[mig.Version] = mig
+	}
+
+	for version, checksum := range applied {
+		if version > latest {
+			return fmt.Errorf("%w: database is at version %d, binary supports up to %d", ErrSchemaTooNew, version, latest)
+		}
+		mig, ok := embedded[version]
+		if !ok {
+			continue
+		}
+		if mig.Checksum != checksum {
+			return &MigrationError{Version: mig.Version, Name: mig.Name, Err: ErrMigrationChecksum}
+		}
+	}
+	return nil
+}
+
+// apply runs a single migration and records it atomically
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &MigrationError{Version: mig.Version, Name: mig.Name, Err: err}
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, execErr := tx.ExecContext(ctx, mig.SQL); execErr != nil {
+		return &MigrationError{Version: mig.Version, Name: mig.Name, Err: execErr}
+	}
+
+	if _, execErr := tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		mig.Version, mig.Name, mig.Checksum, time.Now(),
+	); execErr != nil {
+		return &MigrationError{Version: mig.Version, Name: mig.Name, Err: execErr}
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return &MigrationError{Version: mig.Version, Name: mig.Name, Err: commitErr}
+	}
+	return nil
+}
+
+// backupBeforeMigrate copies an existing database aside before schema changes.
+// Fresh databases (no applied migrations and no legacy notes table) are skipped.
+func (m *Migrator) backupBeforeMigrate(ctx context.Context, hasApplied bool, target int) error {
+	if m.dbPath == "" || m.dbPath == ":memory:" || strings.HasPrefix(m.dbPath, "file::memory:") {
+		return nil
+	}
+
+	if !hasApplied {
+		legacy, err := m.hasLegacySchema(ctx)
+		if err != nil {
+			return err
+		}
+		if !legacy {
+			return nil
+		}
+	}
+
+	backupPath := fmt.Sprintf("%s.pre-v%d-%s.bak", m.dbPath, target, time.Now().Format("20060102T150405"))
+	if _, err := os.Stat(backupPath); err == nil {
+		return fmt.Errorf("backup file already exists: %s", backupPath)
+	}
+
+	if _, err := m.db.ExecContext(ctx, "VACUUM INTO ?", backupPath); err != nil {
+		return fmt.Errorf("failed to back up database before migration: %w", err)
+	}
+	m.logger.Info("Database backed up before migration", "backup", backupPath, "target_version", target)
+	return nil
+}
+
+// hasLegacySchema reports whether the notes table predates schema_migrations
+func (m *Migrator) hasLegacySchema(ctx context.Context) (bool, error) {
+	var count int
+	err := m.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'notes'",
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RunMigrations applies all embedded migrations without taking a backup
 func RunMigrations(db *sql.DB) error {
-	query := `
-		CREATE TABLE IF NOT EXISTS notes (
-			id TEXT PRIMARY KEY,
-			content TEXT NOT NULL,
-			done BOOLEAN NOT NULL DEFAULT 0,
-			created_at DATETIME NOT NULL,
-			updated_at DATETIME NOT NULL
-		);
-		CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(created_at);
-	`
-	_, err := db.Exec(query)
-	return err
+	migrator, err := NewMigrator(db, "", nil)
+	if err != nil {
+		return err
+	}
+	return migrator.Migrate(context.Background())
 }
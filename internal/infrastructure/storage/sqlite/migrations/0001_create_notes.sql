@@ -0,0 +1,10 @@
+-- Baseline notes schema. IF NOT EXISTS keeps this step safe for databases
+-- created before versioned migrations were introduced.
+CREATE TABLE IF NOT EXISTS notes (
+	id TEXT PRIMARY KEY,
+	content TEXT NOT NULL,
+	done BOOLEAN NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(created_at);
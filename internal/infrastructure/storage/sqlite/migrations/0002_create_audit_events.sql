@@ -0,0 +1,23 @@
+-- Append-only audit trail for note mutations. Rows are written in the same
+-- transaction as the change they describe; triggers reject edits and deletes.
+CREATE TABLE audit_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	actor TEXT NOT NULL,
+	operation TEXT NOT NULL,
+	note_id TEXT NOT NULL,
+	before_json TEXT,
+	after_json TEXT,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX idx_audit_events_note_id ON audit_events(note_id, id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
@@ -0,0 +1,11 @@
+-- Previous versions of a note, captured whenever its content or done state changes.
+CREATE TABLE note_revisions (
+	note_id TEXT NOT NULL,
+	revision INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	done BOOLEAN NOT NULL,
+	updated_at DATETIME NOT NULL,
+	recorded_at DATETIME NOT NULL,
+	actor TEXT NOT NULL,
+	PRIMARY KEY (note_id, revision)
+);
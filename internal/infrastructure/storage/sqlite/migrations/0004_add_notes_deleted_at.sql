@@ -0,0 +1,4 @@
+-- Soft deletion: trashed notes keep their row until purged
+ALTER TABLE notes ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_notes_deleted_at ON notes(deleted_at);
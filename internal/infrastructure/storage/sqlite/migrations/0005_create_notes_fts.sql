@@ -0,0 +1,25 @@
+-- Full-text index over note content. The index is a standalone FTS5 table keyed
+-- by note_id rather than an external-content table, because notes uses a TEXT
+-- primary key and its implicit rowid is not stable across VACUUM.
+CREATE VIRTUAL TABLE notes_fts USING fts5(
+	note_id UNINDEXED,
+	content,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO notes_fts (note_id, content) SELECT id, content FROM notes;
+
+CREATE TRIGGER notes_fts_after_insert AFTER INSERT ON notes
+BEGIN
+	INSERT INTO notes_fts (note_id, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER notes_fts_after_update AFTER UPDATE OF content ON notes
+BEGIN
+	UPDATE notes_fts SET content = new.content WHERE note_id = old.id;
+END;
+
+CREATE TRIGGER notes_fts_after_delete AFTER DELETE ON notes
+BEGIN
+	DELETE FROM notes_fts WHERE note_id = old.id;
+END;
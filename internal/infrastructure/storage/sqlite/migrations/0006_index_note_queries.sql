@@ -0,0 +1,5 @@
+-- Partial indexes backing filtered note queries. They only cover notes outside
+-- the trash, which is the condition every list query starts with.
+CREATE INDEX IF NOT EXISTS idx_notes_active_created ON notes(created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notes_active_updated ON notes(updated_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notes_active_done_created ON notes(done, created_at, id) WHERE deleted_at IS NULL;
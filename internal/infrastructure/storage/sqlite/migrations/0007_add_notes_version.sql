@@ -0,0 +1,3 @@
+-- Optimistic concurrency: every write bumps version, and conditional writes
+-- only apply when the caller saw the current one
+ALTER TABLE notes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,12 @@
+-- Content encryption: a single row records how the key is derived and a
+-- value sealed with it, so a wrong passphrase is caught before any note is read
+CREATE TABLE encryption (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	kdf TEXT NOT NULL,
+	salt BLOB,
+	time_cost INTEGER NOT NULL DEFAULT 0,
+	memory_kib INTEGER NOT NULL DEFAULT 0,
+	threads INTEGER NOT NULL DEFAULT 0,
+	key_check TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
@@ -0,0 +1,26 @@
+-- Two-way sync with a remote API. sync_changes holds one row per note with
+-- local edits not yet pushed; rewriting a row gives it a new seq, so the log
+-- stays in edit order. sync_links maps local notes to remote notes and keeps
+-- the remote version last seen, and sync_state holds the pull cursor.
+CREATE TABLE sync_changes (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	note_id TEXT NOT NULL UNIQUE,
+	operation TEXT NOT NULL,
+	changed_at DATETIME NOT NULL
+);
+
+CREATE TABLE sync_links (
+	note_id TEXT PRIMARY KEY,
+	remote_id TEXT NOT NULL UNIQUE,
+	remote_version INTEGER NOT NULL DEFAULT 0,
+	remote_updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE sync_state (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+-- Notes written before change tracking existed are pushed on the first sync
+INSERT INTO sync_changes (note_id, operation, changed_at)
+SELECT id, 'upsert', updated_at FROM notes WHERE deleted_at IS NULL ORDER BY created_at;
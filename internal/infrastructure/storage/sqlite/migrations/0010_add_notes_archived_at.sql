@@ -0,0 +1,5 @@
+-- Archiving: archived notes stay readable by ID but are left out of lists
+-- unless asked for
+ALTER TABLE notes ADD COLUMN archived_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_notes_active_archived ON notes(archived_at) WHERE deleted_at IS NULL;
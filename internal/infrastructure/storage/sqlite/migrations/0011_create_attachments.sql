@@ -0,0 +1,14 @@
+-- Files attached to notes. The bytes live in blob files named by their
+-- SHA-256, so a file attached to several notes is stored once.
+CREATE TABLE attachments (
+	id TEXT PRIMARY KEY,
+	note_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	sha256 TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_note ON attachments(note_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_attachments_sha256 ON attachments(sha256);
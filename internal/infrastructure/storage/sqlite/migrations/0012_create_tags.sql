@@ -0,0 +1,14 @@
+-- Tags: each name is stored once and joined to the notes carrying it, so
+-- renaming or merging a tag touches one table
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE note_tags (
+	note_id TEXT NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_tags_tag ON note_tags(tag_id, note_id);
@@ -0,0 +1,28 @@
+-- Timestamps are now written in UTC as "YYYY-MM-DD HH:MM:SS.fff+00:00", so
+-- that ORDER BY, range filters and cursors can compare the stored text.
+-- Rewrite note times written earlier in Go's time.String() layout, which
+-- carries whatever zone the writer was in, keeping the fraction as written.
+-- The other tables were always written in UTC and already sort correctly.
+UPDATE notes SET
+	created_at = CASE WHEN created_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *'
+		THEN datetime(substr(created_at, 1, 19) || substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+			|| substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) || '+00:00'
+		ELSE created_at END,
+	updated_at = CASE WHEN updated_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *'
+		THEN datetime(substr(updated_at, 1, 19) || substr(updated_at, instr(substr(updated_at, 20), ' ') + 20, 3) || ':' || substr(updated_at, instr(substr(updated_at, 20), ' ') + 23, 2))
+			|| substr(updated_at, 20, instr(substr(updated_at, 20), ' ') - 1) || '+00:00'
+		ELSE updated_at END,
+	deleted_at = CASE WHEN deleted_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *'
+		THEN datetime(substr(deleted_at, 1, 19) || substr(deleted_at, instr(substr(deleted_at, 20), ' ') + 20, 3) || ':' || substr(deleted_at, instr(substr(deleted_at, 20), ' ') + 23, 2))
+			|| substr(deleted_at, 20, instr(substr(deleted_at, 20), ' ') - 1) || '+00:00'
+		ELSE deleted_at END,
+	archived_at = CASE WHEN archived_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *'
+		THEN datetime(substr(archived_at, 1, 19) || substr(archived_at, instr(substr(archived_at, 20), ' ') + 20, 3) || ':' || substr(archived_at, instr(substr(archived_at, 20), ' ') + 23, 2))
+			|| substr(archived_at, 20, instr(substr(archived_at, 20), ' ') - 1) || '+00:00'
+		ELSE archived_at END;
+
+UPDATE note_revisions SET
+	updated_at = CASE WHEN updated_at GLOB '????-??-?? ??:??:??* [+-][0-9][0-9][0-9][0-9] *'
+		THEN datetime(substr(updated_at, 1, 19) || substr(updated_at, instr(substr(updated_at, 20), ' ') + 20, 3) || ':' || substr(updated_at, instr(substr(updated_at, 20), ' ') + 23, 2))
+			|| substr(updated_at, 20, instr(substr(updated_at, 20), ' ') - 1) || '+00:00'
+		ELSE updated_at END;
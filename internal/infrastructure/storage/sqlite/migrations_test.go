@@ -0,0 +1,250 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestLoadMigrations_OrderedAndChecksummed(t *testing.T) {
+	t.Parallel()
+	migs, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations: %v", err)
+	}
+	if len(migs) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for i, m := range migs {
+		if m.Version != i+1 {
+			t.Fatalf("migration %d has version %d; versions must be contiguous", i, m.Version)
+		}
+		if len(m.Checksum) != 64 {
+			t.Fatalf("migration %d checksum %q", m.Version, m.Checksum)
+		}
+	}
+}
+
+func TestParseMigrationName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		file    string
+		version int
+		name    string
+		wantErr bool
+	}{
+		{file: "0001_create_notes.sql", version: 1, name: "create_notes"},
+		{file: "0012_add_x.sql", version: 12, name: "add_x"},
+		{file: "create_notes.sql", wantErr: true},
+		{file: "0000_zero.sql", wantErr: true},
+		{file: "0003_.sql", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			t.Parallel()
+			v, n, err := parseMigrationName(tt.file)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil || v != tt.version || n != tt.name {
+				t.Fatalf("got (%d, %q, %v)", v, n, err)
+			}
+		})
+	}
+}
+
+func TestMigrator_FreshDatabase(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "fresh.db")
+	db := openTestDB(t, path)
+
+	m, err := NewMigrator(db, path, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	got, err := m.CurrentVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != m.LatestVersion() {
+		t.Fatalf("version=%d want %d", got, m.LatestVersion())
+	}
+
+	// Second run is a no-op
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+
+	backups, _ := filepath.Glob(path + ".pre-v*.bak")
+	if len(backups) != 0 {
+		t.Fatalf("fresh database should not be backed up, found %v", backups)
+	}
+}
+
+func TestMigrator_LegacyDatabaseIsBackedUp(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db := openTestDB(t, path)
+
+	// Schema as written by the pre-versioning RunMigrations
+	if _, err := db.Exec(`CREATE TABLE notes (
+		id TEXT PRIMARY KEY, content TEXT NOT NULL, done BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL, updated_at DATETIME NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO notes VALUES ('a', 'keep me', 0, '2024-01-01', '2024-01-01')`); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(db, path, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	backups, _ := filepath.Glob(path + ".pre-v*.bak")
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v", backups)
+	}
+	if info, err := os.Stat(backups[0]); err != nil || info.Size() == 0 {
+		t.Fatalf("backup not written: %v", err)
+	}
+
+	var content string
+	if err := db.QueryRow("SELECT content FROM notes WHERE id = 'a'").Scan(&content); err != nil || content != "keep me" {
+		t.Fatalf("legacy row lost: %q %v", content, err)
+	}
+}
+
+func TestMigrator_NormalizesNoteTimestamps(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "zones.db")
+	db := openTestDB(t, path)
+
+	m, err := NewMigrator(db, path, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := m.migrations
+	m.migrations = all[:12]
+	if err = m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	// As time.String() wrote them: a local note and, an hour earlier, an
+	// imported one kept in UTC
+	edt := time.FixedZone("EDT", -4*60*60)
+	local := time.Date(2026, 10, 16, 22, 58, 47, 123000000, edt)
+	imported := local.Add(-time.Hour).UTC()
+	for id, at := range map[string]time.Time{"local": local, "imported": imported} {
+		if _, err = db.ExecContext(ctx,
+			"INSERT INTO notes (id, content, done, created_at, updated_at) VALUES (?, ?, 0, ?, ?)",
+			id, id, at.String(), at.String(),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.migrations = all
+	if err = m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	var stored []string
+	rows, err := db.QueryContext(ctx, "SELECT CAST(created_at AS TEXT) FROM notes ORDER BY created_at DESC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var at string
+		if err = rows.Scan(&at); err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, at)
+	}
+	want := []string{"2026-10-17 02:58:47.123+00:00", "2026-10-17 01:58:47.123+00:00"}
+	if !slices.Equal(stored, want) {
+		t.Fatalf("stored times = %q, want %q", stored, want)
+	}
+}
+
+func TestMigrator_RejectsNewerSchema(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "newer.db")
+	db := openTestDB(t, path)
+
+	m, err := NewMigrator(db, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(
+		"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, 'future', 'x', CURRENT_TIMESTAMP)",
+		m.LatestVersion()+1,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Migrate(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestMigrator_DetectsEditedMigration(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db := openTestDB(t, filepath.Join(t.TempDir(), "edited.db"))
+
+	m, err := NewMigrator(db, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE schema_migrations SET checksum = 'tampered' WHERE version = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Migrate(ctx)
+	var migErr *MigrationError
+	if !errors.As(err, &migErr) || !errors.Is(err, ErrMigrationChecksum) {
+		t.Fatalf("expected checksum MigrationError, got %v", err)
+	}
+	if migErr.Version != 1 || !strings.Contains(err.Error(), "0001_") {
+		t.Fatalf("unexpected error detail: %v", err)
+	}
+}
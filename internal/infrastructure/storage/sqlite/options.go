@@ -0,0 +1,171 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+// Options tunes the database connections a Store opens. The pragmas are
+// applied by the driver to every new connection in the pool, not just the
+// first one.
+type Options struct {
+	// JournalMode is DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF. WAL lets
+	// readers proceed while a write is in progress.
+	JournalMode string
+	// BusyTimeout is how long a connection waits for a lock before failing
+	// with "database is locked"
+	BusyTimeout time.Duration
+	// Synchronous is OFF, NORMAL, FULL or EXTRA
+	Synchronous string
+	// ForeignKeys enforces foreign key constraints
+	ForeignKeys bool
+	// CacheSize is the page cache per connection: pages when positive,
+	// KiB when negative, as in PRAGMA cache_size
+	CacheSize int
+	// MaxOpenConns caps the connection pool; 0 leaves it unlimited
+	MaxOpenConns int
+	// MaxIdleConns is how many idle connections the pool keeps
+	MaxIdleConns int
+	// Key encrypts note content when set; an encrypted database cannot be
+	// opened without it
+	Key KeySource
+}
+
+// DefaultOptions returns settings suited to one desktop process serving both
+// the UI and the HTTP API
+func DefaultOptions() Options {
+	return Options{
+		JournalMode:  "WAL",
+		BusyTimeout:  5 * time.Second,
+		Synchronous:  "NORMAL",
+		ForeignKeys:  true,
+		CacheSize:    -2000,
+		MaxOpenConns: 4,
+		MaxIdleConns: 4,
+	}
+}
+
+var (
+	journalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	syncLevels   = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+// Validate checks the options before they are turned into pragmas
+func (o Options) Validate() error {
+	if !containsFold(journalModes, o.JournalMode) {
+		return fmt.Errorf("invalid journal mode %q: want one of %s", o.JournalMode, strings.Join(journalModes, ", "))
+	}
+	if !containsFold(syncLevels, o.Synchronous) {
+		return fmt.Errorf("invalid synchronous level %q: want one of %s", o.Synchronous, strings.Join(syncLevels, ", "))
+	}
+	if o.BusyTimeout < 0 {
+		return fmt.Errorf("busy timeout must not be negative")
+	}
+	if o.MaxOpenConns < 0 || o.MaxIdleConns < 0 {
+		return fmt.Errorf("connection limits must not be negative")
+	}
+	return nil
+}
+
+// dsn builds the data source name that makes the driver apply the options
+// to each connection it opens. Write transactions start with BEGIN IMMEDIATE
+// so that a writer waits for the lock up front, within the busy timeout,
+// instead of failing when it tries to upgrade a read lock. Times are written
+// in UTC in one layout, so the stored text sorts and compares in time order
+// whatever zone a note's timestamps arrived in, and are read back in UTC.
+func (o Options) dsn(path string) string {
+	pragmas := []string{
+		"busy_timeout(" + strconv.FormatInt(o.BusyTimeout.Milliseconds(), 10) + ")",
+		"journal_mode(" + strings.ToUpper(o.JournalMode) + ")",
+		"synchronous(" + strings.ToUpper(o.Synchronous) + ")",
+		"foreign_keys(" + strconv.FormatBool(o.ForeignKeys) + ")",
+		"cache_size(" + strconv.Itoa(o.CacheSize) + ")",
+	}
+	query := url.Values{
+		"_pragma":      pragmas,
+		"_txlock":      {"immediate"},
+		"_time_format": {"sqlite"},
+		"_timezone":    {"UTC"},
+	}
+	return path + "?" + query.Encode()
+}
+
+// rawDSN opens path with only the busy timeout set. Unlike the other
+// pragmas it never reads the schema, so a connection to a database whose
+// schema pages are damaged still opens.
+func (o Options) rawDSN(path string) string {
+	query := url.Values{"_pragma": {"busy_timeout(" + strconv.FormatInt(o.BusyTimeout.Milliseconds(), 10) + ")"}}
+	return path + "?" + query.Encode()
+}
+
+// configurePool applies the connection limits to db
+func (o Options) configurePool(db *sql.DB) {
+	db.SetMaxOpenConns(o.MaxOpenConns)
+	db.SetMaxIdleConns(o.MaxIdleConns)
+}
+
+// logEffective reads the settings back from a live connection, since SQLite
+// can refuse a requested journal mode, and logs them
+func (o Options) logEffective(ctx context.Context, db *sql.DB, log logger.Logger) error {
+	var (
+		journalMode string
+		busyTimeout int
+		synchronous int
+		foreignKeys bool
+		cacheSize   int
+	)
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for pragma, dest := range map[string]any{
+		"journal_mode": &journalMode,
+		"busy_timeout": &busyTimeout,
+		"synchronous":  &synchronous,
+		"foreign_keys": &foreignKeys,
+		"cache_size":   &cacheSize,
+	} {
+		if err = conn.QueryRowContext(ctx, "PRAGMA "+pragma).Scan(dest); err != nil {
+			return fmt.Errorf("failed to read PRAGMA %s: %w", pragma, err)
+		}
+	}
+
+	log.Info("SQLite connection settings",
+		"journal_mode", journalMode,
+		"busy_timeout_ms", busyTimeout,
+		"synchronous", syncLevelName(synchronous),
+		"foreign_keys", foreignKeys,
+		"cache_size", cacheSize,
+		"max_open_conns", o.MaxOpenConns,
+		"max_idle_conns", o.MaxIdleConns,
+	)
+	return nil
+}
+
+// syncLevelName maps PRAGMA synchronous's numeric result to its name
+func syncLevelName(level int) string {
+	if level >= 0 && level < len(syncLevels) {
+		return syncLevels[level]
+	}
+	return strconv.Itoa(level)
+}
+
+// containsFold reports whether values holds s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+)
+
+func TestNewWithOptions_AppliesPragmasToEveryConnection(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	opts := DefaultOptions()
+	opts.BusyTimeout = 1234 * time.Millisecond
+	opts.Synchronous = "full"
+	opts.CacheSize = -4096
+
+	st, err := NewWithOptions(filepath.Join(t.TempDir(), "notes.db"), opts, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	// Hold two connections at once so the pool has to open a second one
+	first, err := st.db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := st.db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	for i, conn := range []interface {
+		QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	}{first, second} {
+		var journalMode string
+		var busyTimeout, synchronous, foreignKeys, cacheSize int
+		if err = conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil {
+			t.Fatal(err)
+		}
+		if err = conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+			t.Fatal(err)
+		}
+		if err = conn.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous); err != nil {
+			t.Fatal(err)
+		}
+		if err = conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+			t.Fatal(err)
+		}
+		if err = conn.QueryRowContext(ctx, "PRAGMA cache_size").Scan(&cacheSize); err != nil {
+			t.Fatal(err)
+		}
+		got := fmt.Sprintf("%s %d %s %d %d", journalMode, busyTimeout, syncLevelName(synchronous), foreignKeys, cacheSize)
+		if want := "wal 1234 FULL 1 -4096"; got != want {
+			t.Fatalf("connection %d settings=%q want %q", i, got, want)
+		}
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	t.Parallel()
+	if err := DefaultOptions().Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+
+	bad := map[string]func(*Options){
+		"journal mode": func(o *Options) { o.JournalMode = "wal); DROP TABLE notes; --" },
+		"synchronous":  func(o *Options) { o.Synchronous = "sometimes" },
+		"busy timeout": func(o *Options) { o.BusyTimeout = -time.Second },
+		"max open":     func(o *Options) { o.MaxOpenConns = -1 },
+	}
+	for name, mutate := range bad {
+		opts := DefaultOptions()
+		mutate(&opts)
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+func TestStore_ConcurrentWritersDoNotLock(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	const writers, perWriter = 8, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWriter {
+				note := model.NewNote(fmt.Sprintf("writer %d note %d", w, i))
+				if err := st.Add(ctx, note); err != nil {
+					errs <- err
+					return
+				}
+				note.Done = true
+				if err := st.Update(ctx, note); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := st.List(ctx); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent write failed: %v", err)
+	}
+
+	notes, err := st.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != writers*perWriter {
+		t.Fatalf("got %d notes want %d", len(notes), writers*perWriter)
+	}
+
+	st.stmts.mu.Lock()
+	cached := len(st.stmts.stmts)
+	st.stmts.mu.Unlock()
+	if cached != len(hotStatements) {
+		t.Fatalf("statement cache holds %d statements, want %d", cached, len(hotStatements))
+	}
+}
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestStore_PageWalksEveryNoteOnce(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	// Several notes share a timestamp so the id tie-breaker is exercised, and
+	// they are written in different zones, as import and sync do
+	zones := []*time.Location{time.UTC, time.FixedZone("EDT", -4*60*60), time.FixedZone("IST", 5*60*60+30*60)}
+	base := time.Now()
+	created := map[string]time.Time{}
+	for i := range 7 {
+		n := &model.Note{
+			ID:        fmt.Sprintf("n%d", i),
+			Content:   fmt.Sprintf("note %d", i),
+			CreatedAt: base.Add(time.Duration(i/3) * time.Second).In(zones[i%len(zones)]),
+			UpdatedAt: base,
+		}
+		created[n.ID] = n.CreatedAt
+		if err := st.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, sort := range []model.NoteSort{model.SortCreatedDesc, model.SortCreatedAsc} {
+		t.Run(string(sort), func(t *testing.T) {
+			t.Parallel()
+			limit := 2
+			filter := model.NoteFilter{Limit: &limit, Sort: sort}
+			seen := map[string]bool{}
+			var order []string
+			for pages := 0; ; pages++ {
+				if pages > 10 {
+					t.Fatal("pagination did not terminate")
+				}
+				notes, next, err := st.Page(ctx, filter)
+				if err != nil {
+					t.Fatalf("Page: %v", err)
+				}
+				for _, n := range notes {
+					if seen[n.ID] {
+						t.Fatalf("note %s returned twice", n.ID)
+					}
+					seen[n.ID] = true
+					order = append(order, n.ID)
+				}
+				if next == "" {
+					break
+				}
+				filter.Cursor = next
+
+				// A note inserted mid-walk ahead of the cursor must not shift later pages
+				if pages == 0 {
+					extra := model.NewNote("late " + string(sort))
+					extra.CreatedAt = base.Add(-time.Hour)
+					if sort == model.SortCreatedDesc {
+						extra.CreatedAt = base.Add(time.Hour)
+					}
+					if err := st.Add(ctx, extra); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if len(seen) < 7 {
+				t.Fatalf("expected all 7 notes, saw %v", order)
+			}
+			var prev time.Time
+			for _, id := range order {
+				at, ok := created[id]
+				if !ok {
+					continue
+				}
+				if !prev.IsZero() && (sort == model.SortCreatedDesc && at.After(prev) ||
+					sort == model.SortCreatedAsc && at.Before(prev)) {
+					t.Fatalf("notes out of %q order: %v", sort, order)
+				}
+				prev = at
+			}
+		})
+	}
+}
+
+func TestStore_PageRejectsBadCursor(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	if _, _, err := st.Page(ctx, model.NoteFilter{Cursor: "not-a-cursor"}); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+	if _, _, err := st.Page(ctx, model.NoteFilter{Cursor: "eyJrIjoiYSIsImkiOiJiIn0", Sort: model.SortUpdatedAsc}); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor for updated_at sort, got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+)
+
+// sortClauses maps each supported sort order to its ORDER BY clause
+var sortClauses = map[model.NoteSort]string{
+	model.SortCreatedDesc: "created_at DESC, id DESC",
+	model.SortCreatedAsc:  "created_at ASC, id ASC",
+	model.SortUpdatedDesc: "updated_at DESC, id DESC",
+	model.SortUpdatedAsc:  "updated_at ASC, id ASC",
+}
+
+// likeEscaper escapes LIKE wildcards so content filters match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Query returns the notes outside the trash that match filter. Content is
+// matched case-insensitively as a substring. Filter.Cursor is ignored; use Page.
+func (s *Store) Query(ctx context.Context, filter model.NoteFilter) ([]model.Note, error) {
+	if s.contentInMemory(filter) {
+		return s.queryDecrypted(ctx, filter)
+	}
+
+	conditions, args := filterConditions(filter)
+	orderBy := orderClause(filter.Sort)
+
+	if filter.Limit != nil && *filter.Limit > 0 {
+		orderBy += " LIMIT ?"
+		args = append(args, *filter.Limit)
+	} else if filter.Offset != nil && *filter.Offset > 0 {
+		orderBy += " LIMIT -1"
+	}
+	if filter.Offset != nil && *filter.Offset > 0 {
+		orderBy += " OFFSET ?"
+		args = append(args, *filter.Offset)
+	}
+
+	return queryNotes(ctx, s.conn(), strings.Join(conditions, " AND "), orderBy, args...)
+}
+
+// Page returns one page of the notes matching filter using keyset
+// pagination over (created_at, id), together with the cursor for the next
+// page. Cursors carry the stored created_at text so that comparisons match
+// the ORDER BY exactly; the driver writes every timestamp in UTC in one
+// layout (see Options.dsn), so that text is in time order whatever zone a
+// note was written in. Filters that cannot use a keyset are answered by
+// Query as a single page.
+func (s *Store) Page(ctx context.Context, filter model.NoteFilter) ([]model.Note, string, error) {
+	if !filter.UsesKeyset() {
+		if filter.Cursor != "" {
+			return nil, "", model.ErrInvalidCursor
+		}
+		notes, err := s.Query(ctx, filter)
+		return notes, "", err
+	}
+
+	var needle string
+	inMemory := s.contentInMemory(filter)
+	if inMemory {
+		needle, filter.Content = *filter.Content, nil
+	}
+
+	conditions, args := filterConditions(filter)
+	desc := filter.Sort != model.SortCreatedAsc
+
+	if filter.Cursor != "" {
+		key, id, err := domainstorage.DecodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		// The key is bound as text, so it compares lexically against the
+		// stored text exactly as ORDER BY does, and the index stays usable
+		conditions = append(conditions,
+			"(created_at "+op+" ? OR (created_at = ? AND id "+op+" ?))")
+		args = append(args, key, key, id)
+	}
+
+	orderBy := orderClause(filter.Sort)
+	limit := 0
+	if filter.Limit != nil && *filter.Limit > 0 {
+		// Fetch one extra row to learn whether another page follows
+		limit = *filter.Limit
+		if !inMemory {
+			orderBy += " LIMIT ?"
+			args = append(args, limit+1)
+		}
+	}
+
+	rows, err := s.conn().QueryContext(ctx,
+		"SELECT "+noteColumns+", CAST(created_at AS TEXT) FROM notes WHERE "+strings.Join(conditions, " AND ")+" ORDER BY "+orderBy,
+		args...,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var notes []model.Note
+	var keys []string
+	for rows.Next() {
+		var key string
+		note, scanErr := scanNote(extraColumns{rows: rows, extra: []any{&key}}, s.keys)
+		if scanErr != nil {
+			return nil, "", scanErr
+		}
+		if inMemory && !contentMatches(note.Content, needle) {
+			continue
+		}
+		notes = append(notes, note)
+		keys = append(keys, key)
+		if limit > 0 && len(notes) > limit {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if limit == 0 || len(notes) <= limit {
+		return notes, "", nil
+	}
+	notes = notes[:limit]
+	last := notes[limit-1]
+	return notes, domainstorage.EncodeCursor(keys[limit-1], last.ID), nil
+}
+
+// contentInMemory reports whether filter matches content that is encrypted
+// at rest, which SQL cannot see, so the match runs on decrypted notes
+func (s *Store) contentInMemory(filter model.NoteFilter) bool {
+	return filter.Content != nil && *filter.Content != "" && s.keys.enabled()
+}
+
+// queryDecrypted answers Query for an encrypted store: SQL applies every
+// other criterion, then the content match, offset and limit run in memory
+func (s *Store) queryDecrypted(ctx context.Context, filter model.NoteFilter) ([]model.Note, error) {
+	needle := *filter.Content
+	unpaged := filter
+	unpaged.Content, unpaged.Limit, unpaged.Offset = nil, nil, nil
+	candidates, err := s.Query(ctx, unpaged)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []model.Note
+	for _, note := range candidates {
+		if contentMatches(note.Content, needle) {
+			notes = append(notes, note)
+		}
+	}
+	if filter.Offset != nil && *filter.Offset > 0 {
+		notes = notes[min(*filter.Offset, len(notes)):]
+	}
+	if filter.Limit != nil && *filter.Limit > 0 && len(notes) > *filter.Limit {
+		notes = notes[:*filter.Limit]
+	}
+	return notes, nil
+}
+
+// contentMatches reports whether content contains needle, ignoring case
+func contentMatches(content, needle string) bool {
+	return strings.Contains(strings.ToLower(content), strings.ToLower(needle))
+}
+
+// filterConditions translates the criteria of a filter into SQL conditions
+func filterConditions(filter model.NoteFilter) ([]string, []any) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+
+	switch filter.Archived {
+	case model.ArchiveOnly:
+		conditions = append(conditions, "archived_at IS NOT NULL")
+	case model.ArchiveInclude:
+	default:
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+	if filter.Done != nil {
+		conditions = append(conditions, "done = ?")
+		args = append(args, *filter.Done)
+	}
+	if filter.Content != nil && *filter.Content != "" {
+		conditions = append(conditions, `content LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(*filter.Content)+"%")
+	}
+	// The driver writes bounds in UTC like the stored timestamps, so the
+	// text comparison follows time order
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, *filter.CreatedBefore)
+	}
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, "EXISTS ("+noteHasTagSQL+")")
+		args = append(args, model.NormalizeTag(tag))
+	}
+	for _, tag := range filter.ExcludeTags {
+		conditions = append(conditions, "NOT EXISTS ("+noteHasTagSQL+")")
+		args = append(args, model.NormalizeTag(tag))
+	}
+	return conditions, args
+}
+
+// orderClause returns the ORDER BY clause for a sort order
+func orderClause(order model.NoteSort) string {
+	if clause, ok := sortClauses[order]; ok {
+		return clause
+	}
+	return sortClauses[model.DefaultNoteSort]
+}
+
+// extraColumns appends destinations for columns selected after noteColumns
+type extraColumns struct {
+	rows  rowScanner
+	extra []any
+}
+
+func (r extraColumns) Scan(dest ...any) error {
+	return r.rows.Scan(append(dest, r.extra...)...)
+}
@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestStore_Query(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	notes := []*model.Note{
+		{ID: "a", Content: "Buy milk", CreatedAt: base, UpdatedAt: base.Add(3 * time.Hour)},
+		{ID: "b", Content: "call mum", Done: true, CreatedAt: base.Add(time.Hour), UpdatedAt: base.Add(time.Hour)},
+		{ID: "c", Content: "100% milk_shake", Done: true, CreatedAt: base.Add(2 * time.Hour), UpdatedAt: base.Add(2 * time.Hour)},
+		{ID: "d", Content: "trashed milk", CreatedAt: base.Add(4 * time.Hour), UpdatedAt: base.Add(4 * time.Hour)},
+	}
+	for _, n := range notes {
+		if err := st.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Delete(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+
+	done := true
+	milk := "MILK"
+	literal := "0% m"
+	wildcard := "_"
+	// Bounds in another zone are compared by instant
+	after := base.Add(30 * time.Minute).UTC()
+	before := base.Add(90 * time.Minute).UTC()
+	one := 1
+
+	tests := []struct {
+		name   string
+		filter model.NoteFilter
+		want   []string
+	}{
+		{name: "default newest first", filter: model.NoteFilter{}, want: []string{"c", "b", "a"}},
+		{name: "done", filter: model.NoteFilter{Done: &done}, want: []string{"c", "b"}},
+		{name: "content case-insensitive", filter: model.NoteFilter{Content: &milk}, want: []string{"c", "a"}},
+		{name: "percent is literal", filter: model.NoteFilter{Content: &literal}, want: []string{"c"}},
+		{name: "underscore is literal", filter: model.NoteFilter{Content: &wildcard}, want: []string{"c"}},
+		{name: "created range", filter: model.NoteFilter{CreatedAfter: &after, CreatedBefore: &before}, want: []string{"b"}},
+		{name: "updated asc", filter: model.NoteFilter{Sort: model.SortUpdatedAsc}, want: []string{"b", "c", "a"}},
+		{name: "limit", filter: model.NoteFilter{Limit: &one}, want: []string{"c"}},
+		{name: "offset without limit", filter: model.NoteFilter{Offset: &one}, want: []string{"b", "a"}},
+		{name: "limit and offset", filter: model.NoteFilter{Limit: &one, Offset: &one}, want: []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := st.Query(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			ids := make([]string, len(got))
+			for i, n := range got {
+				ids[i] = n.ID
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("got %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStore_QueryUsesIndex(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+
+	var id, parent, notused int
+	var detail string
+	err := st.db.QueryRow(
+		"EXPLAIN QUERY PLAN SELECT "+noteColumns+" FROM notes WHERE deleted_at IS NULL AND done = 1 ORDER BY created_at DESC, id DESC",
+	).Scan(&id, &parent, &notused, &detail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "idx_notes_active_done_created"; !strings.Contains(detail, want) {
+		t.Fatalf("query plan %q does not use %s", detail, want)
+	}
+}
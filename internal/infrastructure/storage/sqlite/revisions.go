@@ -0,0 +1,93 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// revisionColumns is the column list shared by every note_revisions SELECT
+const revisionColumns = "note_id, revision, content, done, updated_at, recorded_at, actor"
+
+// insertRevisionSQL numbers and stores a revision; it runs on every edit
+const insertRevisionSQL = `
+		INSERT INTO note_revisions (` + revisionColumns + `)
+		SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?, ?
+		FROM note_revisions WHERE note_id = ?`
+
+// insertRevision stores the previous state of a note as its next revision
+func insertRevision(ctx context.Context, q queryer, before *model.Note) error {
+	content, err := keysOf(q).seal(before.Content)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, insertRevisionSQL,
+		before.ID, content, before.Done, before.UpdatedAt, time.Now().UTC(), audit.ActorFromContext(ctx),
+		before.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record note revision: %w", err)
+	}
+	return nil
+}
+
+// scanRevision reads a row selected with revisionColumns, decrypting its content with keys
+func scanRevision(row rowScanner, keys *keyring) (model.NoteRevision, error) {
+	var rev model.NoteRevision
+	err := row.Scan(&rev.NoteID, &rev.Revision, &rev.Content, &rev.Done, &rev.UpdatedAt, &rev.RecordedAt, &rev.Actor)
+	if err != nil {
+		return rev, err
+	}
+	if rev.Content, err = keys.open(rev.Content); err != nil {
+		return rev, fmt.Errorf("note %s revision %d: %w", rev.NoteID, rev.Revision, err)
+	}
+	return rev, nil
+}
+
+// ListRevisions returns the revisions of a note, newest first
+func (s *Store) ListRevisions(ctx context.Context, noteID string) ([]model.NoteRevision, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	rows, err := s.conn().QueryContext(ctx,
+		"SELECT "+revisionColumns+" FROM note_revisions WHERE note_id = ? ORDER BY revision DESC",
+		noteID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]model.NoteRevision, 0)
+	for rows.Next() {
+		rev, scanErr := scanRevision(rows, s.keys)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// GetRevision returns a single revision of a note
+func (s *Store) GetRevision(ctx context.Context, noteID string, revision int) (*model.NoteRevision, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	rev, err := scanRevision(s.conn().QueryRowContext(ctx,
+		"SELECT "+revisionColumns+" FROM note_revisions WHERE note_id = ? AND revision = ?",
+		noteID, revision,
+	), s.keys)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s revision %d", model.ErrRevisionNotFound, noteID, revision)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	storeerrors "github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
+)
+
+func TestStore_UpdateRecordsRevisions(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("v1")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	note.UpdateContent("v2")
+	if err := st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	note.ToggleDone()
+	if err := st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	// Saving without changing content or done does not add a revision
+	if err := st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := st.ListRevisions(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %d", len(revisions))
+	}
+	if revisions[0].Revision != 2 || revisions[0].Content != "v2" || revisions[0].Done {
+		t.Fatalf("unexpected newest revision: %+v", revisions[0])
+	}
+	if revisions[1].Revision != 1 || revisions[1].Content != "v1" {
+		t.Fatalf("unexpected oldest revision: %+v", revisions[1])
+	}
+
+	rev, err := st.GetRevision(ctx, note.ID, 1)
+	if err != nil || rev.Content != "v1" {
+		t.Fatalf("GetRevision: %+v %v", rev, err)
+	}
+	if _, err := st.GetRevision(ctx, note.ID, 9); !errors.Is(err, model.ErrRevisionNotFound) {
+		t.Fatalf("expected ErrRevisionNotFound, got %v", err)
+	}
+}
+
+func TestStore_PurgeRemovesRevisions(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("a")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	note.UpdateContent("b")
+	if err := st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Purge(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := st.ListRevisions(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 0 {
+		t.Fatalf("expected revisions to be removed, got %d", len(revisions))
+	}
+}
+
+func TestStore_RevisionsAfterClose(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("a")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.ListRevisions(ctx, note.ID); !errors.Is(err, storeerrors.ErrStoreClosed) {
+		t.Fatalf("ListRevisions after Close: expected ErrStoreClosed, got %v", err)
+	}
+	if _, err := st.GetRevision(ctx, note.ID, 1); !errors.Is(err, storeerrors.ErrStoreClosed) {
+		t.Fatalf("GetRevision after Close: expected ErrStoreClosed, got %v", err)
+	}
+}
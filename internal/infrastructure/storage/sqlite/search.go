@@ -0,0 +1,191 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// snippetTokens is the approximate number of tokens in a search snippet
+const snippetTokens = 12
+
+// Search returns notes outside the trash whose content matches query, best
+// matches first. Each whitespace-separated term is matched as a prefix, so
+// partially typed words still find results.
+func (s *Store) Search(ctx context.Context, query string, limit int) ([]model.SearchHit, error) {
+	match := buildMatchQuery(query)
+	if match == "" {
+		return []model.SearchHit{}, nil
+	}
+	if s.keys.enabled() {
+		return s.searchDecrypted(ctx, query, limit)
+	}
+
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT n.id, n.content, n.done, n.created_at, n.updated_at, n.deleted_at, n.version, n.archived_at,
+			`+tagsColumn+`n.id), bm25(notes_fts), snippet(notes_fts, 1, ?, ?, '…', ?)
+		FROM notes_fts
+		JOIN notes n ON n.id = notes_fts.note_id
+		WHERE notes_fts MATCH ? AND n.deleted_at IS NULL
+		ORDER BY bm25(notes_fts)
+		LIMIT ?`,
+		model.HighlightStart, model.HighlightEnd, snippetTokens, match, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := make([]model.SearchHit, 0)
+	for rows.Next() {
+		var hit model.SearchHit
+		note, scanErr := scanNote(extraColumns{rows: rows, extra: []any{&hit.Rank, &hit.Snippet}}, s.keys)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		hit.Note = note
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// buildMatchQuery turns free text into an FTS5 query in which every term is a
+// quoted prefix match. Quoting keeps FTS5 operators and punctuation typed by
+// the user from being parsed as query syntax.
+func buildMatchQuery(query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// searchDecrypted answers Search for an encrypted store, whose full-text
+// index only ever sees ciphertext. It applies the same rule to the decrypted
+// notes, every query token prefix-matching a word, and ranks by the negated
+// number of matching words so that lower is better, as with bm25.
+func (s *Store) searchDecrypted(ctx context.Context, query string, limit int) ([]model.SearchHit, error) {
+	tokens := wordsOf(strings.ToLower(query))
+	if len(tokens) == 0 {
+		return []model.SearchHit{}, nil
+	}
+	notes, err := listNotes(ctx, s.conn())
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]model.SearchHit, 0)
+	for _, note := range notes {
+		words := wordSpans(note.Content)
+		matched, count := matchTokens(note.Content, words, tokens)
+		if matched == nil {
+			continue
+		}
+		hits = append(hits, model.SearchHit{
+			Note:    note,
+			Rank:    -float64(count),
+			Snippet: buildSnippet(note.Content, words, matched),
+		})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank < hits[j].Rank })
+	if limit >= 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// span is the byte range of one word in a string
+type span struct{ start, end int }
+
+// wordSpans splits text into runs of letters and digits, as the FTS
+// tokenizer does
+func wordSpans(text string) []span {
+	var spans []span
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(text)})
+	}
+	return spans
+}
+
+// wordsOf returns the words of text
+func wordsOf(text string) []string {
+	spans := wordSpans(text)
+	words := make([]string, len(spans))
+	for i, sp := range spans {
+		words[i] = text[sp.start:sp.end]
+	}
+	return words
+}
+
+// matchTokens marks the words of text that some token is a prefix of. It
+// returns nil when a token matches no word, and otherwise the marks and how
+// many words matched.
+func matchTokens(text string, words []span, tokens []string) ([]bool, int) {
+	matched := make([]bool, len(words))
+	count := 0
+	for _, token := range tokens {
+		found := false
+		for i, w := range words {
+			if strings.HasPrefix(strings.ToLower(text[w.start:w.end]), token) {
+				found = true
+				if !matched[i] {
+					matched[i] = true
+					count++
+				}
+			}
+		}
+		if !found {
+			return nil, 0
+		}
+	}
+	return matched, count
+}
+
+// buildSnippet returns about snippetTokens words of text around the first
+// match, with matched words highlighted like the FTS snippet function does
+func buildSnippet(text string, words []span, matched []bool) string {
+	first := 0
+	for i, m := range matched {
+		if m {
+			first = i
+			break
+		}
+	}
+	start := max(0, first-snippetTokens/4)
+	end := min(len(words), start+snippetTokens)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		w := words[i]
+		if i > start {
+			b.WriteString(text[words[i-1].end:w.start])
+		}
+		if matched[i] {
+			b.WriteString(model.HighlightStart + text[w.start:w.end] + model.HighlightEnd)
+		} else {
+			b.WriteString(text[w.start:w.end])
+		}
+	}
+	if end < len(words) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestStore_Search(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	milk := model.NewNote("buy milk")
+	cheese := model.NewNote("buy cheese and milk and more milk")
+	call := model.NewNote("call the plumber")
+	trashed := model.NewNote("milk shake recipe")
+	for _, n := range []*model.Note{milk, cheese, call, trashed} {
+		if err := st.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Delete(ctx, trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := st.Search(ctx, "mil", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits for prefix search, got %+v", hits)
+	}
+	for _, hit := range hits {
+		if hit.Note.ID == trashed.ID {
+			t.Fatal("trashed note should not be searchable")
+		}
+		if !strings.Contains(hit.Snippet, model.HighlightStart+"milk"+model.HighlightEnd) {
+			t.Fatalf("snippet not highlighted: %q", hit.Snippet)
+		}
+	}
+	if hits[0].Rank > hits[1].Rank {
+		t.Fatalf("hits not ordered by rank: %+v", hits)
+	}
+
+	// The index follows content updates
+	call.UpdateContent("call the dairy about milk")
+	if err := st.Update(ctx, call); err != nil {
+		t.Fatal(err)
+	}
+	if hits, err = st.Search(ctx, "plumber", 10); err != nil || len(hits) != 0 {
+		t.Fatalf("stale content still indexed: %+v %v", hits, err)
+	}
+	if hits, err = st.Search(ctx, "dairy milk", 10); err != nil || len(hits) != 1 || hits[0].Note.ID != call.ID {
+		t.Fatalf("updated content not indexed: %+v %v", hits, err)
+	}
+
+	// FTS5 syntax typed by the user is treated as plain text
+	for _, q := range []string{`"unbalanced`, "milk AND", "NEAR(", "-milk", "***"} {
+		if _, err := st.Search(ctx, q, 10); err != nil {
+			t.Fatalf("Search(%q): %v", q, err)
+		}
+	}
+
+	if hits, err = st.Search(ctx, "   ", 10); err != nil || len(hits) != 0 {
+		t.Fatalf("blank query: %+v %v", hits, err)
+	}
+}
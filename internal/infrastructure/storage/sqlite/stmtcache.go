@@ -0,0 +1,151 @@
+// Package sqlite provides SQLite-based implementation of the storage interface
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// hotStatements are the statements the cache prepares. Everything else,
+// including queries assembled from filters, runs unprepared so the cache
+// stays bounded.
+var hotStatements = map[string]bool{
+	getNoteSQL:          true,
+	listNotesSQL:        true,
+	insertNoteSQL:       true,
+	updateNoteSQL:       true,
+	deleteNoteSQL:       true,
+	insertAuditSQL:      true,
+	insertRevisionSQL:   true,
+	insertSyncChangeSQL: true,
+}
+
+// stmtCache prepares each hot statement once and shares it between calls.
+// database/sql re-prepares a statement on each pooled connection the first
+// time it runs there. Statements are only ever prepared outside transactions:
+// preparing on the database from inside one would need a second connection,
+// which a full pool of writers waiting on the lock never frees.
+type stmtCache struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// newStmtCache creates an empty cache for db
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// prepareAll prepares every hot statement up front so transactions find them cached
+func (c *stmtCache) prepareAll(ctx context.Context) error {
+	for query := range hotStatements {
+		if _, err := c.prepare(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cached returns the statement for query if it has already been prepared
+func (c *stmtCache) cached(query string) (*sql.Stmt, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stmt, ok := c.stmts[query]
+	return stmt, ok
+}
+
+// prepare returns the cached statement for query, preparing it on first use
+func (c *stmtCache) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// close releases every cached statement
+func (c *stmtCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var firstErr error
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.stmts, query)
+	}
+	return firstErr
+}
+
+// cachedQueryer runs hot statements through the cache and passes everything
+// else straight to the transaction or database. It also carries the keyring
+// the note helpers seal and open content with.
+type cachedQueryer struct {
+	cache *stmtCache
+	tx    *sql.Tx
+	keys  *keyring
+}
+
+// ExecContext implements queryer
+func (q cachedQueryer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt, ok := q.stmt(ctx, query); ok {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return q.direct().ExecContext(ctx, query, args...)
+}
+
+// QueryContext implements queryer
+func (q cachedQueryer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if stmt, ok := q.stmt(ctx, query); ok {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return q.direct().QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext implements queryer
+func (q cachedQueryer) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if stmt, ok := q.stmt(ctx, query); ok {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return q.direct().QueryRowContext(ctx, query, args...)
+}
+
+// stmt returns the prepared statement for a hot query, bound to the
+// transaction if there is one. It reports false for other queries, for hot
+// queries a transaction finds unprepared, and when preparing fails; the query
+// then runs unprepared and surfaces any error itself.
+func (q cachedQueryer) stmt(ctx context.Context, query string) (*sql.Stmt, bool) {
+	if !hotStatements[query] {
+		return nil, false
+	}
+	if q.tx != nil {
+		stmt, ok := q.cache.cached(query)
+		if !ok {
+			return nil, false
+		}
+		return q.tx.StmtContext(ctx, stmt), true
+	}
+	stmt, err := q.cache.prepare(ctx, query)
+	if err != nil {
+		return nil, false
+	}
+	return stmt, true
+}
+
+// direct returns the transaction, or the database when there is none
+func (q cachedQueryer) direct() queryer {
+	if q.tx != nil {
+		return q.tx
+	}
+	return q.cache.db
+}
@@ -7,7 +7,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/jonesrussell/godo/internal/domain/audit"
 	"github.com/jonesrussell/godo/internal/domain/model"
 	"github.com/jonesrussell/godo/internal/infrastructure/logger"
 	"github.com/jonesrussell/godo/internal/infrastructure/storage"
@@ -16,14 +22,71 @@ import (
 	_ "modernc.org/sqlite" // SQLite driver
 )
 
+// tagsColumn selects a note's tag names separated by spaces; it is closed
+// with the note ID column of the query it appears in
+const tagsColumn = "(SELECT group_concat(t.name, ' ') FROM note_tags nt JOIN tags t ON t.id = nt.tag_id WHERE nt.note_id = "
+
+// noteColumns is the column list shared by every notes SELECT
+const noteColumns = "id, content, done, created_at, updated_at, deleted_at, version, archived_at, " + tagsColumn + "notes.id)"
+
+// Statements run on every note read or write; the statement cache prepares
+// them once instead of on each call
+const (
+	getNoteSQL    = "SELECT " + noteColumns + " FROM notes WHERE id = ? AND deleted_at IS NULL"
+	listNotesSQL  = "SELECT " + noteColumns + " FROM notes WHERE deleted_at IS NULL ORDER BY created_at DESC"
+	insertNoteSQL = "INSERT INTO notes (id, content, done, created_at, updated_at, version, archived_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	updateNoteSQL = "UPDATE notes SET content = ?, done = ?, updated_at = ?, version = version + 1 " +
+		"WHERE id = ? AND deleted_at IS NULL AND version = ?"
+	deleteNoteSQL = "UPDATE notes SET deleted_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL AND version = ?"
+)
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 // Store implements storage.NoteStore using SQLite
 type Store struct {
 	db     *sql.DB
+	path   string
 	logger logger.Logger
+	// tx is set on stores handed out by WithinTx; every call then runs in it
+	tx *sql.Tx
+	// stmts holds the prepared hot statements, shared with bound copies
+	stmts *stmtCache
+	// backups controls where Backup writes snapshots and how they rotate
+	backups BackupPolicy
+	// blobs holds attachment content, shared with bound copies
+	blobs *blobStore
+	// health is the result of the last integrity check; writes are refused
+	// while it reports corruption
+	health *healthState
+	// keys encrypts note content when a key is configured
+	keys *keyring
+	// opts are the connection settings the pool was opened with
+	opts Options
+	// closed is set by Close; later calls fail with errors.ErrStoreClosed
+	closed *atomic.Bool
 }
 
-// New creates a new SQLite store
+// New creates a new SQLite store with DefaultOptions
 func New(path string, log logger.Logger) (*Store, error) {
+	return NewWithOptions(path, DefaultOptions(), log)
+}
+
+// NewWithOptions creates a new SQLite store whose connections use opts
+func NewWithOptions(path string, opts Options, log logger.Logger) (*Store, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid SQLite options: %w", err)
+	}
+
 	// Ensure the directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -32,19 +95,68 @@ func New(path string, log logger.Logger) (*Store, error) {
 	}
 	log.Debug("Database directory ensured", "dir", dir)
 
-	db, err := sql.Open("sqlite", path)
+	db, err := sql.Open("sqlite", opts.dsn(path))
 	if err != nil {
 		return nil, err
 	}
+	opts.configurePool(db)
 
 	store := &Store{
-		db:     db,
-		logger: log,
+		db:      db,
+		path:    path,
+		logger:  log,
+		stmts:   newStmtCache(db),
+		backups: BackupPolicy{Dir: filepath.Join(dir, "backups")},
+		blobs:   &blobStore{policy: AttachmentPolicy{Dir: filepath.Join(dir, "attachments"), MaxSize: DefaultMaxAttachmentSize}},
+		health:  &healthState{},
+		keys:    &keyring{source: opts.Key},
+		opts:    opts,
+		closed:  new(atomic.Bool),
+	}
+
+	ctx := context.Background()
+	if logErr := opts.logEffective(ctx, db, log); logErr != nil {
+		log.Warn("Failed to read SQLite connection settings", "path", path, "error", logErr)
 	}
 
-	if migErr := RunMigrations(db); migErr != nil {
+	// Check the file before migrating so a damaged database is never written
+	// to; it opens read-only so its notes can still be read or restored
+	if _, checkErr := store.runHealthCheck(ctx, quickCheck); checkErr != nil {
 		db.Close()
-		return nil, migErr
+		return nil, fmt.Errorf("failed to check database integrity: %w", checkErr)
+	}
+	if store.health.readOnly() {
+		log.Warn("Database is damaged, opening read-only without migrating", "path", path)
+		if keyErr := store.unlock(ctx); keyErr != nil {
+			db.Close()
+			return nil, keyErr
+		}
+		return store, nil
+	}
+
+	migrator, err := NewMigrator(db, path, log)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if migErr := migrator.Migrate(ctx); migErr != nil {
+		log.Error("Failed to migrate database", "path", path, "error", migErr)
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", migErr)
+	}
+
+	if vacErr := store.enableIncrementalVacuum(ctx); vacErr != nil {
+		log.Warn("Failed to enable incremental vacuum", "path", path, "error", vacErr)
+	}
+
+	if prepErr := store.stmts.prepareAll(ctx); prepErr != nil {
+		log.Warn("Failed to prepare statements, running them unprepared", "path", path, "error", prepErr)
+	}
+
+	if keyErr := store.unlock(ctx); keyErr != nil {
+		store.Close()
+		return nil, keyErr
 	}
 
 	return store, nil
@@ -52,134 +164,350 @@ func New(path string, log logger.Logger) (*Store, error) {
 
 // Add creates a new note in the store
 func (s *Store) Add(ctx context.Context, note *model.Note) error {
-	_, err := s.db.ExecContext(ctx,
-		"INSERT INTO notes (id, content, done, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		note.ID, note.Content, note.Done, note.CreatedAt, note.UpdatedAt,
-	)
-	return err
+	return s.withTx(ctx, func(q queryer) error {
+		return addNote(ctx, q, note)
+	})
 }
 
 // GetByID retrieves a note by its ID
 func (s *Store) GetByID(ctx context.Context, id string) (model.Note, error) {
-	var note model.Note
-	err := s.db.QueryRowContext(ctx,
-		"SELECT id, content, done, created_at, updated_at FROM notes WHERE id = ?",
-		id,
-	).Scan(&note.ID, &note.Content, &note.Done, &note.CreatedAt, &note.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		return model.Note{}, &errors.NotFoundError{ID: id}
+	if err := s.checkOpen(); err != nil {
+		return model.Note{}, err
 	}
-	return note, err
+	return getNote(ctx, s.conn(), id)
 }
 
-// Update modifies an existing note
+// Update modifies an existing note. When note.Version is set the write only
+// applies if it is still the stored version; on success note.Version holds the new one.
 func (s *Store) Update(ctx context.Context, note *model.Note) error {
-	result, err := s.db.ExecContext(ctx,
-		"UPDATE notes SET content = ?, done = ?, updated_at = ? WHERE id = ?",
-		note.Content, note.Done, note.UpdatedAt, note.ID,
-	)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rows == 0 {
-		return &errors.NotFoundError{ID: note.ID}
-	}
-	return nil
+	return s.withTx(ctx, func(q queryer) error {
+		return updateNote(ctx, q, note)
+	})
 }
 
 // Delete removes a note by ID
 func (s *Store) Delete(ctx context.Context, id string) error {
-	result, err := s.db.ExecContext(ctx, "DELETE FROM notes WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
+	return s.DeleteIfVersion(ctx, id, 0)
+}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+// DeleteIfVersion moves a note to the trash only if its stored version is
+// version. A zero version deletes unconditionally.
+func (s *Store) DeleteIfVersion(ctx context.Context, id string, version int64) error {
+	return s.withTx(ctx, func(q queryer) error {
+		return deleteNote(ctx, q, id, version)
+	})
+}
 
-	if rows == 0 {
-		return &errors.NotFoundError{ID: id}
+// List returns all notes that are not in the trash
+func (s *Store) List(ctx context.Context) ([]model.Note, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
 	}
-	return nil
+	return listNotes(ctx, s.conn())
 }
 
-// List returns all notes
-func (s *Store) List(ctx context.Context) ([]model.Note, error) {
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, content, done, created_at, updated_at FROM notes ORDER BY created_at DESC",
-	)
-	if err != nil {
+// ListDeleted returns the notes in the trash, most recently deleted first
+func (s *Store) ListDeleted(ctx context.Context) ([]model.Note, error) {
+	if err := s.checkOpen(); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return queryNotes(ctx, s.conn(), "deleted_at IS NOT NULL", "deleted_at DESC")
+}
 
-	var notes []model.Note
-	for rows.Next() {
-		var note model.Note
-		scanErr := rows.Scan(&note.ID, &note.Content, &note.Done, &note.CreatedAt, &note.UpdatedAt)
-		if scanErr != nil {
-			return nil, scanErr
+// Restore moves a note out of the trash
+func (s *Store) Restore(ctx context.Context, id string) error {
+	return s.withTx(ctx, func(q queryer) error {
+		return restoreNote(ctx, q, id)
+	})
+}
+
+// Archive archives a note, leaving it out of filtered lists until unarchived
+func (s *Store) Archive(ctx context.Context, id string) (model.Note, error) {
+	return s.setArchived(ctx, id, true)
+}
+
+// Unarchive takes a note out of the archive
+func (s *Store) Unarchive(ctx context.Context, id string) (model.Note, error) {
+	return s.setArchived(ctx, id, false)
+}
+
+// setArchived runs archiveNote in its own transaction
+func (s *Store) setArchived(ctx context.Context, id string, archived bool) (model.Note, error) {
+	var note model.Note
+	err := s.withTx(ctx, func(q queryer) error {
+		var err error
+		note, err = archiveNote(ctx, q, id, archived)
+		return err
+	})
+	return note, err
+}
+
+// Purge permanently removes notes that were moved to the trash before the
+// given time, together with their revisions. It returns the number of notes removed.
+func (s *Store) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return s.purge(ctx, func(q queryer) ([]model.Note, error) {
+		return queryNotes(ctx, q, "deleted_at IS NOT NULL AND deleted_at < ?", "deleted_at ASC", deletedBefore.UTC())
+	})
+}
+
+// PurgeNote permanently removes one note from the trash, together with its
+// revisions. A note that is not in the trash is not found.
+func (s *Store) PurgeNote(ctx context.Context, id string) error {
+	_, err := s.purge(ctx, func(q queryer) ([]model.Note, error) {
+		note, err := getDeletedNote(ctx, q, id)
+		if err != nil {
+			return nil, err
+		}
+		return []model.Note{note}, nil
+	})
+	return err
+}
+
+// purge removes the trashed notes selected by pick in one transaction, then
+// drops attachment content nothing shares any more
+func (s *Store) purge(ctx context.Context, pick func(q queryer) ([]model.Note, error)) (int, error) {
+	var purged int
+	err := s.withTx(ctx, func(q queryer) error {
+		notes, err := pick(q)
+		if err != nil {
+			return err
+		}
+		purged = len(notes)
+		return purgeNotes(ctx, q, notes)
+	})
+	if err == nil && purged > 0 && s.tx == nil {
+		// The purged notes' attachment rows are gone; drop content nothing shares
+		if _, pruneErr := s.PruneAttachments(ctx); pruneErr != nil {
+			s.logger.Warn("Failed to prune attachments after purge", "error", pruneErr)
 		}
-		notes = append(notes, note)
 	}
-	return notes, rows.Err()
+	return purged, err
 }
 
-// Close closes the database connection
+// Close closes the database connection; later calls fail with
+// errors.ErrStoreClosed and closing again does nothing. On a store bound to a
+// transaction it does nothing; the transaction ends when WithinTx returns.
 func (s *Store) Close() error {
+	if s.tx != nil || s.closed.Swap(true) {
+		return nil
+	}
+	if err := s.stmts.close(); err != nil {
+		s.logger.Warn("Failed to close prepared statements", "error", err)
+	}
 	return s.db.Close()
 }
 
+// WithinTx runs fn with a Store bound to a single transaction, committing
+// when fn returns nil and rolling back otherwise. Calling WithinTx on a store
+// that is already bound joins the existing transaction.
+func (s *Store) WithinTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	return s.runTx(ctx, func(tx *sql.Tx) error {
+		bound := *s
+		bound.tx = tx
+		return fn(&bound)
+	})
+}
+
 // BeginTx starts a new transaction
 func (s *Store) BeginTx(ctx context.Context) (storage.NoteTx, error) {
+	if s.tx != nil {
+		return nil, &storage.TransactionError{Operation: "begin", Message: "store is already bound to a transaction"}
+	}
+	if s.health.readOnly() {
+		return nil, s.readOnlyError()
+	}
+	s.keys.rotating.RLock()
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
+		s.keys.rotating.RUnlock()
 		return nil, err
 	}
-	return &Transaction{tx: tx}, nil
+	return &Transaction{tx: tx, q: s.bind(tx), release: sync.OnceFunc(s.keys.rotating.RUnlock)}, nil
+}
+
+// conn returns the transaction the store is bound to, or the database, with
+// hot statements served from the statement cache
+func (s *Store) conn() queryer {
+	return s.bind(s.tx)
+}
+
+// checkOpen fails with errors.ErrStoreClosed once the store is closed
+func (s *Store) checkOpen() error {
+	if s.closed.Load() {
+		return errors.ErrStoreClosed
+	}
+	return nil
+}
+
+// bind returns a queryer that runs in tx, or on the database when tx is nil,
+// serving hot statements from the cache and carrying the store's keyring
+func (s *Store) bind(tx *sql.Tx) queryer {
+	return cachedQueryer{cache: s.stmts, tx: tx, keys: s.keys}
+}
+
+// withTx runs fn in a transaction, committing on success and rolling back on
+// error. A store bound to a transaction runs fn in that transaction instead.
+// It fails with model.ErrReadOnly while the database is marked damaged.
+func (s *Store) withTx(ctx context.Context, fn func(q queryer) error) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	if s.tx != nil {
+		return fn(s.conn())
+	}
+	return s.runTx(ctx, func(tx *sql.Tx) error {
+		return fn(s.bind(tx))
+	})
+}
+
+// runTx runs fn in a new write transaction, committing on success and
+// rolling back on error. It waits while the encryption key is being changed.
+func (s *Store) runTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if s.health.readOnly() {
+		return s.readOnlyError()
+	}
+	s.keys.rotating.RLock()
+	defer s.keys.rotating.RUnlock()
+	return s.commitTx(ctx, fn)
+}
+
+// commitTx runs fn in a new transaction, committing on success and rolling back on error
+func (s *Store) commitTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &storage.TransactionError{Operation: "begin", Message: "failed to start transaction", Err: err}
+	}
+
+	if fnErr := fn(tx); fnErr != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			s.logger.Error("Failed to roll back transaction", "error", rbErr)
+		}
+		return fnErr
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return &storage.TransactionError{Operation: "commit", Message: "failed to commit transaction", Err: commitErr}
+	}
+	return nil
 }
 
 // Transaction implements storage.NoteTx
 type Transaction struct {
 	tx *sql.Tx
+	q  queryer
+	// release lets a key change proceed once the transaction ends
+	release func()
 }
 
 // Add creates a new note in the transaction
 func (t *Transaction) Add(ctx context.Context, note *model.Note) error {
-	_, err := t.tx.ExecContext(ctx,
-		"INSERT INTO notes (id, content, done, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		note.ID, note.Content, note.Done, note.CreatedAt, note.UpdatedAt,
-	)
-	return err
+	return addNote(ctx, t.q, note)
 }
 
 // List returns all notes in the transaction
 func (t *Transaction) List(ctx context.Context) ([]model.Note, error) {
-	rows, err := t.tx.QueryContext(ctx, "SELECT id, content, done, created_at, updated_at FROM notes")
+	return listNotes(ctx, t.q)
+}
+
+// GetByID returns a note by its ID in the transaction
+func (t *Transaction) GetByID(ctx context.Context, id string) (model.Note, error) {
+	return getNote(ctx, t.q, id)
+}
+
+// Update modifies an existing note in the transaction
+func (t *Transaction) Update(ctx context.Context, note *model.Note) error {
+	return updateNote(ctx, t.q, note)
+}
+
+// Delete removes a note by ID in the transaction
+func (t *Transaction) Delete(ctx context.Context, id string) error {
+	return deleteNote(ctx, t.q, id, 0)
+}
+
+// Commit commits the transaction
+func (t *Transaction) Commit() error {
+	defer t.release()
+	return t.tx.Commit()
+}
+
+// Rollback rolls back the transaction
+func (t *Transaction) Rollback() error {
+	defer t.release()
+	return t.tx.Rollback()
+}
+
+// scanNote reads a row selected with noteColumns, decrypting its content with keys
+func scanNote(row rowScanner, keys *keyring) (model.Note, error) {
+	var note model.Note
+	var deletedAt, archivedAt sql.NullTime
+	var tags sql.NullString
+	err := row.Scan(&note.ID, &note.Content, &note.Done, &note.CreatedAt, &note.UpdatedAt, &deletedAt, &note.Version, &archivedAt, &tags)
+	if err != nil {
+		return note, err
+	}
+	if tags.Valid {
+		note.Tags = model.NormalizeTags(strings.Fields(tags.String))
+	}
+	if deletedAt.Valid {
+		note.DeletedAt = &deletedAt.Time
+	}
+	if archivedAt.Valid {
+		note.ArchivedAt = &archivedAt.Time
+	}
+	if note.Content, err = keys.open(note.Content); err != nil {
+		return note, fmt.Errorf("note %s: %w", note.ID, err)
+	}
+	return note, nil
+}
+
+// getNote loads a single note by ID, ignoring notes in the trash
+func getNote(ctx context.Context, q queryer, id string) (model.Note, error) {
+	return findNote(ctx, q, getNoteSQL, id)
+}
+
+// getDeletedNote loads a single note by ID from the trash
+func getDeletedNote(ctx context.Context, q queryer, id string) (model.Note, error) {
+	return findNote(ctx, q, "SELECT "+noteColumns+" FROM notes WHERE id = ? AND deleted_at IS NOT NULL", id)
+}
+
+// findNote loads the note with the given ID selected by query
+func findNote(ctx context.Context, q queryer, query, id string) (model.Note, error) {
+	note, err := scanNote(q.QueryRowContext(ctx, query, id), keysOf(q))
+	if err == sql.ErrNoRows {
+		return model.Note{}, &errors.NotFoundError{ID: id}
+	}
+	if err != nil {
+		return model.Note{}, err
+	}
+	return note, nil
+}
+
+// listNotes returns every note outside the trash, newest first
+func listNotes(ctx context.Context, q queryer) ([]model.Note, error) {
+	return selectNotes(ctx, q, listNotesSQL)
+}
+
+// queryNotes returns the notes matching condition in the given order
+func queryNotes(ctx context.Context, q queryer, condition, orderBy string, args ...any) ([]model.Note, error) {
+	return selectNotes(ctx, q, "SELECT "+noteColumns+" FROM notes WHERE "+condition+" ORDER BY "+orderBy, args...)
+}
+
+// selectNotes runs a query selecting noteColumns and scans every row
+func selectNotes(ctx context.Context, q queryer, query string, args ...any) ([]model.Note, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	keys := keysOf(q)
 	var notes []model.Note
 	for rows.Next() {
-		var note model.Note
-		if scanErr := rows.Scan(
-			&note.ID,
-			&note.Content,
-			&note.Done,
-			&note.CreatedAt,
-			&note.UpdatedAt,
-		); scanErr != nil {
+		note, scanErr := scanNote(rows, keys)
+		if scanErr != nil {
 			return nil, scanErr
 		}
 		notes = append(notes, note)
@@ -187,33 +515,45 @@ func (t *Transaction) List(ctx context.Context) ([]model.Note, error) {
 	return notes, rows.Err()
 }
 
-// GetByID returns a note by its ID in the transaction
-func (t *Transaction) GetByID(ctx context.Context, id string) (model.Note, error) {
-	var note model.Note
-	err := t.tx.QueryRowContext(ctx,
-		"SELECT id, content, done, created_at, updated_at FROM notes WHERE id = ?",
-		id,
-	).Scan(
-		&note.ID,
-		&note.Content,
-		&note.Done,
-		&note.CreatedAt,
-		&note.UpdatedAt,
-	)
-	if err == sql.ErrNoRows {
-		return model.Note{}, &errors.NotFoundError{ID: id}
+// addNote inserts a note with its tags and records the creation in the audit trail
+func addNote(ctx context.Context, q queryer, note *model.Note) error {
+	if note.Version == 0 {
+		note.Version = 1
 	}
+	note.Tags = model.NormalizeTags(note.Tags)
+	content, err := keysOf(q).seal(note.Content)
 	if err != nil {
-		return model.Note{}, err
+		return err
 	}
-	return note, nil
+	if _, err = q.ExecContext(ctx, insertNoteSQL,
+		note.ID, content, note.Done, note.CreatedAt, note.UpdatedAt, note.Version, note.ArchivedAt,
+	); err != nil {
+		return err
+	}
+	if err = writeNoteTags(ctx, q, note.ID, note.Tags); err != nil {
+		return err
+	}
+	return insertAuditEvent(ctx, q, audit.OperationCreate, note.ID, nil, note)
 }
 
-// Update modifies an existing note in the transaction
-func (t *Transaction) Update(ctx context.Context, note *model.Note) error {
-	result, err := t.tx.ExecContext(ctx,
-		"UPDATE notes SET content = ?, done = ?, updated_at = ? WHERE id = ?",
-		note.Content, note.Done, note.UpdatedAt, note.ID,
+// updateNote writes a note's mutable fields, tags included, keeps the previous
+// state as a revision when content or done changed, and records before/after
+// snapshots. A non-zero note.Version must match the stored version.
+func updateNote(ctx context.Context, q queryer, note *model.Note) error {
+	before, err := getNote(ctx, q, note.ID)
+	if err != nil {
+		return err
+	}
+	if note.Version != 0 && note.Version != before.Version {
+		return fmt.Errorf("note %s is at version %d, not %d: %w", note.ID, before.Version, note.Version, model.ErrVersionConflict)
+	}
+
+	content, err := keysOf(q).seal(note.Content)
+	if err != nil {
+		return err
+	}
+	result, err := q.ExecContext(ctx, updateNoteSQL,
+		content, note.Done, note.UpdatedAt, note.ID, before.Version,
 	)
 	if err != nil {
 		return err
@@ -225,14 +565,39 @@ func (t *Transaction) Update(ctx context.Context, note *model.Note) error {
 	}
 
 	if rows == 0 {
-		return &errors.NotFoundError{ID: note.ID}
+		return fmt.Errorf("note %s changed during update: %w", note.ID, model.ErrVersionConflict)
 	}
-	return nil
+	note.Version = before.Version + 1
+
+	note.Tags = model.NormalizeTags(note.Tags)
+	if !slices.Equal(before.Tags, note.Tags) {
+		if err = writeNoteTags(ctx, q, note.ID, note.Tags); err != nil {
+			return err
+		}
+	}
+
+	if before.Content != note.Content || before.Done != note.Done {
+		if revErr := insertRevision(ctx, q, &before); revErr != nil {
+			return revErr
+		}
+	}
+	return insertAuditEvent(ctx, q, audit.OperationUpdate, note.ID, &before, note)
 }
 
-// Delete removes a note by ID in the transaction
-func (t *Transaction) Delete(ctx context.Context, id string) error {
-	result, err := t.tx.ExecContext(ctx, "DELETE FROM notes WHERE id = ?", id)
+// deleteNote moves a note to the trash and records its final state in the
+// audit trail. A non-zero version must match the stored version.
+func deleteNote(ctx context.Context, q queryer, id string, version int64) error {
+	before, err := getNote(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != before.Version {
+		return fmt.Errorf("note %s is at version %d, not %d: %w", id, before.Version, version, model.ErrVersionConflict)
+	}
+
+	result, err := q.ExecContext(ctx, deleteNoteSQL,
+		time.Now().UTC(), id, before.Version,
+	)
 	if err != nil {
 		return err
 	}
@@ -243,17 +608,94 @@ func (t *Transaction) Delete(ctx context.Context, id string) error {
 	}
 
 	if rows == 0 {
-		return &errors.NotFoundError{ID: id}
+		return fmt.Errorf("note %s changed during delete: %w", id, model.ErrVersionConflict)
 	}
-	return nil
+
+	return insertAuditEvent(ctx, q, audit.OperationDelete, id, &before, nil)
 }
 
-// Commit commits the transaction
-func (t *Transaction) Commit() error {
-	return t.tx.Commit()
+// archiveNote sets or clears a note's archived_at, bumping its version and
+// recording the change in the audit trail as an update. A note already in the
+// wanted state is returned as it is.
+func archiveNote(ctx context.Context, q queryer, id string, archived bool) (model.Note, error) {
+	before, err := getNote(ctx, q, id)
+	if err != nil {
+		return model.Note{}, err
+	}
+	if (before.ArchivedAt != nil) == archived {
+		return before, nil
+	}
+
+	after := before
+	after.ArchivedAt = nil
+	if archived {
+		now := time.Now().UTC()
+		after.ArchivedAt = &now
+	}
+	result, err := q.ExecContext(ctx,
+		"UPDATE notes SET archived_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL AND version = ?",
+		after.ArchivedAt, id, before.Version,
+	)
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	if rows == 0 {
+		return model.Note{}, fmt.Errorf("note %s changed during archive: %w", id, model.ErrVersionConflict)
+	}
+	after.Version++
+	if err = insertAuditEvent(ctx, q, audit.OperationUpdate, id, &before, &after); err != nil {
+		return model.Note{}, err
+	}
+	return after, nil
 }
 
-// Rollback rolls back the transaction
-func (t *Transaction) Rollback() error {
-	return t.tx.Rollback()
+// restoreNote clears a note's deleted_at and records the restore in the audit trail
+func restoreNote(ctx context.Context, q queryer, id string) error {
+	before, err := getDeletedNote(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err = q.ExecContext(ctx, "UPDATE notes SET deleted_at = NULL, version = version + 1 WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	after := before
+	after.DeletedAt = nil
+	after.Version++
+	return insertAuditEvent(ctx, q, audit.OperationRestore, id, &before, &after)
+}
+
+// purgeNotes hard-deletes trashed notes along with their revisions, tags and
+// attachments, recording each removal in the audit trail. Attachment content
+// is left for PruneAttachments.
+func purgeNotes(ctx context.Context, q queryer, notes []model.Note) error {
+	for i := range notes {
+		note := &notes[i]
+		if _, err := q.ExecContext(ctx, "DELETE FROM note_revisions WHERE note_id = ?", note.ID); err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, "DELETE FROM note_tags WHERE note_id = ?", note.ID); err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, "DELETE FROM attachments WHERE note_id = ?", note.ID); err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, "DELETE FROM notes WHERE id = ?", note.ID); err != nil {
+			return err
+		}
+		if err := insertAuditEvent(ctx, q, audit.OperationPurge, note.ID, note, nil); err != nil {
+			return err
+		}
+	}
+	if len(notes) > 0 {
+		return deleteUnusedTags(ctx, q)
+	}
+	return nil
 }
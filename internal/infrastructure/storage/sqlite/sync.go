@@ -0,0 +1,252 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// insertSyncChangeSQL records that a note has local changes to push. REPLACE
+// drops the note's previous row, so the new one gets the next seq.
+const insertSyncChangeSQL = "INSERT OR REPLACE INTO sync_changes (note_id, operation, changed_at) VALUES (?, ?, ?)"
+
+// Change log operations
+const (
+	syncUpsert = "upsert"
+	syncDelete = "delete"
+)
+
+// pullCursorKey is the sync_state key holding the pull cursor
+const pullCursorKey = "pull_cursor"
+
+// syncWriteKey is the context key marking writes made by the sync engine
+type syncWriteKey struct{}
+
+// WithSyncWrite returns a context whose writes are left out of the change
+// log when sync is set, for the sync engine applying what it pulled so it is
+// never pushed back, and logged again when it is not. Unlike an actor name,
+// the mark cannot come from an API request.
+func WithSyncWrite(ctx context.Context, sync bool) context.Context {
+	return context.WithValue(ctx, syncWriteKey{}, sync)
+}
+
+// isSyncWrite reports whether ctx carries the mark set by WithSyncWrite
+func isSyncWrite(ctx context.Context) bool {
+	marked, _ := ctx.Value(syncWriteKey{}).(bool)
+	return marked
+}
+
+// SyncChange is a note with local changes that have not been pushed yet
+type SyncChange struct {
+	// Seq orders changes; a note edited again gets a new, higher seq
+	Seq    int64
+	NoteID string
+	// Deleted is set when the note was moved to the trash
+	Deleted   bool
+	ChangedAt time.Time
+}
+
+// SyncLink ties a local note to its copy on the sync remote, with the remote
+// version and update time last seen so remote edits can be detected
+type SyncLink struct {
+	NoteID          string
+	RemoteID        string
+	RemoteVersion   int64
+	RemoteUpdatedAt time.Time
+}
+
+// recordSyncChange adds an audited write to the change log. Writes made by
+// the sync engine itself carry WithSyncWrite and are not logged, so pulled
+// changes are never pushed back. Purges need no entry: the note was already
+// logged as deleted when it went to the trash.
+func recordSyncChange(ctx context.Context, q queryer, op audit.Operation, noteID string) error {
+	if isSyncWrite(ctx) {
+		return nil
+	}
+
+	var change string
+	switch op {
+	case audit.OperationCreate, audit.OperationUpdate, audit.OperationRestore:
+		change = syncUpsert
+	case audit.OperationDelete:
+		change = syncDelete
+	default:
+		return nil
+	}
+
+	if _, err := q.ExecContext(ctx, insertSyncChangeSQL, noteID, change, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record sync change: %w", err)
+	}
+	return nil
+}
+
+// PendingSyncChanges returns the notes with unpushed local changes, oldest first
+func (s *Store) PendingSyncChanges(ctx context.Context) ([]SyncChange, error) {
+	rows, err := s.conn().QueryContext(ctx,
+		"SELECT seq, note_id, operation, changed_at FROM sync_changes ORDER BY seq ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]SyncChange, 0)
+	for rows.Next() {
+		var change SyncChange
+		var op string
+		if err = rows.Scan(&change.Seq, &change.NoteID, &op, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		change.Deleted = op == syncDelete
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// AckSyncChange removes a pushed change from the log. A note edited again
+// while it was being pushed has a newer seq and stays pending.
+func (s *Store) AckSyncChange(ctx context.Context, change SyncChange) error {
+	_, err := s.conn().ExecContext(ctx,
+		"DELETE FROM sync_changes WHERE note_id = ? AND seq = ?", change.NoteID, change.Seq)
+	return err
+}
+
+// HasNote reports whether a note with the ID exists, in the trash or not
+func (s *Store) HasNote(ctx context.Context, id string) (bool, error) {
+	if err := s.checkOpen(); err != nil {
+		return false, err
+	}
+	var found int
+	err := s.conn().QueryRowContext(ctx, "SELECT 1 FROM notes WHERE id = ?", id).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SyncLinkFor returns the link of a local note; ok is false when the note has
+// never been synced
+func (s *Store) SyncLinkFor(ctx context.Context, noteID string) (SyncLink, bool, error) {
+	return s.findSyncLink(ctx, "note_id", noteID)
+}
+
+// SyncLinkForRemote returns the link of a remote note; ok is false when the
+// note has never been pulled or pushed
+func (s *Store) SyncLinkForRemote(ctx context.Context, remoteID string) (SyncLink, bool, error) {
+	return s.findSyncLink(ctx, "remote_id", remoteID)
+}
+
+// findSyncLink loads the link whose column equals value
+func (s *Store) findSyncLink(ctx context.Context, column, value string) (SyncLink, bool, error) {
+	var link SyncLink
+	err := s.conn().QueryRowContext(ctx,
+		"SELECT note_id, remote_id, remote_version, remote_updated_at FROM sync_links WHERE "+column+" = ?", value,
+	).Scan(&link.NoteID, &link.RemoteID, &link.RemoteVersion, &link.RemoteUpdatedAt)
+	if err == sql.ErrNoRows {
+		return SyncLink{}, false, nil
+	}
+	if err != nil {
+		return SyncLink{}, false, err
+	}
+	return link, true, nil
+}
+
+// ListSyncLinks returns every link between a local and a remote note
+func (s *Store) ListSyncLinks(ctx context.Context) ([]SyncLink, error) {
+	rows, err := s.conn().QueryContext(ctx,
+		"SELECT note_id, remote_id, remote_version, remote_updated_at FROM sync_links ORDER BY note_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]SyncLink, 0)
+	for rows.Next() {
+		var link SyncLink
+		if err = rows.Scan(&link.NoteID, &link.RemoteID, &link.RemoteVersion, &link.RemoteUpdatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// SaveSyncLink records what the remote holds for a local note after a push
+// or pull. A link to the same remote note from another local note is replaced.
+func (s *Store) SaveSyncLink(ctx context.Context, link SyncLink) error {
+	return s.withTx(ctx, func(q queryer) error {
+		if _, err := q.ExecContext(ctx, "DELETE FROM sync_links WHERE remote_id = ? AND note_id <> ?",
+			link.RemoteID, link.NoteID); err != nil {
+			return err
+		}
+		_, err := q.ExecContext(ctx,
+			"INSERT OR REPLACE INTO sync_links (note_id, remote_id, remote_version, remote_updated_at) VALUES (?, ?, ?, ?)",
+			link.NoteID, link.RemoteID, link.RemoteVersion, link.RemoteUpdatedAt.UTC())
+		return err
+	})
+}
+
+// DeleteSyncLink forgets the remote copy of a local note
+func (s *Store) DeleteSyncLink(ctx context.Context, noteID string) error {
+	_, err := s.conn().ExecContext(ctx, "DELETE FROM sync_links WHERE note_id = ?", noteID)
+	return err
+}
+
+// PullCursor returns the remote update time pulls resume from; the zero time
+// means nothing has been pulled yet
+func (s *Store) PullCursor(ctx context.Context) (time.Time, error) {
+	var value string
+	err := s.conn().QueryRowContext(ctx, "SELECT value FROM sync_state WHERE key = ?", pullCursorKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	cursor, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid pull cursor %q: %w", value, err)
+	}
+	return cursor, nil
+}
+
+// SetPullCursor stores the remote update time the next pull resumes from
+func (s *Store) SetPullCursor(ctx context.Context, cursor time.Time) error {
+	_, err := s.conn().ExecContext(ctx, "INSERT OR REPLACE INTO sync_state (key, value) VALUES (?, ?)",
+		pullCursorKey, cursor.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// ApplyRemote writes a note pulled from the sync remote, keeping its content,
+// done state and timestamps; tags are not synced, so a stored note keeps its
+// own. A note in the trash is restored first and a
+// missing one is created. The write is audited and revisioned like any other
+// but is not added to the change log when ctx carries WithSyncWrite.
+// On success note.Version holds the local version.
+func (s *Store) ApplyRemote(ctx context.Context, note *model.Note) error {
+	return s.withTx(ctx, func(q queryer) error {
+		current, err := findNote(ctx, q, "SELECT "+noteColumns+" FROM notes WHERE id = ?", note.ID)
+		switch {
+		case errors.Is(err, model.ErrNoteNotFound):
+			note.Version = 0
+			note.DeletedAt = nil
+			return addNote(ctx, q, note)
+		case err != nil:
+			return err
+		}
+
+		if current.DeletedAt != nil {
+			if err = restoreNote(ctx, q, note.ID); err != nil {
+				return err
+			}
+		}
+		note.Tags = current.Tags
+		note.Version = 0
+		note.DeletedAt = nil
+		return updateNote(ctx, q, note)
+	})
+}
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestStore_ChangeLogCoalescesPerNote(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	first := model.NewNote("first")
+	second := model.NewNote("second")
+	for _, n := range []*model.Note{first, second} {
+		if err := st.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first.UpdateContent("first, edited")
+	if err := st.Update(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete(ctx, second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := st.PendingSyncChanges(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected one change per note, got %+v", changes)
+	}
+	if changes[0].NoteID != first.ID || changes[0].Deleted {
+		t.Errorf("expected the edit of the first note first, got %+v", changes[0])
+	}
+	if changes[1].NoteID != second.ID || !changes[1].Deleted {
+		t.Errorf("expected the delete of the second note last, got %+v", changes[1])
+	}
+
+	// A note edited after its change was read keeps a pending change
+	first.UpdateContent("edited again")
+	if err = st.Update(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		if err = st.AckSyncChange(ctx, change); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changes, err = st.PendingSyncChanges(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].NoteID != first.ID {
+		t.Fatalf("expected the newer edit to stay pending, got %+v", changes)
+	}
+}
+
+func TestStore_ApplyRemoteIsNotLogged(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := WithSyncWrite(audit.WithActor(context.Background(), audit.ActorSync), true)
+
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	pulled := &model.Note{ID: "remote-1", Content: "pulled", Done: true,
+		CreatedAt: updated.Add(-time.Hour), UpdatedAt: updated}
+	if err := st.ApplyRemote(ctx, pulled); err != nil {
+		t.Fatalf("ApplyRemote create: %v", err)
+	}
+
+	// Applying again over a trashed copy restores and updates it
+	if err := st.Delete(ctx, "remote-1"); err != nil {
+		t.Fatal(err)
+	}
+	pulled.Content = "pulled again"
+	if err := st.ApplyRemote(ctx, pulled); err != nil {
+		t.Fatalf("ApplyRemote update: %v", err)
+	}
+
+	got, err := st.GetByID(ctx, "remote-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != "pulled again" || !got.Done || !got.UpdatedAt.Equal(updated) {
+		t.Fatalf("remote note not applied as sent: %+v", got)
+	}
+
+	changes, err := st.PendingSyncChanges(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("writes by the sync engine should not be logged, got %+v", changes)
+	}
+}
+
+func TestStore_SyncActorNameIsStillLogged(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	// An API user who happens to be called "sync" is an ordinary writer
+	ctx := audit.WithActor(context.Background(), audit.ActorSync)
+
+	note := model.NewNote("written by a user named sync")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := st.PendingSyncChanges(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].NoteID != note.ID {
+		t.Fatalf("expected the write to be logged for push, got %+v", changes)
+	}
+}
+
+func TestStore_SyncLinksAndCursor(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	cursor, err := st.PullCursor(ctx)
+	if err != nil || !cursor.IsZero() {
+		t.Fatalf("expected no cursor before the first pull, got %v, %v", cursor, err)
+	}
+	want := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	if err = st.SetPullCursor(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	if cursor, err = st.PullCursor(ctx); err != nil || !cursor.Equal(want) {
+		t.Fatalf("PullCursor = %v, %v; want %v", cursor, err, want)
+	}
+
+	link := SyncLink{NoteID: "local-1", RemoteID: "remote-1", RemoteVersion: 3, RemoteUpdatedAt: want}
+	if err = st.SaveSyncLink(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := st.SyncLinkForRemote(ctx, "remote-1")
+	if err != nil || !ok || got.NoteID != "local-1" || got.RemoteVersion != 3 {
+		t.Fatalf("SyncLinkForRemote = %+v, %v, %v", got, ok, err)
+	}
+
+	// Linking the remote note to another local note replaces the old link
+	link.NoteID = "local-2"
+	if err = st.SaveSyncLink(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err = st.SyncLinkFor(ctx, "local-1"); err != nil || ok {
+		t.Fatalf("expected the old link to be gone, got %v, %v", ok, err)
+	}
+	links, err := st.ListSyncLinks(ctx)
+	if err != nil || len(links) != 1 || links[0].NoteID != "local-2" {
+		t.Fatalf("ListSyncLinks = %+v, %v", links, err)
+	}
+
+	if err = st.DeleteSyncLink(ctx, "local-2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err = st.SyncLinkFor(ctx, "local-2"); err != nil || ok {
+		t.Fatalf("expected no link after delete, got %v, %v", ok, err)
+	}
+}
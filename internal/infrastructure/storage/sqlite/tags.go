@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// noteHasTagSQL matches the notes row of the enclosing query when it carries
+// the tag bound to its one parameter
+const noteHasTagSQL = "SELECT 1 FROM note_tags nt JOIN tags t ON t.id = nt.tag_id WHERE nt.note_id = notes.id AND t.name = ?"
+
+// SetTags replaces the tags of a note outside the trash. The change bumps the
+// note's version and update time and is audited as an update.
+func (s *Store) SetTags(ctx context.Context, id string, tags []string) (model.Note, error) {
+	var note model.Note
+	err := s.withTx(ctx, func(q queryer) error {
+		var err error
+		if note, err = getNote(ctx, q, id); err != nil {
+			return err
+		}
+		note.Tags = tags
+		note.UpdatedAt = time.Now()
+		return updateNote(ctx, q, &note)
+	})
+	return note, err
+}
+
+// ListTags returns every tag on a note outside the trash with the number of
+// such notes carrying it, ordered by name
+func (s *Store) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	rows, err := s.conn().QueryContext(ctx, `
+		SELECT t.name, COUNT(*)
+		FROM tags t
+		JOIN note_tags nt ON nt.tag_id = t.id
+		JOIN notes n ON n.id = nt.note_id
+		WHERE n.deleted_at IS NULL
+		GROUP BY t.id
+		ORDER BY t.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]model.TagCount, 0)
+	for rows.Next() {
+		var tag model.TagCount
+		if err = rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// RenameTag moves every note from one tag to another in a single
+// transaction, merging the two when to is already in use. Notes outside the
+// trash are updated like any other write, with a new version and an audit
+// row; notes in the trash keep their version but follow the rename, so
+// restoring them does not bring the old name back. It returns the number of
+// notes outside the trash that changed.
+func (s *Store) RenameTag(ctx context.Context, from, to string) (int, error) {
+	from, to = model.NormalizeTag(from), model.NormalizeTag(to)
+	var renamed int
+	err := s.withTx(ctx, func(q queryer) error {
+		var fromID int64
+		err := q.QueryRowContext(ctx, "SELECT id FROM tags WHERE name = ?", from).Scan(&fromID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %s", model.ErrTagNotFound, from)
+		}
+		if err != nil || from == to {
+			return err
+		}
+
+		// Notes in the trash follow the rename without a new version
+		toID, err := tagID(ctx, q, to)
+		if err != nil {
+			return err
+		}
+		const trashed = "SELECT note_id FROM note_tags nt JOIN notes n ON n.id = nt.note_id WHERE nt.tag_id = ? AND n.deleted_at IS NOT NULL"
+		if _, err = q.ExecContext(ctx,
+			"INSERT OR IGNORE INTO note_tags (note_id, tag_id) SELECT note_id, ? FROM ("+trashed+")", toID, fromID,
+		); err != nil {
+			return err
+		}
+		if _, err = q.ExecContext(ctx,
+			"DELETE FROM note_tags WHERE tag_id = ? AND note_id IN ("+trashed+")", fromID, fromID,
+		); err != nil {
+			return err
+		}
+
+		notes, err := queryNotes(ctx, q,
+			"deleted_at IS NULL AND EXISTS (SELECT 1 FROM note_tags nt WHERE nt.note_id = notes.id AND nt.tag_id = ?)",
+			"created_at ASC, id ASC", fromID,
+		)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range notes {
+			note := &notes[i]
+			note.Tags = append(slices.DeleteFunc(note.Tags, func(tag string) bool { return tag == from }), to)
+			note.UpdatedAt = now
+			if err = updateNote(ctx, q, note); err != nil {
+				return err
+			}
+		}
+		renamed = len(notes)
+		return deleteUnusedTags(ctx, q)
+	})
+	return renamed, err
+}
+
+// tagID returns the ID of a tag, creating the tag if it is new
+func tagID(ctx context.Context, q queryer, name string) (int64, error) {
+	if _, err := q.ExecContext(ctx, "INSERT OR IGNORE INTO tags (name) VALUES (?)", name); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := q.QueryRowContext(ctx, "SELECT id FROM tags WHERE name = ?", name).Scan(&id)
+	return id, err
+}
+
+// writeNoteTags replaces the tags joined to a note and drops tags no note
+// carries any more
+func writeNoteTags(ctx context.Context, q queryer, noteID string, tags []string) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM note_tags WHERE note_id = ?", noteID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		id, err := tagID(ctx, q, tag)
+		if err != nil {
+			return err
+		}
+		if _, err = q.ExecContext(ctx, "INSERT INTO note_tags (note_id, tag_id) VALUES (?, ?)", noteID, id); err != nil {
+			return err
+		}
+	}
+	return deleteUnusedTags(ctx, q)
+}
+
+// deleteUnusedTags removes tags that no note carries
+func deleteUnusedTags(ctx context.Context, q queryer) error {
+	_, err := q.ExecContext(ctx, "DELETE FROM tags WHERE NOT EXISTS (SELECT 1 FROM note_tags WHERE tag_id = tags.id)")
+	return err
+}
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+// addTagged adds a note carrying tags
+func addTagged(t *testing.T, st *Store, content string, tags ...string) *model.Note {
+	t.Helper()
+	note := model.NewNote(content)
+	note.Tags = tags
+	if err := st.Add(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+	return note
+}
+
+func TestStore_TagsAreStoredAndFiltered(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	report := addTagged(t, st, "report", "Work", "urgent")
+	addTagged(t, st, "review", "work")
+	addTagged(t, st, "milk")
+
+	stored, err := st.GetByID(ctx, report.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(stored.Tags, []string{"urgent", "work"}) {
+		t.Fatalf("tags should be stored normalized, got %v", stored.Tags)
+	}
+
+	work, err := st.Query(ctx, model.NoteFilter{Tags: []string{"work"}, ExcludeTags: []string{"urgent"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(work) != 1 || work[0].Content != "review" {
+		t.Fatalf("expected only the review note, got %+v", work)
+	}
+
+	limit := 1
+	page, next, err := st.Page(ctx, model.NoteFilter{Tags: []string{"work"}, Limit: &limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || next == "" || len(page[0].Tags) == 0 {
+		t.Fatalf("paged tag filter returned %+v, next %q", page, next)
+	}
+
+	hits, err := st.Search(ctx, "report", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || !slices.Equal(hits[0].Note.Tags, []string{"urgent", "work"}) {
+		t.Fatalf("search hits should carry tags, got %+v", hits)
+	}
+}
+
+func TestStore_SetTagsBumpsVersionAndIsAudited(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := addTagged(t, st, "call the bank", "errands")
+	tagged, err := st.SetTags(ctx, note.ID, []string{"finance", "#Errands"})
+	if err != nil {
+		t.Fatalf("SetTags: %v", err)
+	}
+	if !slices.Equal(tagged.Tags, []string{"errands", "finance"}) || tagged.Version != note.Version+1 {
+		t.Fatalf("SetTags returned %+v", tagged)
+	}
+
+	revisions, err := st.ListRevisions(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 0 {
+		t.Fatalf("a tag change should not add a content revision, got %d", len(revisions))
+	}
+	events, err := st.ListAuditEvents(ctx, audit.Filter{NoteID: note.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[1].Operation != audit.OperationUpdate {
+		t.Fatalf("expected create plus an audited update, got %+v", events)
+	}
+}
+
+func TestStore_RenameTagIsAtomic(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	first := addTagged(t, st, "first", "wip")
+	second := addTagged(t, st, "second", "wip", "draft")
+	trashed := addTagged(t, st, "trashed", "wip")
+	if err := st.Delete(ctx, trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fail the rename on the second note, after the first was rewritten
+	if _, err := st.db.Exec(`CREATE TRIGGER fail_rename BEFORE UPDATE OF updated_at ON notes
+		WHEN NEW.id = '` + second.ID + `' BEGIN SELECT RAISE(ABORT, 'injected failure'); END`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.RenameTag(ctx, "wip", "draft"); err == nil {
+		t.Fatal("rename should fail while the trigger is in place")
+	}
+	if got, err := st.GetByID(ctx, first.ID); err != nil || !slices.Equal(got.Tags, []string{"wip"}) || got.Version != first.Version {
+		t.Fatalf("failed rename should leave every note alone, got %+v, %v", got, err)
+	}
+
+	if _, err := st.db.Exec("DROP TRIGGER fail_rename"); err != nil {
+		t.Fatal(err)
+	}
+	renamed, err := st.RenameTag(ctx, "wip", "draft")
+	if err != nil || renamed != 2 {
+		t.Fatalf("RenameTag = %d, %v, want 2", renamed, err)
+	}
+	tags, err := st.ListTags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tags, []model.TagCount{{Name: "draft", Count: 2}}) {
+		t.Fatalf("ListTags after merge = %+v", tags)
+	}
+
+	// The trashed note follows the rename, so restoring it does not revive the old tag
+	if err = st.Restore(ctx, trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, getErr := st.GetByID(ctx, trashed.ID); getErr != nil || !slices.Equal(got.Tags, []string{"draft"}) {
+		t.Fatalf("restored note should carry the new tag, got %+v, %v", got, getErr)
+	}
+	if _, err = st.RenameTag(ctx, "wip", "x"); !errors.Is(err, model.ErrTagNotFound) {
+		t.Fatalf("expected ErrTagNotFound, got %v", err)
+	}
+}
+
+func TestStore_PurgeRemovesTags(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := addTagged(t, st, "gone soon", "temp")
+	if err := st.Delete(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Purge(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	var tags, links int
+	if err := st.db.QueryRow("SELECT (SELECT COUNT(*) FROM tags), (SELECT COUNT(*) FROM note_tags)").Scan(&tags, &links); err != nil {
+		t.Fatal(err)
+	}
+	if tags != 0 || links != 0 {
+		t.Fatalf("purge should remove the note's tags, found %d tags and %d links", tags, links)
+	}
+}
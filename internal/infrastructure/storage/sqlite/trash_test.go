@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	storeerrors "github.com/jonesrussell/godo/internal/infrastructure/storage/errors"
+)
+
+func TestStore_DeleteMovesNoteToTrash(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	keep := model.NewNote("keep")
+	trashed := model.NewNote("trashed")
+	for _, n := range []*model.Note{keep, trashed} {
+		if err := st.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Delete(ctx, trashed.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	notes, err := st.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].ID != keep.ID {
+		t.Fatalf("List should hide trashed notes, got %+v", notes)
+	}
+	if _, err := st.GetByID(ctx, trashed.ID); err == nil {
+		t.Fatal("expected trashed note to be not found")
+	}
+	if err := st.Update(ctx, trashed); err == nil {
+		t.Fatal("expected update of trashed note to fail")
+	}
+	if err := st.Delete(ctx, trashed.ID); err == nil {
+		t.Fatal("expected second delete to fail")
+	}
+
+	deleted, err := st.ListDeleted(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != trashed.ID || deleted[0].DeletedAt == nil {
+		t.Fatalf("unexpected trash: %+v", deleted)
+	}
+
+	if err := st.Restore(ctx, trashed.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	restored, err := st.GetByID(ctx, trashed.ID)
+	if err != nil || restored.DeletedAt != nil {
+		t.Fatalf("restored note: %+v %v", restored, err)
+	}
+	if err := st.Restore(ctx, keep.ID); err == nil {
+		t.Fatal("expected restore of a live note to fail")
+	}
+}
+
+func TestStore_PurgeHonoursCutoff(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("old")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := st.Purge(ctx, time.Now().Add(-time.Hour))
+	if err != nil || purged != 0 {
+		t.Fatalf("purge before deletion: %d %v", purged, err)
+	}
+
+	purged, err = st.Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil || purged != 1 {
+		t.Fatalf("purge after deletion: %d %v", purged, err)
+	}
+	if deleted, _ := st.ListDeleted(ctx); len(deleted) != 0 {
+		t.Fatalf("expected empty trash, got %+v", deleted)
+	}
+
+	events, err := st.ListAuditEvents(ctx, audit.Filter{NoteID: note.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := events[len(events)-1]; last.Operation != audit.OperationPurge || last.Before == nil {
+		t.Fatalf("expected purge audit event, got %+v", last)
+	}
+}
+
+func TestStore_PurgeNoteRemovesOnlyThatNote(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	live := model.NewNote("live")
+	purged := model.NewNote("purged")
+	kept := model.NewNote("kept in the trash")
+	for _, n := range []*model.Note{live, purged, kept} {
+		if err := st.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{purged.ID, kept.ID} {
+		if err := st.Delete(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := st.PurgeNote(ctx, live.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("expected a note outside the trash to be left alone, got %v", err)
+	}
+	if err := st.PurgeNote(ctx, purged.ID); err != nil {
+		t.Fatalf("PurgeNote: %v", err)
+	}
+
+	deleted, err := st.ListDeleted(ctx)
+	if err != nil || len(deleted) != 1 || deleted[0].ID != kept.ID {
+		t.Fatalf("expected only %s left in the trash, got %+v, %v", kept.ID, deleted, err)
+	}
+	if _, err = st.GetByID(ctx, live.ID); err != nil {
+		t.Fatalf("live note: %v", err)
+	}
+	events, err := st.ListAuditEvents(ctx, audit.Filter{NoteID: purged.ID})
+	if err != nil || len(events) == 0 || events[len(events)-1].Operation != audit.OperationPurge {
+		t.Fatalf("expected the purge to be audited, got %+v, %v", events, err)
+	}
+}
+
+func TestStore_ListDeletedAfterClose(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.ListDeleted(context.Background()); !errors.Is(err, storeerrors.ErrStoreClosed) {
+		t.Fatalf("expected ErrStoreClosed, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+)
+
+func TestStore_UpdateChecksVersion(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("draft")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two writers read the same version
+	first, err := st.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := first
+	if first.Version != 1 {
+		t.Fatalf("new note version=%d want 1", first.Version)
+	}
+
+	first.Content = "first writer"
+	if err = st.Update(ctx, &first); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if first.Version != 2 {
+		t.Fatalf("version after update=%d want 2", first.Version)
+	}
+
+	second.Content = "second writer"
+	if err = st.Update(ctx, &second); !errors.Is(err, model.ErrVersionConflict) {
+		t.Fatalf("stale update: expected ErrVersionConflict, got %v", err)
+	}
+
+	stored, err := st.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Content != "first writer" || stored.Version != 2 {
+		t.Fatalf("stale update must not apply, got %+v", stored)
+	}
+
+	// A zero version writes over whatever is current
+	stored.Version = 0
+	stored.Content = "forced"
+	if err = st.Update(ctx, &stored); err != nil {
+		t.Fatalf("unconditional Update: %v", err)
+	}
+	if stored.Version != 3 {
+		t.Fatalf("version after unconditional update=%d want 3", stored.Version)
+	}
+}
+
+func TestStore_DeleteIfVersion(t *testing.T) {
+	t.Parallel()
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	note := model.NewNote("doomed")
+	if err := st.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	note.Content = "edited"
+	if err := st.Update(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.DeleteIfVersion(ctx, note.ID, 1); !errors.Is(err, model.ErrVersionConflict) {
+		t.Fatalf("stale delete: expected ErrVersionConflict, got %v", err)
+	}
+	if err := st.DeleteIfVersion(ctx, note.ID, note.Version); err != nil {
+		t.Fatalf("DeleteIfVersion: %v", err)
+	}
+
+	if err := st.Restore(ctx, note.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := st.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Version != note.Version+2 {
+		t.Fatalf("delete and restore should each bump the version, got %d from %d", restored.Version, note.Version)
+	}
+}
@@ -0,0 +1,477 @@
+// Package syncer keeps a local SQLite store and a remote API backend in step.
+// SQLite stays canonical: every local write is logged as a change and pushed
+// to the remote, and remote edits are pulled by update time and applied
+// locally. Notes changed on both sides are settled by a conflict policy.
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jonesrussell/godo/internal/domain/audit"
+	"github.com/jonesrussell/godo/internal/domain/model"
+	domainstorage "github.com/jonesrussell/godo/internal/domain/storage"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
+)
+
+// sweepInterval is how often a run also lists every remote note to find the
+// ones deleted there, which the update-time pull cannot see
+const sweepInterval = time.Hour
+
+// Local is the canonical store; *sqlite.Store implements it
+type Local interface {
+	GetByID(ctx context.Context, id string) (model.Note, error)
+	Add(ctx context.Context, note *model.Note) error
+	Delete(ctx context.Context, id string) error
+	ApplyRemote(ctx context.Context, note *model.Note) error
+	HasNote(ctx context.Context, id string) (bool, error)
+
+	PendingSyncChanges(ctx context.Context) ([]sqlite.SyncChange, error)
+	AckSyncChange(ctx context.Context, change sqlite.SyncChange) error
+	SyncLinkFor(ctx context.Context, noteID string) (sqlite.SyncLink, bool, error)
+	SyncLinkForRemote(ctx context.Context, remoteID string) (sqlite.SyncLink, bool, error)
+	ListSyncLinks(ctx context.Context) ([]sqlite.SyncLink, error)
+	SaveSyncLink(ctx context.Context, link sqlite.SyncLink) error
+	DeleteSyncLink(ctx context.Context, noteID string) error
+	PullCursor(ctx context.Context) (time.Time, error)
+	SetPullCursor(ctx context.Context, cursor time.Time) error
+}
+
+// Remote is the backend notes are synced with; *api.Store implements it.
+// NotesUpdatedSince returns the notes updated at or after since.
+type Remote interface {
+	domainstorage.UnifiedNoteStorage
+	domainstorage.ConditionalWriter
+	NotesUpdatedSince(ctx context.Context, since time.Time) ([]*model.Note, error)
+}
+
+// Engine runs sync passes between a local and a remote store. Only one pass
+// runs at a time; a pass started while another is running waits for it.
+type Engine struct {
+	local  Local
+	remote Remote
+	policy model.SyncConflictPolicy
+	log    logger.Logger
+
+	mu        sync.Mutex // held for the whole of a pass
+	lastSweep time.Time
+
+	statusMu sync.Mutex
+	status   model.SyncStatus
+}
+
+// runStats counts what one pass did
+type runStats struct {
+	pushed, pulled, conflicts int
+}
+
+// NewEngine creates a sync engine. An empty policy selects last-writer-wins.
+func NewEngine(local Local, remote Remote, policy model.SyncConflictPolicy, log logger.Logger) (*Engine, error) {
+	if policy == "" {
+		policy = model.SyncLastWriterWins
+	}
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("unknown sync conflict policy %q", policy)
+	}
+	return &Engine{
+		local:  local,
+		remote: remote,
+		policy: policy,
+		log:    log,
+		status: model.SyncStatus{State: model.SyncIdle},
+	}, nil
+}
+
+// Sync pushes local changes, then pulls remote ones. An unreachable remote
+// leaves the engine offline with the changes still queued; the error is
+// returned either way.
+func (e *Engine) Sync(ctx context.Context) (*model.SyncStatus, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.setState(model.SyncRunning)
+	ctx = sqlite.WithSyncWrite(audit.WithActor(ctx, audit.ActorSync), true)
+
+	var stats runStats
+	err := e.push(ctx, &stats)
+	if err == nil {
+		err = e.pull(ctx, &stats)
+	}
+	if err == nil && time.Since(e.lastSweep) >= sweepInterval {
+		if err = e.sweep(ctx); err == nil {
+			e.lastSweep = time.Now()
+		}
+	}
+	e.finish(ctx, stats, err)
+
+	status, statusErr := e.SyncStatus(ctx)
+	if err != nil {
+		return status, fmt.Errorf("sync failed: %w", err)
+	}
+	return status, statusErr
+}
+
+// SyncStatus reports the outcome of the last pass and the changes still queued
+func (e *Engine) SyncStatus(ctx context.Context) (*model.SyncStatus, error) {
+	pending, err := e.local.PendingSyncChanges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pending changes: %w", err)
+	}
+
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	status := e.status
+	status.Pending = len(pending)
+	return &status, nil
+}
+
+// setState records that a pass has started
+func (e *Engine) setState(state model.SyncState) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	e.status.State = state
+}
+
+// finish records the outcome of a pass
+func (e *Engine) finish(ctx context.Context, stats runStats, err error) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	e.status.Pushed = stats.pushed
+	e.status.Pulled = stats.pulled
+	e.status.Conflicts = stats.conflicts
+	switch {
+	case err == nil:
+		now := time.Now()
+		e.status.State = model.SyncOK
+		e.status.LastSyncAt = &now
+		e.status.LastError = ""
+		e.log.Info("Sync completed", "pushed", stats.pushed, "pulled", stats.pulled, "conflicts", stats.conflicts)
+	case isOffline(err) || ctx.Err() != nil:
+		e.status.State = model.SyncOffline
+		e.status.LastError = err.Error()
+		e.log.Info("Sync remote unreachable, keeping changes queued", "error", err)
+	default:
+		e.status.State = model.SyncFailed
+		e.status.LastError = err.Error()
+		e.log.Warn("Sync failed", "error", err)
+	}
+}
+
+// isOffline reports whether err means the remote could not be reached at all
+func isOffline(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// push sends the change log to the remote, oldest change first, and removes
+// each change once the remote has it
+func (e *Engine) push(ctx context.Context, stats *runStats) error {
+	changes, err := e.local.PendingSyncChanges(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read change log: %w", err)
+	}
+
+	for _, change := range changes {
+		if change.Deleted {
+			err = e.pushDelete(ctx, change, stats)
+		} else {
+			err = e.pushUpsert(ctx, change, stats)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to push note %s: %w", change.NoteID, err)
+		}
+		if err = e.local.AckSyncChange(ctx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushUpsert creates or updates the remote copy of a local note
+func (e *Engine) pushUpsert(ctx context.Context, change sqlite.SyncChange, stats *runStats) error {
+	note, err := e.local.GetByID(ctx, change.NoteID)
+	if errors.Is(err, model.ErrNoteNotFound) {
+		// Deleted or purged since; a later change covers it
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	link, linked, err := e.local.SyncLinkFor(ctx, note.ID)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return e.create(ctx, &note, stats)
+	}
+
+	if link.RemoteVersion == 0 {
+		// The remote does not version notes, so compare update times instead
+		remote, getErr := e.remote.GetNote(ctx, link.RemoteID)
+		if errors.Is(getErr, model.ErrNoteNotFound) {
+			return e.create(ctx, &note, stats)
+		}
+		if getErr != nil {
+			return getErr
+		}
+		if remote.UpdatedAt.After(link.RemoteUpdatedAt) {
+			return e.resolveUpdate(ctx, &note, link, remote, stats)
+		}
+	}
+
+	remote, err := e.remote.UpdateNoteIfVersion(ctx, link.RemoteID, note.Content, note.Done, link.RemoteVersion)
+	switch {
+	case errors.Is(err, model.ErrNoteNotFound):
+		return e.create(ctx, &note, stats)
+	case errors.Is(err, model.ErrVersionConflict):
+		current, getErr := e.remote.GetNote(ctx, link.RemoteID)
+		if getErr != nil {
+			return getErr
+		}
+		return e.resolveUpdate(ctx, &note, link, current, stats)
+	case err != nil:
+		return err
+	}
+	stats.pushed++
+	return e.link(ctx, note.ID, remote)
+}
+
+// create pushes a note the remote has never seen
+func (e *Engine) create(ctx context.Context, note *model.Note, stats *runStats) error {
+	remote, err := e.remote.CreateNote(ctx, note.Content)
+	if err != nil {
+		return err
+	}
+	if note.Done {
+		if remote, err = e.remote.MarkDone(ctx, remote.ID); err != nil {
+			return err
+		}
+	}
+	stats.pushed++
+	return e.link(ctx, note.ID, remote)
+}
+
+// resolveUpdate settles a note edited on both sides since the last sync
+func (e *Engine) resolveUpdate(ctx context.Context, local *model.Note, link sqlite.SyncLink, remote *model.Note, stats *runStats) error {
+	stats.conflicts++
+	e.log.Info("Sync conflict", "note_id", local.ID, "remote_id", link.RemoteID, "policy", e.policy)
+
+	switch e.policy {
+	case model.SyncLocalWins:
+		return e.overwriteRemote(ctx, local, remote, stats)
+	case model.SyncKeepBoth:
+		// The copy is written as a local change so it is pushed as a new
+		// note on the next pass
+		copied := model.NewNote(local.Content)
+		copied.Done = local.Done
+		copied.Tags = local.Tags
+		if err := e.local.Add(sqlite.WithSyncWrite(audit.WithActor(ctx, audit.ActorSystem), false), copied); err != nil {
+			return fmt.Errorf("failed to keep local copy: %w", err)
+		}
+		return e.applyRemote(ctx, local.ID, remote, stats)
+	default:
+		if local.UpdatedAt.After(remote.UpdatedAt) {
+			return e.overwriteRemote(ctx, local, remote, stats)
+		}
+		return e.applyRemote(ctx, local.ID, remote, stats)
+	}
+}
+
+// overwriteRemote replaces the remote note with the local one
+func (e *Engine) overwriteRemote(ctx context.Context, local, remote *model.Note, stats *runStats) error {
+	updated, err := e.remote.UpdateNoteIfVersion(ctx, remote.ID, local.Content, local.Done, remote.Version)
+	if err != nil {
+		return err
+	}
+	stats.pushed++
+	return e.link(ctx, local.ID, updated)
+}
+
+// pushDelete deletes the remote copy of a note moved to the trash locally
+func (e *Engine) pushDelete(ctx context.Context, change sqlite.SyncChange, stats *runStats) error {
+	link, linked, err := e.local.SyncLinkFor(ctx, change.NoteID)
+	if err != nil || !linked {
+		return err
+	}
+
+	err = e.remote.DeleteNoteIfVersion(ctx, link.RemoteID, link.RemoteVersion)
+	if errors.Is(err, model.ErrVersionConflict) {
+		remote, getErr := e.remote.GetNote(ctx, link.RemoteID)
+		switch {
+		case errors.Is(getErr, model.ErrNoteNotFound):
+			err = getErr
+		case getErr != nil:
+			return getErr
+		default:
+			stats.conflicts++
+			e.log.Info("Sync conflict on delete", "note_id", change.NoteID, "remote_id", link.RemoteID, "policy", e.policy)
+			if e.policy == model.SyncKeepBoth ||
+				(e.policy == model.SyncLastWriterWins && !change.ChangedAt.After(remote.UpdatedAt)) {
+				// The remote edit wins and brings the note back
+				return e.applyRemote(ctx, change.NoteID, remote, stats)
+			}
+			err = e.remote.DeleteNoteIfVersion(ctx, link.RemoteID, remote.Version)
+		}
+	}
+	if err != nil && !errors.Is(err, model.ErrNoteNotFound) {
+		return err
+	}
+	stats.pushed++
+	return e.local.DeleteSyncLink(ctx, change.NoteID)
+}
+
+// pull applies the remote notes updated since the last pull
+func (e *Engine) pull(ctx context.Context, stats *runStats) error {
+	cursor, err := e.local.PullCursor(ctx)
+	if err != nil {
+		return err
+	}
+	notes, err := e.remote.NotesUpdatedSince(ctx, cursor)
+	if err != nil {
+		return fmt.Errorf("failed to pull notes: %w", err)
+	}
+	pending, err := e.pendingNotes(ctx)
+	if err != nil {
+		return err
+	}
+
+	next := cursor
+	for _, remote := range notes {
+		if remote.UpdatedAt.After(next) {
+			next = remote.UpdatedAt
+		}
+
+		link, linked, linkErr := e.local.SyncLinkForRemote(ctx, remote.ID)
+		if linkErr != nil {
+			return linkErr
+		}
+		if linked && link.RemoteVersion == remote.Version && link.RemoteUpdatedAt.Equal(remote.UpdatedAt) {
+			// Already seen, usually our own push coming back
+			continue
+		}
+		if linked && pending[link.NoteID] {
+			// Edited locally too; the next push settles the conflict
+			continue
+		}
+
+		localID := link.NoteID
+		if !linked {
+			localID, err = e.localIDFor(ctx, remote.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if err = e.applyRemote(ctx, localID, remote, stats); err != nil {
+			return fmt.Errorf("failed to apply remote note %s: %w", remote.ID, err)
+		}
+	}
+
+	if next.After(cursor) {
+		return e.local.SetPullCursor(ctx, next)
+	}
+	return nil
+}
+
+// localIDFor picks the local ID for a remote note pulled for the first time.
+// The remote ID is reused unless a local note already has it, linked or not
+// and including notes in the trash.
+func (e *Engine) localIDFor(ctx context.Context, remoteID string) (string, error) {
+	taken, err := e.local.HasNote(ctx, remoteID)
+	if err != nil {
+		return "", err
+	}
+	if !taken {
+		_, taken, err = e.local.SyncLinkFor(ctx, remoteID)
+		if err != nil {
+			return "", err
+		}
+	}
+	if taken {
+		return uuid.New().String(), nil
+	}
+	return remoteID, nil
+}
+
+// applyRemote writes a remote note to the local store and links the two
+func (e *Engine) applyRemote(ctx context.Context, localID string, remote *model.Note, stats *runStats) error {
+	note := &model.Note{
+		ID:        localID,
+		Content:   remote.Content,
+		Done:      remote.Done,
+		CreatedAt: remote.CreatedAt,
+		UpdatedAt: remote.UpdatedAt,
+	}
+	if err := e.local.ApplyRemote(ctx, note); err != nil {
+		return err
+	}
+	stats.pulled++
+	return e.link(ctx, localID, remote)
+}
+
+// link records the remote state of a local note
+func (e *Engine) link(ctx context.Context, localID string, remote *model.Note) error {
+	return e.local.SaveSyncLink(ctx, sqlite.SyncLink{
+		NoteID:          localID,
+		RemoteID:        remote.ID,
+		RemoteVersion:   remote.Version,
+		RemoteUpdatedAt: remote.UpdatedAt,
+	})
+}
+
+// sweep moves to the trash the local notes whose remote copy was deleted,
+// unless they have unpushed edits
+func (e *Engine) sweep(ctx context.Context) error {
+	remoteNotes, err := e.remote.GetAllNotes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list remote notes: %w", err)
+	}
+	onRemote := make(map[string]bool, len(remoteNotes))
+	for _, note := range remoteNotes {
+		onRemote[note.ID] = true
+	}
+
+	links, err := e.local.ListSyncLinks(ctx)
+	if err != nil {
+		return err
+	}
+	pending, err := e.pendingNotes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if onRemote[link.RemoteID] || pending[link.NoteID] {
+			continue
+		}
+		if err = e.local.Delete(ctx, link.NoteID); err != nil && !errors.Is(err, model.ErrNoteNotFound) {
+			return fmt.Errorf("failed to delete note %s removed remotely: %w", link.NoteID, err)
+		}
+		if err = e.local.DeleteSyncLink(ctx, link.NoteID); err != nil {
+			return err
+		}
+		e.log.Debug("Note deleted on the sync remote", "note_id", link.NoteID, "remote_id", link.RemoteID)
+	}
+	return nil
+}
+
+// pendingNotes returns the IDs of the notes with unpushed changes
+func (e *Engine) pendingNotes(ctx context.Context) (map[string]bool, error) {
+	changes, err := e.local.PendingSyncChanges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change log: %w", err)
+	}
+	pending := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		pending[change.NoteID] = true
+	}
+	return pending, nil
+}
@@ -0,0 +1,334 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/logger"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/memory"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
+)
+
+// fakeRemote is an in-memory sync remote that can be taken offline
+type fakeRemote struct {
+	*memory.Store
+	offline bool
+}
+
+func (r *fakeRemote) unreachable() error {
+	return &url.Error{Op: "Get", URL: "http://remote.test/notes", Err: errors.New("connection refused")}
+}
+
+func (r *fakeRemote) CreateNote(ctx context.Context, content string) (*model.Note, error) {
+	if r.offline {
+		return nil, r.unreachable()
+	}
+	return r.Store.CreateNote(ctx, content)
+}
+
+func (r *fakeRemote) NotesUpdatedSince(ctx context.Context, since time.Time) ([]*model.Note, error) {
+	if r.offline {
+		return nil, r.unreachable()
+	}
+	notes, err := r.GetAllNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var updated []*model.Note
+	for _, note := range notes {
+		if !note.UpdatedAt.Before(since) {
+			updated = append(updated, note)
+		}
+	}
+	return updated, nil
+}
+
+func newTestEngine(t *testing.T, policy model.SyncConflictPolicy) (*Engine, *sqlite.Store, *fakeRemote) {
+	t.Helper()
+	local, err := sqlite.New(filepath.Join(t.TempDir(), "notes.db"), logger.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { _ = local.Close() })
+
+	remote := &fakeRemote{Store: memory.New()}
+	engine, err := NewEngine(local, remote, policy, logger.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	return engine, local, remote
+}
+
+func mustSync(t *testing.T, engine *Engine) *model.SyncStatus {
+	t.Helper()
+	status, err := engine.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	return status
+}
+
+// remoteNoteFor returns the remote copy of a local note
+func remoteNoteFor(t *testing.T, local *sqlite.Store, remote *fakeRemote, noteID string) *model.Note {
+	t.Helper()
+	ctx := context.Background()
+	link, ok, err := local.SyncLinkFor(ctx, noteID)
+	if err != nil || !ok {
+		t.Fatalf("note %s is not linked: %v", noteID, err)
+	}
+	note, err := remote.GetNote(ctx, link.RemoteID)
+	if err != nil {
+		t.Fatalf("remote copy of %s: %v", noteID, err)
+	}
+	return note
+}
+
+func TestEngine_PushesAndPulls(t *testing.T) {
+	t.Parallel()
+	engine, local, remote := newTestEngine(t, model.SyncLastWriterWins)
+	ctx := context.Background()
+
+	mine := model.NewNote("written locally")
+	mine.Done = true
+	if err := local.Add(ctx, mine); err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := remote.Store.CreateNote(ctx, "written remotely")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := mustSync(t, engine)
+	if status.State != model.SyncOK || status.Pushed != 1 || status.Pulled != 1 || status.Pending != 0 {
+		t.Fatalf("unexpected status after first sync: %+v", status)
+	}
+	if pushed := remoteNoteFor(t, local, remote, mine.ID); pushed.Content != mine.Content || !pushed.Done {
+		t.Errorf("local note not pushed as written: %+v", pushed)
+	}
+	pulled, err := local.GetByID(ctx, theirs.ID)
+	if err != nil || pulled.Content != "written remotely" {
+		t.Fatalf("remote note not pulled: %+v, %v", pulled, err)
+	}
+
+	// Nothing changed, so nothing moves; our own push is not pulled back
+	status = mustSync(t, engine)
+	if status.Pushed != 0 || status.Pulled != 0 {
+		t.Fatalf("expected an idle second sync, got %+v", status)
+	}
+
+	// A remote edit reaches the local note
+	if _, err = remote.UpdateNote(ctx, theirs.ID, "edited remotely", true); err != nil {
+		t.Fatal(err)
+	}
+	status = mustSync(t, engine)
+	if status.Pulled != 1 || status.Pushed != 0 {
+		t.Fatalf("expected one pull, got %+v", status)
+	}
+	if pulled, err = local.GetByID(ctx, theirs.ID); err != nil || pulled.Content != "edited remotely" || !pulled.Done {
+		t.Fatalf("remote edit not applied: %+v, %v", pulled, err)
+	}
+}
+
+func TestEngine_PushesDeletesAndSweepsRemoteDeletes(t *testing.T) {
+	t.Parallel()
+	engine, local, remote := newTestEngine(t, model.SyncLastWriterWins)
+	ctx := context.Background()
+
+	gone := model.NewNote("deleted locally")
+	kept := model.NewNote("deleted remotely")
+	for _, n := range []*model.Note{gone, kept} {
+		if err := local.Add(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustSync(t, engine)
+	keptRemote := remoteNoteFor(t, local, remote, kept.ID)
+
+	if err := local.Delete(ctx, gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.DeleteNote(ctx, keptRemote.ID); err != nil {
+		t.Fatal(err)
+	}
+	engine.lastSweep = time.Time{}
+	mustSync(t, engine)
+
+	notes, err := remote.GetAllNotes(ctx)
+	if err != nil || len(notes) != 0 {
+		t.Fatalf("expected the local delete to reach the remote, got %+v, %v", notes, err)
+	}
+	if _, err = local.GetByID(ctx, kept.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("expected the remote delete to reach the local store, got %v", err)
+	}
+}
+
+func TestEngine_PullDoesNotReuseATakenLocalID(t *testing.T) {
+	t.Parallel()
+	engine, local, remote := newTestEngine(t, model.SyncLastWriterWins)
+	ctx := context.Background()
+
+	theirs, err := remote.Store.CreateNote(ctx, "written remotely")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A trashed local note with the same ID that was never synced
+	mine := model.NewNote("written locally")
+	mine.ID = theirs.ID
+	unlogged := sqlite.WithSyncWrite(ctx, true)
+	if err = local.Add(unlogged, mine); err != nil {
+		t.Fatal(err)
+	}
+	if err = local.Delete(unlogged, mine.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	mustSync(t, engine)
+
+	link, ok, err := local.SyncLinkForRemote(ctx, theirs.ID)
+	if err != nil || !ok {
+		t.Fatalf("remote note not linked: %v", err)
+	}
+	if link.NoteID == theirs.ID {
+		t.Fatal("expected the pulled note to get a new local ID")
+	}
+	pulled, err := local.GetByID(ctx, link.NoteID)
+	if err != nil || pulled.Content != "written remotely" {
+		t.Fatalf("remote note not pulled: %+v, %v", pulled, err)
+	}
+	if _, err = local.GetByID(ctx, mine.ID); !errors.Is(err, model.ErrNoteNotFound) {
+		t.Fatalf("expected the local note to stay in the trash, got %v", err)
+	}
+}
+
+func TestEngine_PulledNotesSortWithLocalNotes(t *testing.T) {
+	t.Parallel()
+	engine, local, remote := newTestEngine(t, model.SyncLastWriterWins)
+	ctx := context.Background()
+
+	// A note written in a zone west of UTC, and a remote note an hour older
+	// that the remote reports in UTC
+	edt := time.FixedZone("EDT", -4*60*60)
+	now := time.Date(2026, 10, 16, 22, 58, 47, 0, edt)
+	mine := model.NewNote("written locally")
+	mine.CreatedAt, mine.UpdatedAt = now, now
+	if err := local.Add(ctx, mine); err != nil {
+		t.Fatal(err)
+	}
+	older := now.Add(-time.Hour).UTC()
+	theirs := &model.Note{ID: "remote-note", Content: "written remotely", CreatedAt: older, UpdatedAt: older}
+	if err := remote.Store.Add(ctx, theirs); err != nil {
+		t.Fatal(err)
+	}
+
+	mustSync(t, engine)
+
+	notes, err := local.Query(ctx, model.NoteFilter{Sort: model.SortCreatedDesc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || notes[0].ID != mine.ID || notes[1].Content != theirs.Content {
+		t.Fatalf("expected the local note first, got %+v", notes)
+	}
+}
+
+func TestEngine_ConflictPolicies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy     model.SyncConflictPolicy
+		wantLocal  string
+		wantRemote string
+		wantNotes  int
+	}{
+		{model.SyncLastWriterWins, "edited locally", "edited locally", 1},
+		{model.SyncLocalWins, "edited locally", "edited locally", 1},
+		{model.SyncKeepBoth, "edited remotely", "edited remotely", 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			t.Parallel()
+			engine, local, remote := newTestEngine(t, tt.policy)
+			ctx := context.Background()
+
+			note := model.NewNote("original")
+			if err := local.Add(ctx, note); err != nil {
+				t.Fatal(err)
+			}
+			mustSync(t, engine)
+			remoteNote := remoteNoteFor(t, local, remote, note.ID)
+
+			// The remote edit comes first, so the local one is the last writer
+			if _, err := remote.UpdateNote(ctx, remoteNote.ID, "edited remotely", false); err != nil {
+				t.Fatal(err)
+			}
+			note.UpdateContent("edited locally")
+			if err := local.Update(ctx, note); err != nil {
+				t.Fatal(err)
+			}
+
+			status := mustSync(t, engine)
+			if status.Conflicts != 1 {
+				t.Fatalf("expected one conflict, got %+v", status)
+			}
+			got, err := local.GetByID(ctx, note.ID)
+			if err != nil || got.Content != tt.wantLocal {
+				t.Errorf("local note = %q, %v; want %q", got.Content, err, tt.wantLocal)
+			}
+			if got := remoteNoteFor(t, local, remote, note.ID); got.Content != tt.wantRemote {
+				t.Errorf("remote note = %q; want %q", got.Content, tt.wantRemote)
+			}
+
+			// keep-both pushes the local copy on the next pass
+			mustSync(t, engine)
+			localNotes, err := local.List(ctx)
+			if err != nil || len(localNotes) != tt.wantNotes {
+				t.Fatalf("expected %d local notes, got %d, %v", tt.wantNotes, len(localNotes), err)
+			}
+			remoteNotes, err := remote.GetAllNotes(ctx)
+			if err != nil || len(remoteNotes) != tt.wantNotes {
+				t.Fatalf("expected %d remote notes, got %d, %v", tt.wantNotes, len(remoteNotes), err)
+			}
+		})
+	}
+}
+
+func TestEngine_OfflineKeepsChangesQueued(t *testing.T) {
+	t.Parallel()
+	engine, local, remote := newTestEngine(t, model.SyncLastWriterWins)
+	ctx := context.Background()
+
+	note := model.NewNote("written offline")
+	if err := local.Add(ctx, note); err != nil {
+		t.Fatal(err)
+	}
+	remote.offline = true
+
+	status, err := engine.Sync(ctx)
+	if err == nil {
+		t.Fatal("expected sync to fail while offline")
+	}
+	if status.State != model.SyncOffline || status.Pending != 1 {
+		t.Fatalf("expected offline with one pending change, got %+v", status)
+	}
+
+	remote.offline = false
+	status = mustSync(t, engine)
+	if status.State != model.SyncOK || status.Pending != 0 || status.Pushed != 1 {
+		t.Fatalf("expected the queued change to be pushed, got %+v", status)
+	}
+	if status.LastSyncAt == nil {
+		t.Error("expected LastSyncAt after a completed sync")
+	}
+}
+
+func TestNewEngine_RejectsUnknownPolicy(t *testing.T) {
+	t.Parallel()
+	if _, err := NewEngine(nil, nil, "remote-wins", logger.NewNoopLogger()); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+}
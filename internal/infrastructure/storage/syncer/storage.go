@@ -0,0 +1,42 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jonesrussell/godo/internal/domain/model"
+	"github.com/jonesrussell/godo/internal/infrastructure/storage/sqlite"
+)
+
+// Storage is the SQLite backend with sync added. Reads and writes go to the
+// local database as usual, so notes stay available offline; Sync exchanges
+// changes with the remote.
+type Storage struct {
+	*sqlite.UnifiedAdapter
+	engine *Engine
+	remote Remote
+}
+
+// NewStorage wraps a SQLite adapter so that engine syncs it with remote
+func NewStorage(adapter *sqlite.UnifiedAdapter, engine *Engine, remote Remote) *Storage {
+	return &Storage{
+		UnifiedAdapter: adapter,
+		engine:         engine,
+		remote:         remote,
+	}
+}
+
+// Sync runs one sync pass
+func (s *Storage) Sync(ctx context.Context) (*model.SyncStatus, error) {
+	return s.engine.Sync(ctx)
+}
+
+// SyncStatus reports the state of sync without running a pass
+func (s *Storage) SyncStatus(ctx context.Context) (*model.SyncStatus, error) {
+	return s.engine.SyncStatus(ctx)
+}
+
+// Close closes the local database and the remote client
+func (s *Storage) Close() error {
+	return errors.Join(s.UnifiedAdapter.Close(), s.remote.Close())
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"flag"
 	"os"
 
 	"github.com/jonesrussell/godo/internal/application/container"
@@ -9,9 +10,12 @@ import (
 )
 
 func main() {
+	profile := flag.String("profile", "", "configuration profile to use (default: the config file's \"profile\" setting)")
+	flag.Parse()
+
 	code := runtime.ExitOK
 
-	app, cleanup, err := container.InitializeApp()
+	app, cleanup, err := container.InitializeApp(container.ProfileName(*profile))
 	if err != nil {
 		code = runtime.NormalizeExit(err)
 	} else {